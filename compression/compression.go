@@ -0,0 +1,85 @@
+// Package compression provides the codecs report payloads are compressed with.
+//
+// Gzip and None are built in. Other codecs, such as zstd or snappy, can be used by
+// implementing Compressor, e.g. on top of github.com/klauspost/compress; they are not
+// bundled to keep the SDK free of third-party dependencies.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"sync"
+)
+
+// A Compressor compresses report payloads. Implementations must be safe for concurrent use.
+type Compressor interface {
+	// Encoding returns the Content-Encoding of compressed payloads, e.g. "gzip".
+	// Payloads of a Compressor with an empty Encoding are sent without a Content-Encoding.
+	Encoding() string
+	// Compress returns the compressed payload.
+	Compress(payload []byte) ([]byte, error)
+}
+
+// Gzip returns a Compressor gzipping payloads with the default compression level.
+func Gzip() Compressor {
+	return gzipCompressor{level: gzip.DefaultCompression}
+}
+
+// GzipLevel returns a Compressor gzipping payloads with the given level,
+// from gzip.BestSpeed to gzip.BestCompression.
+func GzipLevel(level int) Compressor {
+	return gzipCompressor{level: level}
+}
+
+// None returns a Compressor sending payloads as is.
+func None() Compressor {
+	return noneCompressor{}
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+func (c gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+// gzipWriters pools writers by level, from gzip.HuffmanOnly (-2) to gzip.BestCompression (9):
+// a writer allocates close to a megabyte of compression state.
+var gzipWriters [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
+func (c gzipCompressor) Compress(payload []byte) ([]byte, error) {
+	if c.level < gzip.HuffmanOnly || c.level > gzip.BestCompression {
+		return nil, fmt.Errorf("gzip: invalid compression level: %d", c.level)
+	}
+	pool := &gzipWriters[c.level-gzip.HuffmanOnly]
+	buf := bytes.NewBuffer(make([]byte, 0, len(payload)/4))
+	zw, _ := pool.Get().(*gzip.Writer)
+	if zw == nil {
+		var err error
+		if zw, err = gzip.NewWriterLevel(buf, c.level); err != nil {
+			return nil, err
+		}
+	} else {
+		zw.Reset(buf)
+	}
+	if _, err := zw.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	pool.Put(zw)
+	return buf.Bytes(), nil
+}
+
+type noneCompressor struct{}
+
+func (c noneCompressor) Encoding() string {
+	return ""
+}
+
+func (c noneCompressor) Compress(payload []byte) ([]byte, error) {
+	return payload, nil
+}
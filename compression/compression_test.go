@@ -0,0 +1,52 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	payload := []byte("\"my-metric\" 20 source=\"localhost\"\n")
+	for _, c := range []Compressor{Gzip(), GzipLevel(gzip.BestSpeed)} {
+		assert.Equal(t, "gzip", c.Encoding())
+		// the second round reuses the pooled writer.
+		for _, p := range [][]byte{payload, bytes.Repeat(payload, 3)} {
+			compressed, err := c.Compress(p)
+			require.NoError(t, err)
+			zr, err := gzip.NewReader(bytes.NewReader(compressed))
+			require.NoError(t, err)
+			decompressed, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, p, decompressed)
+		}
+	}
+
+	_, err := GzipLevel(42).Compress(payload)
+	assert.Error(t, err)
+}
+
+func TestNone(t *testing.T) {
+	payload := []byte("\"my-metric\" 20 source=\"localhost\"\n")
+	compressed, err := None().Compress(payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload, compressed)
+	assert.Empty(t, None().Encoding())
+}
+
+func BenchmarkGzip(b *testing.B) {
+	payload := bytes.Repeat([]byte("\"my-metric\" 20 1700000000 source=\"localhost\" \"env\"=\"bench\"\n"), 10000)
+	c := Gzip()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Compress(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package histogram
+
+import (
+	"math"
+)
+
+// Backend is the data structure a histogram accumulates the samples of a time slice into.
+type Backend int8
+
+const (
+	// TDigest merges samples into a bounded number of centroids, see Compression. Memory is
+	// constant, and the relative error is highest at the extreme percentiles.
+	TDigest Backend = iota
+	// HDR counts the values of a bounded range, see HDRRange, in buckets of doubling width split
+	// into linear sub-buckets, as many as needed for the precision of SignificantDigits. Every
+	// percentile is accurate to that precision, at the cost of up to
+	// (log2(highest/lowest)+1) * 2^ceil(log2(10^digits)) counters of 8 bytes per time slice,
+	// e.g. 330KB for the default range and precision, allocated as values are recorded.
+	HDR
+)
+
+func (b Backend) String() string {
+	if b == HDR {
+		return "hdr"
+	}
+	return "tdigest"
+}
+
+// BackendOption sets the data structure of the histogram, TDigest by default.
+func BackendOption(b Backend) Option {
+	return func(args *histogramImpl) {
+		args.backend = b
+	}
+}
+
+// SignificantDigits sets the number of significant decimal digits the HDR backend counts values
+// with, 3 by default, from 1 to 5.
+func SignificantDigits(d int) Option {
+	return func(args *histogramImpl) {
+		args.significantDigits = d
+	}
+}
+
+const (
+	defaultHDRLowest  = 0.001
+	defaultHDRHighest = 1e9
+)
+
+// HDRRange sets the range of the values the HDR backend counts, from 0.001 to 1e9 by default.
+// Values below lowest, including zero and negative values, are counted as 0, and values above
+// highest as highest. An invalid range keeps the default one.
+func HDRRange(lowest, highest float64) Option {
+	return func(args *histogramImpl) {
+		args.hdrLowest = lowest
+		args.hdrHighest = highest
+	}
+}
+
+// digest accumulates the samples of a time slice.
+type digest interface {
+	Add(v float64) error
+	ForEachCentroid(f func(mean float64, count uint64) bool)
+}
+
+// hdrDigest is an HDR histogram of the values from lowest to highest: the range is split into
+// buckets of doubling width, each of which is split into the same number of linear sub-buckets,
+// so that every value is counted with a relative error of at most 1/subBuckets. Values below
+// lowest, including negative values, are counted as 0, and values above highest as highest.
+// The sub-buckets of a bucket are allocated once a value falls in it, so that memory is bounded by
+// len(buckets)*subBuckets counters.
+type hdrDigest struct {
+	digits          int
+	lowest, highest float64
+	subBuckets      int
+	zeros           uint64
+	buckets         [][]uint64
+}
+
+func newHDRDigest(digits int, lowest, highest float64) *hdrDigest {
+	if digits < 1 {
+		digits = 1
+	} else if digits > 5 {
+		digits = 5
+	}
+	if lowest <= 0 || highest <= lowest {
+		lowest, highest = defaultHDRLowest, defaultHDRHighest
+	}
+	return &hdrDigest{
+		digits:     digits,
+		lowest:     lowest,
+		highest:    highest,
+		subBuckets: 1 << int(math.Ceil(math.Log2(math.Pow10(digits)))),
+		buckets:    make([][]uint64, int(math.Ceil(math.Log2(highest/lowest)))+1),
+	}
+}
+
+func (d *hdrDigest) Add(v float64) error {
+	d.add(v, 1)
+	return nil
+}
+
+func (d *hdrDigest) add(v float64, count uint64) {
+	switch {
+	case math.IsNaN(v):
+		return
+	case v < d.lowest:
+		d.zeros += count
+		return
+	case v > d.highest:
+		v = d.highest
+	}
+	bucket, sub := d.index(v)
+	if d.buckets[bucket] == nil {
+		d.buckets[bucket] = make([]uint64, d.subBuckets)
+	}
+	d.buckets[bucket][sub] += count
+}
+
+// index returns the bucket and sub-bucket of v, from lowest to highest.
+func (d *hdrDigest) index(v float64) (int, int) {
+	// v/lowest is frac*2^exp, frac being in [0.5, 1).
+	frac, exp := math.Frexp(v / d.lowest)
+	bucket := exp - 1
+	sub := int((2*frac - 1) * float64(d.subBuckets))
+	if bucket >= len(d.buckets) {
+		bucket, sub = len(d.buckets)-1, d.subBuckets-1
+	}
+	if sub >= d.subBuckets {
+		sub = d.subBuckets - 1
+	}
+	return bucket, sub
+}
+
+// value returns the middle of a sub-bucket, rounded to 2 more significant digits than those of d
+// so that it maps back to the same sub-bucket.
+func (d *hdrDigest) value(bucket, sub int) float64 {
+	v := math.Ldexp(1+(float64(sub)+0.5)/float64(d.subBuckets), bucket) * d.lowest
+	exp := math.Floor(math.Log10(v))
+	scale := math.Pow(10, float64(d.digits+1)-exp)
+	return math.Round(v*scale) / scale
+}
+
+// ForEachCentroid calls f with the middle of each sub-bucket holding values and its count, in
+// increasing order of value, until f returns false.
+func (d *hdrDigest) ForEachCentroid(f func(mean float64, count uint64) bool) {
+	if d.zeros > 0 && !f(0, d.zeros) {
+		return
+	}
+	for bucket, counts := range d.buckets {
+		for sub, count := range counts {
+			if count > 0 && !f(d.value(bucket, sub), count) {
+				return
+			}
+		}
+	}
+}
+
+// hdrQuantile returns the value below which the fraction q of the samples of the completed time
+// slices of the finest granularity fall, to the precision of the HDR backend. It must be called
+// with the mutex held.
+func (h *histogramImpl) hdrQuantile(q float64) float64 {
+	merged := newHDRDigest(h.significantDigits, h.hdrLowest, h.hdrHighest)
+	var total uint64
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
+			merged.add(mean, count)
+			total += count
+			return true
+		})
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	result := math.NaN()
+	merged.ForEachCentroid(func(mean float64, count uint64) bool {
+		seen += count
+		result = mean
+		return seen < rank
+	})
+	return result
+}
@@ -0,0 +1,78 @@
+package histogram
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogram_HDR(t *testing.T) {
+	c := &clock{currTime: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)}
+	h := New(BackendOption(HDR), TimeSupplier(c.Now))
+
+	for i := 1; i <= 1000; i++ {
+		RecordValue(h, int64(i))
+	}
+	RecordValue(h, 123456)
+	RecordDuration(h, 1500*time.Microsecond)
+	c.Add(time.Minute)
+
+	assert.InEpsilon(t, 999.0, h.Quantile(0.998), 0.001)
+	assert.InEpsilon(t, 123456.0, h.Quantile(1), 0.001)
+	assert.InEpsilon(t, 1.5, h.Quantile(0.001), 0.001)
+
+	distributions := h.Distributions()
+	assert.Len(t, distributions, 1)
+	count := 0
+	var values []float64
+	for _, centroid := range distributions[0].Centroids {
+		count += centroid.Count
+		values = append(values, centroid.Value)
+	}
+	assert.Equal(t, 1002, count)
+	assert.IsIncreasing(t, values)
+}
+
+func TestHDRDigest(t *testing.T) {
+	d := newHDRDigest(2, 1, 1000)
+	assert.Equal(t, 128, d.subBuckets)
+	assert.Len(t, d.buckets, 11)
+
+	for _, v := range []float64{-5, 0.5, 1, 3.14159, 99.6, 1000, 5000, math.NaN()} {
+		require.NoError(t, d.Add(v))
+	}
+	var values []float64
+	var counts []uint64
+	d.ForEachCentroid(func(mean float64, count uint64) bool {
+		values = append(values, mean)
+		counts = append(counts, count)
+		return true
+	})
+	require.Len(t, values, 5)
+	assert.Equal(t, []uint64{2, 1, 1, 1, 2}, counts)
+	// values below the range are counted as 0, and values above it as its highest value.
+	assert.Equal(t, 0.0, values[0])
+	for i, want := range []float64{1, 3.14159, 99.6, 1000} {
+		assert.InEpsilon(t, want, values[i+1], 1.0/128, "%v", want)
+	}
+	// only the buckets holding values are allocated.
+	allocated := 0
+	for _, counts := range d.buckets {
+		if counts != nil {
+			allocated++
+		}
+	}
+	assert.Equal(t, 4, allocated)
+
+	// the values of the sub-buckets map back to them.
+	merged := newHDRDigest(2, 1, 1000)
+	d.ForEachCentroid(func(mean float64, count uint64) bool {
+		merged.add(mean, count)
+		return true
+	})
+	assert.Equal(t, d.zeros, merged.zeros)
+	assert.Equal(t, d.buckets, merged.buckets)
+}
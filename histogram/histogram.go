@@ -3,6 +3,7 @@ package histogram
 
 import (
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,7 +30,17 @@ type Option func(*histogramImpl)
 // GranularityOption of the histogram
 func GranularityOption(g Granularity) Option {
 	return func(args *histogramImpl) {
-		args.granularity = g
+		args.granularities = []Granularity{g}
+	}
+}
+
+// Granularities makes the histogram accumulate samples for each of the given granularities
+// simultaneously. Distributions then returns the completed time slices of every granularity,
+// each one tagged with the Granularity it was accumulated for.
+// The finest granularity is used by Granularity and by the statistics methods (Count, Quantile, ...).
+func Granularities(gs ...Granularity) Option {
+	return func(args *histogramImpl) {
+		args.granularities = gs
 	}
 }
 
@@ -56,10 +67,13 @@ func TimeSupplier(supplier func() time.Time) Option {
 
 func defaultHistogramImpl() *histogramImpl {
 	return &histogramImpl{
-		maxBins:      10,
-		granularity:  MINUTE,
-		compression:  3.2,
-		timeSupplier: time.Now,
+		maxBins:           10,
+		granularities:     []Granularity{MINUTE},
+		compression:       3.2,
+		significantDigits: 3,
+		hdrLowest:         defaultHDRLowest,
+		hdrHighest:        defaultHDRHighest,
+		timeSupplier:      time.Now,
 	}
 }
 
@@ -69,29 +83,59 @@ func New(setters ...Option) Histogram {
 	for _, setter := range setters {
 		setter(h)
 	}
+
+	seen := make(map[Granularity]bool, len(h.granularities))
+	for _, g := range h.granularities {
+		if !seen[g] {
+			seen[g] = true
+			h.bins = append(h.bins, &granularityBins{granularity: g})
+		}
+	}
+	if len(h.bins) == 0 {
+		h.bins = append(h.bins, &granularityBins{granularity: MINUTE})
+	}
+	sort.Slice(h.bins, func(i, j int) bool {
+		return h.bins[i].granularity < h.bins[j].granularity
+	})
 	return h
 }
 
 type histogramImpl struct {
-	mutex              sync.Mutex
+	mutex sync.Mutex
+	// bins holds one set of time slices per granularity, finest first.
+	bins []*granularityBins
+
+	granularities     []Granularity
+	backend           Backend
+	compression       float64
+	significantDigits int
+	hdrLowest         float64
+	hdrHighest        float64
+	maxBins           int
+	timeSupplier      func() time.Time
+}
+
+type granularityBins struct {
+	granularity        Granularity
 	priorTimedBinsList []*timedBin
 	currentTimedBin    *timedBin
-
-	granularity  Granularity
-	compression  float64
-	maxBins      int
-	timeSupplier func() time.Time
 }
 
 type timedBin struct {
-	tdigest   *tdigest.TDigest
+	digest    digest
 	timestamp time.Time
 }
 
 // Distribution holds the samples and its timestamp.
 type Distribution struct {
-	Centroids []Centroid
-	Timestamp time.Time
+	Centroids   []Centroid
+	Timestamp   time.Time
+	Granularity Granularity
+}
+
+// Granularities returns the granularity set of the distribution, as expected by Sender.SendDistribution.
+func (d Distribution) Granularities() map[Granularity]bool {
+	return map[Granularity]bool{d.Granularity: true}
 }
 
 // Update registers a new sample in the histogram.
@@ -101,7 +145,19 @@ func (h *histogramImpl) Update(v float64) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	_ = h.currentTimedBin.tdigest.Add(v)
+	for _, bins := range h.bins {
+		_ = bins.currentTimedBin.digest.Add(v)
+	}
+}
+
+// RecordValue registers the integer sample v in h, as HDR histograms are usually fed.
+func RecordValue(h Histogram, v int64) {
+	h.Update(float64(v))
+}
+
+// RecordDuration registers d in h, in milliseconds.
+func RecordDuration(h Histogram, d time.Duration) {
+	h.Update(float64(d) / float64(time.Millisecond))
 }
 
 // Count returns the total number of samples on this histogram.
@@ -112,8 +168,8 @@ func (h *histogramImpl) Count() uint64 {
 	defer h.mutex.Unlock()
 
 	res := uint64(0)
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			res++
 			return true
 		})
@@ -128,9 +184,12 @@ func (h *histogramImpl) Quantile(q float64) float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
+	if h.backend == HDR {
+		return h.hdrQuantile(q)
+	}
 	tempTdigest, _ := tdigest.New()
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			_ = tempTdigest.Add(mean)
 			return true
 		})
@@ -146,12 +205,12 @@ func (h *histogramImpl) Max() float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	if len(h.priorTimedBinsList) == 0 {
+	if len(h.bins[0].priorTimedBinsList) == 0 {
 		return math.NaN()
 	}
 	max := math.SmallestNonzeroFloat64
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			max = math.Max(max, mean)
 			return true
 		})
@@ -166,12 +225,12 @@ func (h *histogramImpl) Min() float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	if len(h.priorTimedBinsList) == 0 {
+	if len(h.bins[0].priorTimedBinsList) == 0 {
 		return math.NaN()
 	}
 	min := math.MaxFloat64
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			min = math.Min(min, mean)
 			return true
 		})
@@ -187,8 +246,8 @@ func (h *histogramImpl) Sum() float64 {
 	defer h.mutex.Unlock()
 
 	sum := float64(0)
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			sum += mean * float64(count)
 			return true
 		})
@@ -203,13 +262,13 @@ func (h *histogramImpl) Mean() float64 {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	if len(h.priorTimedBinsList) == 0 {
+	if len(h.bins[0].priorTimedBinsList) == 0 {
 		return math.NaN()
 	}
 	t := float64(0)
 	c := uint64(0)
-	for _, bin := range h.priorTimedBinsList {
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
+	for _, bin := range h.bins[0].priorTimedBinsList {
+		bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
 			t += mean * float64(count)
 			c += count
 			return true
@@ -218,9 +277,9 @@ func (h *histogramImpl) Mean() float64 {
 	return t / float64(c)
 }
 
-// Granularity value
+// Granularity value, the finest one when the histogram has several granularities.
 func (h *histogramImpl) Granularity() Granularity {
-	return h.granularity
+	return h.bins[0].granularity
 }
 
 // Snapshot returns a copy of all samples on completed time slices
@@ -239,17 +298,23 @@ func (h *histogramImpl) distributions(clean bool) []Distribution {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	distributions := make([]Distribution, len(h.priorTimedBinsList))
-	for idx, bin := range h.priorTimedBinsList {
-		var centroids []Centroid
-		bin.tdigest.ForEachCentroid(func(mean float64, count uint64) bool {
-			centroids = append(centroids, Centroid{Value: mean, Count: int(count)})
-			return true
-		})
-		distributions[idx] = Distribution{Timestamp: bin.timestamp, Centroids: centroids}
-	}
-	if clean {
-		h.priorTimedBinsList = h.priorTimedBinsList[:0]
+	var distributions []Distribution
+	for _, bins := range h.bins {
+		for _, bin := range bins.priorTimedBinsList {
+			var centroids []Centroid
+			bin.digest.ForEachCentroid(func(mean float64, count uint64) bool {
+				centroids = append(centroids, Centroid{Value: mean, Count: int(count)})
+				return true
+			})
+			distributions = append(distributions, Distribution{
+				Timestamp:   bin.timestamp,
+				Centroids:   centroids,
+				Granularity: bins.granularity,
+			})
+		}
+		if clean {
+			bins.priorTimedBinsList = bins.priorTimedBinsList[:0]
+		}
 	}
 	return distributions
 }
@@ -258,22 +323,28 @@ func (h *histogramImpl) rotateCurrentTDigestIfNeedIt() {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
-	if h.currentTimedBin == nil {
-		h.currentTimedBin = h.newTimedBin()
-	} else if h.currentTimedBin.timestamp != h.now() {
-		h.priorTimedBinsList = append(h.priorTimedBinsList, h.currentTimedBin)
-		if len(h.priorTimedBinsList) > h.maxBins {
-			h.priorTimedBinsList = h.priorTimedBinsList[1:]
+	for _, bins := range h.bins {
+		now := h.now(bins.granularity)
+		if bins.currentTimedBin == nil {
+			bins.currentTimedBin = h.newTimedBin(now)
+		} else if bins.currentTimedBin.timestamp != now {
+			bins.priorTimedBinsList = append(bins.priorTimedBinsList, bins.currentTimedBin)
+			if len(bins.priorTimedBinsList) > h.maxBins {
+				bins.priorTimedBinsList = bins.priorTimedBinsList[1:]
+			}
+			bins.currentTimedBin = h.newTimedBin(now)
 		}
-		h.currentTimedBin = h.newTimedBin()
 	}
 }
 
-func (h *histogramImpl) now() time.Time {
-	return h.timeSupplier().Truncate(h.granularity.Duration())
+func (h *histogramImpl) now(g Granularity) time.Time {
+	return h.timeSupplier().Truncate(g.Duration())
 }
 
-func (h *histogramImpl) newTimedBin() *timedBin {
+func (h *histogramImpl) newTimedBin(timestamp time.Time) *timedBin {
+	if h.backend == HDR {
+		return &timedBin{timestamp: timestamp, digest: newHDRDigest(h.significantDigits, h.hdrLowest, h.hdrHighest)}
+	}
 	td, _ := tdigest.New(tdigest.Compression(h.compression))
-	return &timedBin{timestamp: h.now(), tdigest: td}
+	return &timedBin{timestamp: timestamp, digest: td}
 }
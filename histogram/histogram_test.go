@@ -47,6 +47,28 @@ func TestHistogram(t *testing.T) {
 	assert.Equal(t, len(distributions), 0, "Error on distributions number")
 }
 
+func TestHistogram_Granularities(t *testing.T) {
+	c := &clock{currTime: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)}
+	h := New(Granularities(HOUR, MINUTE, HOUR), TimeSupplier(c.Now))
+	assert.Equal(t, MINUTE, h.Granularity())
+
+	for i := 0; i < 3; i++ {
+		h.Update(float64(i))
+		c.Add(time.Minute)
+	}
+	c.Add(time.Hour)
+
+	counts := map[Granularity]int{}
+	for _, distribution := range h.Distributions() {
+		assert.Equal(t, map[Granularity]bool{distribution.Granularity: true}, distribution.Granularities())
+		for _, centroid := range distribution.Centroids {
+			counts[distribution.Granularity] += centroid.Count
+		}
+	}
+	assert.Equal(t, map[Granularity]int{MINUTE: 3, HOUR: 3}, counts)
+	assert.Empty(t, h.Distributions())
+}
+
 func TestCompactHistoLine(t *testing.T) {
 	centroids := Centroids{
 		{Value: 30.0, Count: 20},
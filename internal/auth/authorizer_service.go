@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+type AuthorizerService struct {
+	Authorizer Authorizer
+}
+
+func (s AuthorizerService) IsDirect() bool {
+	return true
+}
+
+func (s AuthorizerService) Authorize(req *http.Request) error {
+	err := s.Authorizer.Authorize(req)
+	var authErr *Err
+	if err != nil && !errors.As(err, &authErr) {
+		return &Err{error: err}
+	}
+	return err
+}
+
+// Close closes the Authorizer if it has a Close method, e.g. to stop refreshing its credentials.
+func (s AuthorizerService) Close() {
+	switch closer := s.Authorizer.(type) {
+	case interface{ Close() }:
+		closer.Close()
+	case interface{ Close() error }:
+		_ = closer.Close()
+	}
+}
+
+// NewAuthorizerService returns a Service instance authorizing requests with authorizer
+func NewAuthorizerService(authorizer Authorizer) Service {
+	return &AuthorizerService{Authorizer: authorizer}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type headerAuthorizer struct {
+	err    error
+	closed bool
+}
+
+func (a *headerAuthorizer) Authorize(r *http.Request) error {
+	if a.err != nil {
+		return a.err
+	}
+	r.Header.Set("Authorization", "HMAC signature")
+	return nil
+}
+
+func (a *headerAuthorizer) Close() {
+	a.closed = true
+}
+
+func TestAuthorizerService(t *testing.T) {
+	authorizer := &headerAuthorizer{}
+	service := NewAuthorizerService(authorizer)
+	assert.True(t, service.IsDirect())
+
+	req, _ := http.NewRequest("POST", "https://example.com/report", nil)
+	assert.NoError(t, service.Authorize(req))
+	assert.Equal(t, "HMAC signature", req.Header.Get("Authorization"))
+
+	service.Close()
+	assert.True(t, authorizer.closed)
+}
+
+func TestAuthorizerService_WhenAuthorizeFails_ReturnsAuthError(t *testing.T) {
+	service := NewAuthorizerService(&headerAuthorizer{err: errors.New("no credentials")})
+
+	req, _ := http.NewRequest("POST", "https://example.com/report", nil)
+	err := service.Authorize(req)
+	assert.EqualError(t, err, "no credentials")
+	var authErr *Err
+	assert.ErrorAs(t, err, &authErr)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
@@ -45,6 +45,26 @@ func NewCSPTokenService(CSPBaseURL, apiToken string) Service {
 	})
 }
 
+// NewRefreshingTokenService returns a Service instance that gets access tokens from fetch, and
+// refreshes them in the background before they expire
+func NewRefreshingTokenService(fetch func() (token string, expiresIn time.Duration, err error)) Service {
+	return newService(tokenFuncClient(fetch))
+}
+
+// tokenFuncClient is a csp.Client getting access tokens from a function.
+type tokenFuncClient func() (string, time.Duration, error)
+
+func (f tokenFuncClient) GetAccessToken() (*csp.AuthorizeResponse, error) {
+	token, expiresIn, err := f()
+	if err != nil {
+		return nil, err
+	}
+	return &csp.AuthorizeResponse{
+		AccessToken: token,
+		ExpiresIn:   int64(expiresIn / time.Second),
+	}, nil
+}
+
 func newService(client csp.Client) Service {
 	return &CSPService{
 		client:                 client,
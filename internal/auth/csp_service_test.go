@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -54,3 +55,39 @@ func TestCSPService_WhenAuthenticationFails_AuthorizeReturnsError(t *testing.T)
 	token := req.Header.Get("Authorization")
 	assert.Equal(t, "", token)
 }
+
+func TestRefreshingTokenService(t *testing.T) {
+	tokens := []string{"abc", "def"}
+	fetches := 0
+	tokenService := NewRefreshingTokenService(func() (string, time.Duration, error) {
+		token := tokens[fetches%len(tokens)]
+		fetches++
+		return token, time.Second, nil
+	})
+	defer tokenService.Close()
+	tokenService.(*CSPService).defaultRefreshInterval = 100 * time.Millisecond
+
+	req, _ := http.NewRequest("GET", "https://example.com", nil)
+	assert.NoError(t, tokenService.Authorize(req))
+	assert.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	assert.True(t, tokenService.IsDirect())
+
+	assert.Eventually(t, func() bool {
+		req, _ := http.NewRequest("GET", "https://example.com", nil)
+		return tokenService.Authorize(req) == nil && req.Header.Get("Authorization") == "Bearer def"
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestRefreshingTokenService_WhenFetchFails_AuthorizeReturnsError(t *testing.T) {
+	tokenService := NewRefreshingTokenService(func() (string, time.Duration, error) {
+		return "", 0, errors.New("identity provider unavailable")
+	})
+	defer tokenService.Close()
+
+	req, _ := http.NewRequest("GET", "https://example.com", nil)
+	err := tokenService.Authorize(req)
+	assert.EqualError(t, err, "identity provider unavailable")
+	var authErr *Err
+	assert.ErrorAs(t, err, &authErr)
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
@@ -2,9 +2,14 @@ package auth
 
 import "net/http"
 
+// Authorizer authorizes outbound requests, e.g. by setting their Authorization header or signing them
+type Authorizer interface {
+	Authorize(r *http.Request) error
+}
+
 // Service Interface for getting authentication tokens (Wavefront, CSP)
 type Service interface {
-	Authorize(r *http.Request) error
+	Authorizer
 	Close()
 	IsDirect() bool
 }
@@ -24,3 +29,7 @@ type CSPAPIToken struct {
 	Token   string
 	BaseURL string
 }
+
+type CustomAuthorizer struct {
+	Authorizer Authorizer
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	lineCountHeader = "X-WF-Line-Count"
+	tsMinHeader     = "X-WF-TS-Min"
+	tsMaxHeader     = "X-WF-TS-Max"
+)
+
+var newline = []byte("\n")
+
+// SetBatchMetadataHeaders adds the X-WF-Line-Count header to every report request, and the
+// X-WF-TS-Min and X-WF-TS-Max headers, in epoch seconds, to metric and histogram batches
+// holding timestamped lines.
+func SetBatchMetadataHeaders() ReporterOption {
+	return func(s *reporterSettings) {
+		s.batchHeaders = true
+	}
+}
+
+func setBatchMetadataHeaders(header http.Header, format string, pointLines []byte) {
+	header.Set(lineCountHeader, strconv.Itoa(bytes.Count(pointLines, newline)))
+	if format != metricFormat && format != histogramFormat {
+		return
+	}
+
+	var min, max int64
+	found := false
+	for _, line := range bytes.Split(pointLines, newline) {
+		ts, ok := lineTimestamp(format, string(line))
+		if !ok {
+			continue
+		}
+		if !found || ts < min {
+			min = ts
+		}
+		if !found || ts > max {
+			max = ts
+		}
+		found = true
+	}
+	if found {
+		header.Set(tsMinHeader, strconv.FormatInt(min, 10))
+		header.Set(tsMaxHeader, strconv.FormatInt(max, 10))
+	}
+}
+
+// lineTimestamp returns the timestamp of a metric or histogram line, in epoch seconds.
+// Metric lines are "<name> <value> [<timestamp>] source=...", histogram lines are
+// "!M [<timestamp>] #<count> <value> ...". Names are sanitized and hold no spaces.
+func lineTimestamp(format string, line string) (int64, bool) {
+	fields := strings.SplitN(line, " ", 4)
+	idx := 2
+	if format == histogramFormat {
+		idx = 1
+	}
+	if len(fields) <= idx {
+		return 0, false
+	}
+	ts, err := strconv.ParseInt(fields[idx], 10, 64)
+	if err != nil || ts <= 0 {
+		return 0, false
+	}
+	// timestamps can be given in milliseconds, microseconds or nanoseconds.
+	for ts > 1e11 {
+		ts /= 1000
+	}
+	return ts, true
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetBatchMetadataHeaders(t *testing.T) {
+	header := http.Header{}
+	setBatchMetadataHeaders(header, metricFormat, []byte("\"foo\" 1 1533531013 source=\"bar\"\n"+
+		"\"foo\" 2 source=\"bar\"\n"+
+		"\"foo\" 3 1533531000123 source=\"bar\"\n"+
+		"\"foo\" 4 1533531020 source=\"bar\" \"env\"=\"prod\"\n"))
+	assert.Equal(t, "4", header.Get("X-WF-Line-Count"))
+	assert.Equal(t, "1533531000", header.Get("X-WF-TS-Min"))
+	assert.Equal(t, "1533531020", header.Get("X-WF-TS-Max"))
+
+	header = http.Header{}
+	setBatchMetadataHeaders(header, histogramFormat, []byte("!M 1533531013 #20 30 #10 5.1 \"foo\" source=\"bar\"\n"+
+		"!H #20 30 \"foo\" source=\"bar\"\n"))
+	assert.Equal(t, "2", header.Get("X-WF-Line-Count"))
+	assert.Equal(t, "1533531013", header.Get("X-WF-TS-Min"))
+	assert.Equal(t, "1533531013", header.Get("X-WF-TS-Max"))
+
+	header = http.Header{}
+	setBatchMetadataHeaders(header, traceFormat, []byte("\"span\" source=\"bar\" 1533531013000 10\n"))
+	assert.Equal(t, "1", header.Get("X-WF-Line-Count"))
+	assert.Empty(t, header.Get("X-WF-TS-Min"))
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+// ErrCircuitOpen matches, with errors.Is, the errors of the flushes skipped and of the lines shed
+// while the circuit breaker of a handler is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitBreaker stops the reports of a handler after consecutive failures. Once open, reports are
+// skipped for a cool-down period, after which the breaker is half-open: the next report probes the
+// endpoint, closing the breaker on success and opening it again on failure.
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+
+	mtx       sync.Mutex
+	failures  int
+	openUntil time.Time
+	opened    *sdkmetrics.DeltaCounter
+}
+
+// SetCircuitBreaker opens the circuit breaker of the handler after threshold consecutive failed
+// reports, for coolDown. While open, flushes are skipped, lines buffered are moved to the
+// persistent buffer if any, and new lines are shed unless there is a persistent buffer.
+func SetCircuitBreaker(threshold int, coolDown time.Duration) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.breaker = &circuitBreaker{threshold: threshold, coolDown: coolDown}
+	}
+}
+
+// open returns an error matching ErrCircuitOpen while the breaker is open, and nil while it is
+// closed or half-open.
+func (cb *circuitBreaker) open(format string) error {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	if time.Now().Before(cb.openUntil) {
+		return fmt.Errorf("%w: %s reporting paused until %s", ErrCircuitOpen, format, cb.openUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a report.
+func (cb *circuitBreaker) record(format string, err error) {
+	cb.mtx.Lock()
+	defer cb.mtx.Unlock()
+	if err == nil {
+		if !cb.openUntil.IsZero() {
+			log.Printf("%s report succeeded, closing circuit breaker\n", format)
+		}
+		cb.failures = 0
+		cb.openUntil = time.Time{}
+		return
+	}
+	cb.failures++
+	// a half-open breaker opens again on the first failure.
+	if cb.failures >= cb.threshold || !cb.openUntil.IsZero() {
+		cb.openUntil = time.Now().Add(cb.coolDown)
+		if cb.opened != nil {
+			cb.opened.Inc()
+		}
+		log.Printf("%d consecutive %s reports failed, opening circuit breaker until %s: %s\n",
+			cb.failures, format, cb.openUntil.Format(time.RFC3339), err)
+	}
+}
+
+// circuitOpen returns an error matching ErrCircuitOpen while the circuit breaker, if any, is open.
+func (lh *RealLineHandler) circuitOpen() error {
+	if lh.breaker == nil {
+		return nil
+	}
+	return lh.breaker.open(lh.format)
+}
+
+// spool moves the buffered lines to the persistent buffer, if any, so that they are not held in
+// memory while the circuit breaker is open. It must be called with mtx held.
+func (lh *RealLineHandler) spool() {
+	if lh.persistence == nil || len(lh.buffer) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(lh.buffer))
+	for len(lh.buffer) > 0 {
+		lines = append(lines, <-lh.buffer)
+	}
+	if err := lh.persistence.Append(lines); err != nil {
+		log.Printf("unable to spool %d %s lines: %s\n", len(lines), lh.format, err)
+		lh.dropped(lines, err)
+	}
+}
+
+// shed drops line while the circuit breaker is open, unless lines are spooled to a persistent
+// buffer, so that a dead endpoint does not keep the buffer full.
+func (lh *RealLineHandler) shed(line string) error {
+	if lh.persistence != nil {
+		return nil
+	}
+	err := lh.circuitOpen()
+	if err == nil {
+		return nil
+	}
+	atomic.AddInt64(&lh.failures, 1)
+	lh.dropped([]string{line}, err)
+	return fmt.Errorf("%w, dropping line: %s", err, line)
+}
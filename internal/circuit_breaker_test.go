@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	reporter := &fakeReporter{}
+	reporter.SetHTTPStatus(503)
+	registry := sdkmetrics.NewMetricRegistry(nil, sdkmetrics.SetPrefix("test"))
+	lh := NewLineHandler(reporter, "wavefront", time.Hour, 10, 100,
+		SetRegistry(registry), SetHandlerPrefix("points"), SetCircuitBreaker(2, 50*time.Millisecond))
+
+	require.NoError(t, lh.HandleLine("a 1"))
+	assert.Error(t, lh.Flush())
+	assert.NoError(t, lh.circuitOpen())
+	assert.Error(t, lh.Flush())
+	assert.Equal(t, 2, reporter.ReportCallCount())
+
+	// open: flushes are skipped, and new lines shed, but the failed batch stays buffered.
+	err := lh.Flush()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, errors.Is(lh.FlushAllWithResult().Err, ErrCircuitOpen))
+	assert.True(t, errors.Is(lh.HandleLine("b 2"), ErrCircuitOpen))
+	assert.Equal(t, 2, reporter.ReportCallCount())
+	assert.Len(t, lh.buffer, 1)
+
+	// half-open: a failed probe opens the breaker again.
+	time.Sleep(60 * time.Millisecond)
+	assert.Error(t, lh.Flush())
+	assert.Equal(t, 3, reporter.ReportCallCount())
+	assert.True(t, errors.Is(lh.Flush(), ErrCircuitOpen))
+
+	// half-open: a successful probe closes it.
+	time.Sleep(60 * time.Millisecond)
+	reporter.SetHTTPStatus(0)
+	assert.NoError(t, lh.Flush())
+	assert.Equal(t, []string{"a 1"}, reporter.lines)
+	assert.NoError(t, lh.HandleLine("c 3"))
+	assert.NoError(t, lh.circuitOpen())
+}
+
+func TestCircuitBreaker_Spools(t *testing.T) {
+	reporter := &fakeReporter{error: errors.New("connection refused")}
+	lh := NewLineHandler(reporter, "wavefront", time.Hour, 10, 100,
+		SetHandlerPrefix("points"), SetPersistentBuffer(t.TempDir(), 0), SetCircuitBreaker(1, time.Hour))
+
+	require.NoError(t, lh.HandleLine("a 1"))
+	assert.Error(t, lh.Flush())
+	// lines are not shed with a persistent buffer, but moved to it by flushes.
+	require.NoError(t, lh.HandleLine("b 2"))
+	assert.True(t, errors.Is(lh.Flush(), ErrCircuitOpen))
+	assert.Empty(t, lh.buffer)
+	assert.Equal(t, 1, reporter.ReportCallCount())
+}
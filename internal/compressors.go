@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"sync/atomic"
+
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+)
+
+// compressors holds the codecs a reporter may compress payloads with, in order of preference,
+// and the index of the preferred one its endpoint has not rejected.
+type compressors struct {
+	codecs  []compression.Compressor
+	current int32
+}
+
+func newCompressors(codecs ...compression.Compressor) *compressors {
+	return &compressors{codecs: codecs}
+}
+
+func (c *compressors) get() (int, compression.Compressor) {
+	i := int(atomic.LoadInt32(&c.current))
+	return i, c.codecs[i]
+}
+
+// fallback moves past the codec at index i, after the endpoint rejected it,
+// and reports whether a codec is left to try.
+func (c *compressors) fallback(i int) bool {
+	if i+1 >= len(c.codecs) {
+		return false
+	}
+	atomic.CompareAndSwapInt32(&c.current, int32(i), int32(i+1))
+	return true
+}
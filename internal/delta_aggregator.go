@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeltaPoint is the sum of all delta counter increments of one series within one bucket.
+type DeltaPoint struct {
+	Name      string
+	Value     float64
+	Timestamp int64 // epoch seconds of the bucket start
+	Source    string
+	Tags      map[string]string
+}
+
+type deltaKey struct {
+	bucket int64
+	series string
+}
+
+// DeltaAggregator sums delta counter increments per series and per time bucket aligned
+// to bucket boundaries (e.g. minutes), and emits one DeltaPoint per series per bucket.
+// Without buckets, increments are summed per series between emissions instead, and emitted
+// with a zero timestamp unless they were added with AddAt.
+type DeltaAggregator struct {
+	bucket   time.Duration
+	interval time.Duration
+	emit     func(DeltaPoint)
+	now      func() time.Time
+
+	mtx    sync.Mutex
+	points map[deltaKey]*DeltaPoint
+
+	ticker   *time.Ticker
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDeltaAggregator creates a DeltaAggregator. Completed buckets are handed to emit
+// every interval once the aggregator is started. A bucket of zero disables buckets, so that
+// every point is handed to emit at the next interval.
+func NewDeltaAggregator(bucket, interval time.Duration, emit func(DeltaPoint)) *DeltaAggregator {
+	return &DeltaAggregator{
+		bucket:   bucket,
+		interval: interval,
+		emit:     emit,
+		now:      time.Now,
+		points:   make(map[deltaKey]*DeltaPoint),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Add records an increment of value for the given series.
+func (a *DeltaAggregator) Add(name string, value float64, source string, tags map[string]string) {
+	if a.bucket <= 0 {
+		// the points are left for the server to timestamp.
+		a.add(0, name, value, source, tags)
+		return
+	}
+	a.AddAt(a.now(), name, value, source, tags)
+}
+
+// AddAt records an increment of value for the given series in the bucket holding t.
+func (a *DeltaAggregator) AddAt(t time.Time, name string, value float64, source string, tags map[string]string) {
+	a.add(t.Truncate(a.bucket).Unix(), name, value, source, tags)
+}
+
+func (a *DeltaAggregator) add(bucket int64, name string, value float64, source string, tags map[string]string) {
+	key := deltaKey{
+		bucket: bucket,
+		series: SeriesKey(name, source, tags),
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if point, ok := a.points[key]; ok {
+		point.Value += value
+		return
+	}
+	copiedTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copiedTags[k] = v
+	}
+	a.points[key] = &DeltaPoint{
+		Name:      name,
+		Value:     value,
+		Timestamp: key.bucket,
+		Source:    source,
+		Tags:      copiedTags,
+	}
+}
+
+// Flush emits the points of the completed buckets. The current bucket is emitted once it is
+// over, or on Stop, so that a bucket is never emitted twice.
+func (a *DeltaAggregator) Flush() {
+	a.drain(false)
+}
+
+func (a *DeltaAggregator) drain(includeCurrent bool) {
+	current := a.now().Truncate(a.bucket).Unix()
+	var completed []DeltaPoint
+
+	a.mtx.Lock()
+	for key, point := range a.points {
+		if includeCurrent || a.bucket <= 0 || key.bucket < current {
+			completed = append(completed, *point)
+			delete(a.points, key)
+		}
+	}
+	a.mtx.Unlock()
+
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].Timestamp < completed[j].Timestamp
+	})
+	for _, point := range completed {
+		a.emit(point)
+	}
+}
+
+func (a *DeltaAggregator) Start() {
+	if a.ticker != nil {
+		return
+	}
+	a.ticker = time.NewTicker(a.interval)
+	go func() {
+		for {
+			select {
+			case <-a.ticker.C:
+				a.drain(false)
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background emission and emits every remaining bucket, including the current one.
+func (a *DeltaAggregator) Stop() {
+	a.stopOnce.Do(func() {
+		if a.ticker != nil {
+			a.ticker.Stop()
+		}
+		close(a.stop)
+	})
+	a.drain(true)
+}
+
+// SeriesKey returns a key identifying the series of the given name, source and tags.
+func SeriesKey(name, source string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sb := GetBuffer()
+	defer PutBuffer(sb)
+	sb.WriteString(name)
+	sb.WriteByte(0)
+	sb.WriteString(source)
+	for _, k := range keys {
+		sb.WriteByte(0)
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(tags[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaAggregator_BucketsByMinuteAndSeries(t *testing.T) {
+	var emitted []DeltaPoint
+	a := NewDeltaAggregator(time.Minute, time.Second, func(p DeltaPoint) {
+		emitted = append(emitted, p)
+	})
+	now := time.Date(2023, 1, 1, 10, 0, 5, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	tags := map[string]string{"env": "prod", "region": "us"}
+	a.Add("∆requests", 1, "web-01", tags)
+	a.Add("∆requests", 2, "web-01", map[string]string{"region": "us", "env": "prod"})
+	a.Add("∆requests", 4, "web-02", tags)
+	tags["env"] = "mutated"
+
+	now = now.Add(time.Minute)
+	a.Add("∆requests", 8, "web-01", map[string]string{"env": "prod", "region": "us"})
+
+	a.drain(false)
+	assert.ElementsMatch(t, []DeltaPoint{
+		{Name: "∆requests", Value: 3, Timestamp: 1672567200, Source: "web-01", Tags: map[string]string{"env": "prod", "region": "us"}},
+		{Name: "∆requests", Value: 4, Timestamp: 1672567200, Source: "web-02", Tags: map[string]string{"env": "prod", "region": "us"}},
+	}, emitted)
+
+	// the current bucket is left for later increments.
+	emitted = nil
+	a.Flush()
+	assert.Empty(t, emitted)
+	a.Add("∆requests", 16, "web-01", map[string]string{"env": "prod", "region": "us"})
+
+	now = now.Add(time.Minute)
+	a.Flush()
+	assert.Equal(t, []DeltaPoint{
+		{Name: "∆requests", Value: 24, Timestamp: 1672567260, Source: "web-01", Tags: map[string]string{"env": "prod", "region": "us"}},
+	}, emitted)
+}
+
+func TestDeltaAggregator_StopEmitsEverything(t *testing.T) {
+	count := 0
+	a := NewDeltaAggregator(time.Minute, time.Hour, func(p DeltaPoint) {
+		count++
+	})
+	a.Start()
+	a.Add("∆a", 1, "", nil)
+	a.Add("∆b", 1, "", nil)
+	a.Stop()
+	assert.Equal(t, 2, count)
+	a.Stop()
+	assert.Equal(t, 2, count)
+}
+
+func TestDeltaAggregator_WithoutBuckets(t *testing.T) {
+	var emitted []DeltaPoint
+	a := NewDeltaAggregator(0, time.Second, func(p DeltaPoint) {
+		emitted = append(emitted, p)
+	})
+	a.Add("∆requests", 1, "web-01", nil)
+	a.Add("∆requests", 2, "web-01", nil)
+	a.AddAt(time.Unix(1672567205, 0), "∆requests", 4, "web-01", nil)
+
+	a.drain(false)
+	assert.ElementsMatch(t, []DeltaPoint{
+		{Name: "∆requests", Value: 3, Source: "web-01", Tags: map[string]string{}},
+		{Name: "∆requests", Value: 4, Timestamp: 1672567205, Source: "web-01", Tags: map[string]string{}},
+	}, emitted)
+}
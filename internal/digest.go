@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+const (
+	digestHeader     = "Digest"
+	wantDigestHeader = "Want-Digest"
+)
+
+// DigestError is returned when the endpoint, or a gateway in front of it, rejects a report
+// request because of its Digest header, e.g. when the payload was altered on its way.
+type DigestError struct {
+	// StatusCode is the status of the rejected request.
+	StatusCode int
+	// Digest is the Digest header that was sent.
+	Digest string
+	// WantDigest is the Want-Digest header of the response, listing the digests the endpoint accepts.
+	WantDigest string
+}
+
+func (e *DigestError) Error() string {
+	return fmt.Sprintf("payload digest rejected. status=%d digest=%q want-digest=%q", e.StatusCode, e.Digest, e.WantDigest)
+}
+
+// SetPayloadDigest adds a Digest header (RFC 3230) holding the SHA-256 digest of the body of every
+// report request, as sent, i.e. after compression. A 4xx response carrying a Want-Digest header
+// is reported as a *DigestError.
+func SetPayloadDigest() ReporterOption {
+	return func(s *reporterSettings) {
+		s.digest = true
+	}
+}
+
+func payloadDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// sendDigested sends req after setting the Digest header for body, when enabled,
+// and turns a rejection of the digest into a *DigestError.
+func (s reporterSettings) sendDigested(client *http.Client, req *http.Request, body []byte) (*http.Response, error) {
+	if !s.digest {
+		return s.send(client, req)
+	}
+	digest := payloadDigest(body)
+	req.Header.Set(digestHeader, digest)
+	resp, err := s.send(client, req)
+	if err != nil {
+		return resp, err
+	}
+	if want := resp.Header.Get(wantDigestHeader); want != "" && 400 <= resp.StatusCode && resp.StatusCode <= 499 {
+		return nil, &DigestError{StatusCode: resp.StatusCode, Digest: digest, WantDigest: want}
+	}
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestPayloadDigest(t *testing.T) {
+	var digests, want []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		digests = append(digests, r.Header.Get("Digest"))
+		want = append(want, "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	}))
+	defer server.Close()
+
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), server.Client(), SetPayloadDigest())
+	_, err := r.Report(metricFormat, []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	_, err = r.Report(eventFormat, []byte("{\"name\":\"event\"}"))
+	require.NoError(t, err)
+	assert.Equal(t, want, digests)
+
+	digests, want = nil, nil
+	r = NewReporter(server.URL, auth.NewNoopTokenService(), server.Client())
+	_, err = r.Report(metricFormat, []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, digests)
+}
+
+func TestPayloadDigest_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Want-Digest", "SHA-512")
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), server.Client(), SetPayloadDigest())
+	lh := NewLineHandler(r, metricFormat, 0, 10, 10)
+	require.NoError(t, lh.HandleLine("\"foo\" 1 source=\"bar\"\n"))
+	result := lh.FlushAllWithResult()
+	var digestErr *DigestError
+	require.True(t, errors.As(result.Err, &digestErr))
+	assert.Equal(t, http.StatusBadRequest, digestErr.StatusCode)
+	assert.Equal(t, "SHA-512", digestErr.WantDigest)
+	assert.Contains(t, digestErr.Digest, "SHA-256=")
+	assert.Equal(t, 1, result.Buffered)
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+const (
+	grpcContentType = "application/grpc"
+	grpcStatus      = "Grpc-Status"
+	grpcEncoding    = "Grpc-Encoding"
+)
+
+// The implementation of a Reporter that makes unary gRPC calls, over the HTTP/2 support of net/http.
+type grpcReporter struct {
+	url          string
+	tokenService auth.Service
+	client       *http.Client
+	reporterSettings
+}
+
+// NewGRPCReporter creates a Reporter calling the unary gRPC method at url, e.g.
+// https://localhost:4317/opentelemetry.proto.collector.metrics.v1.MetricsService/Export,
+// with each batch as the request message. As net/http only speaks HTTP/2 over TLS, url must be https.
+// The gRPC status of a call is reported as the HTTP status closest to it, so that failed calls
+// are retried and buffered like failed HTTP requests, and compressed calls failing with
+// UNIMPLEMENTED fall back to the next compressor like HTTP requests rejected with 415.
+// Of the ReporterOptions, the report path and query options do not apply.
+func NewGRPCReporter(url string, tokenService auth.Service, client *http.Client, setters ...ReporterOption) Reporter {
+	var grpcClient http.Client
+	if client != nil {
+		grpcClient = *withRedirectPolicy(client)
+	}
+	transport := grpcClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	grpcClient.Transport = grpcTransport{transport}
+	return &grpcReporter{
+		url:              url,
+		tokenService:     tokenService,
+		client:           &grpcClient,
+		reporterSettings: newReporterSettings(setters),
+	}
+}
+
+func (reporter grpcReporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	if format == "" || len(pointLines) == 0 {
+		return nil, formatError
+	}
+
+	return reporter.sendCompressed(reporter.client, format, pointLines, func(body []byte, encoding string) (*http.Request, error) {
+		req, err := http.NewRequest("POST", reporter.url, bytes.NewReader(grpcFrame(body, encoding != "")))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(contentType, grpcContentType)
+		req.Header.Set("TE", "trailers")
+		if encoding != "" {
+			req.Header.Set(grpcEncoding, encoding)
+		}
+		if err = reporter.tokenService.Authorize(req); err != nil {
+			return nil, err
+		}
+		reporter.applyHeaders(req)
+		return req, nil
+	})
+}
+
+// grpcFrame prefixes a message with its gRPC length-prefixed message header.
+func grpcFrame(message []byte, compressed bool) []byte {
+	frame := make([]byte, 5, 5+len(message))
+	if compressed {
+		frame[0] = 1
+	}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	return append(frame, message...)
+}
+
+// grpcTransport replaces the status of successful HTTP responses with the one mapped from
+// their gRPC status, which is sent in the trailers, or in the headers of trailers-only responses.
+type grpcTransport struct {
+	http.RoundTripper
+}
+
+func (t grpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	status := resp.Trailer.Get(grpcStatus)
+	if status == "" {
+		status = resp.Header.Get(grpcStatus)
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		code = grpcUnknown
+	}
+	resp.StatusCode = grpcHTTPStatus(code)
+	if code == grpcUnimplemented && req.Header.Get(grpcEncoding) != "" {
+		// gRPC servers reject the encodings they don't support with UNIMPLEMENTED,
+		// reported like the HTTP rejection so that the next compressor is tried.
+		resp.StatusCode = http.StatusUnsupportedMediaType
+	}
+	resp.Status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	return resp, nil
+}
+
+// gRPC status codes, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	grpcOK                = 0
+	grpcUnknown           = 2
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcPermissionDenied  = 7
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcUnavailable       = 14
+	grpcUnauthenticated   = 16
+)
+
+func grpcHTTPStatus(code int) int {
+	switch code {
+	case grpcOK:
+		return http.StatusOK
+	case grpcInvalidArgument:
+		return http.StatusBadRequest
+	case grpcDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case grpcPermissionDenied:
+		return http.StatusForbidden
+	case grpcResourceExhausted:
+		return http.StatusTooManyRequests
+	case grpcUnimplemented:
+		return http.StatusNotImplemented
+	case grpcUnavailable:
+		return http.StatusServiceUnavailable
+	case grpcUnauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func startGRPCServer(t *testing.T, status string, messages chan<- []byte) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, "application/grpc", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(body), 5)
+		assert.Equal(t, r.Header.Get("Grpc-Encoding") != "", body[0] == 1)
+		assert.Equal(t, uint32(len(body)-5), binary.BigEndian.Uint32(body[1:5]))
+		messages <- body[5:]
+
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		if r.Header.Get("Grpc-Encoding") != "" {
+			w.Header().Set("Grpc-Status", "12")
+			return
+		}
+		w.Header().Set("Grpc-Status", status)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	return server
+}
+
+func TestGRPCReporter(t *testing.T) {
+	messages := make(chan []byte, 1)
+	server := startGRPCServer(t, "0", messages)
+	defer server.Close()
+
+	r := NewGRPCReporter(server.URL+"/test.Service/Export", auth.NewNoopTokenService(), server.Client(), SetCompression(false))
+	resp, err := r.Report("wavefront", []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []byte("message"), <-messages)
+}
+
+func TestGRPCReporter_Status(t *testing.T) {
+	messages := make(chan []byte, 1)
+	server := startGRPCServer(t, "16", messages)
+	defer server.Close()
+
+	r := NewGRPCReporter(server.URL+"/test.Service/Export", auth.NewNoopTokenService(), server.Client(), SetCompression(false))
+	resp, err := r.Report("wavefront", []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	<-messages
+
+	assert.Equal(t, http.StatusServiceUnavailable, grpcHTTPStatus(14))
+	assert.Equal(t, http.StatusTooManyRequests, grpcHTTPStatus(8))
+	assert.Equal(t, http.StatusInternalServerError, grpcHTTPStatus(13))
+}
+
+func TestGRPCReporter_CompressorFallback(t *testing.T) {
+	messages := make(chan []byte, 2)
+	server := startGRPCServer(t, "0", messages)
+	defer server.Close()
+
+	r := NewGRPCReporter(server.URL+"/test.Service/Export", auth.NewNoopTokenService(), server.Client(),
+		SetCompressors(compression.Gzip(), compression.None()))
+	resp, err := r.Report("wavefront", []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	<-messages
+	assert.Equal(t, []byte("message"), <-messages)
+
+	// an uncompressed call failing with UNIMPLEMENTED is not an encoding rejection.
+	server = startGRPCServer(t, "12", messages)
+	defer server.Close()
+	r = NewGRPCReporter(server.URL+"/test.Service/Export", auth.NewNoopTokenService(), server.Client(), SetCompression(false))
+	resp, err = r.Report("wavefront", []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	<-messages
+}
@@ -12,6 +12,8 @@ type HandlerFactory struct {
 	flushInterval      time.Duration
 	bufferSize         int
 	lineHandlerOptions []LineHandlerOption
+	rateLimiter        *RateLimiter
+	flushIntervals     map[string]time.Duration
 }
 
 func NewHandlerFactory(
@@ -19,27 +21,58 @@ func NewHandlerFactory(
 	tracesReporter Reporter,
 	flushInterval time.Duration,
 	bufferSize int,
-	registry sdkmetrics.Registry) *HandlerFactory {
+	registry sdkmetrics.Registry,
+	options ...LineHandlerOption) *HandlerFactory {
+	lineHandlerOptions := append([]LineHandlerOption{SetRegistry(registry)}, options...)
 	return &HandlerFactory{
 		metricsReporter: metricsReporter,
 		tracesReporter:  tracesReporter,
 		flushInterval:   flushInterval,
 		bufferSize:      bufferSize,
-		lineHandlerOptions: []LineHandlerOption{
-			SetRegistry(registry),
-		},
+		// clipped, so that each handler appends its own options to a copy.
+		lineHandlerOptions: lineHandlerOptions[:len(lineHandlerOptions):len(lineHandlerOptions)],
 	}
 }
 
+// SetFlushIntervals overrides the flush interval of the point, histogram, span and span log, and
+// event handlers created afterwards. A zero interval keeps the factory's flush interval.
+func (f *HandlerFactory) SetFlushIntervals(points, histograms, traces, events time.Duration) {
+	f.flushIntervals = map[string]time.Duration{}
+	for format, interval := range map[string]time.Duration{
+		metricFormat:    points,
+		histogramFormat: histograms,
+		traceFormat:     traces,
+		spanLogsFormat:  traces,
+		eventFormat:     events,
+	} {
+		if interval > 0 {
+			f.flushIntervals[format] = interval
+		}
+	}
+}
+
+func (f *HandlerFactory) flushIntervalOf(format string) time.Duration {
+	if interval, ok := f.flushIntervals[format]; ok {
+		return interval
+	}
+	return f.flushInterval
+}
+
+// SetRateLimiter makes the point, histogram and span handlers created afterwards share limiter.
+func (f *HandlerFactory) SetRateLimiter(limiter *RateLimiter) {
+	f.rateLimiter = limiter
+}
+
 func (f *HandlerFactory) NewPointHandler(batchSize int) *RealLineHandler {
 	return NewLineHandler(
 		f.metricsReporter,
 		metricFormat,
-		f.flushInterval,
+		f.flushIntervalOf(metricFormat),
 		batchSize,
 		f.bufferSize,
 		append(f.lineHandlerOptions,
-			SetHandlerPrefix("points"))...,
+			SetHandlerPrefix(sdkmetrics.PointsPrefix),
+			SetRateLimiter(f.rateLimiter))...,
 	)
 }
 
@@ -47,11 +80,12 @@ func (f *HandlerFactory) NewHistogramHandler(batchSize int) *RealLineHandler {
 	return NewLineHandler(
 		f.metricsReporter,
 		histogramFormat,
-		f.flushInterval,
+		f.flushIntervalOf(histogramFormat),
 		batchSize,
 		f.bufferSize,
 		append(f.lineHandlerOptions,
-			SetHandlerPrefix("histograms"))...,
+			SetHandlerPrefix(sdkmetrics.HistogramsPrefix),
+			SetRateLimiter(f.rateLimiter))...,
 	)
 }
 
@@ -59,11 +93,12 @@ func (f *HandlerFactory) NewSpanHandler(batchSize int) *RealLineHandler {
 	return NewLineHandler(
 		f.tracesReporter,
 		traceFormat,
-		f.flushInterval,
+		f.flushIntervalOf(traceFormat),
 		batchSize,
 		f.bufferSize,
 		append(f.lineHandlerOptions,
-			SetHandlerPrefix("spans"))...,
+			SetHandlerPrefix(sdkmetrics.SpansPrefix),
+			SetRateLimiter(f.rateLimiter))...,
 	)
 }
 
@@ -71,11 +106,11 @@ func (f *HandlerFactory) NewSpanLogHandler(batchSize int) *RealLineHandler {
 	return NewLineHandler(
 		f.tracesReporter,
 		spanLogsFormat,
-		f.flushInterval,
+		f.flushIntervalOf(spanLogsFormat),
 		batchSize,
 		f.bufferSize,
 		append(f.lineHandlerOptions,
-			SetHandlerPrefix("span_logs"))...,
+			SetHandlerPrefix(sdkmetrics.SpanLogsPrefix))...,
 	)
 }
 
@@ -86,11 +121,11 @@ func (f *HandlerFactory) NewEventHandler() *RealLineHandler {
 	return NewLineHandler(
 		f.metricsReporter,
 		eventFormat,
-		f.flushInterval,
+		f.flushIntervalOf(eventFormat),
 		1,
 		f.bufferSize,
 		append(f.lineHandlerOptions,
-			SetHandlerPrefix("events"),
+			SetHandlerPrefix(sdkmetrics.EventsPrefix),
 			ThrottleRequestsOnBackpressure())...,
 	)
 }
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+func TestHandlerFactory_FlushIntervals(t *testing.T) {
+	f := NewHandlerFactory(&fakeReporter{}, &fakeReporter{}, time.Second, 10, sdkmetrics.NewNoOpRegistry())
+	f.SetFlushIntervals(0, time.Minute, 100*time.Millisecond, 0)
+
+	interval := func(lh *RealLineHandler) time.Duration {
+		return lh.flusher.(*backgroundFlusher).interval
+	}
+	assert.Equal(t, time.Second, interval(f.NewPointHandler(10)))
+	assert.Equal(t, time.Minute, interval(f.NewHistogramHandler(10)))
+	assert.Equal(t, 100*time.Millisecond, interval(f.NewSpanHandler(10)))
+	assert.Equal(t, 100*time.Millisecond, interval(f.NewSpanLogHandler(10)))
+	assert.Equal(t, time.Second, interval(f.NewEventHandler()))
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// sdkFormatsHeader lists the line formats a sender may report, in handshake requests.
+	sdkFormatsHeader = "X-WF-SDK-Formats"
+	// formatsHeader lists the line formats an endpoint accepts, in handshake responses.
+	formatsHeader = "X-WF-Formats"
+)
+
+// Handshaker is implemented by the Reporters able to ask their endpoint which formats it accepts.
+type Handshaker interface {
+	// Handshake declares formats to the endpoint, and returns the formats it accepts, nil when it
+	// does not advertise them.
+	Handshake(formats []string) ([]string, error)
+}
+
+// Handshake sends an OPTIONS request to the report path, declaring formats in the X-WF-SDK-Formats
+// header, and returns the comma separated formats of the X-WF-Formats header of the response.
+func (reporter reporter) Handshake(formats []string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodOptions, reporter.serverURL+reporter.reportPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(sdkFormatsHeader, strings.Join(formats, ","))
+	if err := reporter.tokenService.Authorize(req); err != nil {
+		return nil, err
+	}
+	reporter.applyHeaders(req)
+
+	resp, err := reporter.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return nil, fmt.Errorf("handshake failed: %s", resp.Status)
+	}
+	return parseFormats(resp.Header.Get(formatsHeader)), nil
+}
+
+func parseFormats(header string) []string {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	var formats []string
+	for _, format := range strings.Split(header, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
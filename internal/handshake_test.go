@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestReporter_Handshake(t *testing.T) {
+	var declared string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodOptions, r.Method)
+		declared = r.Header.Get(sdkFormatsHeader)
+		switch r.URL.Path {
+		case "/new/report":
+			w.Header().Set(formatsHeader, "wavefront, histogram,,trace")
+		case "/failing/report":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/old/report":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	handshake := func(path string) ([]string, error) {
+		r := NewReporter(server.URL+path, auth.NewNoopTokenService(), &http.Client{})
+		return r.(Handshaker).Handshake([]string{"wavefront", "spanLogs"})
+	}
+
+	formats, err := handshake("/new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wavefront", "histogram", "trace"}, formats)
+	assert.Equal(t, "wavefront,spanLogs", declared)
+
+	formats, err = handshake("/old")
+	require.NoError(t, err)
+	assert.Nil(t, formats)
+
+	_, err = handshake("/failing")
+	assert.ErrorContains(t, err, "500")
+}
@@ -14,6 +14,12 @@ import (
 // {!M | !H | !D} [<timestamp>] #<count> <mean> [centroids] <histogramName> source=<source> [pointTags]
 // Example: "!M 1533531013 #20 30.0 #10 5.1 request.latency source=appServer1 region=us-west"
 func Line(name string, centroids histogram.Centroids, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string, defaultSource string) (string, error) {
+	return LineWith(internal.QuoteKey, name, centroids, hgs, ts, source, tags, defaultSource)
+}
+
+// LineWith returns the histogram line of Line, with the name and tag keys quoted by quoteKey, see
+// internal.NewKeyQuoter.
+func LineWith(quoteKey func(string) string, name string, centroids histogram.Centroids, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string, defaultSource string) (string, error) {
 	if name == "" {
 		return "", errors.New("empty distribution name")
 	}
@@ -45,18 +51,18 @@ func Line(name string, centroids histogram.Centroids, hgs map[histogram.Granular
 		sb.WriteString(strconv.FormatFloat(centroid.Value, 'f', -1, 64))
 	}
 	sb.WriteString(" ")
-	sb.WriteString(strconv.Quote(internal.Sanitize(name)))
+	sb.WriteString(quoteKey(name))
 	sb.WriteString(" source=")
-	sb.WriteString(internal.SanitizeValue(source))
+	sb.WriteString(internal.QuoteValue(source))
 
 	for k, v := range tags {
 		if v == "" {
 			return "", fmt.Errorf("tag values cannot be empty: histogram=%s tag=%s", name, k)
 		}
 		sb.WriteString(" ")
-		sb.WriteString(strconv.Quote(internal.Sanitize(k)))
+		sb.WriteString(quoteKey(k))
 		sb.WriteString("=")
-		sb.WriteString(internal.SanitizeValue(v))
+		sb.WriteString(internal.QuoteValue(v))
 	}
 	sbBytes := sb.Bytes()
 
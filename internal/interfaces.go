@@ -2,11 +2,15 @@
 // Interfaces within this package are not guaranteed to be backwards compatible between releases.
 package internal
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // Reporter is an interface for reporting data to a Wavefront service.
+// pointLines is owned by the caller and may be reused once Report returns.
 type Reporter interface {
-	Report(format string, pointLines string) (*http.Response, error)
+	Report(format string, pointLines []byte) (*http.Response, error)
 }
 
 type Flusher interface {
@@ -26,10 +30,14 @@ type ConnectionHandler interface {
 
 type LineHandler interface {
 	HandleLine(line string) error
+	// HandleLineCtx waits for room in the buffer until ctx is done, instead of dropping the line.
+	HandleLineCtx(ctx context.Context, line string) error
 	Start()
 	Stop()
 	Flush() error
 	FlushWithThrottling() error
+	// FlushAllWithResult reports all buffered lines and counts their outcome.
+	FlushAllWithResult() FlushResult
 	GetFailureCount() int64
 	Format() string
 }
@@ -37,7 +45,6 @@ type LineHandler interface {
 const (
 	contentType     = "Content-Type"
 	contentEncoding = "Content-Encoding"
-	gzipFormat      = "gzip"
 
 	octetStream     = "application/octet-stream"
 	applicationJSON = "application/json"
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"strconv"
+	"sync"
+)
+
+// maxInterned bounds the number of strings each interner of the formatters keeps.
+const maxInterned = 10000
+
+var (
+	quotedKeys   = NewInterner(func(s string) string { return strconv.Quote(Sanitize(s)) }, maxInterned)
+	quotedValues = NewInterner(SanitizeValue, maxInterned)
+)
+
+// QuoteKey returns the sanitized and quoted form of a metric name or tag key. The most common
+// ones are computed once and shared, so that formatting repeated tag sets does not allocate.
+func QuoteKey(s string) string {
+	return quotedKeys.Get(s)
+}
+
+// NewKeyQuoter returns a function quoting metric names and tag keys as QuoteKey does, sanitized
+// with sanitize rather than Sanitize.
+func NewKeyQuoter(sanitize func(string) string) func(string) string {
+	return NewInterner(func(s string) string { return strconv.Quote(sanitize(s)) }, maxInterned).Get
+}
+
+// QuoteValue returns the SanitizeValue form of a source or tag value, interned like QuoteKey.
+func QuoteValue(s string) string {
+	return quotedValues.Get(s)
+}
+
+// Interner memoizes a string function for the first max distinct strings it is given; others,
+// e.g. the values of high cardinality tags, are computed on every call, which bounds its memory.
+type Interner struct {
+	fn  func(string) string
+	max int
+
+	mtx     sync.RWMutex
+	strings map[string]string
+}
+
+// NewInterner creates an Interner memoizing fn for up to max strings.
+func NewInterner(fn func(string) string, max int) *Interner {
+	return &Interner{fn: fn, max: max, strings: map[string]string{}}
+}
+
+// Get returns fn(s), from memory when s was given before.
+func (i *Interner) Get(s string) string {
+	i.mtx.RLock()
+	result, ok := i.strings[s]
+	full := len(i.strings) >= i.max
+	i.mtx.RUnlock()
+	if ok {
+		return result
+	}
+	result = i.fn(s)
+	if full {
+		return result
+	}
+	i.mtx.Lock()
+	if len(i.strings) < i.max {
+		i.strings[s] = result
+	}
+	i.mtx.Unlock()
+	return result
+}
+
+// Len returns the number of strings in memory.
+func (i *Interner) Len() int {
+	i.mtx.RLock()
+	defer i.mtx.RUnlock()
+	return len(i.strings)
+}
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterner(t *testing.T) {
+	calls := 0
+	interner := NewInterner(func(s string) string {
+		calls++
+		return "<" + s + ">"
+	}, 2)
+
+	assert.Equal(t, "<a>", interner.Get("a"))
+	assert.Equal(t, "<a>", interner.Get("a"))
+	assert.Equal(t, "<b>", interner.Get("b"))
+	assert.Equal(t, 2, calls)
+
+	// once full, other strings are computed on every call.
+	assert.Equal(t, "<c>", interner.Get("c"))
+	assert.Equal(t, "<c>", interner.Get("c"))
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 2, interner.Len())
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, strconv.Quote(Sanitize("hello world")), QuoteKey("hello world"))
+	assert.Equal(t, strconv.Quote(Sanitize("∆requests")), QuoteKey("∆requests"))
+	assert.Equal(t, SanitizeValue(" say \"hi\"\n"), QuoteValue(" say \"hi\"\n"))
+}
+
+var quoted string
+
+// BenchmarkQuoteKey and BenchmarkQuoteKeyUninterned compare formatting a repeated tag set with and
+// without interning.
+func BenchmarkQuoteKey(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		quoted = QuoteKey("service.name") + QuoteValue("checkout")
+	}
+}
+
+func BenchmarkQuoteKeyUninterned(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		quoted = strconv.Quote(Sanitize("service.name")) + SanitizeValue("checkout")
+	}
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJournalMaxBytes = 10 * 1024 * 1024
+	defaultJournalMaxFiles = 5
+)
+
+// JournalEntry records a report request: when it was sent, where, what it carried and how it went.
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Endpoint string    `json:"endpoint"`
+	Format   string    `json:"format"`
+	// Lines is the number of lines of the batch, Bytes its size before compression.
+	Lines int `json:"lines"`
+	Bytes int `json:"bytes"`
+	// Status is the status code of the response, zero if none was received.
+	Status  int           `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Journal appends JournalEntries, one JSON object per line, to a file rotated once it grows over
+// maxBytes: the file at path is renamed path.1, path.1 is renamed path.2 and so on, up to maxFiles
+// rotated files.
+type Journal struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+
+	mtx    sync.Mutex
+	file   *os.File
+	size   int64
+	closed bool
+}
+
+// NewJournal creates a journal at path. The file is opened, and appended to if it exists, when
+// the first entry is recorded. Zero or negative maxBytes and maxFiles default to 10 MiB and 5 files.
+func NewJournal(path string, maxBytes int64, maxFiles int) *Journal {
+	if maxBytes <= 0 {
+		maxBytes = defaultJournalMaxBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultJournalMaxFiles
+	}
+	return &Journal{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+}
+
+func (j *Journal) open() error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open journal: %s", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to open journal: %s", err)
+	}
+	j.file, j.size = file, info.Size()
+	return nil
+}
+
+// Record appends entry to the journal, rotating it first if it is over its size.
+func (j *Journal) Record(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	if j.closed {
+		return os.ErrClosed
+	}
+	if j.file == nil {
+		if err := j.open(); err != nil {
+			return err
+		}
+	}
+	if j.size > 0 && j.size+int64(len(data)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.Write(data)
+	j.size += int64(n)
+	return err
+}
+
+// rotate shifts the rotated files and starts a new file. It must be called with mtx held.
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("unable to rotate journal: %s", err)
+	}
+	j.file = nil
+	_ = os.Remove(rotatedPath(j.path, j.maxFiles))
+	for i := j.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedPath(j.path, i), rotatedPath(j.path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to rotate journal: %s", err)
+		}
+	}
+	if err := os.Rename(j.path, rotatedPath(j.path, 1)); err != nil {
+		return fmt.Errorf("unable to rotate journal: %s", err)
+	}
+	return j.open()
+}
+
+// Close closes the journal file. Entries recorded afterwards are discarded.
+func (j *Journal) Close() error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.closed = true
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+func rotatedPath(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// ReadJournal returns the entries of the journal at path, rotated files included, oldest first.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	var files []string
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotatedPath(path, i)); err != nil {
+			break
+		}
+		files = append([]string{rotatedPath(path, i)}, files...)
+	}
+	files = append(files, path)
+
+	var entries []JournalEntry
+	for _, file := range files {
+		read, err := readJournalFile(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, read...)
+	}
+	return entries, nil
+}
+
+func readJournalFile(path string) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry at %s:%d: %s", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestJournalRotation(t *testing.T) {
+	entry := func(i int) JournalEntry {
+		return JournalEntry{Endpoint: "http://localhost/report", Lines: i, Status: 200}
+	}
+	data, err := json.Marshal(entry(1))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// room for two entries per file, and one rotated file.
+	j := NewJournal(path, int64(2*(len(data)+1)), 1)
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, j.Record(entry(i)))
+	}
+	require.NoError(t, j.Close())
+	assert.ErrorIs(t, j.Record(JournalEntry{}), os.ErrClosed)
+
+	_, err = os.Stat(path + ".2")
+	assert.True(t, os.IsNotExist(err))
+	entries, err := ReadJournal(path)
+	require.NoError(t, err)
+	var lines []int
+	for _, entry := range entries {
+		lines = append(lines, entry.Lines)
+	}
+	assert.Equal(t, []int{3, 4, 5, 6}, lines)
+}
+
+func TestReporterJournal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("f") == histogramFormat {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	journal := NewJournal(path, 0, 0)
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{}, SetJournal(journal))
+	_, err := r.Report(metricFormat, []byte("a 1\nb 2\n"))
+	require.NoError(t, err)
+	_, err = r.Report(histogramFormat, []byte("!M #1 1 h\n"))
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+
+	entries, err := ReadJournal(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, server.URL+"/report?f=wavefront", entries[0].Endpoint)
+	assert.Equal(t, metricFormat, entries[0].Format)
+	assert.Equal(t, 2, entries[0].Lines)
+	assert.Equal(t, 8, entries[0].Bytes)
+	assert.Equal(t, http.StatusAccepted, entries[0].Status)
+	assert.WithinDuration(t, time.Now(), entries[0].Time, time.Minute)
+	assert.Equal(t, histogramFormat, entries[1].Format)
+	assert.Equal(t, http.StatusBadRequest, entries[1].Status)
+}
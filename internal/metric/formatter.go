@@ -4,46 +4,138 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 
 	"github.com/wavefronthq/wavefront-sdk-go/internal"
 )
 
+// lineBuffers pools the byte slices Line formats lines into.
+var lineBuffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
 // Gets a metric line in the Wavefront metrics data format:
 // <metricName> <metricValue> [<timestamp>] source=<source> [pointTags]
 // Example: "new-york.power.usage 42422.0 1533531013 source=localhost datacenter=dc1"
 func Line(name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) (string, error) {
+	return LineWith(internal.QuoteKey, name, value, ts, source, tags, defaultSource)
+}
+
+// LineWith returns the metric line of Line, with the name and tag keys quoted by quoteKey, see
+// internal.NewKeyQuoter.
+func LineWith(quoteKey func(string) string, name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) (string, error) {
+	buf := lineBuffers.Get().(*[]byte)
+	defer lineBuffers.Put(buf)
+
+	var err error
+	*buf, err = AppendLineWith((*buf)[:0], quoteKey, name, value, ts, source, tags, defaultSource)
+	if err != nil {
+		return "", err
+	}
+	return string(*buf), nil
+}
+
+// AppendLine appends the metric line of Line to dst and returns the extended slice. It does not
+// allocate beyond growing dst, as long as the name, source and tags were formatted before.
+func AppendLine(dst []byte, name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) ([]byte, error) {
+	return AppendLineWith(dst, internal.QuoteKey, name, value, ts, source, tags, defaultSource)
+}
+
+// AppendLineWith appends the metric line of LineWith to dst, as AppendLine does.
+func AppendLineWith(dst []byte, quoteKey func(string) string, name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) ([]byte, error) {
 	if name == "" {
-		return "", errors.New("empty metric name")
+		return dst, errors.New("empty metric name")
 	}
 
 	if source == "" {
 		source = defaultSource
 	}
 
-	sb := internal.GetBuffer()
-	defer internal.PutBuffer(sb)
-
-	sb.WriteString(strconv.Quote(internal.Sanitize(name)))
-	sb.WriteString(" ")
-	sb.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	start := len(dst)
+	dst = append(dst, quoteKey(name)...)
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, value, 'f', -1, 64)
 
 	if ts != 0 {
-		sb.WriteString(" ")
-		sb.WriteString(strconv.FormatInt(ts, 10))
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, ts, 10)
 	}
 
-	sb.WriteString(" source=")
-	sb.WriteString(internal.SanitizeValue(source))
+	dst = append(dst, " source="...)
+	dst = append(dst, internal.QuoteValue(source)...)
 
 	for k, v := range tags {
 		if v == "" {
-			return "", fmt.Errorf("tag values cannot be empty: metric=%s tag=%s", name, k)
+			return dst[:start], fmt.Errorf("tag values cannot be empty: metric=%s tag=%s", name, k)
+		}
+		dst = append(dst, ' ')
+		dst = append(dst, quoteKey(k)...)
+		dst = append(dst, '=')
+		dst = append(dst, internal.QuoteValue(v)...)
+	}
+	return append(dst, '\n'), nil
+}
+
+// RawLine returns the metric line of AppendRawLine.
+func RawLine(name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) (string, error) {
+	buf := lineBuffers.Get().(*[]byte)
+	defer lineBuffers.Put(buf)
+
+	var err error
+	*buf, err = AppendRawLine((*buf)[:0], name, value, ts, source, tags, defaultSource)
+	if err != nil {
+		return "", err
+	}
+	return string(*buf), nil
+}
+
+// AppendRawLine appends a metric line to dst as AppendLine does, with the name, source and tags
+// quoted as given rather than sanitized, for callers making sure they are valid. Only their
+// quotes and line breaks are escaped, so that they can't break the line.
+func AppendRawLine(dst []byte, name string, value float64, ts int64, source string, tags map[string]string, defaultSource string) ([]byte, error) {
+	if name == "" {
+		return dst, errors.New("empty metric name")
+	}
+
+	if source == "" {
+		source = defaultSource
+	}
+
+	dst = appendQuoted(dst, name)
+	dst = append(dst, ' ')
+	dst = strconv.AppendFloat(dst, value, 'f', -1, 64)
+
+	if ts != 0 {
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, ts, 10)
+	}
+
+	dst = append(dst, " source="...)
+	dst = appendQuoted(dst, source)
+
+	for k, v := range tags {
+		dst = append(dst, ' ')
+		dst = appendQuoted(dst, k)
+		dst = append(dst, '=')
+		dst = appendQuoted(dst, v)
+	}
+	return append(dst, '\n'), nil
+}
+
+func appendQuoted(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			dst = append(dst, '\\', '"')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		default:
+			dst = append(dst, s[i])
 		}
-		sb.WriteString(" ")
-		sb.WriteString(strconv.Quote(internal.Sanitize(k)))
-		sb.WriteString("=")
-		sb.WriteString(internal.SanitizeValue(v))
 	}
-	sb.WriteString("\n")
-	return sb.String(), nil
+	return append(dst, '"')
 }
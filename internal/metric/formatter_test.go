@@ -15,6 +15,7 @@ func BenchmarkMetricLine(b *testing.B) {
 	src := "test_source"
 	tags := map[string]string{"env": "test"}
 
+	b.ReportAllocs()
 	var r string
 	for n := 0; n < b.N; n++ {
 		r, _ = Line(name, value, ts, src, tags, "")
@@ -22,6 +23,42 @@ func BenchmarkMetricLine(b *testing.B) {
 	line = r
 }
 
+// BenchmarkMetricLine_RepeatedTags formats points with the same tag set over and over, the common
+// case that interned tag keys and values make cheaper.
+func BenchmarkMetricLine_RepeatedTags(b *testing.B) {
+	tags := map[string]string{"env": "prod", "service": "checkout", "region": "us-west-1", "version": "1.4.2"}
+	b.ReportAllocs()
+	var r string
+	for n := 0; n < b.N; n++ {
+		r, _ = Line("http.requests", float64(n), 1533529977, "web-01", tags, "")
+	}
+	line = r
+}
+
+// BenchmarkAppendMetricLine formats points into a reused buffer, which does not allocate.
+func BenchmarkAppendMetricLine(b *testing.B) {
+	tags := map[string]string{"env": "prod", "service": "checkout", "region": "us-west-1", "version": "1.4.2"}
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		buf, _ = AppendLine(buf[:0], "http.requests", float64(n), 1533529977, "web-01", tags, "")
+	}
+	line = string(buf)
+}
+
+func TestAppendLine(t *testing.T) {
+	buf, err := AppendLine([]byte("x "), "foo.metric", 1.2, 1533529977, "", map[string]string{"env": "test"}, "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "x \"foo.metric\" 1.2 1533529977 source=\"default\" \"env\"=\"test\"\n", string(buf))
+
+	buf, err = AppendLine(buf, "foo.metric", 1.2, 0, "src", map[string]string{"env": ""}, "")
+	assert.NotNil(t, err)
+	assert.Equal(t, "x \"foo.metric\" 1.2 1533529977 source=\"default\" \"env\"=\"test\"\n", string(buf))
+
+	_, err = AppendLine(nil, "", 1, 0, "src", nil, "")
+	assert.NotNil(t, err)
+}
+
 func TestMetricLine(t *testing.T) {
 	line, err := Line("foo.metric", 1.2, 1533529977, "test_source",
 		map[string]string{"env": "test"}, "")
@@ -41,3 +78,16 @@ func TestMetricLine(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, expected, line)
 }
+
+func TestRawLine(t *testing.T) {
+	line, err := RawLine("foo metric", 1.2, 1533529977, "", map[string]string{"env": "test"}, "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "\"foo metric\" 1.2 1533529977 source=\"default\" \"env\"=\"test\"\n", line)
+
+	line, err = RawLine("foo\"metric", 1, 0, "a\nsource", map[string]string{"env": "\"test\""}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "\"foo\\\"metric\" 1 source=\"a\\nsource\" \"env\"=\"\\\"test\\\"\"\n", line)
+
+	_, err = RawLine("", 1, 0, "src", nil, "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+// The implementation of a Reporter that posts raw Wavefront lines to an OTel collector's report endpoint.
+type otelReporter struct {
+	url          string
+	contentType  string
+	tokenService auth.Service
+	client       *http.Client
+	reporterSettings
+}
+
+// NewOTelReporter creates a Reporter posting every batch, whatever its format, to url.
+// Lines are sent with the given Content-Type, uncompressed unless SetCompression(true) is given.
+// Of the ReporterOptions, the report path and query options do not apply.
+func NewOTelReporter(url, contentType string, tokenService auth.Service, client *http.Client, setters ...ReporterOption) Reporter {
+	return &otelReporter{
+		url:              url,
+		contentType:      contentType,
+		tokenService:     tokenService,
+		client:           withRedirectPolicy(client),
+		reporterSettings: newReporterSettings(append([]ReporterOption{SetCompression(false)}, setters...)),
+	}
+}
+
+func (reporter otelReporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	if format == "" || len(pointLines) == 0 {
+		return nil, formatError
+	}
+
+	return reporter.sendCompressed(reporter.client, format, pointLines, func(body []byte, encoding string) (*http.Request, error) {
+		req, err := http.NewRequest("POST", reporter.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(contentType, reporter.contentType)
+		if encoding != "" {
+			req.Header.Set(contentEncoding, encoding)
+		}
+		if reporter.batchHeaders {
+			setBatchMetadataHeaders(req.Header, format, pointLines)
+		}
+		if err = reporter.tokenService.Authorize(req); err != nil {
+			return nil, err
+		}
+		reporter.applyHeaders(req)
+		return req, nil
+	})
+}
@@ -0,0 +1,46 @@
+package internal
+
+import "log"
+
+// SetMaxPayloadBytes splits the batches of the handler into report requests of at most maxBytes
+// bytes of lines each, before compression. A line longer than maxBytes is reported on its own.
+func SetMaxPayloadBytes(maxBytes int) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.maxPayloadBytes = maxBytes
+	}
+}
+
+// reportChunked reports lines in chunks of at most maxPayloadBytes bytes. The lines left after a
+// failed chunk are buffered for a later flush without being reported.
+func (lh *RealLineHandler) reportChunked(lines []string) FlushResult {
+	var result FlushResult
+	for len(lines) > 0 {
+		n := chunkLen(lines, lh.maxPayloadBytes)
+		chunk := lh.reportBatch(lines[:n])
+		result.add(chunk)
+		lines = lines[n:]
+		if chunk.Err != nil && len(lines) > 0 {
+			dropped := lh.bufferLines(lines)
+			result.Buffered += len(lines) - dropped
+			result.Dropped += dropped
+			break
+		}
+	}
+	return result
+}
+
+// chunkLen returns the number of lines, at least one, of the first chunk of at most maxBytes bytes.
+func chunkLen(lines []string, maxBytes int) int {
+	size := len(lines[0])
+	if size > maxBytes {
+		log.Printf("line of %d bytes over the payload limit of %d bytes, reporting it on its own\n", size, maxBytes)
+	}
+	n := 1
+	for ; n < len(lines); n++ {
+		if size+len(lines[n]) > maxBytes {
+			break
+		}
+		size += len(lines[n])
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkLen(t *testing.T) {
+	lines := []string{"a 1\n", "b 2\n", "c 3\n", strings.Repeat("x", 20) + "\n", "d 4\n"}
+	assert.Equal(t, 2, chunkLen(lines, 8))
+	assert.Equal(t, 3, chunkLen(lines, 15))
+	assert.Equal(t, 1, chunkLen(lines[3:], 8))
+	assert.Equal(t, 5, chunkLen(lines, 100))
+}
+
+func TestMaxPayloadBytes(t *testing.T) {
+	reporter := &fakeReporter{}
+	lh := NewLineHandler(reporter, "wavefront", time.Hour, 10, 100, SetMaxPayloadBytes(8))
+	for _, line := range []string{"a 1\n", "b 2\n", "c 3\n", "d 4\n", "e 5\n"} {
+		assert.NoError(t, lh.HandleLine(line))
+	}
+	result := lh.FlushAllWithResult()
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 5, result.Sent)
+	assert.Equal(t, []string{"a 1\nb 2\n", "c 3\nd 4\n", "e 5\n"}, reporter.lines)
+}
+
+func TestMaxPayloadBytes_Failure(t *testing.T) {
+	reporter := &fakeReporter{error: errors.New("connection refused")}
+	lh := NewLineHandler(reporter, "wavefront", time.Hour, 10, 100, SetMaxPayloadBytes(8))
+	for _, line := range []string{"a 1\n", "b 2\n", "c 3\n"} {
+		assert.NoError(t, lh.HandleLine(line))
+	}
+	result := lh.FlushAllWithResult()
+	assert.Error(t, result.Err)
+	// the lines after the failed request are buffered without being reported.
+	assert.Equal(t, 1, reporter.ReportCallCount())
+	assert.Equal(t, 3, result.Buffered)
+	assert.Len(t, lh.buffer, 3)
+
+	reporter.error = nil
+	reporter.SetHTTPStatus(http.StatusOK)
+	assert.NoError(t, lh.Flush())
+	assert.Equal(t, 3, reporter.ReportCallCount())
+}
@@ -0,0 +1,192 @@
+// Package persistence provides a disk-backed queue of line batches, so that lines which could
+// not be delivered survive process restarts.
+package persistence
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const segmentExt = ".batch"
+
+// ErrFull is returned by Append when the batch does not fit within the queue's maximum size.
+var ErrFull = errors.New("persistent buffer full")
+
+// errCorrupt is returned by decode for a batch whose records are truncated.
+var errCorrupt = errors.New("corrupt batch")
+
+// Queue is a FIFO of line batches stored in a directory, one file per batch. Each line is stored
+// as a record prefixed with its length, so that lines holding newlines, such as binary OTLP
+// payloads, are kept intact.
+// Files are written to a temporary name and renamed, so a crash never leaves a partial batch behind.
+type Queue struct {
+	dir      string
+	maxBytes int64
+
+	mtx      sync.Mutex
+	segments []segment
+	size     int64
+	nextSeq  uint64
+}
+
+type segment struct {
+	seq  uint64
+	size int64
+}
+
+// Open opens the queue stored in dir, creating the directory if needed. Batches left by a previous
+// process are kept and returned first by Peek. A maxBytes of zero or less means no size limit.
+func Open(dir string, maxBytes int64) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{dir: dir, maxBytes: maxBytes}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, segment{seq: seq, size: info.Size()})
+		q.size += info.Size()
+		if seq >= q.nextSeq {
+			q.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].seq < q.segments[j].seq
+	})
+	return q, nil
+}
+
+// Append stores lines as a new batch at the tail of the queue.
+func (q *Queue) Append(lines []string) error {
+	size := 0
+	for _, line := range lines {
+		size += binary.MaxVarintLen64 + len(line)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	data := make([]byte, 0, size)
+	for _, line := range lines {
+		data = binary.AppendUvarint(data, uint64(len(line)))
+		data = append(data, line...)
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.maxBytes > 0 && q.size+int64(len(data)) > q.maxBytes {
+		return ErrFull
+	}
+
+	seq := q.nextSeq
+	tmp := filepath.Join(q.dir, fmt.Sprintf(".%020d.tmp", seq))
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, q.path(seq)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	q.nextSeq++
+	q.segments = append(q.segments, segment{seq: seq, size: int64(len(data))})
+	q.size += int64(len(data))
+	return nil
+}
+
+// Peek returns the lines of the batch at the head of the queue, or nil when the queue is empty.
+// Batches that cannot be read, e.g. deleted or corrupt files, are logged and skipped, so that
+// they never keep the batches behind them from being delivered.
+func (q *Queue) Peek() []string {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	for len(q.segments) > 0 {
+		head := q.segments[0]
+		data, err := os.ReadFile(q.path(head.seq))
+		if err == nil {
+			var lines []string
+			if lines, err = decode(data); err == nil {
+				return lines
+			}
+			// the file is of no use to a later process either.
+			_ = os.Remove(q.path(head.seq))
+		}
+		if !os.IsNotExist(err) {
+			log.Printf("skipping unreadable batch %s of persistent buffer: %s\n", q.path(head.seq), err)
+		}
+		q.segments = q.segments[1:]
+		q.size -= head.size
+	}
+	return nil
+}
+
+// decode returns the lines of the records of data.
+func decode(data []byte) ([]string, error) {
+	var lines []string
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return nil, errCorrupt
+		}
+		data = data[n:]
+		lines = append(lines, string(data[:size]))
+		data = data[size:]
+	}
+	return lines, nil
+}
+
+// Remove deletes the batch at the head of the queue, once it has been delivered.
+func (q *Queue) Remove() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if len(q.segments) == 0 {
+		return nil
+	}
+	head := q.segments[0]
+	if err := os.Remove(q.path(head.seq)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	q.segments = q.segments[1:]
+	q.size -= head.size
+	return nil
+}
+
+// Len returns the number of batches in the queue.
+func (q *Queue) Len() int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return len(q.segments)
+}
+
+// Size returns the number of bytes stored in the queue.
+func (q *Queue) Size() int64 {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	return q.size
+}
+
+func (q *Queue) path(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	require.NoError(t, err)
+
+	assert.Nil(t, q.Peek())
+
+	require.NoError(t, q.Append([]string{"a 1\n", "b 2\n"}))
+	require.NoError(t, q.Append([]string{"c 3\n"}))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(15), q.Size())
+
+	// a new process sees the same batches, in order.
+	q, err = Open(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a 1\n", "b 2\n"}, q.Peek())
+	require.NoError(t, q.Remove())
+
+	require.NoError(t, q.Append([]string{"d 4\n"}))
+	assert.Equal(t, []string{"c 3\n"}, q.Peek())
+	require.NoError(t, q.Remove())
+	assert.Equal(t, []string{"d 4\n"}, q.Peek())
+	require.NoError(t, q.Remove())
+	assert.Equal(t, 0, q.Len())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueue_BinaryLines(t *testing.T) {
+	q, err := Open(t.TempDir(), 0)
+	require.NoError(t, err)
+	lines := []string{"\x0a\x03\n\x00span", "", "\n"}
+	require.NoError(t, q.Append(lines))
+	assert.Equal(t, lines, q.Peek())
+}
+
+func TestQueue_SkipsUnreadableBatches(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, q.Append([]string{"a 1\n"}))
+	require.NoError(t, q.Append([]string{"b 2\n"}))
+	require.NoError(t, q.Append([]string{"c 3\n"}))
+
+	require.NoError(t, os.Remove(q.path(0)))
+	require.NoError(t, os.WriteFile(q.path(1), []byte{0x7f, 'b'}, 0o644))
+	assert.Equal(t, []string{"c 3\n"}, q.Peek())
+	assert.Equal(t, 1, q.Len())
+	assert.Equal(t, int64(5), q.Size())
+	_, err = os.Stat(q.path(1))
+	assert.True(t, os.IsNotExist(err), "corrupt batches must be removed")
+}
+
+func TestQueue_MaxBytes(t *testing.T) {
+	q, err := Open(t.TempDir(), 10)
+	require.NoError(t, err)
+	require.NoError(t, q.Append([]string{"a 1\n"}))
+	require.NoError(t, q.Append([]string{"b 2\n"}))
+	assert.ErrorIs(t, q.Append([]string{"c 3\n"}), ErrFull)
+	require.NoError(t, q.Remove())
+	assert.NoError(t, q.Append([]string{"c 3\n"}))
+}
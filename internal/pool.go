@@ -20,6 +20,18 @@ func GetBuffer() *bytes.Buffer {
 	return buffers.Get().(*bytes.Buffer)
 }
 
+// writeLines appends lines to buf, growing it once to fit them all.
+func writeLines(buf *bytes.Buffer, lines []string) {
+	size := 0
+	for _, line := range lines {
+		size += len(line)
+	}
+	buf.Grow(size)
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+}
+
 // PutBuffer returns a buffers to the pool
 func PutBuffer(buf *bytes.Buffer) {
 	buf.Reset()
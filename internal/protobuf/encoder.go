@@ -0,0 +1,103 @@
+// Package protobuf encodes messages in the protocol buffers wire format, for the few
+// messages the SDK sends without depending on generated code.
+package protobuf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// An Encoder appends the fields of a message. Fields holding a zero value are
+// omitted, as in proto3.
+type Encoder struct {
+	buf []byte
+}
+
+// Bytes returns the encoded message.
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+func (e *Encoder) tag(field int, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+// Varint encodes an int32, int64, uint32, uint64 or enum field.
+func (e *Encoder) Varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// Bool encodes a bool field.
+func (e *Encoder) Bool(field int, v bool) {
+	if v {
+		e.Varint(field, 1)
+	}
+}
+
+// Fixed64 encodes a fixed64 or sfixed64 field.
+func (e *Encoder) Fixed64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireFixed64)
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+}
+
+// Double encodes a double field.
+func (e *Encoder) Double(field int, v float64) {
+	e.Fixed64(field, math.Float64bits(v))
+}
+
+// OneofDouble encodes a double field member of a oneof, which is encoded even when zero
+// to tell which member is set.
+func (e *Encoder) OneofDouble(field int, v float64) {
+	e.tag(field, wireFixed64)
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+// OneofString encodes a string field member of a oneof, which is encoded even when empty.
+func (e *Encoder) OneofString(field int, v string) {
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// BytesField encodes a bytes field.
+func (e *Encoder) BytesField(field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// String encodes a string field.
+func (e *Encoder) String(field int, v string) {
+	if v == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// Message encodes an embedded message field, whose fields are encoded by fn.
+// Unlike other fields, empty messages are encoded, as they may be elements of repeated fields.
+func (e *Encoder) Message(field int, fn func(*Encoder)) {
+	var m Encoder
+	fn(&m)
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(m.buf)))
+	e.buf = append(e.buf, m.buf...)
+}
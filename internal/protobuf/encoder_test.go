@@ -0,0 +1,34 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	var e Encoder
+	e.Varint(1, 150)
+	e.Varint(2, 0)
+	e.String(3, "testing")
+	e.Bool(4, true)
+	e.Fixed64(5, 1)
+	e.Double(6, 0)
+	e.OneofDouble(7, 0)
+	e.BytesField(8, []byte{0xca, 0xfe})
+	e.Message(9, func(m *Encoder) {
+		m.Varint(1, 1)
+	})
+	e.Message(10, func(*Encoder) {})
+
+	assert.Equal(t, []byte{
+		0x08, 0x96, 0x01,
+		0x1a, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+		0x20, 0x01,
+		0x29, 0x01, 0, 0, 0, 0, 0, 0, 0,
+		0x39, 0, 0, 0, 0, 0, 0, 0, 0,
+		0x42, 0x02, 0xca, 0xfe,
+		0x4a, 0x02, 0x08, 0x01,
+		0x52, 0x00,
+	}, e.Bytes())
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxQuotaBody bounds how much of a 403 or 429 response body is read to recognize a quota error.
+	maxQuotaBody = 4096
+	// defaultQuotaPause is how long a handler pauses on a quota error without a reset hint.
+	defaultQuotaPause = time.Minute
+	// rateLimitReset is the header some gateways send the reset time of the quota in.
+	rateLimitReset = "X-RateLimit-Reset"
+)
+
+// ErrQuotaExceeded matches, with errors.Is, the errors of requests rejected because the quota
+// of the tenant is exceeded.
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// QuotaError is returned when the endpoint rejects a report request with a 403 or 429 status
+// whose body mentions the quota of the tenant. Handlers pause reporting until the quota resets,
+// instead of retrying right away.
+type QuotaError struct {
+	// StatusCode is the status of the rejected request.
+	StatusCode int
+	// Message is the beginning of the response body.
+	Message string
+	// ResetAfter is how long until the quota resets, from the Retry-After or X-RateLimit-Reset
+	// header of the response, or zero when the response gave no hint.
+	ResetAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	if e.ResetAfter > 0 {
+		return fmt.Sprintf("%s. status=%d reset-after=%s: %s", ErrQuotaExceeded, e.StatusCode, e.ResetAfter, e.Message)
+	}
+	return fmt.Sprintf("%s. status=%d: %s", ErrQuotaExceeded, e.StatusCode, e.Message)
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// pause returns how long to pause reporting for.
+func (e *QuotaError) pause() time.Duration {
+	if e.ResetAfter > 0 {
+		return e.ResetAfter
+	}
+	return defaultQuotaPause
+}
+
+// quotaError returns the *QuotaError resp stands for, or nil. The body of 403 and 429 responses
+// is partly read.
+func quotaError(resp *http.Response) *QuotaError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxQuotaBody))
+	message := strings.TrimSpace(string(body))
+	if !strings.Contains(strings.ToLower(message), "quota") {
+		return nil
+	}
+	qe := &QuotaError{StatusCode: resp.StatusCode, Message: message}
+	if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		qe.ResetAfter = d
+	} else if d, ok := rateLimitResetAfter(resp.Header.Get(rateLimitReset)); ok {
+		qe.ResetAfter = d
+	}
+	return qe
+}
+
+// rateLimitResetAfter parses an X-RateLimit-Reset header, given either in seconds from now or,
+// for values past 2001, as epoch seconds.
+func rateLimitResetAfter(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	if seconds < 1e9 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	d := time.Until(time.Unix(seconds, 0))
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
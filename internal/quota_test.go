@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func quotaResponse(status int, body string, keyValues ...string) *http.Response {
+	header := http.Header{}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		header.Set(keyValues[i], keyValues[i+1])
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestQuotaError(t *testing.T) {
+	qe := quotaError(quotaResponse(http.StatusTooManyRequests, "Tenant quota exceeded\n",
+		"Retry-After", "30"))
+	require.NotNil(t, qe)
+	assert.Equal(t, http.StatusTooManyRequests, qe.StatusCode)
+	assert.Equal(t, "Tenant quota exceeded", qe.Message)
+	assert.Equal(t, 30*time.Second, qe.ResetAfter)
+	assert.True(t, errors.Is(qe, ErrQuotaExceeded))
+
+	qe = quotaError(quotaResponse(http.StatusForbidden, "points quota exceeded",
+		rateLimitReset, "120"))
+	require.NotNil(t, qe)
+	assert.Equal(t, 2*time.Minute, qe.ResetAfter)
+
+	reset := time.Now().Add(time.Hour).Unix()
+	qe = quotaError(quotaResponse(http.StatusForbidden, "quota exceeded",
+		rateLimitReset, strconv.FormatInt(reset, 10)))
+	require.NotNil(t, qe)
+	assert.InDelta(t, time.Hour, qe.ResetAfter, float64(2*time.Second))
+
+	qe = quotaError(quotaResponse(http.StatusTooManyRequests, "quota exceeded"))
+	require.NotNil(t, qe)
+	assert.Zero(t, qe.ResetAfter)
+	assert.Equal(t, defaultQuotaPause, qe.pause())
+
+	assert.Nil(t, quotaError(quotaResponse(http.StatusForbidden, "invalid token")))
+	assert.Nil(t, quotaError(quotaResponse(http.StatusTooManyRequests, "slow down")))
+	assert.Nil(t, quotaError(quotaResponse(http.StatusBadRequest, "quota exceeded")))
+}
+
+func TestReporter_QuotaExceededNotRetried(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("tenant quota exceeded"))
+	}))
+	defer server.Close()
+
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{}, SetRetries(3, 0)).(*reporter)
+	r.retry.sleep = func(time.Duration) {}
+
+	_, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	var qe *QuotaError
+	require.True(t, errors.As(err, &qe))
+	assert.Equal(t, 5*time.Second, qe.ResetAfter)
+}
+
+func TestLineHandler_PausesOnQuotaExceeded(t *testing.T) {
+	reporter := &fakeReporter{error: &QuotaError{StatusCode: http.StatusTooManyRequests, ResetAfter: time.Hour}}
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 10, 100)
+	require.NoError(t, lh.HandleLine("dummyLine"))
+
+	err := lh.Flush()
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(t, 1, reporter.ReportCallCount())
+	assert.Equal(t, int64(1), lh.GetFailureCount())
+
+	err = lh.Flush()
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(t, 1, reporter.ReportCallCount(), "paused handler should not report")
+	assert.Len(t, lh.buffer, 1)
+
+	reporter.error = nil
+	lh.quotaPausedUntil = 0
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, []string{"dummyLine"}, reporter.lines)
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the number of lines reported per second. It holds up to
+// one second worth of lines, so that a handler idle for a while may send a burst of that size.
+// It is safe for concurrent use, so that several handlers can share a single rate.
+type RateLimiter struct {
+	mtx    sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter creates a RateLimiter allowing linesPerSecond lines per second.
+func NewRateLimiter(linesPerSecond int) *RateLimiter {
+	return &RateLimiter{
+		rate:   float64(linesPerSecond),
+		tokens: float64(linesPerSecond),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Wait blocks until n lines may be reported. Batches larger than the bucket are let through once
+// it is full, and the following ones wait for the deficit to be paid back.
+func (l *RateLimiter) Wait(n int) {
+	if delay := l.reserve(n); delay > 0 {
+		l.sleep(delay)
+	}
+}
+
+// reserve takes n tokens, possibly going into debt, and returns how long to wait for the debt to be paid.
+func (l *RateLimiter) reserve(n int) time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.rate {
+			l.tokens = l.rate
+		}
+	}
+	l.last = now
+
+	// a batch larger than the bucket only needs it full.
+	need := float64(n)
+	if need > l.rate {
+		need = l.rate
+	}
+	var delay time.Duration
+	if l.tokens < need {
+		delay = time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+	}
+	l.tokens -= float64(n)
+	return delay
+}
+
+// SetRateLimiter makes the handler wait for limiter before reporting each batch.
+// A nil limiter does not limit the rate.
+func SetRateLimiter(limiter *RateLimiter) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.rateLimiter = limiter
+	}
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var sleeps []time.Duration
+	l := NewRateLimiter(10)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+
+	l.Wait(10) // the bucket starts full.
+	l.Wait(5)
+	l.Wait(5)
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, sleeps)
+
+	// a batch larger than the bucket waits for it to be full, the next one pays the deficit.
+	sleeps = nil
+	now = now.Add(time.Hour)
+	l.Wait(30)
+	l.Wait(10)
+	assert.Equal(t, []time.Duration{3 * time.Second}, sleeps)
+}
+
+func TestRateLimiterSharedByHandlers(t *testing.T) {
+	limiter := NewRateLimiter(2)
+	var slept time.Duration
+	limiter.sleep = func(d time.Duration) { slept += d }
+	reporter := &fakeReporter{}
+	points := NewLineHandler(reporter, metricFormat, time.Minute, 10, 10, SetRateLimiter(limiter))
+	spans := NewLineHandler(reporter, traceFormat, time.Minute, 10, 10, SetRateLimiter(limiter))
+
+	require.NoError(t, points.HandleLine("1\n"))
+	require.NoError(t, points.HandleLine("2\n"))
+	require.NoError(t, points.Flush())
+	assert.Zero(t, slept)
+	require.NoError(t, spans.HandleLine("3\n"))
+	require.NoError(t, spans.Flush())
+	assert.InDelta(t, 500*time.Millisecond, slept, float64(10*time.Millisecond))
+	assert.Equal(t, []string{"1\n2\n", "3\n"}, reporter.lines)
+}
@@ -1,15 +1,17 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/persistence"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 )
 
@@ -29,6 +31,10 @@ type RealLineHandler struct {
 	// See https://github.com/golang/go/issues/599
 	failures  int64
 	throttled int64
+	// unix nanoseconds until which reporting is paused by a quota error.
+	quotaPausedUntil int64
+	// duration of the last report request, in nanoseconds.
+	lastReportLatency int64
 
 	Reporter      Reporter
 	BatchSize     int
@@ -41,16 +47,90 @@ type RealLineHandler struct {
 	throttledSleepDuration time.Duration
 	mtx                    sync.Mutex
 
-	buffer   chan string
-	flusher  BackgroundFlusher
-	resumeAt time.Time
+	buffer  chan string
+	flusher BackgroundFlusher
+	// resumeAt is the time throttled flushes resume at, in nanoseconds since the epoch. It is
+	// accessed atomically, as the background and threshold flushes run concurrently.
+	resumeAt int64
+
+	persistenceDir      string
+	persistenceMaxBytes int64
+	persistence         *persistence.Queue
+
+	latencyBudget     time.Duration
+	adaptiveBatchSize bool
+	currentBatchSize  int
+	budgetExceeded    *sdkmetrics.DeltaCounter
+
+	overflowPolicy  OverflowPolicy
+	overflowTimeout time.Duration
+	evicted         *sdkmetrics.DeltaCounter
+
+	rateLimiter     *RateLimiter
+	maxPayloadBytes int
+	breaker         *circuitBreaker
+
+	quotaExceeded *sdkmetrics.DeltaCounter
+	sent          *sdkmetrics.DeltaCounter
+	reportErrors  *sdkmetrics.DeltaCounter
+
+	onDropped func(lines []string, reason error)
+	onReport  func(format string, result FlushResult)
+
+	flushThreshold int
+	flushTrigger   chan struct{}
+	stopTrigger    chan struct{}
+	triggerDone    sync.WaitGroup
+
+	// stopped is closed by Stop, failing the lines handled from then on.
+	stopped  chan struct{}
+	stopOnce sync.Once
+	// registers the report metrics on the first report request.
+	reportMetricsOnce sync.Once
 }
 
+// OverflowPolicy decides what HandleLine does with a line when the buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest rejects the line.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered line to make room for the line.
+	OverflowDropOldest
+	// OverflowBlock waits for room in the buffer, up to the overflow timeout if any.
+	OverflowBlock
+)
+
 func (lh *RealLineHandler) Format() string {
 	return lh.format
 }
 
-var errThrottled = errors.New("error: throttled event creation")
+// FlushResult counts the lines reported by a flush.
+type FlushResult struct {
+	// Sent lines were accepted by the server.
+	Sent int
+	// Buffered lines failed to be reported and were buffered for a later flush.
+	Buffered int
+	// Dropped lines failed to be reported and could not be buffered.
+	Dropped int
+	// Err is the last reporting error.
+	Err error
+}
+
+func (r *FlushResult) add(other FlushResult) {
+	r.Sent += other.Sent
+	r.Buffered += other.Buffered
+	r.Dropped += other.Dropped
+	if other.Err != nil {
+		r.Err = other.Err
+	}
+}
+
+// ErrBufferFull is the reason lines are dropped for lack of room in the buffer.
+var ErrBufferFull = errors.New("buffer full")
+
+// ErrHandlerStopped is the reason lines handled after Stop are dropped.
+var ErrHandlerStopped = errors.New("line handler stopped")
 
 type LineHandlerOption func(*RealLineHandler)
 
@@ -72,6 +152,65 @@ func ThrottleRequestsOnBackpressure() LineHandlerOption {
 	}
 }
 
+// SetPersistentBuffer stores the batches that fail to be reported in a disk-backed queue in a
+// subdirectory of dir named after the handler prefix, instead of buffering them in memory.
+// Stored batches are replayed, oldest first, before buffered lines on each flush, including
+// the batches left by a previous process. maxBytes limits the size of the queue; batches that
+// do not fit are buffered in memory.
+func SetPersistentBuffer(dir string, maxBytes int64) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.persistenceDir = dir
+		handler.persistenceMaxBytes = maxBytes
+	}
+}
+
+// SetLatencyBudget counts the flushes taking longer than budget in the flush.budget_exceeded
+// internal metric. With adaptive set, the batch size is also halved on each such flush, down to
+// a single line, and doubled back towards its configured value when a flush takes less than half
+// the budget, bounding how long each flush holds the handler.
+func SetLatencyBudget(budget time.Duration, adaptive bool) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.latencyBudget = budget
+		handler.adaptiveBatchSize = adaptive
+	}
+}
+
+// SetOverflowPolicy sets what HandleLine does when the buffer is full, OverflowDropNewest by default.
+// With OverflowBlock, a positive timeout bounds the wait, after which the line is rejected.
+// Lines discarded by OverflowDropOldest are counted in the buffer.evicted internal metric.
+func SetOverflowPolicy(policy OverflowPolicy, timeout time.Duration) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.overflowPolicy = policy
+		handler.overflowTimeout = timeout
+	}
+}
+
+// SetDropHandler calls fn with the lines the handler drops and the reason: ErrBufferFull when
+// the buffer is full, or the error of a report rejected for good, or of the last flush of Stop.
+// fn is called from the goroutine dropping the lines, possibly with the handler locked, so it
+// should return quickly and must not flush the handler.
+func SetDropHandler(fn func(lines []string, reason error)) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.onDropped = fn
+	}
+}
+
+// SetReportHandler calls fn with the outcome of every batch the handler reports. fn is called from
+// the goroutine flushing the handler, so it should return quickly and must not flush the handler.
+func SetReportHandler(fn func(format string, result FlushResult)) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.onReport = fn
+	}
+}
+
+// SetFlushThreshold flushes the handler as soon as threshold lines are buffered, and again after
+// each successful flush while they still are, instead of waiting for the next flush interval.
+func SetFlushThreshold(threshold int) LineHandlerOption {
+	return func(handler *RealLineHandler) {
+		handler.flushThreshold = threshold
+	}
+}
+
 func NewLineHandler(reporter Reporter, format string, flushInterval time.Duration, batchSize, maxBufferSize int, setters ...LineHandlerOption) *RealLineHandler {
 	lh := &RealLineHandler{
 		Reporter:               reporter,
@@ -82,17 +221,45 @@ func NewLineHandler(reporter Reporter, format string, flushInterval time.Duratio
 	}
 
 	lh.buffer = make(chan string, lh.MaxBufferSize)
+	lh.stopped = make(chan struct{})
 	lh.flusher = NewBackgroundFlusher(flushInterval, lh)
 
 	for _, setter := range setters {
 		setter(lh)
 	}
 
+	if lh.flushThreshold > 0 {
+		lh.flushTrigger = make(chan struct{}, 1)
+		lh.stopTrigger = make(chan struct{})
+	}
+
+	if lh.persistenceDir != "" {
+		name := lh.prefix
+		if name == "" {
+			name = lh.format
+		}
+		queue, err := persistence.Open(filepath.Join(lh.persistenceDir, name), lh.persistenceMaxBytes)
+		if err != nil {
+			log.Printf("unable to open persistent buffer, buffering %s lines in memory only: %s\n", lh.format, err)
+		} else {
+			lh.persistence = queue
+		}
+	}
+
 	if lh.internalRegistry != nil {
-		lh.internalRegistry.NewGauge(lh.prefix+".queue.size", func() int64 {
+		if lh.latencyBudget > 0 {
+			lh.budgetExceeded = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.FlushBudgetExceededSuffix)
+		}
+		if lh.overflowPolicy == OverflowDropOldest {
+			lh.evicted = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.BufferEvictedSuffix)
+		}
+		if lh.breaker != nil {
+			lh.breaker.opened = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.CircuitOpenedSuffix)
+		}
+		lh.internalRegistry.NewGauge(lh.prefix+sdkmetrics.QueueSizeSuffix, func() int64 {
 			return int64(len(lh.buffer))
 		})
-		lh.internalRegistry.NewGauge(lh.prefix+".queue.remaining_capacity", func() int64 {
+		lh.internalRegistry.NewGauge(lh.prefix+sdkmetrics.QueueRemainingCapacitySuffix, func() int64 {
 			return int64(lh.MaxBufferSize - len(lh.buffer))
 		})
 	}
@@ -101,15 +268,151 @@ func NewLineHandler(reporter Reporter, format string, flushInterval time.Duratio
 
 func (lh *RealLineHandler) Start() {
 	lh.flusher.Start()
+	if lh.flushTrigger != nil {
+		lh.triggerDone.Add(1)
+		go lh.flushOnThreshold()
+	}
+}
+
+// flushOnThreshold flushes the handler each time the flush threshold is reached, until Stop.
+func (lh *RealLineHandler) flushOnThreshold() {
+	defer lh.triggerDone.Done()
+	for {
+		select {
+		case <-lh.flushTrigger:
+			for lh.overFlushThreshold() && !lh.isStopped() {
+				if err := lh.FlushWithThrottling(); err != nil {
+					log.Printf("%s -- error during threshold flush: %s\n", lh.format, err)
+					break
+				}
+			}
+		case <-lh.stopTrigger:
+			return
+		}
+	}
+}
+
+func (lh *RealLineHandler) overFlushThreshold() bool {
+	return lh.flushThreshold > 0 && len(lh.buffer) >= lh.flushThreshold
+}
+
+// triggerFlush wakes up flushOnThreshold if the flush threshold is reached.
+func (lh *RealLineHandler) triggerFlush() {
+	if lh.flushTrigger != nil && lh.overFlushThreshold() {
+		select {
+		case lh.flushTrigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (lh *RealLineHandler) HandleLineCtx(ctx context.Context, line string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if lh.isStopped() {
+		return lh.dropStopped(line)
+	}
+	if err := lh.shed(line); err != nil {
+		return err
+	}
+	select {
+	case lh.buffer <- line:
+		lh.triggerFlush()
+		return nil
+	case <-ctx.Done():
+		atomic.AddInt64(&lh.failures, 1)
+		return ctx.Err()
+	case <-lh.stopped:
+		return lh.dropStopped(line)
+	}
 }
 
 func (lh *RealLineHandler) HandleLine(line string) error {
+	if lh.isStopped() {
+		return lh.dropStopped(line)
+	}
+	if err := lh.shed(line); err != nil {
+		return err
+	}
+	return lh.enqueue(line)
+}
+
+// isStopped reports whether Stop was called.
+func (lh *RealLineHandler) isStopped() bool {
+	select {
+	case <-lh.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func (lh *RealLineHandler) dropStopped(line string) error {
+	atomic.AddInt64(&lh.failures, 1)
+	lh.dropped([]string{line}, ErrHandlerStopped)
+	return fmt.Errorf("%w, dropping line: %s", ErrHandlerStopped, line)
+}
+
+// enqueue buffers line, applying the overflow policy when the buffer is full.
+func (lh *RealLineHandler) enqueue(line string) error {
 	select {
 	case lh.buffer <- line:
+		lh.triggerFlush()
 		return nil
 	default:
+	}
+
+	switch lh.overflowPolicy {
+	case OverflowDropOldest:
+		defer lh.triggerFlush()
+		return lh.evictOldest(line)
+	case OverflowBlock:
+		defer lh.triggerFlush()
+		return lh.waitForRoom(line)
+	}
+	atomic.AddInt64(&lh.failures, 1)
+	lh.dropped([]string{line}, ErrBufferFull)
+	return fmt.Errorf("%w, dropping line: %s", ErrBufferFull, line)
+}
+
+// evictOldest discards buffered lines, oldest first, until line fits in the buffer.
+func (lh *RealLineHandler) evictOldest(line string) error {
+	for {
+		select {
+		case lh.buffer <- line:
+			return nil
+		default:
+		}
+		select {
+		case evicted := <-lh.buffer:
+			atomic.AddInt64(&lh.failures, 1)
+			if lh.evicted != nil {
+				lh.evicted.Inc()
+			}
+			lh.dropped([]string{evicted}, ErrBufferFull)
+		default:
+		}
+	}
+}
+
+// waitForRoom waits for room in the buffer, up to the overflow timeout if any.
+func (lh *RealLineHandler) waitForRoom(line string) error {
+	var timeout <-chan time.Time
+	if lh.overflowTimeout > 0 {
+		timer := time.NewTimer(lh.overflowTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case lh.buffer <- line:
+		return nil
+	case <-lh.stopped:
+		return lh.dropStopped(line)
+	case <-timeout:
 		atomic.AddInt64(&lh.failures, 1)
-		return fmt.Errorf("buffer full, dropping line: %s", line)
+		lh.dropped([]string{line}, ErrBufferFull)
+		return fmt.Errorf("%w for %s, dropping line: %s", ErrBufferFull, lh.overflowTimeout, line)
 	}
 }
 
@@ -123,83 +426,256 @@ func minInt(x, y int) int {
 func (lh *RealLineHandler) flush() error {
 	lh.mtx.Lock()
 	defer lh.mtx.Unlock()
+	if lh.latencyBudget > 0 {
+		defer lh.enforceLatencyBudget(time.Now())
+	}
+	if err := lh.quotaPaused(); err != nil {
+		return err
+	}
+	if err := lh.circuitOpen(); err != nil {
+		lh.spool()
+		return err
+	}
+	if err := lh.replayPersisted(); err != nil {
+		return err
+	}
 	bufLen := len(lh.buffer)
 	if bufLen > 0 {
-		size := minInt(bufLen, lh.BatchSize)
+		size := minInt(bufLen, lh.batchSize())
 		lines := make([]string, size)
 		for i := 0; i < size; i++ {
 			lines[i] = <-lh.buffer
 		}
-		return lh.report(lines)
+		return lh.report(lines).Err
 	}
 	return nil
 }
 
+// batchSize returns the number of lines reported per request, which the latency budget may
+// have reduced below BatchSize.
+func (lh *RealLineHandler) batchSize() int {
+	if lh.currentBatchSize > 0 {
+		return lh.currentBatchSize
+	}
+	return lh.BatchSize
+}
+
+// enforceLatencyBudget records a flush started at start that went over the latency budget and,
+// if adaptive, resizes the batches of the next flushes. It must be called with mtx held.
+func (lh *RealLineHandler) enforceLatencyBudget(start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed > lh.latencyBudget {
+		if lh.budgetExceeded != nil {
+			lh.budgetExceeded.Inc()
+		}
+		if lh.adaptiveBatchSize && lh.batchSize() > 1 {
+			lh.currentBatchSize = lh.batchSize() / 2
+			log.Printf("%s flush took %v, over its %v budget, reducing batch size to %d\n",
+				lh.format, elapsed, lh.latencyBudget, lh.currentBatchSize)
+		}
+		return
+	}
+	if lh.adaptiveBatchSize && elapsed < lh.latencyBudget/2 && lh.batchSize() < lh.BatchSize {
+		lh.currentBatchSize = minInt(lh.batchSize()*2, lh.BatchSize)
+	}
+}
+
 func (lh *RealLineHandler) FlushWithThrottling() error {
-	if time.Now().Before(lh.resumeAt) {
+	if resumeAt := lh.resumeTime(); time.Now().Before(resumeAt) {
 		log.Println("attempting to flush, but flushing is currently throttled by the server")
-		log.Printf("sleeping until: %s\n", lh.resumeAt.Format(time.RFC3339))
-		time.Sleep(time.Until(lh.resumeAt))
+		log.Printf("sleeping until: %s\n", resumeAt.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(resumeAt))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-lh.stopped:
+			// Stop flushes the buffered lines itself.
+			return nil
+		}
 	}
 	return lh.Flush()
 }
 
+func (lh *RealLineHandler) resumeTime() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&lh.resumeAt))
+}
+
 func (lh *RealLineHandler) Flush() error {
 	flushErr := lh.flush()
-	if flushErr == errThrottled && lh.throttleOnBackpressure {
+	if backpressure(flushErr) && lh.throttleOnBackpressure {
 		atomic.AddInt64(&lh.throttled, 1)
 		log.Printf("pausing requests for %v, buffer size: %d\n", lh.throttledSleepDuration, len(lh.buffer))
-		lh.resumeAt = time.Now().Add(lh.throttledSleepDuration)
+		atomic.StoreInt64(&lh.resumeAt, time.Now().Add(lh.throttledSleepDuration).UnixNano())
 	}
 	return flushErr
 }
 
 func (lh *RealLineHandler) FlushAll() error {
+	return lh.FlushAllWithResult().Err
+}
+
+// FlushAllWithResult reports all buffered lines in batches of at most BatchSize lines,
+// stopping at the first failed batch, and counts the lines of the reported batches.
+func (lh *RealLineHandler) FlushAllWithResult() FlushResult {
 	lh.mtx.Lock()
 	defer lh.mtx.Unlock()
+	var result FlushResult
+	if err := lh.circuitOpen(); err != nil {
+		lh.spool()
+		result.Err = err
+		return result
+	}
 	bufLen := len(lh.buffer)
 	if bufLen > 0 {
 		var imod int
-		size := minInt(bufLen, lh.BatchSize)
+		size := minInt(bufLen, lh.batchSize())
 		lines := make([]string, size)
 		for i := 0; i < bufLen; i++ {
 			imod = i % size
 			lines[imod] = <-lh.buffer
 			if imod == size-1 { // report batch
-				if err := lh.report(lines); err != nil {
-					return err
+				result.add(lh.report(lines))
+				if result.Err != nil {
+					return result
 				}
 			}
 		}
 		if imod < size-1 { // report remaining
-			return lh.report(lines[0 : imod+1])
+			result.add(lh.report(lines[0 : imod+1]))
 		}
 	}
-	return nil
+	return result
 }
 
-func (lh *RealLineHandler) report(lines []string) error {
-	strLines := strings.Join(lines, "")
-	resp, err := lh.Reporter.Report(lh.format, strLines)
+func (lh *RealLineHandler) report(lines []string) FlushResult {
+	if lh.maxPayloadBytes > 0 {
+		return lh.reportChunked(lines)
+	}
+	return lh.reportBatch(lines)
+}
 
+// reportBatch reports lines in a single request, and buffers or drops them on failure.
+func (lh *RealLineHandler) reportBatch(lines []string) FlushResult {
+	retry, err := lh.send(lines)
+	result := FlushResult{Err: err}
+	switch {
+	case err == nil:
+		result.Sent = len(lines)
+	case retry:
+		result.Dropped = lh.bufferLines(lines)
+		result.Buffered = len(lines) - result.Dropped
+	default:
+		result.Dropped = len(lines)
+		// lines is reused for the next batch of FlushAllWithResult.
+		lh.dropped(append([]string(nil), lines...), err)
+	}
+	if lh.onReport != nil {
+		lh.onReport(lh.format, result)
+	}
+	return result
+}
+
+// registerReportMetrics registers the internal metrics of report requests, on the first one,
+// so that the handlers of the data types a sender doesn't send don't report them.
+func (lh *RealLineHandler) registerReportMetrics() {
+	if lh.internalRegistry == nil {
+		return
+	}
+	lh.quotaExceeded = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.QuotaExceededSuffix)
+	lh.sent = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.SentSuffix)
+	lh.reportErrors = lh.internalRegistry.NewDeltaCounter(lh.prefix + sdkmetrics.ReportErrorsSuffix)
+	lh.internalRegistry.NewGauge(lh.prefix+sdkmetrics.FlushLatencySuffix, func() int64 {
+		return time.Duration(atomic.LoadInt64(&lh.lastReportLatency)).Milliseconds()
+	})
+}
+
+// recordReport updates the internal metrics of a report request of n lines that took elapsed.
+func (lh *RealLineHandler) recordReport(n int, elapsed time.Duration, err error) {
+	atomic.StoreInt64(&lh.lastReportLatency, int64(elapsed))
 	if err != nil {
-		if shouldRetry(err) {
-			lh.bufferLines(lines)
+		if lh.reportErrors != nil {
+			lh.reportErrors.Inc()
 		}
-		return fmt.Errorf("error reporting %s format data to Wavefront: %q", lh.format, err)
+		return
+	}
+	if lh.sent != nil {
+		lh.sent.Add(int64(n))
+	}
+}
+
+// send reports lines and returns whether they should be reported again on failure.
+func (lh *RealLineHandler) send(lines []string) (retry bool, err error) {
+	lh.reportMetricsOnce.Do(lh.registerReportMetrics)
+	if lh.rateLimiter != nil {
+		lh.rateLimiter.Wait(len(lines))
+	}
+	defer func(start time.Time) {
+		lh.recordReport(len(lines), time.Since(start), err)
+	}(time.Now())
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	writeLines(buf, lines)
+	resp, err := lh.Reporter.Report(lh.format, buf.Bytes())
+	if lh.breaker != nil {
+		defer func() {
+			lh.breaker.record(lh.format, err)
+		}()
+	}
+
+	if err != nil {
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			lh.pauseForQuota(qe)
+		}
+		return shouldRetry(err), fmt.Errorf("error reporting %s format data to Wavefront: %w", lh.format, err)
 	}
 
 	if 400 <= resp.StatusCode && resp.StatusCode <= 599 {
 		atomic.AddInt64(&lh.failures, 1)
-		lh.bufferLines(lines)
-		if resp.StatusCode == 406 {
-			return errThrottled
-		}
-		return fmt.Errorf("error reporting %s format data to Wavefront. status=%d", lh.format, resp.StatusCode)
+		return true, reportError(lh.format, lines, resp)
+	}
+	return false, nil
+}
+
+// pauseForQuota pauses the flushes of the handler until the quota of qe resets.
+func (lh *RealLineHandler) pauseForQuota(qe *QuotaError) {
+	atomic.AddInt64(&lh.failures, 1)
+	if lh.quotaExceeded != nil {
+		lh.quotaExceeded.Inc()
+	}
+	until := time.Now().Add(qe.pause())
+	atomic.StoreInt64(&lh.quotaPausedUntil, until.UnixNano())
+	log.Printf("tenant quota exceeded, pausing %s reporting until %s\n", lh.format, until.Format(time.RFC3339))
+}
+
+// quotaPaused returns an error matching ErrQuotaExceeded while flushes are paused by a quota error.
+func (lh *RealLineHandler) quotaPaused() error {
+	until := time.Unix(0, atomic.LoadInt64(&lh.quotaPausedUntil))
+	if time.Now().Before(until) {
+		return fmt.Errorf("%w: %s reporting paused until %s", ErrQuotaExceeded, lh.format, until.Format(time.RFC3339))
 	}
 	return nil
 }
 
+// replayPersisted reports the oldest batch of the persistent buffer, if any,
+// and removes it unless it should be reported again.
+func (lh *RealLineHandler) replayPersisted() error {
+	if lh.persistence == nil {
+		return nil
+	}
+	lines := lh.persistence.Peek()
+	if len(lines) == 0 {
+		return nil
+	}
+	retry, err := lh.send(lines)
+	if !retry {
+		if removeErr := lh.persistence.Remove(); removeErr != nil {
+			log.Printf("unable to remove replayed batch from persistent buffer: %s\n", removeErr)
+		}
+	}
+	return err
+}
+
 func shouldRetry(err error) bool {
 	switch err.(type) {
 	case *auth.Err:
@@ -208,11 +684,41 @@ func shouldRetry(err error) bool {
 	return true
 }
 
-func (lh *RealLineHandler) bufferLines(batch []string) {
+// bufferLines buffers batch for a later flush and returns the number of lines dropped
+// for lack of room.
+func (lh *RealLineHandler) bufferLines(batch []string) int {
+	if lh.persistence != nil {
+		err := lh.persistence.Append(batch)
+		if err == nil {
+			log.Println("error reporting to Wavefront. persisting lines.")
+			return 0
+		}
+		log.Printf("unable to persist lines: %s\n", err)
+	}
 	log.Println("error reporting to Wavefront. buffering lines.")
+	dropped := 0
 	for _, line := range batch {
-		_ = lh.HandleLine(line)
+		if err := lh.requeue(line); err != nil {
+			dropped++
+		}
 	}
+	return dropped
+}
+
+// requeue buffers line again after a failed report. Unlike enqueue, it never waits for room:
+// it is called by flushes, holding the lock of the flushes that would make room.
+func (lh *RealLineHandler) requeue(line string) error {
+	if lh.overflowPolicy != OverflowBlock {
+		return lh.enqueue(line)
+	}
+	select {
+	case lh.buffer <- line:
+		return nil
+	default:
+	}
+	atomic.AddInt64(&lh.failures, 1)
+	lh.dropped([]string{line}, ErrBufferFull)
+	return fmt.Errorf("%w, dropping line: %s", ErrBufferFull, line)
 }
 
 func (lh *RealLineHandler) GetFailureCount() int64 {
@@ -224,10 +730,50 @@ func (lh *RealLineHandler) GetThrottledCount() int64 {
 	return atomic.LoadInt64(&lh.throttled)
 }
 
+// Stop stops flushing, fails the lines handled from then on, and flushes the buffered lines.
+// Calling it again does nothing.
 func (lh *RealLineHandler) Stop() {
+	lh.stopOnce.Do(lh.stop)
+}
+
+func (lh *RealLineHandler) stop() {
+	if lh.stopped != nil {
+		close(lh.stopped)
+	}
 	lh.flusher.Stop()
+	if lh.stopTrigger != nil {
+		close(lh.stopTrigger)
+		lh.triggerDone.Wait()
+	}
 	if err := lh.FlushAll(); err != nil {
 		log.Println(err)
+		lh.persistRemaining(err)
+	}
+}
+
+// persistRemaining moves the lines left in the buffer to the persistent buffer, if any, and
+// drops them for reason otherwise.
+func (lh *RealLineHandler) persistRemaining(reason error) {
+	lines := make([]string, 0, len(lh.buffer))
+	for len(lh.buffer) > 0 {
+		lines = append(lines, <-lh.buffer)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if lh.persistence == nil {
+		lh.dropped(lines, reason)
+		return
+	}
+	if err := lh.persistence.Append(lines); err != nil {
+		log.Printf("unable to persist %d %s lines: %s\n", len(lines), lh.format, err)
+		lh.dropped(lines, err)
+	}
+}
+
+// dropped tells the drop handler, if any, about lines dropped for reason.
+func (lh *RealLineHandler) dropped(lines []string, reason error) {
+	if lh.onDropped != nil {
+		lh.onDropped(lines, reason)
 	}
-	lh.buffer = nil
 }
@@ -1,14 +1,21 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 )
 
 type fakeReporter struct {
@@ -18,7 +25,7 @@ type fakeReporter struct {
 	lines              []string
 }
 
-func (reporter *fakeReporter) Report(_ string, lines string) (*http.Response, error) {
+func (reporter *fakeReporter) Report(_ string, lines []byte) (*http.Response, error) {
 	atomic.AddInt64(&reporter.reportCallCount, 1)
 	if reporter.error != nil {
 		return nil, reporter.error
@@ -27,7 +34,7 @@ func (reporter *fakeReporter) Report(_ string, lines string) (*http.Response, er
 	if status != 0 {
 		return &http.Response{StatusCode: int(status)}, nil
 	}
-	reporter.lines = append(reporter.lines, lines)
+	reporter.lines = append(reporter.lines, string(lines))
 	return &http.Response{StatusCode: 200}, nil
 }
 
@@ -109,10 +116,10 @@ func TestFlushWithThrottling_WhenThrottling_DelayUntilThrottleInterval(t *testin
 	deadline := startTime.Add(1 * time.Second)
 	assert.Error(t, lh.Flush())
 	assert.Equal(t, 100, len(lh.buffer))
-	assert.WithinRange(t, lh.resumeAt, startTime, deadline)
+	assert.WithinRange(t, lh.resumeTime(), startTime, deadline)
 	lh.Reporter.(*fakeReporter).SetHTTPStatus(0)
 	assert.NoError(t, lh.FlushWithThrottling())
-	assert.Greater(t, time.Now(), lh.resumeAt)
+	assert.Greater(t, time.Now(), lh.resumeTime())
 	assert.Equal(t, 90, len(lh.buffer))
 }
 
@@ -132,10 +139,10 @@ func TestBackgroundFlushWithThrottling_WhenThrottling_DelayUntilThrottleInterval
 	deadline := startTime.Add(1 * time.Second)
 	assert.Error(t, lh.Flush())
 	assert.Equal(t, 100, len(lh.buffer))
-	assert.WithinRange(t, lh.resumeAt, startTime, deadline)
+	assert.WithinRange(t, lh.resumeTime(), startTime, deadline)
 	lh.Reporter = &fakeReporter{}
 	assert.NoError(t, lh.FlushWithThrottling())
-	assert.Greater(t, time.Now(), lh.resumeAt)
+	assert.Greater(t, time.Now(), lh.resumeTime())
 	assert.Equal(t, 90, len(lh.buffer))
 }
 
@@ -214,6 +221,77 @@ func makeBuffer(num int) []string {
 	return buf
 }
 
+func TestFlushAllWithResult(t *testing.T) {
+	lh := makeLineHandler(10, 4)
+	addLines(lh, 10, 10, t)
+	result := lh.FlushAllWithResult()
+	assert.Equal(t, FlushResult{Sent: 10}, result)
+
+	reporter := lh.Reporter.(*fakeReporter)
+	reporter.SetHTTPStatus(503)
+	addLines(lh, 6, 6, t)
+	result = lh.FlushAllWithResult()
+	assert.Equal(t, 0, result.Sent)
+	assert.Equal(t, 4, result.Buffered)
+	assert.Error(t, result.Err)
+	assert.Len(t, lh.buffer, 6)
+
+	reporter.SetHTTPStatus(0)
+	reporter.error = auth.NewAuthError(fmt.Errorf("unauthorized"))
+	result = lh.FlushAllWithResult()
+	assert.Equal(t, 4, result.Dropped)
+	assert.Error(t, result.Err)
+	assert.Len(t, lh.buffer, 2)
+}
+
+type slowReporter struct {
+	fakeReporter
+	delay time.Duration
+}
+
+func (reporter *slowReporter) Report(format string, lines []byte) (*http.Response, error) {
+	time.Sleep(reporter.delay)
+	return reporter.fakeReporter.Report(format, lines)
+}
+
+func TestLatencyBudget(t *testing.T) {
+	reporter := &slowReporter{delay: 20 * time.Millisecond}
+	registry := sdkmetrics.NewNoOpRegistry()
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 8, 100,
+		SetRegistry(registry), SetHandlerPrefix("points"), SetLatencyBudget(10*time.Millisecond, true))
+	addLines(lh, 20, 20, t)
+
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, int64(1), lh.budgetExceeded.Count())
+	assert.Equal(t, 4, lh.batchSize())
+	assert.Len(t, lh.buffer, 12)
+
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, int64(2), lh.budgetExceeded.Count())
+	assert.Equal(t, 2, lh.batchSize())
+	assert.Len(t, lh.buffer, 8)
+
+	// fast flushes grow the batch size back, up to BatchSize.
+	reporter.delay = 0
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, 4, lh.batchSize())
+	require.NoError(t, lh.Flush())
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, 8, lh.batchSize())
+	assert.Equal(t, int64(2), lh.budgetExceeded.Count())
+}
+
+func TestLatencyBudgetNotAdaptive(t *testing.T) {
+	reporter := &slowReporter{delay: 20 * time.Millisecond}
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 8, 100,
+		SetRegistry(sdkmetrics.NewNoOpRegistry()), SetLatencyBudget(10*time.Millisecond, false))
+	addLines(lh, 20, 20, t)
+
+	require.NoError(t, lh.Flush())
+	assert.Equal(t, int64(1), lh.budgetExceeded.Count())
+	assert.Equal(t, 8, lh.batchSize())
+}
+
 func makeLineHandler(bufSize, batchSize int) *RealLineHandler {
 	return &RealLineHandler{
 		Reporter:      &fakeReporter{},
@@ -222,3 +300,298 @@ func makeLineHandler(bufSize, batchSize int) *RealLineHandler {
 		buffer:        make(chan string, bufSize),
 	}
 }
+
+func TestPersistentBuffer(t *testing.T) {
+	dir := t.TempDir()
+	reporter := &fakeReporter{}
+	reporter.SetHTTPStatus(503)
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 2, 10,
+		SetHandlerPrefix("points"), SetPersistentBuffer(dir, 0))
+	lh.Start()
+	for _, line := range []string{"a 1\n", "b 2\n", "c 3\n"} {
+		assert.NoError(t, lh.HandleLine(line))
+	}
+	assert.Error(t, lh.Flush())
+	assert.Equal(t, 1, len(lh.buffer), "failed batch must not be buffered in memory")
+	assert.Equal(t, 1, lh.persistence.Len())
+	lh.Stop()
+	assert.Equal(t, 2, lh.persistence.Len(), "lines left on stop must be persisted")
+
+	// a new handler replays the persisted batches, oldest first.
+	reporter = &fakeReporter{}
+	lh = NewLineHandler(reporter, metricFormat, time.Hour, 2, 10,
+		SetHandlerPrefix("points"), SetPersistentBuffer(dir, 0))
+	assert.NoError(t, lh.HandleLine("d 4\n"))
+	assert.NoError(t, lh.Flush())
+	assert.NoError(t, lh.Flush())
+	assert.Equal(t, []string{"a 1\nb 2\n", "d 4\n", "c 3\n"}, reporter.lines)
+	assert.Equal(t, 0, lh.persistence.Len())
+}
+
+func TestPersistentBuffer_MissingBatch(t *testing.T) {
+	dir := t.TempDir()
+	reporter := &fakeReporter{}
+	reporter.SetHTTPStatus(503)
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 2, 10,
+		SetHandlerPrefix("points"), SetPersistentBuffer(dir, 0))
+	assert.NoError(t, lh.HandleLine("a 1\n"))
+	assert.Error(t, lh.Flush())
+	require.Equal(t, 1, lh.persistence.Len())
+
+	// batches deleted from disk are skipped, and the lines in memory are still reported.
+	batches, err := filepath.Glob(filepath.Join(dir, "*", "*.batch"))
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.NoError(t, os.Remove(batches[0]))
+	reporter.SetHTTPStatus(0)
+	assert.NoError(t, lh.HandleLine("b 2\n"))
+	assert.NoError(t, lh.Flush())
+	assert.Equal(t, []string{"b 2\n"}, reporter.lines)
+	assert.Equal(t, 0, lh.persistence.Len())
+}
+
+func TestHandleLineCtx(t *testing.T) {
+	lh := makeLineHandler(1, 1)
+	assert.NoError(t, lh.HandleLineCtx(context.Background(), "dummyLine"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lh.HandleLineCtx(ctx, "dummyLine"), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() { done <- lh.HandleLineCtx(context.Background(), "waiting") }()
+	<-lh.buffer
+	assert.NoError(t, <-done)
+	assert.Equal(t, "waiting", <-lh.buffer)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, lh.HandleLineCtx(canceled, "dummyLine"), context.Canceled)
+	assert.Equal(t, 0, len(lh.buffer))
+}
+
+type discardTransport struct{}
+
+func (discardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, _ = io.Copy(io.Discard, req.Body)
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+// BenchmarkFlushAll measures flushing 100k buffered metric lines through a reporter
+// gzipping its payloads.
+func BenchmarkFlushAll(b *testing.B) {
+	reporter := NewReporter("http://localhost:2878", auth.NewNoopTokenService(),
+		&http.Client{Transport: discardTransport{}})
+	lines := make([]string, 100000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("\"benchmark.metric.%d\" %d 1700000000 source=\"localhost\" \"env\"=\"bench\"\n", i%100, i)
+	}
+	lh := NewLineHandler(reporter, metricFormat, time.Minute, 10000, len(lines))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			lh.buffer <- line
+		}
+		if err := lh.FlushAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	lh := makeLineHandler(2, 10)
+	require.NoError(t, lh.HandleLine("1"))
+	require.NoError(t, lh.HandleLine("2"))
+	assert.Error(t, lh.HandleLine("3"))
+
+	registry := sdkmetrics.NewMetricRegistry(nil)
+	lh = NewLineHandler(&fakeReporter{}, metricFormat, time.Minute, 10, 2,
+		SetRegistry(registry), SetHandlerPrefix("points"), SetOverflowPolicy(OverflowDropOldest, 0))
+	for _, line := range []string{"1", "2", "3", "4"} {
+		require.NoError(t, lh.HandleLine(line))
+	}
+	assert.Equal(t, "3", <-lh.buffer)
+	assert.Equal(t, "4", <-lh.buffer)
+	assert.Equal(t, int64(2), lh.evicted.Count())
+	assert.Equal(t, int64(2), lh.GetFailureCount())
+
+	lh = makeLineHandler(1, 10)
+	SetOverflowPolicy(OverflowBlock, 10*time.Millisecond)(lh)
+	require.NoError(t, lh.HandleLine("1"))
+	assert.Error(t, lh.HandleLine("2"))
+
+	SetOverflowPolicy(OverflowBlock, 0)(lh)
+	done := make(chan error)
+	go func() { done <- lh.HandleLine("3") }()
+	select {
+	case <-done:
+		t.Fatal("HandleLine returned while the buffer was full")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, "1", <-lh.buffer)
+	require.NoError(t, <-done)
+	assert.Equal(t, "3", <-lh.buffer)
+}
+
+// fillingReporter fails its reports with a 503 status, after handling lines into the handler
+// being flushed, as senders would while the report is in flight.
+type fillingReporter struct {
+	fakeReporter
+	lh    *RealLineHandler
+	lines []string
+}
+
+func (r *fillingReporter) Report(string, []byte) (*http.Response, error) {
+	for _, line := range r.lines {
+		_ = r.lh.HandleLine(line)
+	}
+	r.lines = nil
+	return &http.Response{StatusCode: 503}, nil
+}
+
+func TestOverflowBlockFullBufferDuringReport(t *testing.T) {
+	reporter := &fillingReporter{lines: []string{"3", "4"}}
+	var dropped []string
+	lh := NewLineHandler(reporter, metricFormat, time.Minute, 2, 2,
+		SetOverflowPolicy(OverflowBlock, 0),
+		SetDropHandler(func(lines []string, _ error) { dropped = append(dropped, lines...) }))
+	reporter.lh = lh
+	require.NoError(t, lh.HandleLine("1"))
+	require.NoError(t, lh.HandleLine("2"))
+
+	done := make(chan error)
+	go func() { done <- lh.Flush() }()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Flush blocked re-buffering the lines of a failed report")
+	}
+	assert.Equal(t, []string{"1", "2"}, dropped)
+	assert.Equal(t, "3", <-lh.buffer)
+	assert.Equal(t, "4", <-lh.buffer)
+}
+
+func TestHandleLineAfterStop(t *testing.T) {
+	lh := NewLineHandler(&fakeReporter{}, metricFormat, time.Minute, 10, 1,
+		SetOverflowPolicy(OverflowBlock, 0))
+	lh.Start()
+	require.NoError(t, lh.HandleLine("1"))
+
+	done := make(chan error, 1)
+	go func() { done <- lh.HandleLine("2") }()
+	time.Sleep(10 * time.Millisecond)
+	lh.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			assert.ErrorIs(t, err, ErrHandlerStopped)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("HandleLine blocked after Stop")
+	}
+
+	assert.ErrorIs(t, lh.HandleLine("3"), ErrHandlerStopped)
+	assert.ErrorIs(t, lh.HandleLineCtx(context.Background(), "4"), ErrHandlerStopped)
+	lh.Stop()
+}
+
+func TestDropHandler(t *testing.T) {
+	type drop struct {
+		lines  []string
+		reason error
+	}
+	var drops []drop
+	onDropped := SetDropHandler(func(lines []string, reason error) {
+		// the lines are kept as is: the handler must not reuse them.
+		drops = append(drops, drop{lines, reason})
+	})
+
+	lh := NewLineHandler(&fakeReporter{}, metricFormat, time.Minute, 10, 1, onDropped)
+	require.NoError(t, lh.HandleLine("1"))
+	err := lh.HandleLine("2")
+	assert.True(t, errors.Is(err, ErrBufferFull))
+	lh = NewLineHandler(&fakeReporter{}, metricFormat, time.Minute, 10, 1, onDropped,
+		SetOverflowPolicy(OverflowDropOldest, 0))
+	require.NoError(t, lh.HandleLine("3"))
+	require.NoError(t, lh.HandleLine("4"))
+	assert.Equal(t, []drop{{[]string{"2"}, ErrBufferFull}, {[]string{"3"}, ErrBufferFull}}, drops)
+
+	drops = nil
+	authErr := auth.NewAuthError(errors.New("invalid token"))
+	lh = NewLineHandler(&fakeReporter{error: authErr}, metricFormat, time.Minute, 10, 10, onDropped)
+	require.NoError(t, lh.HandleLine("5"))
+	require.Error(t, lh.Flush())
+	require.Len(t, drops, 1)
+	assert.Equal(t, []string{"5"}, drops[0].lines)
+	assert.True(t, errors.Is(drops[0].reason, authErr))
+
+	drops = nil
+	batch := []string{"5"}
+	lh.reportBatch(batch)
+	batch[0] = "next batch"
+	require.Len(t, drops, 1)
+	assert.Equal(t, []string{"5"}, drops[0].lines)
+
+	drops = nil
+	reporter := &fakeReporter{}
+	reporter.SetHTTPStatus(http.StatusServiceUnavailable)
+	lh = NewLineHandler(reporter, metricFormat, time.Minute, 10, 10, onDropped)
+	lh.Start()
+	require.NoError(t, lh.HandleLine("6"))
+	lh.Stop()
+	require.Len(t, drops, 1)
+	assert.Equal(t, []string{"6"}, drops[0].lines)
+	assert.Equal(t, http.StatusServiceUnavailable, drops[0].reason.(*ReportError).StatusCode)
+}
+
+func TestFlushThreshold(t *testing.T) {
+	reporter := &fakeReporter{}
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 5, 100, SetFlushThreshold(10))
+	lh.Start()
+
+	for i := 0; i < 9; i++ {
+		require.NoError(t, lh.HandleLine(fmt.Sprintf("a %d\n", i)))
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, reporter.ReportCallCount(), "must not flush below the threshold")
+
+	require.NoError(t, lh.HandleLine("a 9\n"))
+	// flushes batches until less than the threshold is buffered.
+	assert.Eventually(t, func() bool { return reporter.ReportCallCount() == 1 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return len(lh.buffer) == 5 }, time.Second, time.Millisecond)
+
+	lh.Stop()
+	assert.Equal(t, 2, reporter.ReportCallCount())
+	assert.Equal(t, "a 0\na 1\na 2\na 3\na 4\n", reporter.lines[0])
+}
+
+func TestFlushThreshold_StopWhileThrottled(t *testing.T) {
+	reporter := &fakeReporter{}
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 5, 100, SetFlushThreshold(5),
+		ThrottleRequestsOnBackpressure())
+	lh.throttledSleepDuration = time.Hour
+	lh.Start()
+
+	reporter.SetHTTPStatus(406)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, lh.HandleLine(fmt.Sprintf("a %d\n", i)))
+	}
+	assert.Eventually(t, func() bool { return lh.GetThrottledCount() == 1 }, time.Second, time.Millisecond)
+
+	// the next threshold flush sleeps until the throttling is over, or the handler stops.
+	require.NoError(t, lh.HandleLine("a 5\n"))
+	time.Sleep(20 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		lh.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop must not wait for the throttling to be over")
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const maxRedirects = 10
+
+// withRedirectPolicy returns a copy of client that only follows redirects that keep the payload intact:
+// 307 and 308 redirects to the same host, for which the body is sent again.
+// The client is returned as is when it already has its own redirect policy.
+func withRedirectPolicy(client *http.Client) *http.Client {
+	if client == nil || client.CheckRedirect != nil {
+		return client
+	}
+	c := *client
+	c.CheckRedirect = checkRedirect
+	return &c
+}
+
+// checkRedirect refuses the redirects the default policy follows by turning a POST into a GET,
+// which silently drops the payload, and redirects to another host, which could leak it.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.Response != nil {
+		switch req.Response.StatusCode {
+		case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return fmt.Errorf("refusing %d redirect to %s: payload would be dropped", req.Response.StatusCode, req.URL.Redacted())
+		}
+	}
+	if req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("refusing redirect from host %s to host %s", via[0].URL.Host, req.URL.Host)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestReporter_Redirects(t *testing.T) {
+	var bodies []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same/report":
+			http.Redirect(w, r, "/moved/report", http.StatusTemporaryRedirect)
+		case "/found/report":
+			http.Redirect(w, r, "/moved/report", http.StatusFound)
+		case "/other/report":
+			http.Redirect(w, r, other.URL+"/report", http.StatusPermanentRedirect)
+		case "/moved/report":
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	report := func(path string) (*http.Response, error) {
+		r := NewReporter(server.URL+path, auth.NewNoopTokenService(), &http.Client{}, SetCompression(false))
+		return r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	}
+
+	resp, err := report("/same")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"\"foo\" 1 source=\"bar\"\n"}, bodies)
+
+	_, err = report("/found")
+	assert.ErrorContains(t, err, "refusing 302 redirect")
+
+	_, err = report("/other")
+	assert.ErrorContains(t, err, "refusing redirect from host")
+	assert.Len(t, bodies, 1)
+}
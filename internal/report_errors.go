@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody bounds how much of a 400 response body is kept as the reason of an InvalidLineError.
+const maxErrorBody = 4096
+
+// Errors matched, with errors.Is, by the *ReportError of the requests rejected with the status
+// they stand for, so that callers can branch on the failure mode.
+var (
+	// ErrThrottled matches 406 and 429 statuses.
+	ErrThrottled = errors.New("throttled")
+	// ErrUnauthorized matches 401 and 403 statuses.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPayloadTooLarge matches 413 statuses.
+	ErrPayloadTooLarge = errors.New("payload too large")
+	// ErrInvalidLine matches 400 statuses, and every *InvalidLineError.
+	ErrInvalidLine = errors.New("invalid line")
+)
+
+// ReportError is the error of a report request rejected with a 4xx or 5xx status.
+type ReportError struct {
+	// Format of the rejected batch.
+	Format string
+	// StatusCode is the status of the rejected request.
+	StatusCode int
+	// Err is the *InvalidLineError of a 400 response, nil otherwise.
+	Err error
+}
+
+func (e *ReportError) Error() string {
+	msg := fmt.Sprintf("error reporting %s format data to Wavefront. status=%d", e.Format, e.StatusCode)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is the Err* error of the status of e.
+func (e *ReportError) Is(target error) bool {
+	switch target {
+	case ErrThrottled:
+		return e.StatusCode == http.StatusNotAcceptable || e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrPayloadTooLarge:
+		return e.StatusCode == http.StatusRequestEntityTooLarge
+	case ErrInvalidLine:
+		return e.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+func (e *ReportError) Unwrap() error {
+	return e.Err
+}
+
+// backpressure reports whether err is the 406 status Wavefront pushes back with.
+func backpressure(err error) bool {
+	var re *ReportError
+	return errors.As(err, &re) && re.StatusCode == http.StatusNotAcceptable
+}
+
+// InvalidLineError describes the line of a batch rejected by the server with a 400 status.
+type InvalidLineError struct {
+	// Line is the line the response points at, empty when it could not be told.
+	Line string
+	// Reason is the beginning of the response body.
+	Reason string
+}
+
+func (e *InvalidLineError) Error() string {
+	if e.Line == "" {
+		return fmt.Sprintf("%s: %s", ErrInvalidLine, e.Reason)
+	}
+	return fmt.Sprintf("%s %q: %s", ErrInvalidLine, e.Line, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidLine.
+func (e *InvalidLineError) Is(target error) bool {
+	return target == ErrInvalidLine
+}
+
+// keepErrorBody replaces the body of a 400 response with its beginning, kept for the
+// InvalidLineError built by the line handler. Other bodies are left for the caller to drain.
+func keepErrorBody(resp *http.Response) {
+	if resp.StatusCode != http.StatusBadRequest {
+		return
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+}
+
+// reportError returns the *ReportError of resp, a response to the report of lines.
+func reportError(format string, lines []string, resp *http.Response) *ReportError {
+	re := &ReportError{Format: format, StatusCode: resp.StatusCode}
+	if resp.StatusCode == http.StatusBadRequest {
+		var reason string
+		if resp.Body != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+			reason = strings.TrimSpace(string(body))
+		}
+		re.Err = &InvalidLineError{Line: invalidLine(lines, reason), Reason: reason}
+	}
+	return re
+}
+
+// invalidLine returns the line of lines reason quotes, or the only line of a single line batch.
+func invalidLine(lines []string, reason string) string {
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	if reason == "" {
+		return ""
+	}
+	for _, line := range lines {
+		if line != "" && strings.Contains(reason, strings.TrimSpace(line)) {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestReportError_Is(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+	}{
+		{http.StatusNotAcceptable, ErrThrottled},
+		{http.StatusTooManyRequests, ErrThrottled},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusRequestEntityTooLarge, ErrPayloadTooLarge},
+		{http.StatusBadRequest, ErrInvalidLine},
+	}
+	targets := []error{ErrThrottled, ErrUnauthorized, ErrPayloadTooLarge, ErrInvalidLine}
+	for _, tt := range tests {
+		err := &ReportError{Format: metricFormat, StatusCode: tt.status}
+		for _, target := range targets {
+			assert.Equal(t, target == tt.target, errors.Is(err, target), "status %d, target %s", tt.status, target)
+		}
+	}
+	assert.False(t, errors.Is(&ReportError{StatusCode: http.StatusInternalServerError}, ErrThrottled))
+	assert.Equal(t, "error reporting wavefront format data to Wavefront. status=503",
+		(&ReportError{Format: metricFormat, StatusCode: 503}).Error())
+}
+
+func TestLineHandler_InvalidLineError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("Invalid metric name in: \"b@d\" 2 source=\"s\""))
+	}))
+	defer server.Close()
+
+	reporter := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{}, SetCompression(false))
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 10, 100)
+	require.NoError(t, lh.HandleLine("\"good\" 1 source=\"s\""))
+	require.NoError(t, lh.HandleLine("\"b@d\" 2 source=\"s\""))
+
+	err := lh.Flush()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidLine))
+	var ile *InvalidLineError
+	require.True(t, errors.As(err, &ile))
+	assert.Equal(t, "\"b@d\" 2 source=\"s\"", ile.Line)
+	assert.Equal(t, "Invalid metric name in: \"b@d\" 2 source=\"s\"", ile.Reason)
+	var re *ReportError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, http.StatusBadRequest, re.StatusCode)
+}
+
+func TestLineHandler_ThrottledError(t *testing.T) {
+	reporter := &fakeReporter{}
+	reporter.SetHTTPStatus(http.StatusNotAcceptable)
+	lh := NewLineHandler(reporter, metricFormat, time.Hour, 10, 100, ThrottleRequestsOnBackpressure())
+	require.NoError(t, lh.HandleLine("dummyLine"))
+
+	err := lh.Flush()
+	assert.True(t, errors.Is(err, ErrThrottled))
+	assert.Equal(t, int64(1), lh.GetThrottledCount())
+}
@@ -2,10 +2,7 @@ package internal
 
 import (
 	"bytes"
-	"compress/gzip"
-	"io"
 	"net/http"
-	"strings"
 
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
 )
@@ -15,72 +12,63 @@ type reporter struct {
 	serverURL    string
 	tokenService auth.Service
 	client       *http.Client
+	reporterSettings
 }
 
 // NewReporter creates a metrics Reporter
-func NewReporter(server string, tokenService auth.Service, client *http.Client) Reporter {
+func NewReporter(server string, tokenService auth.Service, client *http.Client, setters ...ReporterOption) Reporter {
 	return &reporter{
-		serverURL:    server,
-		tokenService: tokenService,
-		client:       client,
+		serverURL:        server,
+		tokenService:     tokenService,
+		client:           withRedirectPolicy(client),
+		reporterSettings: newReporterSettings(setters),
 	}
 }
 
 // Report creates and sends a POST to the reportEndpoint with the given pointLines
-func (reporter reporter) Report(format string, pointLines string) (*http.Response, error) {
-	if format == "" || pointLines == "" {
+func (reporter reporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	if format == "" || len(pointLines) == 0 {
 		return nil, formatError
 	}
 
 	if format == eventFormat {
-		return reporter.reportEvent(pointLines)
+		return reporter.reportEvent(string(pointLines))
 	}
 
-	requestBody, err := linesToGzippedBytes(pointLines)
-	if err != nil {
-		return nil, err
-	}
+	return reporter.sendCompressed(reporter.client, format, pointLines, func(body []byte, encoding string) (*http.Request, error) {
+		return reporter.buildRequest(format, pointLines, body, encoding)
+	})
+}
 
-	req, err := reporter.buildRequest(format, requestBody)
+func (reporter reporter) buildRequest(format string, pointLines []byte, body []byte, encoding string) (*http.Request, error) {
+	apiURL := reporter.serverURL + reporter.reportPath
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
 
-	return reporter.execute(req)
-}
-
-func linesToGzippedBytes(pointLines string) ([]byte, error) {
-	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
-	_, err := zw.Write([]byte(pointLines))
-	if err != nil {
-		_ = zw.Close()
-		return nil, err
+	req.Header.Set(contentType, octetStream)
+	if encoding != "" {
+		req.Header.Set(contentEncoding, encoding)
 	}
-	if err = zw.Close(); err != nil {
-		return nil, err
+	if reporter.batchHeaders {
+		setBatchMetadataHeaders(req.Header, format, pointLines)
 	}
-	return buf.Bytes(), err
-}
 
-func (reporter reporter) buildRequest(format string, body []byte) (*http.Request, error) {
-	apiURL := reporter.serverURL + reportEndpoint
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
+	q := req.URL.Query()
+	q.Set(formatKey, format)
+	setParams(q, reporter.queryParams)
+	if reporter.queryFunc != nil {
+		setParams(q, reporter.queryFunc(format, string(pointLines)))
 	}
+	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set(contentType, octetStream)
-	req.Header.Set(contentEncoding, gzipFormat)
-
+	// The request is authorized once its URL and body are final, for authorizers signing them.
 	err = reporter.tokenService.Authorize(req)
 	if err != nil {
 		return nil, err
 	}
-
-	q := req.URL.Query()
-	q.Add(formatKey, format)
-	req.URL.RawQuery = q.Encode()
+	reporter.applyHeaders(req)
 	return req, nil
 }
 
@@ -90,7 +78,8 @@ func (reporter reporter) reportEvent(event string) (*http.Response, error) {
 	}
 
 	apiURL := reporter.serverURL + eventEndpoint
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(event))
+	body := []byte(event)
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -102,18 +91,9 @@ func (reporter reporter) reportEvent(event string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	reporter.applyHeaders(req)
 
-	return reporter.execute(req)
-}
-
-func (reporter reporter) execute(req *http.Request) (*http.Response, error) {
-	resp, err := reporter.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	_, _ = io.Copy(io.Discard, resp.Body)
-	defer resp.Body.Close()
-	return resp, nil
+	return reporter.sendDigested(reporter.client, req, body)
 }
 
 func (reporter reporter) Close() {
@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	tenantIDHeader      = "dx_tenant_id"
+)
+
+// reporterSettings holds the request customizations shared by the HTTP reporters.
+type reporterSettings struct {
+	reportPath    string
+	queryParams   url.Values
+	queryFunc     func(format string, pointLines string) url.Values
+	tenantID      string
+	authHeaders   []string
+	tenantHeaders []string
+	extraHeaders  map[string]string
+	compressors   *compressors
+	batchHeaders  bool
+	digest        bool
+	retry         retryPolicy
+	timeout       requestTimeout
+	journal       *Journal
+	counters      *ReporterCounters
+}
+
+// ReporterCounters are the internal metrics reporters count into, shared by the reporters
+// successively created for the same sender.
+type ReporterCounters struct {
+	uncompressedBytes *sdkmetrics.DeltaCounter
+	compressedBytes   *sdkmetrics.DeltaCounter
+	retriesShed       *sdkmetrics.DeltaCounter
+}
+
+// NewReporterCounters registers the bytes.uncompressed, bytes.compressed and retries.shed
+// internal metrics in registry.
+func NewReporterCounters(registry sdkmetrics.Registry) *ReporterCounters {
+	return &ReporterCounters{
+		uncompressedBytes: registry.NewDeltaCounter(sdkmetrics.BytesUncompressed),
+		compressedBytes:   registry.NewDeltaCounter(sdkmetrics.BytesCompressed),
+		retriesShed:       registry.NewDeltaCounter(sdkmetrics.RetriesShed),
+	}
+}
+
+// ReporterOption allows reporter customization
+type ReporterOption func(*reporterSettings)
+
+func newReporterSettings(setters []ReporterOption) reporterSettings {
+	s := reporterSettings{
+		reportPath:  reportEndpoint,
+		compressors: newCompressors(compression.Gzip()),
+		retry:       defaultRetryPolicy(),
+	}
+	for _, setter := range setters {
+		setter(&s)
+	}
+	return s
+}
+
+// SetReportPath replaces the default /report path points are posted to.
+func SetReportPath(path string) ReporterOption {
+	return func(s *reporterSettings) {
+		s.reportPath = path
+	}
+}
+
+// SetReportQueryParams adds static query params to every report request.
+// A param named like the format param overrides the format of the batch.
+func SetReportQueryParams(params url.Values) ReporterOption {
+	return func(s *reporterSettings) {
+		s.queryParams = params
+	}
+}
+
+// SetReportQueryFunc adds the query params returned by fn for each batch to its report request.
+// They take precedence over the static query params.
+func SetReportQueryFunc(fn func(format string, pointLines string) url.Values) ReporterOption {
+	return func(s *reporterSettings) {
+		s.queryFunc = fn
+	}
+}
+
+// SetCompression turns gzip compression of report payloads on/off.
+func SetCompression(enabled bool) ReporterOption {
+	if enabled {
+		return SetCompressors(compression.Gzip())
+	}
+	return SetCompressors(compression.None())
+}
+
+// SetCompressors compresses report payloads with the first of codecs, falling back to the
+// next one for good each time the endpoint rejects an encoding with a 415 status.
+func SetCompressors(codecs ...compression.Compressor) ReporterOption {
+	return func(s *reporterSettings) {
+		if len(codecs) > 0 {
+			s.compressors = newCompressors(codecs...)
+		}
+	}
+}
+
+// SetReporterRegistry counts the bytes of report payloads, before and after compression,
+// in the bytes.uncompressed and bytes.compressed internal metrics of registry, and the requests
+// not retried for lack of retry budget in retries.shed.
+func SetReporterRegistry(registry sdkmetrics.Registry) ReporterOption {
+	return SetReporterCounters(NewReporterCounters(registry))
+}
+
+// SetReporterCounters counts into counters, as SetReporterRegistry does into their registry.
+func SetReporterCounters(counters *ReporterCounters) ReporterOption {
+	return func(s *reporterSettings) {
+		s.counters = counters
+	}
+}
+
+// SetJournal records each report request in journal.
+func SetJournal(journal *Journal) ReporterOption {
+	return func(s *reporterSettings) {
+		s.journal = journal
+	}
+}
+
+// SetTenantID sets the dx_tenant_id header of every request.
+func SetTenantID(tenantID string) ReporterOption {
+	return func(s *reporterSettings) {
+		s.tenantID = tenantID
+	}
+}
+
+// SetAuthHeaders sends the Authorization header value under each of names instead.
+// Include "Authorization" in names to keep the original header as well.
+func SetAuthHeaders(names ...string) ReporterOption {
+	return func(s *reporterSettings) {
+		s.authHeaders = names
+	}
+}
+
+// SetTenantHeaders sends the dx_tenant_id header value under each of names instead.
+// Include "dx_tenant_id" in names to keep the original header as well.
+func SetTenantHeaders(names ...string) ReporterOption {
+	return func(s *reporterSettings) {
+		s.tenantHeaders = names
+	}
+}
+
+// SetExtraHeaders sets headers on every request, over the headers set by the reporter.
+func SetExtraHeaders(headers map[string]string) ReporterOption {
+	return func(s *reporterSettings) {
+		s.extraHeaders = headers
+	}
+}
+
+// applyHeaders sets the extra headers and the tenant header, and renames the auth and tenant
+// headers of an authorized request.
+func (s reporterSettings) applyHeaders(req *http.Request) {
+	for key, value := range s.extraHeaders {
+		req.Header.Set(key, value)
+	}
+	if s.tenantID != "" {
+		req.Header.Set(tenantIDHeader, s.tenantID)
+	}
+	renameHeader(req.Header, authorizationHeader, s.authHeaders)
+	renameHeader(req.Header, tenantIDHeader, s.tenantHeaders)
+}
+
+func renameHeader(header http.Header, from string, to []string) {
+	if len(to) == 0 {
+		return
+	}
+	values := header.Values(from)
+	if len(values) == 0 {
+		return
+	}
+	header.Del(from)
+	for _, name := range to {
+		for _, value := range values {
+			header.Add(name, value)
+		}
+	}
+}
+
+// encodeBody returns the request body for pointLines, compressed with codec.
+func (s reporterSettings) encodeBody(pointLines []byte, codec compression.Compressor) ([]byte, error) {
+	if s.counters != nil {
+		s.counters.uncompressedBytes.Add(int64(len(pointLines)))
+	}
+	body, err := codec.Compress(pointLines)
+	if err != nil {
+		return nil, err
+	}
+	if codec.Encoding() == "" {
+		// the http client may read the body after the request completed, pointLines is reused by then.
+		body = append([]byte(nil), body...)
+	}
+	if s.counters != nil && codec.Encoding() != "" {
+		s.counters.compressedBytes.Add(int64(len(body)))
+	}
+	return body, nil
+}
+
+// sendCompressed sends the request built by build for pointLines, compressed with the preferred
+// codec, and again with the next codecs as long as the endpoint rejects their encoding.
+func (s reporterSettings) sendCompressed(client *http.Client, format string, pointLines []byte,
+	build func(body []byte, encoding string) (*http.Request, error)) (resp *http.Response, err error) {
+	var endpoint string
+	if s.journal != nil {
+		defer func(start time.Time) {
+			s.record(start, endpoint, format, pointLines, resp, err)
+		}(time.Now())
+	}
+	for {
+		i, codec := s.compressors.get()
+		body, err := s.encodeBody(pointLines, codec)
+		if err != nil {
+			return nil, err
+		}
+		req, err := build(body, codec.Encoding())
+		if err != nil {
+			return nil, err
+		}
+		endpoint = req.URL.Redacted()
+		resp, err := s.sendDigested(client, req, body)
+		if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType || !s.compressors.fallback(i) {
+			return resp, err
+		}
+		log.Printf("%q encoding rejected by %s, falling back to the next compressor\n",
+			codec.Encoding(), req.URL.Redacted())
+	}
+}
+
+// record adds the outcome of a report request of pointLines, started at start, to the journal.
+func (s reporterSettings) record(start time.Time, endpoint, format string, pointLines []byte, resp *http.Response, err error) {
+	entry := JournalEntry{
+		Time:     start,
+		Endpoint: endpoint,
+		Format:   format,
+		Lines:    bytes.Count(pointLines, []byte{'\n'}),
+		Bytes:    len(pointLines),
+		Latency:  time.Since(start),
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		var qe *QuotaError
+		if errors.As(err, &qe) {
+			entry.Status = qe.StatusCode
+		}
+	}
+	if journalErr := s.journal.Record(entry); journalErr != nil {
+		log.Printf("unable to record report request in journal: %s\n", journalErr)
+	}
+}
+
+func setParams(q url.Values, params url.Values) {
+	for k, v := range params {
+		q[k] = v
+	}
+}
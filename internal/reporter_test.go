@@ -2,16 +2,115 @@ package internal
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 )
 
 func TestReporter_BuildRequest(t *testing.T) {
 	r := NewReporter("http://localhost:8010/wavefront", auth.NewNoopTokenService(), &http.Client{}).(*reporter)
-	request, err := r.buildRequest("wavefront", nil)
+	request, err := r.buildRequest("wavefront", nil, nil, "")
 	require.NoError(t, err)
 	assert.Equal(t, "http://localhost:8010/wavefront/report?f=wavefront", request.URL.String())
 }
+
+func TestReporter_BuildRequestWithOptions(t *testing.T) {
+	r := NewReporter("http://localhost:8010/wavefront", auth.NewNoopTokenService(), &http.Client{},
+		SetReportPath("/v1/ingest"),
+		SetReportQueryParams(url.Values{"tenant": {"acme"}}),
+		SetReportQueryFunc(func(format string, pointLines string) url.Values {
+			return url.Values{"lines": {strconv.Itoa(strings.Count(pointLines, "\n"))}}
+		}),
+	).(*reporter)
+	request, err := r.buildRequest("wavefront", []byte("a 1\nb 2\n"), nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8010/wavefront/v1/ingest?f=wavefront&lines=2&tenant=acme", request.URL.String())
+
+	r = NewReporter("http://localhost:8010", auth.NewNoopTokenService(), &http.Client{},
+		SetReportQueryParams(url.Values{"f": {"otlp"}}),
+	).(*reporter)
+	request, err = r.buildRequest("wavefront", nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8010/report?f=otlp", request.URL.String())
+}
+
+func TestReporter_RenameHeaders(t *testing.T) {
+	r := NewReporter("http://localhost:8010", auth.NewWavefrontTokenService("token"), &http.Client{},
+		SetAuthHeaders("X-API-Key", "Authorization"),
+	).(*reporter)
+	request, err := r.buildRequest("wavefront", nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", request.Header.Get("X-API-Key"))
+	assert.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+
+	header := http.Header{}
+	header.Set(tenantIDHeader, "16")
+	renameHeader(header, tenantIDHeader, []string{"X-Tenant"})
+	assert.Equal(t, http.Header{"X-Tenant": {"16"}}, header)
+}
+
+func TestReporter_Compression(t *testing.T) {
+	registry := sdkmetrics.NewMetricRegistry(nil)
+	uncompressed := registry.NewDeltaCounter("bytes.uncompressed")
+	compressed := registry.NewDeltaCounter("bytes.compressed")
+	lines := []byte(strings.Repeat("\"foo\" 1 source=\"bar\"\n", 100))
+
+	r := NewReporter("http://localhost:8010", auth.NewNoopTokenService(), &http.Client{},
+		SetReporterRegistry(registry)).(*reporter)
+	_, codec := r.compressors.get()
+	body, err := r.encodeBody(lines, codec)
+	require.NoError(t, err)
+	assert.Less(t, len(body), len(lines))
+	assert.Equal(t, int64(len(lines)), uncompressed.Count())
+	assert.Equal(t, int64(len(body)), compressed.Count())
+	request, err := r.buildRequest("wavefront", lines, body, codec.Encoding())
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", request.Header.Get("Content-Encoding"))
+
+	r = NewReporter("http://localhost:8010", auth.NewNoopTokenService(), &http.Client{},
+		SetCompression(false)).(*reporter)
+	_, codec = r.compressors.get()
+	body, err = r.encodeBody(lines, codec)
+	require.NoError(t, err)
+	assert.Equal(t, lines, body)
+	request, err = r.buildRequest("wavefront", lines, body, codec.Encoding())
+	require.NoError(t, err)
+	assert.Empty(t, request.Header.Get("Content-Encoding"))
+}
+
+func TestReporter_CompressorFallback(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer server.Close()
+
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), server.Client(),
+		SetCompressors(compression.Gzip(), compression.None()))
+	resp, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = r.Report("wavefront", []byte("\"foo\" 2 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"gzip", "", ""}, encodings)
+
+	// the last codec's rejection is returned as is.
+	encodings = nil
+	r = NewReporter(server.URL, auth.NewNoopTokenService(), server.Client())
+	resp, err = r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Equal(t, []string{"gzip"}, encodings)
+}
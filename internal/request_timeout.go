@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const mebibyte = 1 << 20
+
+// requestTimeout scales the timeout of report requests with the size of their body.
+type requestTimeout struct {
+	base  time.Duration
+	perMB time.Duration
+}
+
+// SetRequestTimeout times out each attempt of a report request after base plus perMB for every
+// MiB of its body, as sent, i.e. compressed. A zero base leaves the timeout to the client.
+func SetRequestTimeout(base, perMB time.Duration) ReporterOption {
+	return func(s *reporterSettings) {
+		s.timeout = requestTimeout{base: base, perMB: perMB}
+	}
+}
+
+// forSize returns the timeout of a request whose body is size bytes.
+func (t requestTimeout) forSize(size int64) time.Duration {
+	if size <= 0 {
+		return t.base
+	}
+	return t.base + time.Duration(float64(t.perMB)*float64(size)/mebibyte)
+}
+
+// apply returns req with the deadline of its size, and the function to call once its response
+// body is read.
+func (t requestTimeout) apply(req *http.Request) (*http.Request, context.CancelFunc) {
+	if t.base <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.forSize(req.ContentLength))
+	return req.WithContext(ctx), cancel
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestRequestTimeout_ForSize(t *testing.T) {
+	timeout := requestTimeout{base: 5 * time.Second, perMB: 10 * time.Second}
+	assert.Equal(t, 5*time.Second, timeout.forSize(0))
+	assert.Equal(t, 5*time.Second, timeout.forSize(-1))
+	assert.Equal(t, 10*time.Second, timeout.forSize(mebibyte/2))
+	assert.Equal(t, 45*time.Second, timeout.forSize(4*mebibyte))
+}
+
+func TestReporter_RequestTimeout(t *testing.T) {
+	delay := make(chan time.Duration, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(<-delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// a small batch times out after about base, a large one is given perMB more per MiB.
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{},
+		SetCompression(false), SetRequestTimeout(50*time.Millisecond, time.Second))
+
+	delay <- 200 * time.Millisecond
+	_, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err.Error())
+
+	delay <- 200 * time.Millisecond
+	resp, err := r.Report("wavefront", []byte(strings.Repeat("\"foo\" 1 source=\"bar\"\n", mebibyte/20)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
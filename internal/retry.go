@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryDelay       = 30 * time.Second
+)
+
+// retryPolicy retries report requests that failed with a transient error:
+// a 429 or 503 status, or a connection reset by the server.
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+	budget     *RetryBudget
+	sleep      func(time.Duration)
+}
+
+// SetRetries retries transient failures up to maxRetries times, waiting an exponentially growing,
+// jittered delay starting at backoff between attempts. A Retry-After header takes precedence over the
+// computed delay. Delays are capped at 30 seconds. A zero backoff uses a default of 500ms.
+func SetRetries(maxRetries int, backoff time.Duration) ReporterOption {
+	return func(s *reporterSettings) {
+		s.retry.maxRetries = maxRetries
+		if backoff > 0 {
+			s.retry.backoff = backoff
+		}
+	}
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{backoff: defaultRetryBackoff, sleep: time.Sleep}
+}
+
+// send executes req, retrying it according to the retry policy. The response body is drained and closed,
+// but for the beginning of the body of a 400 response, see keepErrorBody.
+func (s reporterSettings) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	if s.retry.budget != nil {
+		s.retry.budget.request()
+	}
+	for attempt := 0; ; attempt++ {
+		attemptReq, cancel := s.timeout.apply(req)
+		resp, err := client.Do(attemptReq)
+		if err == nil {
+			qe := quotaError(resp)
+			keepErrorBody(resp)
+			if resp.StatusCode != http.StatusBadRequest {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}
+			if qe != nil {
+				cancel()
+				return nil, qe
+			}
+		}
+		cancel()
+		if attempt >= s.retry.maxRetries || !retryable(resp, err) || req.GetBody == nil {
+			return resp, err
+		}
+		if s.retry.budget != nil && !s.retry.budget.allowRetry() {
+			if s.counters != nil {
+				s.counters.retriesShed.Inc()
+			}
+			log.Printf("retry budget exhausted, not retrying report to %s\n", req.URL.Redacted())
+			return resp, err
+		}
+
+		delay := s.retry.delay(attempt, resp)
+		log.Printf("transient error reporting to %s, retrying in %v (attempt %d of %d)\n",
+			req.URL.Redacted(), delay, attempt+1, s.retry.maxRetries)
+		s.retry.sleep(delay)
+
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// delay returns the time to wait before retrying after the given attempt (starting at 0).
+func (p retryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return minDuration(d, maxRetryDelay)
+		}
+	}
+	d := p.backoff << attempt
+	if d <= 0 || d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	// equal jitter: half of the delay is fixed, the other half is random.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		d := time.Until(date)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
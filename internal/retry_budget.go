@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+const retryBudgetWindow = time.Minute
+
+// RetryBudget bounds the retries of report requests across all the reporters sharing it, so that
+// a flapping endpoint does not see its load multiplied by retries. Within each minute, a retry is
+// allowed while the retries stay under both the maximum count and the maximum ratio of the
+// requests sent; at least one retry per minute is allowed by the ratio. Requests that may not be
+// retried fail right away and their lines are buffered, or persisted, for a later flush.
+// It is safe for concurrent use.
+type RetryBudget struct {
+	mtx       sync.Mutex
+	perMinute int
+	ratio     float64
+
+	windowStart time.Time
+	requests    int
+	retries     int
+
+	now func() time.Time
+}
+
+// NewRetryBudget creates a RetryBudget allowing up to perMinute retries per minute, and retries up
+// to ratio of the requests, e.g. 0.1 for 10%. Zero disables either limit.
+func NewRetryBudget(perMinute int, ratio float64) *RetryBudget {
+	return &RetryBudget{
+		perMinute: perMinute,
+		ratio:     ratio,
+		now:       time.Now,
+	}
+}
+
+// SetRetryBudget makes the retries of the reporter, see SetRetries, draw from budget.
+func SetRetryBudget(budget *RetryBudget) ReporterOption {
+	return func(s *reporterSettings) {
+		s.retry.budget = budget
+	}
+}
+
+// request counts a request sent for the first time.
+func (b *RetryBudget) request() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.roll()
+	b.requests++
+}
+
+// allowRetry reports whether a request may be retried, and counts the retry if so.
+func (b *RetryBudget) allowRetry() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.roll()
+	if b.perMinute > 0 && b.retries >= b.perMinute {
+		return false
+	}
+	if b.ratio > 0 && b.retries > 0 && float64(b.retries+1) > b.ratio*float64(b.requests) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// roll starts a new window once the current one is over.
+func (b *RetryBudget) roll() {
+	now := b.now()
+	if now.Sub(b.windowStart) >= retryBudgetWindow {
+		b.windowStart = now
+		b.requests = 0
+		b.retries = 0
+	}
+}
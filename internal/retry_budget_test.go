@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestRetryBudget_PerMinute(t *testing.T) {
+	now := time.Now()
+	b := NewRetryBudget(2, 0)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		b.request()
+	}
+	assert.True(t, b.allowRetry())
+	assert.True(t, b.allowRetry())
+	assert.False(t, b.allowRetry())
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.allowRetry())
+}
+
+func TestRetryBudget_Ratio(t *testing.T) {
+	now := time.Now()
+	b := NewRetryBudget(0, 0.1)
+	b.now = func() time.Time { return now }
+
+	b.request()
+	assert.True(t, b.allowRetry(), "the first retry of a window is always allowed")
+	assert.False(t, b.allowRetry())
+
+	for i := 0; i < 19; i++ {
+		b.request()
+	}
+	assert.True(t, b.allowRetry())
+	assert.False(t, b.allowRetry())
+}
+
+func TestReporter_RetryBudgetExhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	budget := NewRetryBudget(3, 0)
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{},
+		SetRetries(2, 0), SetRetryBudget(budget)).(*reporter)
+	r.retry.sleep = func(time.Duration) {}
+
+	resp, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+
+	resp, err = r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 5, attempts, "only one retry left in the budget")
+}
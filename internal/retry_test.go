@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestReporter_Retries(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		status := statuses[0]
+		statuses = statuses[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "7")
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{},
+		SetCompression(false), SetRetries(3, time.Second)).(*reporter)
+	r.retry.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	resp, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"\"foo\" 1 source=\"bar\"\n", "\"foo\" 1 source=\"bar\"\n", "\"foo\" 1 source=\"bar\"\n"}, bodies)
+	require.Len(t, delays, 2)
+	assert.GreaterOrEqual(t, delays[0], 500*time.Millisecond)
+	assert.LessOrEqual(t, delays[0], time.Second)
+	assert.Equal(t, 7*time.Second, delays[1])
+}
+
+func TestReporter_RetriesExhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	r := NewReporter(server.URL, auth.NewNoopTokenService(), &http.Client{}, SetRetries(2, 0)).(*reporter)
+	r.retry.sleep = func(time.Duration) {}
+
+	resp, err := r.Report("wavefront", []byte("\"foo\" 1 source=\"bar\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := retryPolicy{backoff: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := p.delay(attempt, nil)
+		expected := minDuration(time.Second<<attempt, maxRetryDelay)
+		assert.GreaterOrEqual(t, d, expected/2)
+		assert.LessOrEqual(t, d, expected)
+	}
+
+	d, ok := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, float64(time.Minute), float64(d), float64(2*time.Second))
+	_, ok = retryAfter("soon")
+	assert.False(t, ok)
+}
@@ -10,6 +10,18 @@ var /* const */ lineBreak = regexp.MustCompile(`\n`)
 
 // Sanitize sanitizes string of metric name, source and key of tags according to the rule of Wavefront proxy.
 func Sanitize(str string) string {
+	return sanitize(str, "")
+}
+
+// SanitizeAllowing returns a function sanitizing as Sanitize does, except for the characters of
+// allowed, which are left as they are.
+func SanitizeAllowing(allowed string) func(string) string {
+	return func(str string) string {
+		return sanitize(str, allowed)
+	}
+}
+
+func sanitize(str, allowed string) string {
 	sb := GetBuffer()
 	defer PutBuffer(sb)
 
@@ -45,7 +57,8 @@ func Sanitize(str string) string {
 		strCur := string(cur)
 		isLegal := true
 
-		if !(44 <= cur && cur <= 57) && !(65 <= cur && cur <= 90) && !(97 <= cur && cur <= 122) && cur != 95 {
+		if !(44 <= cur && cur <= 57) && !(65 <= cur && cur <= 90) && !(97 <= cur && cur <= 122) && cur != 95 &&
+			strings.IndexByte(allowed, cur) < 0 {
 			isLegal = false
 		}
 		if isLegal {
@@ -25,6 +25,13 @@ func TestSanitizeInternal(t *testing.T) {
 		"heartbeat")))
 }
 
+func TestSanitizeAllowing(t *testing.T) {
+	sanitize := SanitizeAllowing(":")
+	assert.Equal(t, "kube:pod-cpu", sanitize("kube:pod cpu"))
+	assert.Equal(t, "∆kube:requests", sanitize("∆kube:requests"))
+	assert.Equal(t, "kube-pod", Sanitize("kube:pod"))
+}
+
 func TestSanitizeValue(t *testing.T) {
 	assert.Equal(t, "\"hello\"", SanitizeValue("hello"))
 	assert.Equal(t, "\"hello world\"", SanitizeValue("hello world"))
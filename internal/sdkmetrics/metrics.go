@@ -19,6 +19,16 @@ func (c *MetricCounter) count() int64 {
 	return atomic.LoadInt64(&c.value)
 }
 
+// Add increments the counter by n.
+func (c *MetricCounter) Add(n int64) {
+	atomic.AddInt64(&c.value, n)
+}
+
+// Count returns the current value of the counter.
+func (c *MetricCounter) Count() int64 {
+	return c.count()
+}
+
 type DeltaCounter struct {
 	MetricCounter
 }
@@ -0,0 +1,38 @@
+package sdkmetrics
+
+// Parts of the names of the internal metrics, relative to the registry prefix.
+// Per data type metrics are named <type prefix><suffix>, e.g. points.valid.
+const (
+	PointsPrefix     = "points"
+	HistogramsPrefix = "histograms"
+	SpansPrefix      = "spans"
+	SpanLogsPrefix   = "span_logs"
+	EventsPrefix     = "events"
+
+	ValidSuffix                  = ".valid"
+	InvalidSuffix                = ".invalid"
+	DroppedSuffix                = ".dropped"
+	QueueSizeSuffix              = ".queue.size"
+	QueueRemainingCapacitySuffix = ".queue.remaining_capacity"
+	FlushBudgetExceededSuffix    = ".flush.budget_exceeded"
+	BufferEvictedSuffix          = ".buffer.evicted"
+	DisabledSuffix               = ".disabled"
+	QuotaExceededSuffix          = ".quota_exceeded"
+	CircuitOpenedSuffix          = ".circuit_breaker.opened"
+	SentSuffix                   = ".sent"
+	ReportErrorsSuffix           = ".report.errors"
+	FlushLatencySuffix           = ".flush.latency_ms"
+
+	DroppedNonFiniteSuffix   = DroppedSuffix + ".non_finite"
+	DroppedOutOfBoundsSuffix = DroppedSuffix + ".out_of_bounds"
+	DroppedDisabledSuffix    = DroppedSuffix + ".disabled"
+	DroppedInvalidSuffix     = DroppedSuffix + ".strict_validation"
+
+	BytesUncompressed = "bytes.uncompressed"
+	BytesCompressed   = "bytes.compressed"
+	PointsNonFinite   = PointsPrefix + ".non_finite"
+	PointsOutOfBounds = PointsPrefix + ".out_of_bounds"
+	Startup           = "startup"
+	RetriesShed       = "retries.shed"
+	SpanLogsTruncated = SpanLogsPrefix + ".truncated"
+)
@@ -39,6 +39,13 @@ func (n *noOpRegistry) NewGauge(string, func() int64) *FunctionalGauge {
 	return &FunctionalGauge{}
 }
 
+func (n *noOpRegistry) NewDeltaCounter(string) *DeltaCounter {
+	return &DeltaCounter{}
+}
+
+func (n *noOpRegistry) SendOnce(string, float64, map[string]string) {
+}
+
 type noOpTracker struct{}
 
 func (n noOpTracker) IncValid() {
@@ -21,6 +21,14 @@ type realRegistry struct {
 	spansTracker      *realSuccessTracker
 	spanLogsTracker   *realSuccessTracker
 	eventsTracker     *realSuccessTracker
+	once              []onceMetric
+}
+
+// onceMetric is a metric sent with the next report only.
+type onceMetric struct {
+	name  string
+	value float64
+	tags  map[string]string
 }
 
 func (registry *realRegistry) Flush() {
@@ -49,9 +57,9 @@ func (registry *realRegistry) EventsTracker() SuccessTracker {
 
 func (registry *realRegistry) newSuccessTracker(prefix string) *realSuccessTracker {
 	return &realSuccessTracker{
-		Valid:   registry.NewDeltaCounter(prefix + ".valid"),
-		Invalid: registry.NewDeltaCounter(prefix + ".invalid"),
-		Dropped: registry.NewDeltaCounter(prefix + ".dropped"),
+		Valid:   registry.NewDeltaCounter(prefix + ValidSuffix),
+		Invalid: registry.NewDeltaCounter(prefix + InvalidSuffix),
+		Dropped: registry.NewDeltaCounter(prefix + DroppedSuffix),
 	}
 }
 
@@ -63,11 +71,11 @@ func NewMetricRegistry(sender internalSender, setters ...RegistryOption) Registr
 		done:         make(chan struct{}),
 	}
 
-	registry.pointsTracker = registry.newSuccessTracker("points")
-	registry.histogramsTracker = registry.newSuccessTracker("histograms")
-	registry.spansTracker = registry.newSuccessTracker("spans")
-	registry.spanLogsTracker = registry.newSuccessTracker("span_logs")
-	registry.eventsTracker = registry.newSuccessTracker("events")
+	registry.pointsTracker = registry.newSuccessTracker(PointsPrefix)
+	registry.histogramsTracker = registry.newSuccessTracker(HistogramsPrefix)
+	registry.spansTracker = registry.newSuccessTracker(SpansPrefix)
+	registry.spanLogsTracker = registry.newSuccessTracker(SpanLogsPrefix)
+	registry.eventsTracker = registry.newSuccessTracker(EventsPrefix)
 
 	for _, setter := range setters {
 		setter(registry)
@@ -116,6 +124,23 @@ func (registry *realRegistry) report() {
 			_ = registry.sender.SendMetric(registry.prefix+"."+k, m.instantValue(), 0, "", registry.tags)
 		}
 	}
+	for _, m := range registry.once {
+		_ = registry.sender.SendMetric(registry.prefix+"."+m.name, m.value, 0, "", m.tags)
+	}
+	registry.once = nil
+}
+
+func (registry *realRegistry) SendOnce(name string, value float64, tags map[string]string) {
+	merged := make(map[string]string, len(registry.tags)+len(tags))
+	for k, v := range registry.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+	registry.once = append(registry.once, onceMetric{name: name, value: value, tags: merged})
 }
 
 func (registry *realRegistry) getOrAdd(name string, metric interface{}) interface{} {
@@ -37,16 +37,33 @@ func TestRealMetricRegistry(t *testing.T) {
 	}, sender.deltaCounters)
 }
 
+func TestRealMetricRegistry_SendOnce(t *testing.T) {
+	sender := &mockSender{}
+	registry := NewMetricRegistry(sender, SetPrefix("~test"), SetTag("pid", "1"))
+	registry.SendOnce("startup", 1, map[string]string{"config_hash": "abc"})
+
+	registry.Flush()
+	assert.Equal(t, 1.0, sender.metrics["~test.startup"])
+	assert.Equal(t, map[string]string{"pid": "1", "config_hash": "abc"}, sender.tags["~test.startup"])
+
+	sender.metrics = nil
+	registry.Flush()
+	assert.NotContains(t, sender.metrics, "~test.startup")
+}
+
 type mockSender struct {
 	metrics       map[string]float64
+	tags          map[string]map[string]string
 	deltaCounters map[string]float64
 }
 
-func (m *mockSender) SendMetric(name string, value float64, _ int64, _ string, _ map[string]string) error {
+func (m *mockSender) SendMetric(name string, value float64, _ int64, _ string, tags map[string]string) error {
 	if m.metrics == nil {
 		m.metrics = make(map[string]float64)
+		m.tags = make(map[string]map[string]string)
 	}
 	m.metrics[name] = value
+	m.tags[name] = tags
 	return nil
 }
 
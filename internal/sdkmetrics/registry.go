@@ -25,5 +25,8 @@ type Registry interface {
 	EventsTracker() SuccessTracker
 
 	NewGauge(s string, f func() int64) *FunctionalGauge
+	NewDeltaCounter(s string) *DeltaCounter
+	// SendOnce sends a metric with the next report only, tagged with tags on top of the registry tags.
+	SendOnce(s string, value float64, tags map[string]string)
 	Flush()
 }
@@ -34,9 +34,9 @@ func Line(name string, startMillis, durationMillis int64, source, traceID, spanI
 	sb := internal.GetBuffer()
 	defer internal.PutBuffer(sb)
 
-	sb.WriteString(internal.SanitizeValue(name))
+	sb.WriteString(internal.QuoteValue(name))
 	sb.WriteString(" source=")
-	sb.WriteString(internal.SanitizeValue(source))
+	sb.WriteString(internal.QuoteValue(source))
 	sb.WriteString(" traceId=")
 	sb.WriteString(traceID)
 	sb.WriteString(" spanId=")
@@ -54,9 +54,9 @@ func Line(name string, startMillis, durationMillis int64, source, traceID, spanI
 
 	if len(spanLogs) > 0 {
 		sb.WriteString(" ")
-		sb.WriteString(strconv.Quote(internal.Sanitize("_spanLogs")))
+		sb.WriteString(internal.QuoteKey("_spanLogs"))
 		sb.WriteString("=")
-		sb.WriteString(strconv.Quote(internal.Sanitize("true")))
+		sb.WriteString(internal.QuoteKey("true"))
 	}
 
 	for _, tag := range tags {
@@ -67,9 +67,9 @@ func Line(name string, startMillis, durationMillis int64, source, traceID, spanI
 			return "", fmt.Errorf("tag values cannot be empty: span=%s tag=%s", name, tag.Key)
 		}
 		sb.WriteString(" ")
-		sb.WriteString(strconv.Quote(internal.Sanitize(tag.Key)))
+		sb.WriteString(internal.QuoteKey(tag.Key))
 		sb.WriteString("=")
-		sb.WriteString(internal.SanitizeValue(tag.Value))
+		sb.WriteString(internal.QuoteValue(tag.Value))
 	}
 	sb.WriteString(" ")
 	sb.WriteString(strconv.FormatInt(startMillis, 10))
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"net/http"
+	"sync"
+)
+
+// SwitchableReporter is a Reporter whose underlying Reporter can be replaced at runtime.
+type SwitchableReporter struct {
+	mtx      sync.RWMutex
+	reporter Reporter
+}
+
+// NewSwitchableReporter creates a SwitchableReporter initially delegating to reporter.
+func NewSwitchableReporter(reporter Reporter) *SwitchableReporter {
+	return &SwitchableReporter{reporter: reporter}
+}
+
+// Report reports pointLines with the current Reporter.
+func (r *SwitchableReporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.reporter.Report(format, pointLines)
+}
+
+// Swap replaces the current Reporter with reporter and returns the previous one.
+// It waits for the reports in flight with the previous Reporter to complete; reports
+// started after Swap is called use the new Reporter.
+func (r *SwitchableReporter) Swap(reporter Reporter) Reporter {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	previous := r.reporter
+	r.reporter = reporter
+	return previous
+}
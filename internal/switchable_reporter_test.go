@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type blockingReporter struct {
+	started chan struct{}
+	release chan struct{}
+	lines   []string
+}
+
+func (r *blockingReporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	if r.started != nil {
+		close(r.started)
+		<-r.release
+	}
+	r.lines = append(r.lines, string(pointLines))
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestSwitchableReporter_SwapDrainsInFlightReports(t *testing.T) {
+	previous := &blockingReporter{started: make(chan struct{}), release: make(chan struct{})}
+	next := &blockingReporter{}
+	r := NewSwitchableReporter(previous)
+
+	go func() { _, _ = r.Report(metricFormat, []byte("in flight\n")) }()
+	<-previous.started
+
+	swapped := make(chan Reporter)
+	go func() { swapped <- r.Swap(next) }()
+	select {
+	case <-swapped:
+		t.Fatal("swap must wait for in-flight reports")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(previous.release)
+	assert.Equal(t, previous, <-swapped)
+	_, _ = r.Report(metricFormat, []byte("after swap\n"))
+	assert.Equal(t, []string{"in flight\n"}, previous.lines)
+	assert.Equal(t, []string{"after swap\n"}, next.lines)
+}
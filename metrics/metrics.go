@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// Counter counts events. It is reported as a delta counter, of its increase since the previous
+// report, so that counts from several processes and restarts add up in Wavefront.
+type Counter struct {
+	count    int64
+	reported int64
+}
+
+// NewCounter returns the counter registered to reporter under name and tags, registering a new
+// one if there is none.
+func NewCounter(reporter Reporter, name string, tags map[string]string) *Counter {
+	return reporter.register(name, tags, &Counter{}).(*Counter)
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.count, 1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n int64) {
+	atomic.AddInt64(&c.count, n)
+}
+
+// Count returns the number of events counted since the counter was created.
+func (c *Counter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+func (c *Counter) report(sender senders.MetricSender, name, source string, tags map[string]string) error {
+	count := atomic.LoadInt64(&c.count)
+	delta := count - atomic.SwapInt64(&c.reported, count)
+	if delta == 0 {
+		return nil
+	}
+	return sender.SendDeltaCounter(name, float64(delta), source, tags)
+}
+
+// Gauge holds the last value it was updated with.
+type Gauge struct {
+	bits uint64
+}
+
+// NewGauge returns the gauge registered to reporter under name and tags, registering a new one,
+// of value 0, if there is none.
+func NewGauge(reporter Reporter, name string, tags map[string]string) *Gauge {
+	return reporter.register(name, tags, &Gauge{}).(*Gauge)
+}
+
+// Update sets the value of the gauge.
+func (g *Gauge) Update(value float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(value))
+}
+
+// Value returns the value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func (g *Gauge) report(sender senders.MetricSender, name, source string, tags map[string]string) error {
+	return sender.SendMetric(name, g.Value(), 0, source, tags)
+}
+
+// FunctionalGauge reports the value returned by a function, e.g. the size of a queue.
+type FunctionalGauge struct {
+	value func() float64
+}
+
+// NewFunctionalGauge registers a gauge of the value of f to reporter, under name and tags,
+// replacing the metric registered under those if any. f is called at each report, and may be
+// called from any goroutine.
+func NewFunctionalGauge(reporter Reporter, name string, tags map[string]string, f func() float64) *FunctionalGauge {
+	return reporter.register(name, tags, &FunctionalGauge{value: f}).(*FunctionalGauge)
+}
+
+// Value returns the current value of the gauge.
+func (g *FunctionalGauge) Value() float64 {
+	return g.value()
+}
+
+func (g *FunctionalGauge) report(sender senders.MetricSender, name, source string, tags map[string]string) error {
+	return sender.SendMetric(name, g.value(), 0, source, tags)
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+func TestReporter(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+
+	reporter := NewReporter(sender, Source("app"), ReportInterval(time.Hour))
+	requests := NewCounter(reporter, "requests", map[string]string{"env": "dev"})
+	assert.Same(t, requests, NewCounter(reporter, "requests", map[string]string{"env": "dev"}))
+	inFlight := NewGauge(reporter, "in.flight", nil)
+	NewFunctionalGauge(reporter, "queue.size", nil, func() float64 { return 7 })
+
+	requests.Inc()
+	requests.Add(2)
+	inFlight.Update(1.5)
+	reporter.Report()
+
+	// the counter is not reported again until it increases.
+	requests.Inc()
+	reporter.Close()
+	assert.Equal(t, int64(4), requests.Count())
+
+	lines := sender.Lines()
+	sort.Strings(lines)
+	assert.Equal(t, []string{
+		"\"in.flight\" 1.5 source=\"app\"\n",
+		"\"in.flight\" 1.5 source=\"app\"\n",
+		"\"queue.size\" 7 source=\"app\"\n",
+		"\"queue.size\" 7 source=\"app\"\n",
+		"\"∆requests\" 1 source=\"app\" \"env\"=\"dev\"\n",
+		"\"∆requests\" 3 source=\"app\" \"env\"=\"dev\"\n",
+	}, lines)
+
+	// metrics registered to a closed reporter are not reported.
+	reporter.Report()
+	assert.Len(t, sender.Lines(), 6)
+}
+
+func TestReporter_Interval(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+
+	reporter := NewReporter(sender, Source("app"), ReportInterval(10*time.Millisecond))
+	defer reporter.Close()
+	NewGauge(reporter, "up", nil).Update(1)
+
+	assert.Eventually(t, func() bool { return len(sender.Lines()) > 0 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "\"up\" 1 source=\"app\"\n", sender.Lines()[0])
+}
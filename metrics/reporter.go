@@ -0,0 +1,160 @@
+// Package metrics is a lightweight facade of counters and gauges reported through a sender, for
+// apps that do not need a metrics library of their own:
+//
+//	reporter := metrics.NewReporter(sender, metrics.Source("app-1"))
+//	defer reporter.Close()
+//
+//	requests := metrics.NewCounter(reporter, "http.requests", map[string]string{"env": "prod"})
+//	inFlight := metrics.NewGauge(reporter, "http.in_flight", nil)
+//	metrics.NewFunctionalGauge(reporter, "go.goroutines", nil, func() float64 {
+//		return float64(runtime.NumGoroutine())
+//	})
+//
+//	requests.Inc()
+//	inFlight.Update(12)
+package metrics
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// Reporter sends the metrics registered to it through a sender, every reporting interval.
+type Reporter interface {
+	// Report sends the current value of every metric right away.
+	Report()
+
+	// Close stops the periodic reports, after a last one. Metrics registered to a closed
+	// reporter are no longer sent.
+	Close()
+
+	register(name string, tags map[string]string, m metric) metric
+}
+
+// ReporterOption sets an optional setting of NewReporter.
+type ReporterOption func(*reporter)
+
+// ReportInterval sets the interval between reports, one minute by default.
+func ReportInterval(d time.Duration) ReporterOption {
+	return func(r *reporter) {
+		r.interval = d
+	}
+}
+
+// Source sets the source of the metrics, the default source of the sender by default.
+func Source(source string) ReporterOption {
+	return func(r *reporter) {
+		r.source = source
+	}
+}
+
+// metric is implemented by Counter, Gauge and FunctionalGauge.
+type metric interface {
+	report(sender senders.MetricSender, name, source string, tags map[string]string) error
+}
+
+type registered struct {
+	name   string
+	tags   map[string]string
+	metric metric
+}
+
+type reporter struct {
+	sender   senders.MetricSender
+	interval time.Duration
+	source   string
+
+	mtx     sync.Mutex
+	metrics map[string]*registered
+
+	ticker *time.Ticker
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewReporter creates a Reporter sending through sender, and starts its periodic reports.
+func NewReporter(sender senders.MetricSender, setters ...ReporterOption) Reporter {
+	r := &reporter{
+		sender:   sender,
+		interval: time.Minute,
+		metrics:  map[string]*registered{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, setter := range setters {
+		setter(r)
+	}
+	r.ticker = time.NewTicker(r.interval)
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-r.ticker.C:
+				r.Report()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func (r *reporter) Report() {
+	r.mtx.Lock()
+	metrics := make([]*registered, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mtx.Unlock()
+
+	for _, m := range metrics {
+		if err := m.metric.report(r.sender, m.name, r.source, m.tags); err != nil {
+			log.Printf("unable to report metric %s: %s\n", m.name, err)
+		}
+	}
+}
+
+func (r *reporter) Close() {
+	r.ticker.Stop()
+	close(r.stop)
+	<-r.done
+	r.Report()
+
+	r.mtx.Lock()
+	r.metrics = map[string]*registered{}
+	r.mtx.Unlock()
+}
+
+// register returns the metric registered under name and tags, registering m if there is none
+// or if it is of another type.
+func (r *reporter) register(name string, tags map[string]string, m metric) metric {
+	key := internal.SeriesKey(name, "", tags)
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if existing, ok := r.metrics[key]; ok && sameType(existing.metric, m) {
+		return existing.metric
+	}
+	copiedTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copiedTags[k] = v
+	}
+	r.metrics[key] = &registered{name: name, tags: copiedTags, metric: m}
+	return m
+}
+
+func sameType(a, b metric) bool {
+	switch a.(type) {
+	case *Counter:
+		_, ok := b.(*Counter)
+		return ok
+	case *Gauge:
+		_, ok := b.(*Gauge)
+		return ok
+	}
+	return false
+}
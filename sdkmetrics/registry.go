@@ -0,0 +1,47 @@
+// Package sdkmetrics lets applications receive the internal metrics of a sender, such as the
+// number of points queued, sent and dropped, the failed report requests and the flush latency,
+// to route them to their own monitoring instead of Wavefront.
+//
+//	sender, _ := senders.NewSender("http://localhost", senders.InternalMetricsRegistry(myRegistry))
+//
+// The names of the metrics are listed by senders.InternalMetricNames.
+package sdkmetrics
+
+// Registry receives the internal metrics of a sender each time they are reported, every minute.
+// Names are prefixed with the internal metrics prefix of the
+// sender, see senders.InternalMetricsPrefix, and tags include the pid and version tags and the
+// tags of senders.SDKMetricsTags; they must not be modified. Registry methods may be called from
+// several goroutines.
+type Registry interface {
+	// Gauge receives the current value of a gauge, e.g. the number of points in the buffer.
+	Gauge(name string, value float64, tags map[string]string)
+
+	// Counter receives how much a counter increased since it was last reported.
+	Counter(name string, delta float64, tags map[string]string)
+}
+
+// Discard is a Registry ignoring all the metrics it receives.
+var Discard Registry = discard{}
+
+type discard struct{}
+
+func (discard) Gauge(string, float64, map[string]string)   {}
+func (discard) Counter(string, float64, map[string]string) {}
+
+// Funcs is a Registry calling GaugeFunc and CounterFunc, when set, with the metrics it receives.
+type Funcs struct {
+	GaugeFunc   func(name string, value float64, tags map[string]string)
+	CounterFunc func(name string, delta float64, tags map[string]string)
+}
+
+func (f Funcs) Gauge(name string, value float64, tags map[string]string) {
+	if f.GaugeFunc != nil {
+		f.GaugeFunc(name, value, tags)
+	}
+}
+
+func (f Funcs) Counter(name string, delta float64, tags map[string]string) {
+	if f.CounterFunc != nil {
+		f.CounterFunc(name, delta, tags)
+	}
+}
@@ -0,0 +1,29 @@
+package senders
+
+import (
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// AuditEntry is the record of a report request in the audit journal, see AuditJournal.
+type AuditEntry = internal.JournalEntry
+
+// AuditJournal records every report request of the sender in the file at path, one JSON object
+// per line: when it was sent, the endpoint, the data format, the number of lines and bytes of the
+// batch, the response status, the error if any, and the latency. It tells which batches were
+// delivered and when, e.g. to reconcile the data sent with the data received by a collector.
+// Events are not recorded. The file is rotated once it grows over maxBytes, keeping up to maxFiles
+// rotated files named path.1, path.2 and so on, path.1 being the most recent. Zero maxBytes and
+// maxFiles default to 10 MiB and 5 files. Senders must not share a journal file.
+func AuditJournal(path string, maxBytes int64, maxFiles int) Option {
+	return func(cfg *configuration) {
+		cfg.AuditJournalPath = path
+		cfg.AuditJournalMaxBytes = maxBytes
+		cfg.AuditJournalMaxFiles = maxFiles
+	}
+}
+
+// ReadAuditJournal returns the entries of the audit journal at path, rotated files included,
+// oldest first.
+func ReadAuditJournal(path string) ([]AuditEntry, error) {
+	return internal.ReadJournal(path)
+}
@@ -20,6 +20,12 @@ func tokenServiceForCfg(cfg *configuration) auth.Service {
 		log.Println("The Wavefront SDK will use Direct Ingestion authenticated using CSP API Token.")
 		cspAuth := cfg.Authentication.(auth.CSPAPIToken)
 		return auth.NewCSPTokenService(cspAuth.BaseURL, cspAuth.Token)
+	case TokenService:
+		log.Println("The Wavefront SDK will use Direct Ingestion authenticated using a custom token service.")
+		return auth.NewRefreshingTokenService(cfg.Authentication.(TokenService).Token)
+	case auth.CustomAuthorizer:
+		log.Println("The Wavefront SDK will use Direct Ingestion authenticated using a custom authorizer.")
+		return auth.NewAuthorizerService(cfg.Authentication.(auth.CustomAuthorizer).Authorizer)
 	}
 
 	log.Println("The Wavefront SDK will communicate with a Wavefront Proxy.")
@@ -0,0 +1,109 @@
+package senders
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// BatchSender Interface for sending many pre-built points, distributions or spans in one call,
+// e.g. when replaying dump files. Each element is handled as by its single counterpart
+// (SendMetric, SendDistribution and SendSpan); invalid or dropped elements do not stop the
+// batch and are reported in a *BatchError.
+type BatchSender interface {
+	SendMetrics(points []MetricPoint) error
+	SendDistributions(distributions []Distribution) error
+	SendSpans(spans []Span) error
+}
+
+// Distribution is a single distribution, as given to Sender.SendDistribution.
+type Distribution struct {
+	Name          string
+	Centroids     []histogram.Centroid
+	Granularities map[histogram.Granularity]bool
+	Timestamp     int64
+	Source        string
+	Tags          map[string]string
+}
+
+// Span is a single tracing span, as given to Sender.SendSpan.
+type Span struct {
+	Name           string
+	StartMillis    int64
+	DurationMillis int64
+	Source         string
+	TraceID        string
+	SpanID         string
+	Parents        []string
+	FollowsFrom    []string
+	Tags           []SpanTag
+	SpanLogs       []SpanLog
+}
+
+// BatchError holds the errors of the elements of a batch that could not be sent,
+// by index in the batch.
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var errors []string
+	for _, i := range indexes {
+		errors = append(errors, fmt.Sprintf("%d: %s", i, e.Errors[i]))
+	}
+	return fmt.Sprintf("%d of the batch failed: %s", len(indexes), strings.Join(errors, ","))
+}
+
+func (e *BatchError) add(i int, err error) {
+	if e.Errors == nil {
+		e.Errors = make(map[int]error)
+	}
+	e.Errors[i] = err
+}
+
+func (e *BatchError) get() error {
+	if len(e.Errors) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (sender *realSender) SendMetrics(points []MetricPoint) error {
+	var errors BatchError
+	for i, p := range points {
+		if err := sender.sendMetric(handleLine, p.Name, p.Value, p.Timestamp, p.Source, p.Tags); err != nil {
+			errors.add(i, err)
+		}
+	}
+	return errors.get()
+}
+
+func (sender *realSender) SendDistributions(distributions []Distribution) error {
+	var errors BatchError
+	for i, d := range distributions {
+		err := sender.sendDistribution(handleLine, d.Name, d.Centroids, d.Granularities, d.Timestamp, d.Source, d.Tags)
+		if err != nil {
+			errors.add(i, err)
+		}
+	}
+	return errors.get()
+}
+
+func (sender *realSender) SendSpans(spans []Span) error {
+	var errors BatchError
+	for i, s := range spans {
+		err := sender.sendSpan(handleLine, s.Name, s.StartMillis, s.DurationMillis, s.Source, s.TraceID, s.SpanID,
+			s.Parents, s.FollowsFrom, s.Tags, s.SpanLogs)
+		if err != nil {
+			errors.add(i, err)
+		}
+	}
+	return errors.get()
+}
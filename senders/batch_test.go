@@ -0,0 +1,51 @@
+package senders
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestSendMetrics(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+
+	require.NoError(t, sender.SendMetrics([]MetricPoint{
+		{Name: "foo", Value: 1, Timestamp: 1533529977, Source: "test"},
+		{Name: "bar", Value: 2, Tags: map[string]string{"env": "dev"}},
+	}))
+	assert.Equal(t, []string{
+		"\"foo\" 1 1533529977 source=\"test\"\n",
+		"\"bar\" 2 source=\"test\" \"env\"=\"dev\"\n",
+	}, pointHandler.Lines)
+
+	err := sender.SendMetrics([]MetricPoint{{Name: "foo", Value: 3}, {Value: 4}, {Name: "bar", Value: 5}})
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 1)
+	assert.Error(t, batchErr.Errors[1])
+	assert.Len(t, pointHandler.Lines, 4)
+}
+
+func TestSendDistributionsAndSpans(t *testing.T) {
+	sender := newMockSender(&mockHandler{})
+
+	require.NoError(t, sender.SendDistributions([]Distribution{{
+		Name:          "foo",
+		Centroids:     []histogram.Centroid{{Value: 1, Count: 2}},
+		Granularities: map[histogram.Granularity]bool{histogram.MINUTE: true},
+		Source:        "test",
+	}}))
+	assert.Len(t, sender.histoHandler.(*mockHandler).Lines, 1)
+
+	err := sender.SendSpans([]Span{
+		{Name: "foo", DurationMillis: 1, Source: "test",
+			TraceID: "7b3bf470-9456-11e8-9eb6-529269fb1459", SpanID: "0313bafe-9457-11e8-9eb6-529269fb1459"},
+		{Name: "bar", DurationMillis: 1, Source: "test", TraceID: "not a uuid", SpanID: "not a uuid"},
+	})
+	assert.EqualError(t, err, "1 of the batch failed: 1: traceId is not in UUID format: span=bar traceId=not a uuid")
+	assert.Len(t, sender.spanHandler.(*mockHandler).Lines, 1)
+}
@@ -0,0 +1,287 @@
+package senders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// senderConfig is a sender of a config file, see NewFromConfigFile.
+type senderConfig struct {
+	URL                 string            `json:"url" yaml:"url"`
+	Endpoints           []string          `json:"endpoints" yaml:"endpoints"`
+	Auth                authConfig        `json:"auth" yaml:"auth"`
+	TenantID            string            `json:"tenantId" yaml:"tenantId"`
+	Batching            batchingConfig    `json:"batching" yaml:"batching"`
+	Buffer              bufferConfig      `json:"buffer" yaml:"buffer"`
+	Tags                map[string]string `json:"tags" yaml:"tags"`
+	SendInternalMetrics *bool             `json:"sendInternalMetrics" yaml:"sendInternalMetrics"`
+	Failover            *senderConfig     `json:"failover" yaml:"failover"`
+	Routes              []routeConfig     `json:"routes" yaml:"routes"`
+}
+
+type authConfig struct {
+	APIToken        string `json:"apiToken" yaml:"apiToken"`
+	CSPAPIToken     string `json:"cspApiToken" yaml:"cspApiToken"`
+	CSPClientID     string `json:"cspClientId" yaml:"cspClientId"`
+	CSPClientSecret string `json:"cspClientSecret" yaml:"cspClientSecret"`
+	CSPOrgID        string `json:"cspOrgId" yaml:"cspOrgId"`
+	CSPBaseURL      string `json:"cspBaseUrl" yaml:"cspBaseUrl"`
+}
+
+type batchingConfig struct {
+	BatchSize     int            `json:"batchSize" yaml:"batchSize"`
+	FlushInterval configDuration `json:"flushInterval" yaml:"flushInterval"`
+	Timeout       configDuration `json:"timeout" yaml:"timeout"`
+	MaxRetries    *int           `json:"maxRetries" yaml:"maxRetries"`
+	RateLimit     int            `json:"rateLimit" yaml:"rateLimit"`
+	Compression   *bool          `json:"compression" yaml:"compression"`
+}
+
+type bufferConfig struct {
+	MaxSize            int    `json:"maxSize" yaml:"maxSize"`
+	PersistentDir      string `json:"persistentDir" yaml:"persistentDir"`
+	PersistentMaxBytes int64  `json:"persistentMaxBytes" yaml:"persistentMaxBytes"`
+}
+
+type routeConfig struct {
+	Prefix       string `json:"prefix" yaml:"prefix"`
+	senderConfig `yaml:",inline"`
+}
+
+// configDuration is a duration of a config file, such as 1m30s, or a number of seconds.
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalJSON(b []byte) error {
+	return d.parse(strings.Trim(string(b), `"`))
+}
+
+func (d *configDuration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *configDuration) parse(value string) error {
+	duration, err := parseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %s", value, err)
+	}
+	*d = configDuration(duration)
+	return nil
+}
+
+// NewFromConfigFile creates the Sender declared by the YAML or JSON config file at path, told
+// apart by their .yaml, .yml or .json extension:
+//
+//	url: https://example.wavefront.com
+//	auth:
+//	  apiToken: ${WAVEFRONT_API_TOKEN}
+//	batching:
+//	  batchSize: 10000
+//	  flushInterval: 5s
+//	buffer:
+//	  maxSize: 100000
+//	tags:
+//	  env: production
+//	routes:
+//	  - prefix: kubernetes.*
+//	    url: http://proxy.example.com:2878
+//
+// A sender reports to url, or is a ShardedSender of endpoints. It has the following settings:
+//
+//	auth                 apiToken, cspApiToken, or cspClientId and cspClientSecret, with
+//	                     the optional cspOrgId and cspBaseUrl. ${VAR} are expanded from the
+//	                     environment, keeping secrets out of the file.
+//	tenantId             TenantID
+//	batching             batchSize, flushInterval, timeout, maxRetries, rateLimit and compression
+//	buffer               maxSize, and persistentDir and persistentMaxBytes for PersistentBuffer
+//	tags                 tags added to every metric, distribution and span not already having them
+//	sendInternalMetrics  SendInternalMetrics
+//	failover             a sender the sender fails over to, see NewFailoverSender
+//	routes               senders of the metrics whose name starts with their prefix, see
+//	                     NewRouterSender
+//
+// The senders of failover and routes inherit the settings of their parent, except its
+// persistentDir, and override them with their own. setters are applied to every sender, after
+// the settings of the file.
+func NewFromConfigFile(path string, setters ...Option) (Sender, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.build(nil, setters)
+}
+
+// readConfigFile parses the YAML or JSON config file at path.
+func readConfigFile(path string) (*senderConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %s", err)
+	}
+	cfg := &senderConfig{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension '%s', expected .yaml, .yml or .json", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %s", path, err)
+	}
+	return cfg, nil
+}
+
+// build creates the sender of c, inheriting the options of its parent.
+func (c *senderConfig) build(inherited, setters []Option) (Sender, error) {
+	options, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	options = append(inherited[:len(inherited):len(inherited)], options...)
+	all := append(options[:len(options):len(options)], setters...)
+	if c.Buffer.PersistentDir != "" {
+		if len(c.Endpoints) > 0 {
+			return nil, errors.New("persistentDir cannot be shared by the senders of endpoints")
+		}
+		all = append(all, PersistentBuffer(c.Buffer.PersistentDir, c.Buffer.PersistentMaxBytes))
+	}
+
+	var sender Sender
+	switch {
+	case c.URL != "" && len(c.Endpoints) > 0:
+		return nil, errors.New("only one of url and endpoints can be set")
+	case c.URL != "":
+		sender, err = NewSender(c.URL, all...)
+	case len(c.Endpoints) > 0:
+		sender, err = NewShardedSender(c.Endpoints, all...)
+	default:
+		return nil, errors.New("url or endpoints must be set")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Failover != nil {
+		secondary, err := c.Failover.build(options, setters)
+		if err != nil {
+			sender.Close()
+			return nil, fmt.Errorf("invalid failover: %s", err)
+		}
+		sender = NewFailoverSender(sender, secondary)
+	}
+
+	if len(c.Routes) > 0 {
+		routes := make([]Route, 0, len(c.Routes))
+		for i := range c.Routes {
+			route := &c.Routes[i]
+			routeSender, err := route.build(options, setters)
+			if err != nil {
+				sender.Close()
+				for _, r := range routes {
+					r.Sender.Close()
+				}
+				return nil, fmt.Errorf("invalid route '%s': %s", route.Prefix, err)
+			}
+			routes = append(routes, Route{Prefix: route.Prefix, Sender: routeSender})
+		}
+		return NewRouterSender(sender, routes...)
+	}
+	return sender, nil
+}
+
+// options returns the Options of the settings of c, but those of its sender graph and
+// persistentDir.
+func (c *senderConfig) options() ([]Option, error) {
+	var options []Option
+	authOption, err := c.Auth.option()
+	if err != nil {
+		return nil, err
+	}
+	if authOption != nil {
+		options = append(options, authOption)
+	}
+	if c.TenantID != "" {
+		options = append(options, TenantID(c.TenantID))
+	}
+	if c.Batching.BatchSize > 0 {
+		options = append(options, BatchSize(c.Batching.BatchSize))
+	}
+	if c.Batching.FlushInterval > 0 {
+		options = append(options, FlushInterval(time.Duration(c.Batching.FlushInterval)))
+	}
+	if c.Batching.Timeout > 0 {
+		options = append(options, Timeout(time.Duration(c.Batching.Timeout)))
+	}
+	if c.Batching.MaxRetries != nil {
+		options = append(options, MaxRetries(*c.Batching.MaxRetries))
+	}
+	if c.Batching.RateLimit > 0 {
+		options = append(options, RateLimit(c.Batching.RateLimit))
+	}
+	if c.Batching.Compression != nil {
+		options = append(options, Compression(*c.Batching.Compression))
+	}
+	if c.Buffer.MaxSize > 0 {
+		options = append(options, MaxBufferSize(c.Buffer.MaxSize))
+	}
+	if len(c.Tags) > 0 {
+		tags := copyTags(c.Tags)
+		options = append(options, func(cfg *configuration) {
+			cfg.Enrichers = append(cfg.Enrichers, newStaticEnricher(tags))
+		})
+	}
+	if c.SendInternalMetrics != nil {
+		options = append(options, SendInternalMetrics(*c.SendInternalMetrics))
+	}
+	return options, nil
+}
+
+// option returns the authentication Option of a, if any.
+func (a authConfig) option() (Option, error) {
+	apiToken := os.ExpandEnv(a.APIToken)
+	cspAPIToken := os.ExpandEnv(a.CSPAPIToken)
+	clientID := os.ExpandEnv(a.CSPClientID)
+
+	set := 0
+	for _, value := range []string{apiToken, cspAPIToken, clientID} {
+		if value != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, errors.New("only one of apiToken, cspApiToken and cspClientId can be set")
+	}
+
+	var cspOptions []CSPOption
+	if baseURL := os.ExpandEnv(a.CSPBaseURL); baseURL != "" {
+		cspOptions = append(cspOptions, CSPBaseURL(baseURL))
+	}
+	if orgID := os.ExpandEnv(a.CSPOrgID); orgID != "" {
+		cspOptions = append(cspOptions, CSPOrgID(orgID))
+	}
+	switch {
+	case apiToken != "":
+		return APIToken(apiToken), nil
+	case cspAPIToken != "":
+		return CSPAPIToken(cspAPIToken, cspOptions...), nil
+	case clientID != "":
+		clientSecret := os.ExpandEnv(a.CSPClientSecret)
+		if clientSecret == "" {
+			return nil, errors.New("cspClientSecret is not set")
+		}
+		return CSPClientCredentials(clientID, clientSecret, cspOptions...), nil
+	}
+	return nil, nil
+}
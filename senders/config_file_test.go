@@ -0,0 +1,111 @@
+package senders
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+const yamlConfig = `
+url: http://localhost:2878
+auth:
+  apiToken: ${TEST_WAVEFRONT_TOKEN}
+tenantId: "16"
+batching:
+  batchSize: 4000
+  flushInterval: 5s
+  timeout: 2
+  maxRetries: 2
+  compression: false
+buffer:
+  maxSize: 64000
+tags:
+  env: production
+sendInternalMetrics: false
+failover:
+  url: http://secondary:2878
+routes:
+  - prefix: kubernetes.*
+    endpoints: [http://proxy-1:2878, http://proxy-2:2878]
+    batching:
+      batchSize: 100
+`
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewFromConfigFile_YAML(t *testing.T) {
+	t.Setenv("TEST_WAVEFRONT_TOKEN", "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e")
+	sender, err := NewFromConfigFile(writeConfigFile(t, "wavefront.yaml", yamlConfig))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	router, ok := sender.(*routerSender)
+	require.True(t, ok)
+	assert.IsType(t, &failoverSender{}, router.fallback)
+	require.Len(t, router.routes, 1)
+	assert.Equal(t, "kubernetes.", router.routes[0].Prefix)
+	assert.IsType(t, &shardedSender{}, router.routes[0].Sender)
+}
+
+func TestReadConfigFile(t *testing.T) {
+	t.Setenv("TEST_WAVEFRONT_TOKEN", "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e")
+	path := writeConfigFile(t, "wavefront.yaml", yamlConfig)
+	parsed, err := readConfigFile(path)
+	require.NoError(t, err)
+	options, err := parsed.options()
+	require.NoError(t, err)
+	cfg, err := createConfig("http://localhost:2878", options...)
+	require.NoError(t, err)
+	assert.Equal(t, auth.APIToken{Token: "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e"}, cfg.Authentication)
+	assert.Equal(t, "16", cfg.TenantID)
+	assert.Equal(t, 4000, cfg.BatchSize)
+	assert.Equal(t, 5*time.Second, cfg.FlushInterval)
+	assert.Equal(t, 2*time.Second, cfg.HTTPClient.Timeout)
+	assert.Equal(t, 2, cfg.MaxRetries)
+	require.NotNil(t, cfg.Compression)
+	assert.False(t, *cfg.Compression)
+	assert.Equal(t, 64000, cfg.MaxBufferSize)
+	assert.Len(t, cfg.Enrichers, 1)
+	assert.False(t, cfg.SendInternalMetrics)
+}
+
+func TestNewFromConfigFile_JSON(t *testing.T) {
+	path := writeConfigFile(t, "wavefront.json", `{
+		"url": "http://localhost:2878",
+		"auth": {"cspClientId": "client", "cspClientSecret": "secret", "cspOrgId": "org"},
+		"batching": {"flushInterval": "500ms"},
+		"sendInternalMetrics": false
+	}`)
+	sender, err := NewFromConfigFile(path)
+	require.NoError(t, err)
+	defer sender.Close()
+	assert.IsType(t, &realSender{}, sender)
+}
+
+func TestNewFromConfigFile_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml":  "url: http://localhost:2878\nbatchSize: 10\n",
+		"unknown.json":  `{"url": "http://localhost:2878", "batchSize": 10}`,
+		"duration.yaml": "url: http://localhost:2878\nbatching:\n  flushInterval: soon\n",
+		"auth.yaml":     "url: http://localhost:2878\nauth:\n  apiToken: token\n  cspApiToken: token\n",
+		"secret.yaml":   "url: http://localhost:2878\nauth:\n  cspClientId: client\n",
+		"url.yaml":      "batching:\n  batchSize: 10\n",
+		"both.yaml":     "url: http://localhost:2878\nendpoints: [http://proxy:2878]\n",
+		"route.yaml":    "url: http://localhost:2878\nroutes:\n  - prefix: k8s.\n",
+		"config.toml":   "url = \"http://localhost:2878\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFromConfigFile(writeConfigFile(t, name, content))
+			assert.Error(t, err)
+		})
+	}
+}
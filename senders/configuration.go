@@ -7,9 +7,13 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+	"github.com/wavefronthq/wavefront-sdk-go/sdkmetrics"
 )
 
 const (
@@ -20,6 +24,8 @@ const (
 	defaultBufferSize    = 50_000
 	defaultFlushInterval = 1 * time.Second
 	defaultTimeout       = 10 * time.Second
+
+	defaultDeltaCounterSkew = 1 * time.Hour
 )
 
 // Configuration for the direct ingestion sender
@@ -38,6 +44,44 @@ type configuration struct {
 	// send, or don't send, internal SDK metrics that begin with ~sdk.go.core
 	SendInternalMetrics bool
 
+	// receives the internal SDK metrics instead of Wavefront when set.
+	InternalMetricsRegistry sdkmetrics.Registry
+
+	// prefix of the internal SDK metrics. defaults to InternalMetricPrefixDirect or InternalMetricPrefixProxy.
+	InternalMetricsPrefix string
+
+	// flush intervals of each data type. zero means FlushInterval.
+	MetricsFlushInterval       time.Duration
+	DistributionsFlushInterval time.Duration
+	TracesFlushInterval        time.Duration
+	EventsFlushInterval        time.Duration
+
+	// validation of names, sources and tags on Send* calls.
+	Validation ValidationMode
+	// sanitizes the names and tag keys of metrics and distributions. nil means the proxy rules.
+	NameSanitizer func(string) string
+
+	// data types discarded instead of sent.
+	DisableDistributions bool
+	DisableSpans         bool
+	DisableEvents        bool
+
+	// whether to ask the endpoint which line formats it accepts, in NewSender.
+	Handshake bool
+
+	// lines reported per second across metrics, distributions and spans. zero means no limit.
+	RateLimit int
+
+	// what happens to data received while internal buffers are full.
+	OverflowPolicy OverflowPolicy
+
+	// number of metric names and tag keys reported by TopK, every TopKInterval. zero disables it.
+	TopK         int
+	TopKInterval time.Duration
+
+	// called with the lines dropped by the line handlers and the reason.
+	OnDropped func(lines []string, reason error)
+
 	// size of internal buffers beyond which received data is dropped.
 	// helps with handling brief increases in data and buffering on errors.
 	// separate buffers are maintained per data type (metrics, spans and distributions)
@@ -47,12 +91,134 @@ type configuration struct {
 
 	// interval (in seconds) at which to flush data to Wavefront. defaults to 1 Second.
 	// together with batch size controls the max theoretical throughput of the sender.
-	FlushInterval           time.Duration
+	FlushInterval time.Duration
+	// number of buffered lines of a data type triggering a flush. zero means flushes on the interval only.
+	FlushThreshold int
+	// bytes of lines above which batches are split into several requests. zero means no limit.
+	MaxPayloadBytes         int
 	SDKMetricsTags          map[string]string
 	Path                    string
 	Authentication          interface{}
 	httpClientConfiguration *httpClientConfiguration
 	HTTPClient              *http.Client
+
+	// lookup tables joined against every point to add tags.
+	Enrichers []*lookupEnricher
+
+	// resolver of the default source, and how often it is called. nil means the hostname.
+	SourceResolver        func() string
+	SourceRefreshInterval *time.Duration
+
+	// max size of the span logs of a span, in bytes. zero means unlimited.
+	MaxSpanLogBytes int
+
+	// functions run on every metric, delta counter and distribution before it is serialized.
+	PointTransformers []PointTransformer
+	// functions run on every span, and on every event, before it is serialized.
+	SpanTransformers  []SpanTransformer
+	EventTransformers []PointTransformer
+
+	// value validation applied before points are sent.
+	NonFinitePolicy   nonFinitePolicy
+	NonFiniteSentinel float64
+	ValueBounds       []valueBound
+
+	// directory and max size per data type of the disk-backed buffer of undelivered batches.
+	// an empty directory buffers in memory only.
+	PersistenceDir      string
+	PersistenceMaxBytes int64
+
+	// file, max size and number of rotated files of the audit journal of report requests.
+	// an empty path disables it.
+	AuditJournalPath     string
+	AuditJournalMaxBytes int64
+	AuditJournalMaxFiles int
+
+	// timeout of report requests, base plus per MiB of compressed body. a zero base keeps the flat Timeout.
+	RequestTimeoutBase  time.Duration
+	RequestTimeoutPerMB time.Duration
+
+	// maximum duration of a flush, and whether the batch size is reduced to stay within it.
+	// a zero budget disables enforcement.
+	FlushLatencyBudget time.Duration
+	AdaptiveBatchSize  bool
+
+	// size of the buckets delta counters are aggregated in. zero disables aggregation.
+	DeltaCounterBucket time.Duration
+	// sums delta counters per series between flushes, when DeltaCounterBucket is zero.
+	AggregateDeltaCounters bool
+
+	// rules renaming metrics and distributions at send time.
+	MetricRenames []metricRename
+	metricRenamer *metricRenamer
+
+	// max distance from now of delta counter timestamps. zero means defaultDeltaCounterSkew.
+	DeltaCounterSkew time.Duration
+
+	// unit of the timestamps given to the Send APIs.
+	TimestampUnit TimestampUnit
+
+	// report endpoint customization. an empty ReportPath keeps the default /report path.
+	ReportPath           string
+	ReportQueryParams    url.Values
+	ReportQueryTemplates map[string]string
+	reportQueryTemplates map[string]*template.Template
+
+	// header names the authentication and tenant are sent under, when not the default ones.
+	AuthHeaders   []string
+	TenantHeaders []string
+
+	// headers set on every request.
+	ExtraHeaders map[string]string
+
+	// retries of report requests failing with a transient error. zero disables retries.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// budget of the retries shared by all the report requests. zero disables either limit.
+	RetryBudgetPerMinute int
+	RetryBudgetRatio     float64
+
+	// granularities RecordDistribution accumulates values for. empty means MINUTE.
+	DistributionGranularities []histogram.Granularity
+	// data structure RecordDistribution accumulates values into.
+	DistributionBackend histogram.Backend
+	// unit of the values SendDuration records. zero means milliseconds.
+	DurationUnit time.Duration
+
+	// consecutive failed reports opening the circuit breaker, and how long it stays open. zero disables it.
+	CircuitBreakerFailures int
+	CircuitBreakerCoolDown time.Duration
+
+	// protocol spoken by NewSender. empty means ProtocolWavefront.
+	Protocol string
+
+	// serializer of metric, distribution and span lines. nil means the Wavefront data format.
+	Serializer LineSerializer
+
+	// registry told about new metric series before they are sent.
+	SchemaRegistry SchemaRegistry
+
+	// codecs report payloads are compressed with, by preference. Overrides Compression.
+	Compressors []compression.Compressor
+
+	// X-WF-* headers describing each batch.
+	BatchMetadataHeaders bool
+
+	// SHA-256 Digest header of each request body.
+	PayloadDigest bool
+
+	// gzip compression of report payloads. nil keeps the reporter default.
+	Compression *bool
+
+	// tenant sent in the dx_tenant_id header of every request. empty sends no header.
+	TenantID string
+
+	// OTel report sender settings, see NewOTelReportSender.
+	OTelContentType string
+
+	// whether the URL scheme resolves to a transport registered with Register.
+	CustomTransport bool
 }
 
 func (c *configuration) Direct() bool {
@@ -87,6 +253,16 @@ func createConfig(wfURL string, setters ...Option) (*configuration, error) {
 		set(cfg)
 	}
 
+	if err := cfg.parseReportQueryTemplates(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateProtocol(); err != nil {
+		return nil, err
+	}
+	if cfg.metricRenamer, err = newMetricRenamer(cfg.MetricRenames); err != nil {
+		return nil, err
+	}
+
 	switch strings.ToLower(u.Scheme) {
 	case "http":
 		if cfg.Direct() {
@@ -99,6 +275,10 @@ func createConfig(wfURL string, setters ...Option) (*configuration, error) {
 			cfg.setDefaultPort(443)
 		}
 	default:
+		if _, ok := lookupTransport(u.Scheme); ok {
+			cfg.CustomTransport = true
+			break
+		}
 		return nil, fmt.Errorf("invalid scheme '%s' in '%s', only 'http/https' is supported", u.Scheme, u)
 	}
 
@@ -113,16 +293,26 @@ func createConfig(wfURL string, setters ...Option) (*configuration, error) {
 			return nil, fmt.Errorf("unable to convert port to integer: %s", err)
 		}
 		cfg.setDefaultPort(port)
+		// keep IPv6 literals bracketed, as the port is appended to Server.
 		u.Host = u.Hostname()
+		if strings.Contains(u.Host, ":") {
+			u.Host = "[" + u.Host + "]"
+		}
 	}
 	cfg.Server = u.String()
 
 	if cfg.HTTPClient == nil {
+		transport, err := cfg.httpClientConfiguration.transport()
+		if err != nil {
+			return nil, err
+		}
 		cfg.HTTPClient = &http.Client{
-			Timeout: cfg.httpClientConfiguration.Timeout,
-			Transport: &http.Transport{
-				TLSClientConfig: cfg.httpClientConfiguration.TLSClientConfig,
-			},
+			Timeout:   cfg.httpClientConfiguration.Timeout,
+			Transport: transport,
+		}
+		if cfg.RequestTimeoutBase > 0 {
+			// the timeout of each request is set by the reporters instead.
+			cfg.HTTPClient.Timeout = 0
 		}
 	}
 
@@ -143,9 +333,12 @@ func (c *configuration) metricsURL() string {
 }
 
 func (c *configuration) MetricPrefix() string {
-	result := "~sdk.go.core.sender.proxy"
+	if c.InternalMetricsPrefix != "" {
+		return c.InternalMetricsPrefix
+	}
+	result := InternalMetricPrefixProxy
 	if c.Direct() {
-		result = "~sdk.go.core.sender.direct"
+		result = InternalMetricPrefixDirect
 	}
 	return result
 }
@@ -0,0 +1,247 @@
+package senders
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// The conformance tests serialize the same points with the Wavefront and the OTLP serializers and
+// check that both carry the same names, values, timestamps, sources and tags, so that moving from
+// one protocol to the other does not change the data. Names are valid Wavefront names, which the
+// Wavefront serializer sanitizes and the OTLP one keeps as is.
+
+var conformanceMetrics = []struct {
+	name   string
+	value  float64
+	ts     int64
+	source string
+	tags   map[string]string
+}{
+	{"cpu.usage", 85.5, 1533529977, "web-01", map[string]string{"env": "prod", "region": "us-west-1"}},
+	{"queue.depth", -3, 1533529977, "web-01", nil},
+	{"∆requests.count", 42, 1533529977, "web-02", map[string]string{"status": "200"}},
+	{"big.value", 1.5e300, 1533529977, "db-01", map[string]string{"unit": "bytes"}},
+	{"tiny.value", 2.5e-10, 1533529977, "db-01", map[string]string{"quoted": `say "hi"`}},
+}
+
+func TestConformance_Metrics(t *testing.T) {
+	for _, m := range conformanceMetrics {
+		t.Run(m.name, func(t *testing.T) {
+			wfLine, err := WavefrontSerializer().MetricLine(m.name, m.value, m.ts, m.source, m.tags)
+			require.NoError(t, err)
+			otlpLine, err := OTLPSerializer().MetricLine(m.name, m.value, m.ts, m.source, m.tags)
+			require.NoError(t, err)
+
+			wf := parseWavefrontLine(t, string(wfLine))
+			require.Len(t, wf.values, 2)
+			var request otlpMetricsRequest
+			require.NoError(t, json.Unmarshal(otlpLine, &request))
+			resource := request.ResourceMetrics[0]
+			metric := resource.ScopeMetrics[0].Metrics[0]
+
+			assert.Equal(t, m.name, wf.name)
+			assert.Equal(t, trimDeltaPrefix(wf.name), metric.Name)
+			assert.Equal(t, m.source, wf.tags["source"])
+			assert.Equal(t, m.source, otlpAttributeMap(resource.Resource.Attributes)["source"])
+
+			var point otlpNumberDataPoint
+			if internal.HasDeltaPrefix(m.name) {
+				require.NotNil(t, metric.Sum, "delta counters are sums")
+				assert.Equal(t, otlpDeltaTemporality, metric.Sum.AggregationTemporality)
+				assert.True(t, metric.Sum.IsMonotonic)
+				point = metric.Sum.DataPoints[0]
+			} else {
+				require.NotNil(t, metric.Gauge, "metrics are gauges")
+				point = metric.Gauge.DataPoints[0]
+			}
+			assert.Equal(t, parseFloat(t, wf.values[0]), point.AsDouble)
+			assert.Equal(t, m.value, point.AsDouble)
+			assert.Equal(t, wavefrontTimeNano(t, wf.values[1]), point.TimeUnixNano)
+
+			delete(wf.tags, "source")
+			assert.Equal(t, wf.tags, otlpAttributeMap(point.Attributes))
+		})
+	}
+}
+
+func TestConformance_Distributions(t *testing.T) {
+	centroids := []histogram.Centroid{{Value: 1, Count: 3}, {Value: 5, Count: 1}, {Value: 2.5, Count: 2}}
+	tags := map[string]string{"env": "prod"}
+	hgs := map[histogram.Granularity]bool{histogram.MINUTE: true}
+
+	wfLine, err := WavefrontSerializer().DistributionLine("request.latency", centroids, hgs, 1533529977, "web-01", tags)
+	require.NoError(t, err)
+	otlpLine, err := OTLPSerializer().DistributionLine("request.latency", centroids, hgs, 1533529977, "web-01", tags)
+	require.NoError(t, err)
+
+	// !M <timestamp> #<count> <value>... "<name>" source="<source>" tags...
+	wf := parseWavefrontLine(t, strings.TrimPrefix(string(wfLine), "!M "))
+	var request otlpMetricsRequest
+	require.NoError(t, json.Unmarshal(otlpLine, &request))
+	resource := request.ResourceMetrics[0]
+	metric := resource.ScopeMetrics[0].Metrics[0]
+	require.NotNil(t, metric.Summary, "distributions are summaries")
+	point := metric.Summary.DataPoints[0]
+
+	assert.Equal(t, "request.latency", metric.Name)
+	assert.Equal(t, "web-01", otlpAttributeMap(resource.Resource.Attributes)["source"])
+	assert.Equal(t, wf.tags["source"], otlpAttributeMap(resource.Resource.Attributes)["source"])
+	assert.Equal(t, wavefrontTimeNano(t, wf.prefix[0]), point.TimeUnixNano)
+
+	var count uint64
+	var sum float64
+	var values []float64
+	for i := 1; i+1 < len(wf.prefix); i += 2 {
+		n, err := strconv.ParseUint(strings.TrimPrefix(wf.prefix[i], "#"), 10, 64)
+		require.NoError(t, err)
+		value := parseFloat(t, wf.prefix[i+1])
+		count += n
+		sum += value * float64(n)
+		values = append(values, value)
+	}
+	assert.Equal(t, count, point.Count)
+	assert.Equal(t, sum, point.Sum)
+	assert.Equal(t, []otlpQuantile{{Quantile: 0, Value: 1}, {Quantile: 1, Value: 5}}, point.QuantileValues)
+	assert.ElementsMatch(t, []float64{1, 5, 2.5}, values)
+
+	delete(wf.tags, "source")
+	assert.Equal(t, wf.tags, otlpAttributeMap(point.Attributes))
+}
+
+func TestConformance_Spans(t *testing.T) {
+	tags := []SpanTag{{Key: "application", Value: "checkout"}, {Key: "http.status_code", Value: "200"}}
+	wfLine, err := WavefrontSerializer().SpanLine("getCart", 1533531013000, 343, "web-01", testTraceID, testSpanID,
+		[]string{testParent}, nil, tags, nil)
+	require.NoError(t, err)
+	otlpLine, err := OTLPSerializer().SpanLine("getCart", 1533531013000, 343, "web-01", testTraceID, testSpanID,
+		[]string{testParent}, nil, tags, nil)
+	require.NoError(t, err)
+
+	wf := parseWavefrontLine(t, string(wfLine))
+	require.Len(t, wf.values, 2)
+	var request otlpTracesRequest
+	require.NoError(t, json.Unmarshal(otlpLine, &request))
+	resource := request.ResourceSpans[0]
+	span := resource.ScopeSpans[0].Spans[0]
+
+	assert.Equal(t, wf.name, span.Name)
+	assert.Equal(t, wf.tags["source"], otlpAttributeMap(resource.Resource.Attributes)["source"])
+	assert.Equal(t, strings.ReplaceAll(wf.tags["traceId"], "-", ""), span.TraceID)
+	assert.True(t, strings.HasSuffix(strings.ReplaceAll(wf.tags["spanId"], "-", ""), span.SpanID))
+	assert.True(t, strings.HasSuffix(strings.ReplaceAll(wf.tags["parent"], "-", ""), span.ParentSpanID))
+
+	startMillis := parseFloat(t, wf.values[0])
+	durationMillis := parseFloat(t, wf.values[1])
+	assert.Equal(t, uint64(startMillis)*uint64(time.Millisecond), span.StartTimeUnixNano)
+	assert.Equal(t, uint64(startMillis+durationMillis)*uint64(time.Millisecond), span.EndTimeUnixNano)
+
+	for _, key := range []string{"source", "traceId", "spanId", "parent"} {
+		delete(wf.tags, key)
+	}
+	assert.Equal(t, wf.tags, otlpAttributeMap(span.Attributes))
+}
+
+// wavefrontLine is a line of the Wavefront data format, split into the values before its name,
+// its name, the tags, source included, and the values after its name.
+type wavefrontLine struct {
+	prefix []string
+	name   string
+	tags   map[string]string
+	values []string
+}
+
+// parseWavefrontLine parses the lines of the Wavefront serializer, whose name is always quoted.
+func parseWavefrontLine(t *testing.T, line string) wavefrontLine {
+	t.Helper()
+	parsed := wavefrontLine{tags: map[string]string{}}
+	tokens := splitWavefrontLine(t, strings.TrimSuffix(line, "\n"))
+	i := 0
+	for ; i < len(tokens) && !strings.HasPrefix(tokens[i], `"`); i++ {
+		parsed.prefix = append(parsed.prefix, tokens[i])
+	}
+	require.Less(t, i, len(tokens), "no name in %q", line)
+	parsed.name = unquote(t, tokens[i])
+	for _, token := range tokens[i+1:] {
+		key, value, ok := cutTag(token)
+		if !ok {
+			parsed.values = append(parsed.values, token)
+			continue
+		}
+		parsed.tags[unquote(t, key)] = unquote(t, value)
+	}
+	return parsed
+}
+
+// splitWavefrontLine splits line on the spaces outside of quotes.
+func splitWavefrontLine(t *testing.T, line string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(line):
+			token.WriteByte(c)
+			i++
+			c = line[i]
+		case c == '"':
+			quoted = !quoted
+		case c == ' ' && !quoted:
+			tokens = append(tokens, token.String())
+			token.Reset()
+			continue
+		}
+		token.WriteByte(c)
+	}
+	require.False(t, quoted, "unterminated quote in %q", line)
+	return append(tokens, token.String())
+}
+
+// cutTag splits a key=value token, whose key may be quoted.
+func cutTag(token string) (string, string, bool) {
+	if strings.HasPrefix(token, `"`) {
+		end := strings.Index(token[1:], `"=`)
+		if end < 0 {
+			return "", "", false
+		}
+		return token[:end+2], token[end+3:], true
+	}
+	return strings.Cut(token, "=")
+}
+
+func unquote(t *testing.T, s string) string {
+	if !strings.HasPrefix(s, `"`) {
+		return s
+	}
+	unquoted, err := strconv.Unquote(s)
+	require.NoError(t, err, s)
+	return unquoted
+}
+
+func parseFloat(t *testing.T, s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	require.NoError(t, err)
+	return f
+}
+
+func wavefrontTimeNano(t *testing.T, s string) uint64 {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	require.NoError(t, err)
+	return uint64(unixNano(ts))
+}
+
+func otlpAttributeMap(attributes []otlpKeyValue) map[string]string {
+	m := make(map[string]string, len(attributes))
+	for _, a := range attributes {
+		m[a.Key] = a.Value.StringValue
+	}
+	return m
+}
@@ -0,0 +1,260 @@
+package senders
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// DistributionRecorder accumulates values into distributions client-side.
+type DistributionRecorder interface {
+	// RecordDistribution adds value to the distribution of the series of the given name, source
+	// and tags. Distributions are accumulated for each of the granularities of the sender, see
+	// DistributionGranularities, and sent once their interval is over, or when the sender is
+	// closed, so that apps need not maintain histograms of their own.
+	RecordDistribution(name string, value float64, source string, tags map[string]string) error
+
+	// SendDuration records d, in the unit of DurationUnit, milliseconds by default, as
+	// RecordDistribution does:
+	//
+	//	start := time.Now()
+	//	handle(req)
+	//	sender.SendDuration("http.request.latency", time.Since(start), "", tags)
+	SendDuration(name string, d time.Duration, source string, tags map[string]string) error
+}
+
+// DistributionGranularities sets the granularities RecordDistribution accumulates values for,
+// MINUTE by default. Each granularity sends a distribution per series and per interval, e.g.
+// DistributionGranularities(histogram.MINUTE, histogram.HOUR) sends 61 distributions per hour
+// for each series.
+func DistributionGranularities(granularities ...histogram.Granularity) Option {
+	return func(cfg *configuration) {
+		cfg.DistributionGranularities = granularities
+	}
+}
+
+// DurationUnit sets the unit of the values SendDuration records, e.g. time.Microsecond,
+// time.Millisecond by default.
+func DurationUnit(unit time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.DurationUnit = unit
+	}
+}
+
+// DistributionBackend sets the data structure RecordDistribution accumulates values into,
+// histogram.TDigest by default. histogram.HDR bounds the relative error of every value,
+// using memory bounded by the value range it tracks.
+func DistributionBackend(b histogram.Backend) Option {
+	return func(cfg *configuration) {
+		cfg.DistributionBackend = b
+	}
+}
+
+// distributionAccumulator accumulates the values of RecordDistribution in a histogram per
+// series, and sends their completed intervals every interval. Series without values during the
+// last completed interval of their longest granularity are evicted once sent, so that series
+// recorded once in a while do not pile up.
+type distributionAccumulator struct {
+	granularities []histogram.Granularity
+	backend       histogram.Backend
+	interval      time.Duration
+	send          func(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error
+	clock         func() time.Time
+
+	// closing makes the histograms complete their current interval, so that it is sent on close.
+	closing atomic.Bool
+
+	// mtx is held for reading while recording values, and for writing while adding or evicting
+	// series, so that no value is recorded into an evicted series.
+	mtx     sync.RWMutex
+	series  map[string]*accumulatedSeries
+	started bool
+	closed  bool
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+type accumulatedSeries struct {
+	name      string
+	source    string
+	tags      map[string]string
+	histogram histogram.Histogram
+	// lastRecorded is the time of the last value, in nanoseconds since the epoch.
+	lastRecorded atomic.Int64
+}
+
+func newDistributionAccumulator(granularities []histogram.Granularity, backend histogram.Backend, interval time.Duration, send func(string, []histogram.Centroid, map[histogram.Granularity]bool, int64, string, map[string]string) error) *distributionAccumulator {
+	if len(granularities) == 0 {
+		granularities = []histogram.Granularity{histogram.MINUTE}
+	}
+	return &distributionAccumulator{
+		granularities: granularities,
+		backend:       backend,
+		interval:      interval,
+		send:          send,
+		clock:         time.Now,
+		series:        map[string]*accumulatedSeries{},
+		stop:          make(chan struct{}),
+	}
+}
+
+func (a *distributionAccumulator) now() time.Time {
+	if a.closing.Load() {
+		// past the end of the current day, the longest granularity.
+		return a.clock().Add(48 * time.Hour)
+	}
+	return a.clock()
+}
+
+func (a *distributionAccumulator) record(name string, value float64, source string, tags map[string]string) error {
+	if name == "" {
+		return errors.New("empty distribution name")
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("non-finite value %v: distribution=%s", value, name)
+	}
+	key := internal.SeriesKey(name, source, tags)
+
+	a.mtx.RLock()
+	s, ok := a.series[key]
+	if ok && !a.closed {
+		s.update(value, a.clock())
+		a.mtx.RUnlock()
+		return nil
+	}
+	a.mtx.RUnlock()
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.closed {
+		return errors.New("sender closed")
+	}
+	if !a.started {
+		// the background sends start with the first value, so that senders not recording any
+		// distribution do not run them.
+		a.started = true
+		a.start()
+	}
+	s, ok = a.series[key]
+	if !ok {
+		copiedTags := make(map[string]string, len(tags))
+		for k, v := range tags {
+			copiedTags[k] = v
+		}
+		s = &accumulatedSeries{
+			name:   name,
+			source: source,
+			tags:   copiedTags,
+			histogram: histogram.New(histogram.Granularities(a.granularities...),
+				histogram.BackendOption(a.backend), histogram.TimeSupplier(a.now)),
+		}
+		a.series[key] = s
+	}
+	s.update(value, a.clock())
+	return nil
+}
+
+func (s *accumulatedSeries) update(value float64, now time.Time) {
+	s.lastRecorded.Store(now.UnixNano())
+	s.histogram.Update(value)
+}
+
+// drain sends the completed intervals of every series, and evicts the idle ones.
+func (a *distributionAccumulator) drain() {
+	a.mtx.RLock()
+	series := make([]*accumulatedSeries, 0, len(a.series))
+	for _, s := range a.series {
+		series = append(series, s)
+	}
+	a.mtx.RUnlock()
+
+	for _, s := range series {
+		for _, d := range s.histogram.Distributions() {
+			if len(d.Centroids) == 0 {
+				continue
+			}
+			if err := a.send(s.name, d.Centroids, d.Granularities(), d.Timestamp.Unix(), s.source, s.tags); err != nil {
+				log.Printf("unable to send accumulated distribution %s: %s\n", s.name, err)
+			}
+		}
+	}
+	a.evictIdle()
+}
+
+// evictIdle removes the series without values since the start of the last completed interval
+// of the longest granularity: their intervals are all completed, and were sent by drain.
+func (a *distributionAccumulator) evictIdle() {
+	longest := a.granularities[0].Duration()
+	for _, g := range a.granularities[1:] {
+		if g.Duration() > longest {
+			longest = g.Duration()
+		}
+	}
+	idleSince := a.clock().Truncate(longest).Add(-longest).UnixNano()
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	for key, s := range a.series {
+		if s.lastRecorded.Load() < idleSince {
+			delete(a.series, key)
+		}
+	}
+}
+
+func (a *distributionAccumulator) start() {
+	a.done.Add(1)
+	go func() {
+		defer a.done.Done()
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.drain()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the background sends and sends every interval, including the current ones.
+func (a *distributionAccumulator) close() {
+	a.mtx.Lock()
+	a.closed = true
+	if a.started {
+		close(a.stop)
+	}
+	a.mtx.Unlock()
+	a.done.Wait()
+	a.closing.Store(true)
+	a.drain()
+}
+
+func (sender *realSender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	if sender.distributions == nil {
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return errors.New("distributions cannot be recorded by this sender")
+	}
+	if err := sender.distributions.record(name, value, source, tags); err != nil {
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return err
+	}
+	return nil
+}
+
+func (sender *realSender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	unit := time.Millisecond
+	if sender.durationUnit > 0 {
+		unit = sender.durationUnit
+	}
+	return sender.RecordDistribution(name, float64(d)/float64(unit), source, tags)
+}
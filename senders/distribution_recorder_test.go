@@ -0,0 +1,128 @@
+package senders
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestRecordDistribution(t *testing.T) {
+	sender, err := NewValidatingSender(DistributionGranularities(histogram.MINUTE, histogram.HOUR))
+	require.NoError(t, err)
+
+	for _, v := range []float64{1, 1, 3} {
+		require.NoError(t, sender.RecordDistribution("latency", v, "test", map[string]string{"env": "dev"}))
+	}
+	require.NoError(t, sender.RecordDistribution("size", 10, "test", nil))
+	assert.Error(t, sender.RecordDistribution("", 1, "test", nil))
+	assert.Error(t, sender.RecordDistribution("latency", math.NaN(), "test", nil))
+
+	// the current intervals are sent on close.
+	sender.Close()
+	lines := sender.Lines()
+	sort.Strings(lines)
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "!H "))
+	assert.Contains(t, lines[0], " #1 10 \"size\" source=\"test\"\n")
+	assert.True(t, strings.HasPrefix(lines[1], "!H "))
+	assert.Contains(t, lines[1], " #2 1 ")
+	assert.Contains(t, lines[1], " #1 3 ")
+	assert.Contains(t, lines[1], " \"latency\" source=\"test\" \"env\"=\"dev\"\n")
+	assert.True(t, strings.HasPrefix(lines[2], "!M "))
+	assert.True(t, strings.HasPrefix(lines[3], "!M "))
+	assert.Contains(t, lines[3], " #2 1 ")
+	assert.Contains(t, lines[3], " #1 3 ")
+
+	assert.Error(t, sender.RecordDistribution("latency", 1, "test", nil))
+}
+
+func TestDistributionAccumulatorDrain(t *testing.T) {
+	var mtx sync.Mutex
+	var sent []int64
+	send := func(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		assert.Equal(t, "latency", name)
+		assert.True(t, hgs[histogram.MINUTE])
+		sent = append(sent, ts)
+		return nil
+	}
+	a := newDistributionAccumulator(nil, histogram.TDigest, time.Hour, send)
+	require.NoError(t, a.record("latency", 1, "test", nil))
+
+	// the current minute is not over yet.
+	a.drain()
+	mtx.Lock()
+	assert.Empty(t, sent)
+	mtx.Unlock()
+
+	a.close()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, time.Now().Truncate(time.Minute).Unix(), sent[0])
+}
+
+func TestDistributionAccumulatorEvictsIdleSeries(t *testing.T) {
+	var sent int
+	send := func(string, []histogram.Centroid, map[histogram.Granularity]bool, int64, string, map[string]string) error {
+		sent++
+		return nil
+	}
+	now := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC)
+	a := newDistributionAccumulator(nil, histogram.TDigest, time.Hour, send)
+	a.clock = func() time.Time { return now }
+	defer a.close()
+	require.NoError(t, a.record("latency", 1, "test", nil))
+
+	// the minute of the value is sent, but the series is kept until a minute goes by without values.
+	now = now.Add(time.Minute)
+	a.drain()
+	assert.Equal(t, 1, sent)
+	assert.Len(t, a.series, 1)
+
+	now = now.Add(time.Minute)
+	a.drain()
+	assert.Equal(t, 1, sent)
+	assert.Empty(t, a.series)
+
+	require.NoError(t, a.record("latency", 2, "test", nil))
+	assert.Len(t, a.series, 1)
+}
+
+func TestRecordDistribution_HDR(t *testing.T) {
+	sender, err := NewValidatingSender(DistributionBackend(histogram.HDR))
+	require.NoError(t, err)
+
+	require.NoError(t, sender.RecordDistribution("latency", 1.2345, "test", nil))
+	require.NoError(t, sender.RecordDistribution("latency", 1.236, "test", nil))
+	sender.Close()
+
+	lines := sender.Lines()
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], " #1 1.2345 #1 1.2365 ")
+}
+
+func TestSendDuration(t *testing.T) {
+	for _, tc := range []struct {
+		setters []Option
+		want    string
+	}{
+		{want: " #1 1.5 \"latency\""},
+		{setters: []Option{DurationUnit(time.Microsecond)}, want: " #1 1500 \"latency\""},
+	} {
+		sender, err := NewValidatingSender(tc.setters...)
+		require.NoError(t, err)
+		require.NoError(t, sender.SendDuration("latency", 1500*time.Microsecond, "test", nil))
+		sender.Close()
+
+		lines := sender.Lines()
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], tc.want)
+	}
+}
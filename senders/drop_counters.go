@@ -0,0 +1,52 @@
+package senders
+
+import "github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+
+// dropReason is why the sender drops data on purpose, before buffering it.
+type dropReason int
+
+const (
+	dropNone dropReason = iota
+	// a non-finite value, see RejectNonFiniteValues and DropNonFiniteValues.
+	dropNonFinite
+	// a value out of its ValueBounds.
+	dropOutOfBounds
+	// data of a disabled type, see DisableDistributions, DisableSpans and DisableEvents.
+	dropDisabled
+	// data failing ValidationStrict.
+	dropInvalid
+)
+
+// dropCounters count the data of one type dropped on purpose, in one <type>.dropped.<reason>
+// internal metric per reason. A nil *dropCounters counts nothing.
+type dropCounters struct {
+	nonFinite   *sdkmetrics.DeltaCounter
+	outOfBounds *sdkmetrics.DeltaCounter
+	disabled    *sdkmetrics.DeltaCounter
+	invalid     *sdkmetrics.DeltaCounter
+}
+
+func newDropCounters(registry sdkmetrics.Registry, prefix string) *dropCounters {
+	return &dropCounters{
+		nonFinite:   registry.NewDeltaCounter(prefix + sdkmetrics.DroppedNonFiniteSuffix),
+		outOfBounds: registry.NewDeltaCounter(prefix + sdkmetrics.DroppedOutOfBoundsSuffix),
+		disabled:    registry.NewDeltaCounter(prefix + sdkmetrics.DroppedDisabledSuffix),
+		invalid:     registry.NewDeltaCounter(prefix + sdkmetrics.DroppedInvalidSuffix),
+	}
+}
+
+func (d *dropCounters) inc(reason dropReason) {
+	if d == nil {
+		return
+	}
+	switch reason {
+	case dropNonFinite:
+		d.nonFinite.Inc()
+	case dropOutOfBounds:
+		d.outOfBounds.Inc()
+	case dropDisabled:
+		d.disabled.Inc()
+	case dropInvalid:
+		d.invalid.Inc()
+	}
+}
@@ -0,0 +1,53 @@
+package senders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestDropCounters(t *testing.T) {
+	registry := &mockRegistry{}
+	sender := newMockSender(&mockHandler{})
+	sender.internalRegistry = registry
+	sender.pointsDropped = newDropCounters(registry, "points")
+	sender.histogramsDropped = newDropCounters(registry, "histograms")
+	sender.spansDropped = newDropCounters(registry, "spans")
+	sender.eventsDropped = newDropCounters(registry, "events")
+	cfg, err := createConfig("https://localhost", ValueBounds("cpu.", 0, 100))
+	require.NoError(t, err)
+	sender.valueGuard = newValueGuard(cfg, registry)
+	sender.validation = ValidationStrict
+	sender.disabledSpans = registry.NewDeltaCounter(InternalMetricSpansDisabled)
+	sender.disabledEvents = registry.NewDeltaCounter(InternalMetricEventsDisabled)
+
+	hgs := map[histogram.Granularity]bool{histogram.MINUTE: true}
+	assert.Error(t, sender.SendMetric("cpu.usage", 101, 0, "test", nil))
+	assert.Error(t, sender.SendMetric("cpu usage", 1, 0, "test", nil))
+	assert.Error(t, sender.SendDeltaCounter("cpu usage", 1, "test", nil))
+	assert.Error(t, sender.SendDistribution("cpu.usage", []histogram.Centroid{{Value: 101, Count: 1}}, hgs, 0, "test", nil))
+	assert.Error(t, sender.SendDistribution("cpu usage", []histogram.Centroid{{Value: 1, Count: 1}}, hgs, 0, "test", nil))
+	assert.NoError(t, sender.SendSpan("my.span", 0, 10, "test", "7b3bf470-9456-11e8-9eb6-529269fb1459",
+		"0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+	assert.NoError(t, sender.SendEvent("my event", 0, 0, "test", nil))
+
+	counts := map[string]int64{}
+	for name, counter := range registry.deltaCounters {
+		if counter.Count() > 0 {
+			counts[name] = counter.Count()
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"points.out_of_bounds":                 2,
+		"points.dropped.out_of_bounds":         1,
+		"points.dropped.strict_validation":     2,
+		"histograms.dropped.out_of_bounds":     1,
+		"histograms.dropped.strict_validation": 1,
+		"spans.disabled":                       1,
+		"spans.dropped.disabled":               1,
+		"events.disabled":                      1,
+		"events.dropped.disabled":              1,
+	}, counts)
+}
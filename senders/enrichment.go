@@ -0,0 +1,237 @@
+package senders
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LookupTable maps a join key (a source or a point tag value) to the tags that
+// should be added to points carrying that key.
+type LookupTable map[string]map[string]string
+
+// LookupFunc loads a LookupTable. It is called once when the sender starts and
+// then on every refresh interval.
+type LookupFunc func() (LookupTable, error)
+
+// CSVLookup returns a LookupFunc that reads a LookupTable from a CSV file.
+// The first row is a header naming the columns. The first column holds the join key,
+// every other column becomes a tag named after its header.
+// Empty cells are skipped.
+//
+// Example file:
+//
+//	host,team,datacenter
+//	web-01,storefront,us-west-1
+//	db-01,storage,us-east-2
+func CSVLookup(path string) LookupFunc {
+	return func() (LookupTable, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse lookup file %s: %s", path, err)
+		}
+		if len(records) == 0 {
+			return LookupTable{}, nil
+		}
+
+		header := records[0]
+		table := make(LookupTable, len(records)-1)
+		for _, record := range records[1:] {
+			if len(record) == 0 || record[0] == "" {
+				continue
+			}
+			tags := make(map[string]string, len(header)-1)
+			for i := 1; i < len(header) && i < len(record); i++ {
+				if record[i] != "" {
+					tags[header[i]] = record[i]
+				}
+			}
+			table[record[0]] = tags
+		}
+		return table, nil
+	}
+}
+
+// Enrichment joins every metric, distribution and span against the LookupTable returned
+// by lookup and adds the matching tags to the point. The join key is the point's source
+// when keyTag is "source", otherwise it is the value of the keyTag point tag.
+// Tags already set on the point are never overridden.
+// The table is reloaded every refreshInterval; a zero refreshInterval loads it only once.
+// Multiple Enrichment options are applied in the order they were given.
+func Enrichment(keyTag string, lookup LookupFunc, refreshInterval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.Enrichers = append(cfg.Enrichers, newLookupEnricher(keyTag, lookup, refreshInterval))
+	}
+}
+
+// Tags added by CaptureTags.
+const (
+	CaptureRegionTag = "_wf.capture.region"
+	CaptureRunIDTag  = "_wf.capture.run_id"
+)
+
+// CaptureTags tags every metric, distribution and span with the region or zone the sender
+// reports from, as _wf.capture.region, and with the run ID of the capture, as _wf.capture.run_id,
+// so that large multi-region load tests can be sliced by region and by run in Wavefront.
+// An empty region is left out; an empty runID defaults to the Unix time, in seconds, at which the
+// sender is created, the capture epoch. Tags already set on the point are never overridden.
+func CaptureTags(region, runID string) Option {
+	return func(cfg *configuration) {
+		if runID == "" {
+			runID = strconv.FormatInt(time.Now().Unix(), 10)
+		}
+		tags := map[string]string{CaptureRunIDTag: runID}
+		if region != "" {
+			tags[CaptureRegionTag] = region
+		}
+		cfg.Enrichers = append(cfg.Enrichers, newStaticEnricher(tags))
+	}
+}
+
+type lookupEnricher struct {
+	// tags added to every point, by the enrichers of CaptureTags.
+	static map[string]string
+
+	keyTag          string
+	lookup          LookupFunc
+	refreshInterval time.Duration
+
+	mtx      sync.RWMutex
+	table    LookupTable
+	ticker   *time.Ticker
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newLookupEnricher(keyTag string, lookup LookupFunc, refreshInterval time.Duration) *lookupEnricher {
+	return &lookupEnricher{
+		keyTag:          keyTag,
+		lookup:          lookup,
+		refreshInterval: refreshInterval,
+		stop:            make(chan struct{}),
+	}
+}
+
+func newStaticEnricher(tags map[string]string) *lookupEnricher {
+	e := newLookupEnricher("", func() (LookupTable, error) { return nil, nil }, 0)
+	e.static = tags
+	return e
+}
+
+func (e *lookupEnricher) Start() {
+	e.refresh()
+	if e.refreshInterval <= 0 || e.ticker != nil {
+		return
+	}
+	e.ticker = time.NewTicker(e.refreshInterval)
+	go func() {
+		for {
+			select {
+			case <-e.ticker.C:
+				e.refresh()
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the refreshes. It can be called more than once, e.g. when a sender is closed twice.
+func (e *lookupEnricher) Stop() {
+	e.stopOnce.Do(func() {
+		if e.ticker != nil {
+			e.ticker.Stop()
+		}
+		close(e.stop)
+	})
+}
+
+// refresh reloads the table. On error the previous table is kept.
+func (e *lookupEnricher) refresh() {
+	table, err := e.lookup()
+	if err != nil {
+		log.Printf("unable to refresh enrichment table for key '%s': %s\n", e.keyTag, err)
+		return
+	}
+	e.mtx.Lock()
+	e.table = table
+	e.mtx.Unlock()
+}
+
+func (e *lookupEnricher) tagsFor(source string, tags map[string]string) map[string]string {
+	if e.static != nil {
+		return e.static
+	}
+	key := source
+	if e.keyTag != "source" {
+		key = tags[e.keyTag]
+	}
+	if key == "" {
+		return nil
+	}
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.table[key]
+}
+
+// enrich returns tags with the lookup tags of every enricher added.
+// The caller's map is never modified; a copy, from the pool of tag maps, is made only when tags
+// are added.
+func enrich(enrichers []*lookupEnricher, source string, tags map[string]string) map[string]string {
+	result := tags
+	copied := false
+	for _, e := range enrichers {
+		for k, v := range e.tagsFor(source, result) {
+			if _, ok := result[k]; ok {
+				continue
+			}
+			if !copied {
+				result = copyTagsTo(getTagMap(), result)
+				copied = true
+			}
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// enrichSpanTags is the []SpanTag equivalent of enrich.
+func enrichSpanTags(enrichers []*lookupEnricher, source string, tags []SpanTag) []SpanTag {
+	if len(enrichers) == 0 {
+		return tags
+	}
+	existing := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		existing[tag.Key] = tag.Value
+	}
+	enriched := enrich(enrichers, source, existing)
+	if len(enriched) == len(existing) {
+		return tags
+	}
+	defer putTagMap(enriched)
+	added := make([]string, 0, len(enriched)-len(existing))
+	for k := range enriched {
+		if _, ok := existing[k]; !ok {
+			added = append(added, k)
+		}
+	}
+	// the added tags are appended in a stable order, as span tags are formatted in order.
+	sort.Strings(added)
+	result := make([]SpanTag, len(tags), len(tags)+len(added))
+	copy(result, tags)
+	for _, k := range added {
+		result = append(result, SpanTag{Key: k, Value: enriched[k]})
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package senders
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.csv")
+	require.NoError(t, os.WriteFile(path, []byte("host,team,datacenter\nweb-01,storefront,us-west-1\ndb-01,storage,\n"), 0o600))
+
+	table, err := CSVLookup(path)()
+	require.NoError(t, err)
+	assert.Equal(t, LookupTable{
+		"web-01": {"team": "storefront", "datacenter": "us-west-1"},
+		"db-01":  {"team": "storage"},
+	}, table)
+
+	_, err = CSVLookup(filepath.Join(t.TempDir(), "missing.csv"))()
+	assert.Error(t, err)
+}
+
+func TestEnrichment_BySource(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.enrichers = []*lookupEnricher{newLookupEnricher("source", staticLookup(LookupTable{
+		"web-01": {"team": "storefront", "env": "prod"},
+	}), 0)}
+	sender.Start()
+
+	tags := map[string]string{"env": "dev"}
+	assert.NoError(t, sender.SendMetric("foo", 1, 0, "web-01", tags))
+	assert.Contains(t, pointHandler.Lines[0], "\"team\"=\"storefront\"")
+	assert.Contains(t, pointHandler.Lines[0], "\"env\"=\"dev\"")
+	assert.Equal(t, map[string]string{"env": "dev"}, tags, "caller tags must not be modified")
+	pointHandler.Reset()
+
+	assert.NoError(t, sender.SendMetric("foo", 1, 0, "web-02", nil))
+	assert.NotContains(t, pointHandler.Lines[0], "team")
+}
+
+func TestEnrichment_ByTagAndRefresh(t *testing.T) {
+	var table atomic.Value
+	table.Store(LookupTable{"checkout": {"owner": "alice"}})
+	lookup := func() (LookupTable, error) {
+		return table.Load().(LookupTable), nil
+	}
+	e := newLookupEnricher("service", lookup, 10*time.Millisecond)
+	e.Start()
+	defer e.Stop()
+
+	assert.Equal(t, map[string]string{"service": "checkout", "owner": "alice"},
+		enrich([]*lookupEnricher{e}, "host", map[string]string{"service": "checkout"}))
+
+	table.Store(LookupTable{"checkout": {"owner": "bob"}})
+	assert.Eventually(t, func() bool {
+		return enrich([]*lookupEnricher{e}, "host", map[string]string{"service": "checkout"})["owner"] == "bob"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestEnrichment_SharedOption(t *testing.T) {
+	option := Enrichment("source", staticLookup(LookupTable{"web-01": {"team": "storefront"}}), 10*time.Millisecond)
+	first, second := &configuration{}, &configuration{}
+	option(first)
+	option(second)
+	require.NotSame(t, first.Enrichers[0], second.Enrichers[0])
+
+	for _, e := range []*lookupEnricher{first.Enrichers[0], second.Enrichers[0]} {
+		e.Start()
+		e.Stop()
+		e.Stop()
+	}
+}
+
+func TestCaptureTags(t *testing.T) {
+	cfg := &configuration{}
+	CaptureTags("us-west-2a", "run-42")(cfg)
+	CaptureTags("", "")(cfg)
+	require.Len(t, cfg.Enrichers, 2)
+
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.enrichers = cfg.Enrichers[:1]
+	sender.Start()
+	assert.NoError(t, sender.SendMetric("foo", 1, 0, "web-01", map[string]string{CaptureRunIDTag: "mine"}))
+	assert.Contains(t, pointHandler.Lines[0], "\"_wf.capture.region\"=\"us-west-2a\"")
+	assert.Contains(t, pointHandler.Lines[0], "\"_wf.capture.run_id\"=\"mine\"")
+
+	tags := enrich(cfg.Enrichers[1:], "web-01", nil)
+	assert.NotContains(t, tags, CaptureRegionTag)
+	assert.InDelta(t, time.Now().Unix(), mustParseInt(t, tags[CaptureRunIDTag]), 5)
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	require.NoError(t, err)
+	return n
+}
+
+func TestEnrichSpanTags(t *testing.T) {
+	e := newLookupEnricher("source", staticLookup(LookupTable{
+		"web-01": {"team": "storefront", "dc": "us-east", "zone": "a", "http.method": "POST"},
+	}), 0)
+	e.Start()
+	tags := []SpanTag{{Key: "http.method", Value: "GET"}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, []SpanTag{
+			{Key: "http.method", Value: "GET"},
+			{Key: "dc", Value: "us-east"},
+			{Key: "team", Value: "storefront"},
+			{Key: "zone", Value: "a"},
+		}, enrichSpanTags([]*lookupEnricher{e}, "web-01", tags))
+	}
+	assert.Equal(t, tags, enrichSpanTags([]*lookupEnricher{e}, "web-02", tags))
+}
+
+func staticLookup(table LookupTable) LookupFunc {
+	return func() (LookupTable, error) {
+		return table, nil
+	}
+}
+
+func newMockSender(pointHandler *mockHandler) *realSender {
+	return &realSender{
+		defaultSource:    "test",
+		pointHandler:     pointHandler,
+		histoHandler:     &mockHandler{},
+		spanHandler:      &mockHandler{},
+		spanLogHandler:   &mockHandler{},
+		eventHandler:     &mockHandler{},
+		internalRegistry: &mockRegistry{},
+	}
+}
@@ -0,0 +1,188 @@
+package senders
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environmentOptions translates the environment variables of EnvironmentOptions to Options.
+var environmentOptions = map[string]func(value string) (Option, error){
+	"WAVEFRONT_API_TOKEN": func(value string) (Option, error) {
+		return APIToken(value), nil
+	},
+	"WAVEFRONT_TENANT_ID": func(value string) (Option, error) {
+		return TenantID(value), nil
+	},
+	"WAVEFRONT_BATCH_SIZE": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return BatchSize(n), err
+	},
+	"WAVEFRONT_MAX_BUFFER_SIZE": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return MaxBufferSize(n), err
+	},
+	"WAVEFRONT_FLUSH_INTERVAL": func(value string) (Option, error) {
+		d, err := parseDuration(value)
+		return FlushInterval(d), err
+	},
+	"WAVEFRONT_TIMEOUT": func(value string) (Option, error) {
+		d, err := parseDuration(value)
+		return Timeout(d), err
+	},
+	"WAVEFRONT_MAX_RETRIES": func(value string) (Option, error) {
+		n, err := strconv.Atoi(value)
+		if err == nil && n < 0 {
+			err = errors.New("must not be negative")
+		}
+		return MaxRetries(n), err
+	},
+	"WAVEFRONT_RATE_LIMIT": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return RateLimit(n), err
+	},
+	"WAVEFRONT_METRICS_PORT": func(value string) (Option, error) {
+		port, err := positiveInt(value)
+		return MetricsPort(port), err
+	},
+	"WAVEFRONT_TRACES_PORT": func(value string) (Option, error) {
+		port, err := positiveInt(value)
+		return TracesPort(port), err
+	},
+	"WAVEFRONT_COMPRESSION": func(value string) (Option, error) {
+		enabled, err := strconv.ParseBool(value)
+		return Compression(enabled), err
+	},
+	"WAVEFRONT_SEND_INTERNAL_METRICS": func(value string) (Option, error) {
+		enabled, err := strconv.ParseBool(value)
+		return SendInternalMetrics(enabled), err
+	},
+	"WAVEFRONT_PROXY_URL": func(value string) (Option, error) {
+		proxyURL, err := url.Parse(value)
+		return ProxyURL(proxyURL), err
+	},
+}
+
+// NewFromEnvironment creates a Sender for the URL of the WAVEFRONT_URL environment variable,
+// configured by the other variables of EnvironmentOptions, so that containers can be configured
+// without code changes. setters are applied after the Options of the environment, and take
+// precedence over them.
+func NewFromEnvironment(setters ...Option) (Sender, error) {
+	wfURL := strings.TrimSpace(os.Getenv("WAVEFRONT_URL"))
+	if wfURL == "" {
+		return nil, errors.New("WAVEFRONT_URL is not set")
+	}
+	options, err := EnvironmentOptions()
+	if err != nil {
+		return nil, err
+	}
+	return NewSender(wfURL, append(options, setters...)...)
+}
+
+// EnvironmentOptions returns the Options set by environment variables:
+//
+//	WAVEFRONT_API_TOKEN              APIToken
+//	WAVEFRONT_CSP_API_TOKEN          CSPAPIToken
+//	WAVEFRONT_CSP_CLIENT_ID          CSPClientCredentials, with WAVEFRONT_CSP_CLIENT_SECRET
+//	WAVEFRONT_CSP_ORG_ID             CSPOrgID
+//	WAVEFRONT_CSP_BASE_URL           CSPBaseURL
+//	WAVEFRONT_TENANT_ID              TenantID
+//	WAVEFRONT_BATCH_SIZE             BatchSize
+//	WAVEFRONT_MAX_BUFFER_SIZE        MaxBufferSize
+//	WAVEFRONT_FLUSH_INTERVAL         FlushInterval, e.g. 5s, or in seconds
+//	WAVEFRONT_TIMEOUT                Timeout, e.g. 500ms, or in seconds
+//	WAVEFRONT_MAX_RETRIES            MaxRetries
+//	WAVEFRONT_RATE_LIMIT             RateLimit
+//	WAVEFRONT_METRICS_PORT           MetricsPort
+//	WAVEFRONT_TRACES_PORT            TracesPort
+//	WAVEFRONT_COMPRESSION            Compression
+//	WAVEFRONT_SEND_INTERNAL_METRICS  SendInternalMetrics
+//	WAVEFRONT_PROXY_URL              ProxyURL
+//
+// Empty variables are ignored. Invalid values, or more than one of WAVEFRONT_API_TOKEN,
+// WAVEFRONT_CSP_API_TOKEN and WAVEFRONT_CSP_CLIENT_ID, return an error.
+func EnvironmentOptions() ([]Option, error) {
+	names := make([]string, 0, len(environmentOptions))
+	for name := range environmentOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var options []Option
+	for _, name := range names {
+		value := strings.TrimSpace(os.Getenv(name))
+		if value == "" {
+			continue
+		}
+		option, err := environmentOptions[name](value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s=%s: %s", name, value, err)
+		}
+		options = append(options, option)
+	}
+
+	cspOption, err := environmentCSPOption()
+	if err != nil {
+		return nil, err
+	}
+	if cspOption != nil {
+		options = append(options, cspOption)
+	}
+	return options, nil
+}
+
+// environmentCSPOption returns the CSP authentication Option of the environment, if any.
+func environmentCSPOption() (Option, error) {
+	apiToken := strings.TrimSpace(os.Getenv("WAVEFRONT_API_TOKEN"))
+	cspAPIToken := strings.TrimSpace(os.Getenv("WAVEFRONT_CSP_API_TOKEN"))
+	clientID := strings.TrimSpace(os.Getenv("WAVEFRONT_CSP_CLIENT_ID"))
+	clientSecret := strings.TrimSpace(os.Getenv("WAVEFRONT_CSP_CLIENT_SECRET"))
+
+	set := 0
+	for _, value := range []string{apiToken, cspAPIToken, clientID} {
+		if value != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, errors.New("only one of WAVEFRONT_API_TOKEN, WAVEFRONT_CSP_API_TOKEN and WAVEFRONT_CSP_CLIENT_ID can be set")
+	}
+
+	var cspOptions []CSPOption
+	if baseURL := strings.TrimSpace(os.Getenv("WAVEFRONT_CSP_BASE_URL")); baseURL != "" {
+		cspOptions = append(cspOptions, CSPBaseURL(baseURL))
+	}
+	if orgID := strings.TrimSpace(os.Getenv("WAVEFRONT_CSP_ORG_ID")); orgID != "" {
+		cspOptions = append(cspOptions, CSPOrgID(orgID))
+	}
+	switch {
+	case cspAPIToken != "":
+		return CSPAPIToken(cspAPIToken, cspOptions...), nil
+	case clientID != "":
+		if clientSecret == "" {
+			return nil, errors.New("WAVEFRONT_CSP_CLIENT_SECRET is not set")
+		}
+		return CSPClientCredentials(clientID, clientSecret, cspOptions...), nil
+	}
+	return nil, nil
+}
+
+// parseDuration parses a duration such as 1m30s, or a number of seconds.
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		value = strconv.Itoa(seconds) + "s"
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return d, nil
+}
@@ -0,0 +1,87 @@
+package senders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+func TestEnvironmentOptions(t *testing.T) {
+	t.Setenv("WAVEFRONT_API_TOKEN", "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e")
+	t.Setenv("WAVEFRONT_TENANT_ID", "16")
+	t.Setenv("WAVEFRONT_BATCH_SIZE", "4000")
+	t.Setenv("WAVEFRONT_MAX_BUFFER_SIZE", "64000")
+	t.Setenv("WAVEFRONT_FLUSH_INTERVAL", "5")
+	t.Setenv("WAVEFRONT_TIMEOUT", "500ms")
+	t.Setenv("WAVEFRONT_MAX_RETRIES", "2")
+	t.Setenv("WAVEFRONT_COMPRESSION", "false")
+	t.Setenv("WAVEFRONT_PROXY_URL", "http://egress.example.com:3128")
+
+	options, err := EnvironmentOptions()
+	require.NoError(t, err)
+	cfg, err := createConfig("https://example.wavefront.com", options...)
+	require.NoError(t, err)
+	assert.Equal(t, auth.APIToken{Token: "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e"}, cfg.Authentication)
+	assert.Equal(t, "16", cfg.TenantID)
+	assert.Equal(t, 4000, cfg.BatchSize)
+	assert.Equal(t, 64000, cfg.MaxBufferSize)
+	assert.Equal(t, 5*time.Second, cfg.FlushInterval)
+	assert.Equal(t, 500*time.Millisecond, cfg.HTTPClient.Timeout)
+	assert.Equal(t, 2, cfg.MaxRetries)
+	require.NotNil(t, cfg.Compression)
+	assert.False(t, *cfg.Compression)
+	assert.Equal(t, "http://egress.example.com:3128", cfg.httpClientConfiguration.ProxyURL.String())
+}
+
+func TestEnvironmentOptions_CSP(t *testing.T) {
+	t.Setenv("WAVEFRONT_CSP_CLIENT_ID", "client")
+	t.Setenv("WAVEFRONT_CSP_CLIENT_SECRET", "secret")
+	t.Setenv("WAVEFRONT_CSP_ORG_ID", "org")
+	t.Setenv("WAVEFRONT_CSP_BASE_URL", "https://csp.example.com")
+
+	options, err := EnvironmentOptions()
+	require.NoError(t, err)
+	cfg, err := createConfig("https://example.wavefront.com", options...)
+	require.NoError(t, err)
+	orgID := "org"
+	assert.Equal(t, auth.CSPClientCredentials{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		BaseURL:      "https://csp.example.com",
+		OrgID:        &orgID,
+	}, cfg.Authentication)
+
+	t.Setenv("WAVEFRONT_CSP_CLIENT_SECRET", "")
+	_, err = EnvironmentOptions()
+	assert.EqualError(t, err, "WAVEFRONT_CSP_CLIENT_SECRET is not set")
+
+	t.Setenv("WAVEFRONT_API_TOKEN", "token")
+	_, err = EnvironmentOptions()
+	assert.Error(t, err)
+}
+
+func TestEnvironmentOptions_Invalid(t *testing.T) {
+	t.Setenv("WAVEFRONT_BATCH_SIZE", "lots")
+	_, err := EnvironmentOptions()
+	assert.EqualError(t, err, `invalid WAVEFRONT_BATCH_SIZE=lots: strconv.Atoi: parsing "lots": invalid syntax`)
+
+	t.Setenv("WAVEFRONT_BATCH_SIZE", "")
+	t.Setenv("WAVEFRONT_FLUSH_INTERVAL", "-1s")
+	_, err = EnvironmentOptions()
+	assert.EqualError(t, err, "invalid WAVEFRONT_FLUSH_INTERVAL=-1s: must be positive")
+}
+
+func TestNewFromEnvironment(t *testing.T) {
+	t.Setenv("WAVEFRONT_URL", "")
+	_, err := NewFromEnvironment()
+	assert.EqualError(t, err, "WAVEFRONT_URL is not set")
+
+	t.Setenv("WAVEFRONT_URL", "http://localhost")
+	t.Setenv("WAVEFRONT_SEND_INTERNAL_METRICS", "false")
+	sender, err := NewFromEnvironment(BatchSize(100))
+	require.NoError(t, err)
+	sender.Close()
+}
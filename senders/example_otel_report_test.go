@@ -6,6 +6,9 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+	"github.com/wavefronthq/wavefront-sdk-go/senders/replay"
 )
 
 // Example_otelReport demonstrates how to send Wavefront metrics to an OTel collector's /report endpoint
@@ -22,16 +25,16 @@ func Example_otelReport() {
 	}
 
 	// Join metrics with newlines
-	payload := ""
+	var payload bytes.Buffer
 	for i, metric := range metrics {
 		if i > 0 {
-			payload += "\n"
+			payload.WriteByte('\n')
 		}
-		payload += metric
+		payload.WriteString(metric)
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", otelURL, bytes.NewBufferString(payload))
+	req, err := http.NewRequest("POST", otelURL, &payload)
 	if err != nil {
 		fmt.Printf("Failed to create request: %v\n", err)
 		return
@@ -87,15 +90,15 @@ func Example_otelReportBatched() {
 		}
 
 		batch := allMetrics[i:end]
-		payload := ""
+		var payload bytes.Buffer
 		for j, metric := range batch {
 			if j > 0 {
-				payload += "\n"
+				payload.WriteByte('\n')
 			}
-			payload += metric
+			payload.WriteString(metric)
 		}
 
-		req, err := http.NewRequest("POST", otelURL, bytes.NewBufferString(payload))
+		req, err := http.NewRequest("POST", otelURL, &payload)
 		if err != nil {
 			fmt.Printf("Failed to create request: %v\n", err)
 			continue
@@ -120,75 +123,40 @@ func Example_otelReportBatched() {
 	fmt.Printf("Successfully sent %d metrics in batches of %d\n", totalSent, batchSize)
 }
 
-// Example_otelReportReplay demonstrates replaying metrics multiple times with sleep intervals
+// Example_otelReportReplay demonstrates replaying dump files multiple times with sleep intervals
 func Example_otelReportReplay() {
-	otelURL := "http://localhost:8085/report"
-	tenantID := "16"
-
-	// Configuration for replaying
-	replayCount := 3                // Replay 3 times
-	sleepBetween := 1 * time.Second // 1 second sleep between replays
-	batchSize := 1000
-
-	// Sample metrics
-	metrics := []string{
-		`"cpu.usage" 85.5 1234567890 source="server-01" "env"="prod"`,
-		`"memory.used" 4096 1234567890 source="server-01" "env"="prod"`,
-		`"disk.free" 50000 1234567890 source="server-02" "env"="staging"`,
+	target := replay.NewHTTPTarget("http://localhost:8085/report", replay.Header("dx_tenant_id", "16"))
+	r := replay.New(target,
+		replay.ReplayCount(3),              // Replay 3 times
+		replay.SleepBetween(1*time.Second), // 1 second sleep between replays
+		replay.BatchSize(1000),
+		replay.RewriteTimestamps(), // Send the metrics as of now
+	)
+
+	// Replays all the *.txt.log files of the directory
+	summary, err := r.Replay("./test/wf-dumps/locust-loadgen-0")
+	if err != nil {
+		fmt.Printf("Failed to replay: %v\n", err)
+		return
 	}
 
-	totalSent := 0
-
-	// Replay loop
-	for replay := 1; replay <= replayCount; replay++ {
-		fmt.Printf("Starting replay %d/%d\n", replay, replayCount)
-
-		// Send metrics in batches
-		for i := 0; i < len(metrics); i += batchSize {
-			end := i + batchSize
-			if end > len(metrics) {
-				end = len(metrics)
-			}
-
-			batch := metrics[i:end]
-			payload := ""
-			for j, metric := range batch {
-				if j > 0 {
-					payload += "\n"
-				}
-				payload += metric
-			}
-
-			req, err := http.NewRequest("POST", otelURL, bytes.NewBufferString(payload))
-			if err != nil {
-				fmt.Printf("Failed to create request: %v\n", err)
-				continue
-			}
-
-			req.Header.Set("Content-Type", "application/octet-stream")
-			req.Header.Set("dx_tenant_id", tenantID)
-
-			client := &http.Client{Timeout: 30 * time.Second}
-			resp, err := client.Do(req)
-			if err != nil {
-				fmt.Printf("Failed to send batch: %v\n", err)
-				continue
-			}
+	fmt.Printf("Successfully sent %d metrics in %d batches (%d failed), p99 latency %s\n",
+		summary.Lines, summary.Batches, summary.FailedBatches, summary.P99Latency)
+}
 
-			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted {
-				totalSent += len(batch)
-			}
-			resp.Body.Close()
-		}
+// Example_otelReportSender demonstrates sending metrics to an OTel collector's /report endpoint with a Sender
+func Example_otelReportSender() {
+	sender, err := senders.NewOTelReportSender("http://localhost:8085/report", senders.TenantID("16"))
+	if err != nil {
+		fmt.Printf("Failed to create sender: %v\n", err)
+		return
+	}
+	defer sender.Close()
 
-		fmt.Printf("Completed replay %d/%d\n", replay, replayCount)
+	_ = sender.SendMetric("cpu.usage", 85.5, 0, "server-01", map[string]string{"env": "prod"})
+	_ = sender.SendMetric("memory.used", 4096, 0, "server-01", map[string]string{"env": "prod"})
 
-		// Sleep between replays (except after the last one)
-		if replay < replayCount {
-			fmt.Printf("Sleeping for %v before next replay...\n", sleepBetween)
-			time.Sleep(sleepBetween)
-		}
+	if err := sender.Flush(); err != nil {
+		fmt.Printf("Failed to flush metrics: %v\n", err)
 	}
-
-	fmt.Printf("Successfully sent %d metrics across %d replays\n", totalSent, replayCount)
 }
@@ -0,0 +1,248 @@
+// Package expvarbridge periodically reports the Go runtime metrics of the process, memory, GC and
+// goroutine statistics, and the numeric values published with the expvar package, as metrics
+// through a sender, so that applications do not each have to hand-roll it.
+//
+//	sender, _ := senders.NewSender("http://localhost")
+//	collector := expvarbridge.New(sender, expvarbridge.Tags(map[string]string{"app": "checkout"}))
+//	collector.Start()
+//	defer collector.Stop()
+//
+// Metrics are named after the prefix, "go" by default, e.g. go.goroutines, go.memstats.heap_alloc_bytes,
+// go.gc.count or go.expvar.requests for an expvar.Int named requests. Map variables and JSON objects
+// are flattened, e.g. go.expvar.requests.by_code.200.
+package expvarbridge
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultPrefix   = "go"
+)
+
+// vars already reported as runtime metrics, or that are not metrics.
+var skippedVars = map[string]bool{"memstats": true, "cmdline": true}
+
+// Collector reports runtime metrics and expvar values through a sender on an interval.
+type Collector struct {
+	sender   senders.MetricSender
+	interval time.Duration
+	prefix   string
+	source   string
+	tags     map[string]string
+	runtime  bool
+	expvar   bool
+	vars     map[string]bool
+
+	mtx    sync.Mutex
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// Option configures a Collector.
+type Option func(*Collector)
+
+// Interval sets the time between two collections. Defaults to 1 minute.
+func Interval(interval time.Duration) Option {
+	return func(c *Collector) {
+		c.interval = interval
+	}
+}
+
+// Prefix sets the prefix of the names of the metrics. Defaults to "go".
+func Prefix(prefix string) Option {
+	return func(c *Collector) {
+		c.prefix = prefix
+	}
+}
+
+// Source sets the source of the metrics. Defaults to the default source of the sender.
+func Source(source string) Option {
+	return func(c *Collector) {
+		c.source = source
+	}
+}
+
+// Tags adds tags to all the metrics.
+func Tags(tags map[string]string) Option {
+	return func(c *Collector) {
+		c.tags = tags
+	}
+}
+
+// Vars restricts the reported expvar values to the variables with the given names.
+// By default, all the variables holding numbers are reported.
+func Vars(names ...string) Option {
+	return func(c *Collector) {
+		c.vars = map[string]bool{}
+		for _, name := range names {
+			c.vars[name] = true
+		}
+	}
+}
+
+// WithoutRuntime leaves the runtime metrics out, to only report expvar values.
+func WithoutRuntime() Option {
+	return func(c *Collector) {
+		c.runtime = false
+	}
+}
+
+// WithoutExpvar leaves the expvar values out, to only report runtime metrics.
+func WithoutExpvar() Option {
+	return func(c *Collector) {
+		c.expvar = false
+	}
+}
+
+// New creates a Collector reporting through sender.
+func New(sender senders.MetricSender, setters ...Option) *Collector {
+	c := &Collector{
+		sender:   sender,
+		interval: defaultInterval,
+		prefix:   defaultPrefix,
+		runtime:  true,
+		expvar:   true,
+		stop:     make(chan struct{}),
+	}
+	for _, set := range setters {
+		set(c)
+	}
+	return c
+}
+
+// Start collects the metrics on every interval, in the background, until Stop is called.
+func (c *Collector) Start() {
+	if c.ticker != nil {
+		return
+	}
+	c.ticker = time.NewTicker(c.interval)
+	go func() {
+		for {
+			select {
+			case <-c.ticker.C:
+				if err := c.Collect(); err != nil {
+					log.Printf("expvar bridge: %s\n", err)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the collections started by Start.
+func (c *Collector) Stop() {
+	if c.ticker == nil {
+		return
+	}
+	c.ticker.Stop()
+	c.stop <- struct{}{}
+}
+
+// Collect reads and sends the metrics once. Metrics that fail to be sent do not stop the others.
+func (c *Collector) Collect() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	metrics := map[string]float64{}
+	if c.runtime {
+		collectRuntime(metrics)
+	}
+	if c.expvar {
+		c.collectExpvar(metrics)
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var failed int
+	var firstErr error
+	for _, name := range names {
+		if err := c.sender.SendMetric(c.prefix+"."+name, metrics[name], 0, c.source, c.tags); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to send %d of %d metrics: %s", failed, len(names), firstErr)
+	}
+	return nil
+}
+
+// collectRuntime adds the memory, GC and goroutine statistics of the process to metrics.
+func collectRuntime(metrics map[string]float64) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	metrics["goroutines"] = float64(runtime.NumGoroutine())
+	metrics["cgo_calls"] = float64(runtime.NumCgoCall())
+	metrics["memstats.alloc_bytes"] = float64(ms.Alloc)
+	metrics["memstats.total_alloc_bytes"] = float64(ms.TotalAlloc)
+	metrics["memstats.sys_bytes"] = float64(ms.Sys)
+	metrics["memstats.mallocs"] = float64(ms.Mallocs)
+	metrics["memstats.frees"] = float64(ms.Frees)
+	metrics["memstats.heap_alloc_bytes"] = float64(ms.HeapAlloc)
+	metrics["memstats.heap_sys_bytes"] = float64(ms.HeapSys)
+	metrics["memstats.heap_idle_bytes"] = float64(ms.HeapIdle)
+	metrics["memstats.heap_inuse_bytes"] = float64(ms.HeapInuse)
+	metrics["memstats.heap_released_bytes"] = float64(ms.HeapReleased)
+	metrics["memstats.heap_objects"] = float64(ms.HeapObjects)
+	metrics["memstats.stack_inuse_bytes"] = float64(ms.StackInuse)
+	metrics["memstats.next_gc_bytes"] = float64(ms.NextGC)
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	metrics["gc.count"] = float64(gc.NumGC)
+	metrics["gc.pause_total_seconds"] = gc.PauseTotal.Seconds()
+	if len(gc.Pause) > 0 {
+		metrics["gc.last_pause_seconds"] = gc.Pause[0].Seconds()
+	}
+	metrics["gc.cpu_fraction"] = ms.GCCPUFraction
+}
+
+// collectExpvar adds the numbers of the published expvar variables to metrics.
+func (c *Collector) collectExpvar(metrics map[string]float64) {
+	expvar.Do(func(kv expvar.KeyValue) {
+		if skippedVars[kv.Key] || (c.vars != nil && !c.vars[kv.Key]) {
+			return
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(kv.Value.String()), &value); err != nil {
+			return
+		}
+		flatten(metrics, "expvar."+kv.Key, value)
+	})
+}
+
+// flatten adds the numbers of value, a decoded JSON value, to metrics, named after name and
+// the keys of the objects holding them.
+func flatten(metrics map[string]float64, name string, value interface{}) {
+	switch v := value.(type) {
+	case float64:
+		metrics[name] = v
+	case bool:
+		if v {
+			metrics[name] = 1
+		} else {
+			metrics[name] = 0
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(metrics, name+"."+key, child)
+		}
+	}
+}
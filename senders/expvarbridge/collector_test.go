@@ -0,0 +1,60 @@
+package expvarbridge
+
+import (
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+func init() {
+	expvar.NewInt("expvarbridge_test_requests").Set(42)
+	m := expvar.NewMap("expvarbridge_test_codes")
+	m.Add("200", 3)
+	m.Add("500", 1)
+	expvar.NewString("expvarbridge_test_version").Set("1.2.3")
+}
+
+func metricNames(lines []string) map[string]string {
+	names := map[string]string{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		names[strings.Trim(fields[0], "\"")] = fields[1]
+	}
+	return names
+}
+
+func TestCollect(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	c := New(sender, Prefix("app.go"), Source("host-1"), Tags(map[string]string{"env": "test"}))
+	require.NoError(t, c.Collect())
+
+	lines := sender.Lines()
+	names := metricNames(lines)
+	for _, name := range []string{"app.go.goroutines", "app.go.memstats.heap_alloc_bytes", "app.go.gc.count"} {
+		assert.Contains(t, names, name)
+	}
+	assert.Equal(t, "42", names["app.go.expvar.expvarbridge_test_requests"])
+	assert.Equal(t, "3", names["app.go.expvar.expvarbridge_test_codes.200"])
+	assert.Equal(t, "1", names["app.go.expvar.expvarbridge_test_codes.500"])
+	assert.NotContains(t, names, "app.go.expvar.expvarbridge_test_version")
+	assert.NotContains(t, names, "app.go.expvar.memstats.Alloc")
+	assert.Contains(t, lines[0], "source=\"host-1\" \"env\"=\"test\"")
+}
+
+func TestCollect_Options(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	require.NoError(t, New(sender, WithoutRuntime(), Vars("expvarbridge_test_requests")).Collect())
+	assert.Equal(t, map[string]string{"go.expvar.expvarbridge_test_requests": "42"}, metricNames(sender.Lines()))
+
+	sender.Reset()
+	require.NoError(t, New(sender, WithoutExpvar()).Collect())
+	for name := range metricNames(sender.Lines()) {
+		assert.False(t, strings.HasPrefix(name, "go.expvar."), name)
+	}
+}
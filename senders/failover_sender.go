@@ -0,0 +1,260 @@
+package senders
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/event"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// FailoverSender is a Sender sending to a primary sender, and to a secondary one while the
+// primary is unhealthy, see NewFailoverSender.
+type FailoverSender interface {
+	Sender
+
+	// FailedOver reports whether data is currently sent to the secondary sender.
+	FailedOver() bool
+}
+
+// FailoverOption sets an optional setting of NewFailoverSender.
+type FailoverOption func(*failoverSender)
+
+// FailoverThreshold sets the number of consecutive failed reports of the primary sender after
+// which data is sent to the secondary sender, 3 by default.
+func FailoverThreshold(n int) FailoverOption {
+	return func(fs *failoverSender) {
+		fs.threshold = n
+	}
+}
+
+// FailoverCheckInterval sets how often the health of the primary sender is checked, and probed
+// once failed over, every 10 seconds by default.
+func FailoverCheckInterval(d time.Duration) FailoverOption {
+	return func(fs *failoverSender) {
+		fs.interval = d
+	}
+}
+
+// FailbackProbe sets the probe of the primary sender once failed over: data is sent to the
+// primary again as soon as probe returns nil, e.g. when a health endpoint of the proxy answers.
+// The default probe flushes the primary, whose buffered lines are reported again, and succeeds
+// when the Health of the primary reports no consecutive failures. Primaries dropping their
+// lines rather than buffering them should be given a probe of their own.
+func FailbackProbe(probe func(primary Sender) error) FailoverOption {
+	return func(fs *failoverSender) {
+		fs.probe = probe
+	}
+}
+
+type failoverSender struct {
+	primary   Sender
+	secondary Sender
+	threshold int
+	interval  time.Duration
+	probe     func(Sender) error
+
+	failedOver atomic.Bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewFailoverSender creates a Sender sending to primary, e.g. a sender of the local proxy, and
+// failing over to secondary, e.g. a sender of another proxy or region, once the reports of
+// primary fail FailoverThreshold times in a row, as told by its Health. Once failed over,
+// primary is probed every FailoverCheckInterval and data is sent to it again as soon as the probe
+// succeeds. The lines buffered by primary when it fails over are reported by primary once it
+// recovers.
+// Closing the FailoverSender closes both senders.
+func NewFailoverSender(primary, secondary Sender, setters ...FailoverOption) FailoverSender {
+	fs := &failoverSender{
+		primary:   primary,
+		secondary: secondary,
+		threshold: 3,
+		interval:  10 * time.Second,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	fs.probe = fs.healthProbe
+	for _, setter := range setters {
+		setter(fs)
+	}
+	go fs.monitor()
+	return fs
+}
+
+func (fs *failoverSender) monitor() {
+	defer close(fs.done)
+	ticker := time.NewTicker(fs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fs.check()
+		case <-fs.stop:
+			return
+		}
+	}
+}
+
+// check fails over when the primary is unhealthy, and back once it passes the probe.
+func (fs *failoverSender) check() {
+	if !fs.failedOver.Load() {
+		if health := fs.primary.Health(); health.ConsecutiveFailures >= fs.threshold {
+			log.Printf("%d consecutive reports of the primary sender failed, failing over to the secondary sender: %s\n",
+				health.ConsecutiveFailures, health.LastError)
+			fs.failedOver.Store(true)
+		}
+		return
+	}
+	if err := fs.probe(fs.primary); err != nil {
+		return
+	}
+	log.Println("primary sender healthy again, failing back")
+	fs.failedOver.Store(false)
+}
+
+func (fs *failoverSender) healthProbe(primary Sender) error {
+	_ = primary.Flush()
+	if health := primary.Health(); health.ConsecutiveFailures > 0 {
+		return health.LastError
+	}
+	return nil
+}
+
+func (fs *failoverSender) FailedOver() bool {
+	return fs.failedOver.Load()
+}
+
+// active returns the sender data is sent to.
+func (fs *failoverSender) active() Sender {
+	if fs.failedOver.Load() {
+		return fs.secondary
+	}
+	return fs.primary
+}
+
+func (fs *failoverSender) private() {
+}
+
+func (fs *failoverSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendMetric(name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendIntMetric(name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendBoolMetric(name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendAny(name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	return fs.active().SendDeltaCounter(name, value, source, tags)
+}
+
+func (fs *failoverSender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendDeltaCounterWithTimestamp(name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendDistribution(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendDistribution(name, centroids, hgs, ts, source, tags)
+}
+
+func (fs *failoverSender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	return fs.active().RecordDistribution(name, value, source, tags)
+}
+
+func (fs *failoverSender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	return fs.active().SendDuration(name, d, source, tags)
+}
+
+func (fs *failoverSender) SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return fs.active().SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (fs *failoverSender) SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return fs.active().SendEvent(name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (fs *failoverSender) SendMetrics(points []MetricPoint) error {
+	return fs.active().SendMetrics(points)
+}
+
+func (fs *failoverSender) SendDistributions(distributions []Distribution) error {
+	return fs.active().SendDistributions(distributions)
+}
+
+func (fs *failoverSender) SendSpans(spans []Span) error {
+	return fs.active().SendSpans(spans)
+}
+
+func (fs *failoverSender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendMetricCtx(ctx, name, value, ts, source, tags)
+}
+
+func (fs *failoverSender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	return fs.active().SendDeltaCounterCtx(ctx, name, value, source, tags)
+}
+
+func (fs *failoverSender) SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return fs.active().SendDistributionCtx(ctx, name, centroids, hgs, ts, source, tags)
+}
+
+func (fs *failoverSender) SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return fs.active().SendSpanCtx(ctx, name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (fs *failoverSender) SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return fs.active().SendEventCtx(ctx, name, startMillis, endMillis, source, tags, setters...)
+}
+
+// Flush flushes both senders, the secondary one holding the data sent while failed over.
+func (fs *failoverSender) Flush() error {
+	var errors multiError
+	for _, sender := range []Sender{fs.primary, fs.secondary} {
+		if err := sender.Flush(); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (fs *failoverSender) FlushWithReport() FlushReport {
+	report := fs.primary.FlushWithReport()
+	report.add(fs.secondary.FlushWithReport())
+	return report
+}
+
+// Health reports the health of the sender data is currently sent to.
+func (fs *failoverSender) Health() Health {
+	return fs.active().Health()
+}
+
+func (fs *failoverSender) GetFailureCount() int64 {
+	return fs.primary.GetFailureCount() + fs.secondary.GetFailureCount()
+}
+
+func (fs *failoverSender) Start() {
+	fs.primary.Start()
+	fs.secondary.Start()
+}
+
+// Close closes both senders. It can be called more than once.
+func (fs *failoverSender) Close() {
+	fs.closeOnce.Do(func() {
+		close(fs.stop)
+		<-fs.done
+		fs.primary.Close()
+		fs.secondary.Close()
+	})
+}
@@ -0,0 +1,89 @@
+package senders
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unhealthySender is a primary sender whose health is set by the test.
+type unhealthySender struct {
+	noOpSender
+	mtx     sync.Mutex
+	health  Health
+	flushes int
+}
+
+func (s *unhealthySender) Health() Health {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.health
+}
+
+func (s *unhealthySender) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.flushes++
+	return nil
+}
+
+func (s *unhealthySender) setFailures(n int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.health = Health{ConsecutiveFailures: n}
+	if n > 0 {
+		s.health.LastError = errors.New("connection refused")
+	}
+}
+
+func TestFailoverSender(t *testing.T) {
+	primary := &unhealthySender{}
+	secondary, err := NewValidatingSender()
+	require.NoError(t, err)
+	fs := NewFailoverSender(primary, secondary, FailoverThreshold(2), FailoverCheckInterval(time.Hour)).(*failoverSender)
+	defer fs.Close()
+
+	require.NoError(t, fs.SendMetric("requests", 1, 0, "test", nil))
+	assert.Empty(t, secondary.Lines())
+
+	primary.setFailures(1)
+	fs.check()
+	assert.False(t, fs.FailedOver())
+
+	primary.setFailures(2)
+	fs.check()
+	assert.True(t, fs.FailedOver())
+	assert.Zero(t, fs.Health().ConsecutiveFailures)
+	require.NoError(t, fs.SendMetric("requests", 2, 0, "test", nil))
+	assert.Equal(t, []string{"\"requests\" 2 source=\"test\"\n"}, secondary.Lines())
+
+	// the default probe flushes the primary, and fails back once it reports no failures.
+	fs.check()
+	assert.True(t, fs.FailedOver())
+	primary.setFailures(0)
+	fs.check()
+	assert.False(t, fs.FailedOver())
+	assert.Equal(t, 2, primary.flushes)
+
+	fs.Close()
+	assert.NotPanics(t, fs.Close)
+}
+
+func TestFailoverSender_Probe(t *testing.T) {
+	primary := &unhealthySender{}
+	primary.setFailures(3)
+	secondary := &noOpSender{}
+	probeErr := errors.New("proxy down")
+	fs := NewFailoverSender(primary, secondary, FailoverCheckInterval(10*time.Millisecond),
+		FailbackProbe(func(Sender) error { return probeErr })).(*failoverSender)
+	defer fs.Close()
+
+	assert.Eventually(t, fs.FailedOver, time.Second, 5*time.Millisecond)
+	primary.setFailures(0)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, fs.FailedOver(), "the probe keeps failing")
+}
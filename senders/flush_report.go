@@ -0,0 +1,82 @@
+package senders
+
+import "github.com/wavefronthq/wavefront-sdk-go/internal"
+
+// FlushReport describes the outcome of a FlushWithReport call for each data type.
+type FlushReport struct {
+	Points     FlushResult
+	Histograms FlushResult
+	Spans      FlushResult
+	SpanLogs   FlushResult
+	Events     FlushResult
+}
+
+// FlushResult counts the lines of one data type sent by a FlushWithReport call.
+type FlushResult struct {
+	// Sent is the number of lines accepted by Wavefront.
+	Sent int
+	// Buffered is the number of lines that failed to be sent and were buffered for a later flush.
+	Buffered int
+	// Dropped is the number of lines that failed to be sent and were discarded,
+	// because the buffer was full or the request was rejected for good (e.g. an authentication error).
+	Dropped int
+	// Err holds the errors reporting this data type, nil if all lines were sent.
+	Err error
+}
+
+// Err returns the errors of all data types, nil if all lines were sent.
+func (r FlushReport) Err() error {
+	var errors multiError
+	for _, result := range r.results() {
+		if result.Err != nil {
+			errors.add(result.Err)
+		}
+	}
+	return errors.get()
+}
+
+// Failed reports whether any line failed to be sent.
+func (r FlushReport) Failed() bool {
+	for _, result := range r.results() {
+		if result.Err != nil || result.Buffered > 0 || result.Dropped > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r FlushReport) results() []FlushResult {
+	return []FlushResult{r.Points, r.Histograms, r.Spans, r.SpanLogs, r.Events}
+}
+
+func (r *FlushReport) add(other FlushReport) {
+	r.Points.add(other.Points)
+	r.Histograms.add(other.Histograms)
+	r.Spans.add(other.Spans)
+	r.SpanLogs.add(other.SpanLogs)
+	r.Events.add(other.Events)
+}
+
+func (r *FlushResult) add(other FlushResult) {
+	r.Sent += other.Sent
+	r.Buffered += other.Buffered
+	r.Dropped += other.Dropped
+	if other.Err != nil {
+		var errors multiError
+		if r.Err != nil {
+			errors.add(r.Err)
+		}
+		errors.add(other.Err)
+		r.Err = errors.get()
+	}
+}
+
+func flushResult(handler internal.LineHandler) FlushResult {
+	result := handler.FlushAllWithResult()
+	return FlushResult{
+		Sent:     result.Sent,
+		Buffered: result.Buffered,
+		Dropped:  result.Dropped,
+		Err:      result.Err,
+	}
+}
@@ -0,0 +1,26 @@
+// Package formats formats and parses lines of the Wavefront data format, for tools that read or
+// write lines without going through a Sender, such as the tools handling proxy dump files.
+package formats
+
+import (
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/metric"
+)
+
+// hostname is the source of the lines formatted without one, as for the senders of NewSender.
+var hostname = internal.GetHostname("wavefront_direct_sender")
+
+// AppendMetricLine appends the line of a metric point in the Wavefront data format, newline
+// terminated, to dst and returns the extended slice, like the strconv.Append functions:
+//
+//	buf = buf[:0]
+//	for _, p := range points {
+//		buf, err = formats.AppendMetricLine(buf, p.Name, p.Value, p.Timestamp, p.Source, p.Tags)
+//	}
+//
+// Names and tags are sanitized and quoted as by Sender.SendMetric, and an empty source stands for
+// the local hostname. Formatting does not allocate once dst is large enough, except for the first
+// occurrences of names, sources and tags, which are cached. On error, dst is returned unchanged.
+func AppendMetricLine(dst []byte, name string, value float64, ts int64, source string, tags map[string]string) ([]byte, error) {
+	return metric.AppendLine(dst, name, value, ts, source, tags, hostname)
+}
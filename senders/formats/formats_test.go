@@ -0,0 +1,41 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMetricLine(t *testing.T) {
+	buf := []byte("prefix ")
+	buf, err := AppendMetricLine(buf, "foo metric", 1.5, 1533529977, "web-01", map[string]string{"env": "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "prefix \"foo-metric\" 1.5 1533529977 source=\"web-01\" \"env\"=\"prod\"\n", string(buf))
+
+	buf, err = AppendMetricLine(buf[:0], "foo", 2, 0, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "\"foo\" 2 source=\""+hostname+"\"\n", string(buf))
+
+	buf, err = AppendMetricLine(buf, "foo", 2, 0, "web-01", map[string]string{"env": ""})
+	assert.Error(t, err)
+	assert.Equal(t, "\"foo\" 2 source=\""+hostname+"\"\n", string(buf))
+}
+
+func TestAppendMetricLine_Allocs(t *testing.T) {
+	tags := map[string]string{"env": "prod", "service": "checkout"}
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = AppendMetricLine(buf[:0], "http.requests", 42.5, 1533529977, "web-01", tags)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkAppendMetricLine(b *testing.B) {
+	tags := map[string]string{"env": "prod", "service": "checkout", "region": "us-west-1", "version": "1.4.2"}
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		buf, _ = AppendMetricLine(buf[:0], "http.requests", float64(n), 1533529977, "web-01", tags)
+	}
+}
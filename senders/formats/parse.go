@@ -0,0 +1,288 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	histogramInternal "github.com/wavefronthq/wavefront-sdk-go/internal/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/span"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// MetricPoint is a metric point, as parsed by ParseMetricLine. It can be sent as is with
+// Sender.SendMetrics, or formatted again with AppendMetricLine.
+type MetricPoint = senders.MetricPoint
+
+// SpanTag is a tag of a span, as parsed by ParseSpanLine.
+type SpanTag = senders.SpanTag
+
+// HistogramPoint is a distribution, as parsed by ParseHistogramLine.
+type HistogramPoint struct {
+	Name        string
+	Granularity histogram.Granularity
+	Timestamp   int64
+	Centroids   []histogram.Centroid
+	Source      string
+	Tags        map[string]string
+}
+
+// Span is a span, as parsed by ParseSpanLine.
+type Span struct {
+	Name           string
+	StartMillis    int64
+	DurationMillis int64
+	Source         string
+	TraceID        string
+	SpanID         string
+	Parents        []string
+	FollowsFrom    []string
+	Tags           []SpanTag
+}
+
+// token is a space separated part of a line, either a value or a key=value pair, unquoted.
+type token struct {
+	key    string
+	value  string
+	hasKey bool
+}
+
+// ParseMetricLine parses a line of the Wavefront metrics data format:
+//
+//	<metricName> <metricValue> [<timestamp>] source=<source> [pointTags]
+//
+// Names, sources and tags may be double quoted or not. The source may be given as host=<source>,
+// and is left empty when the line has none. The trailing newline, if any, is ignored.
+func ParseMetricLine(line string) (MetricPoint, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return MetricPoint{}, err
+	}
+	if len(tokens) < 2 || tokens[0].hasKey || tokens[1].hasKey {
+		return MetricPoint{}, fmt.Errorf("invalid metric line, expecting a name and a value: %q", line)
+	}
+	p := MetricPoint{Name: tokens[0].value}
+	if p.Value, err = strconv.ParseFloat(tokens[1].value, 64); err != nil {
+		return MetricPoint{}, fmt.Errorf("invalid metric value %q: %q", tokens[1].value, line)
+	}
+	rest := tokens[2:]
+	if len(rest) > 0 && !rest[0].hasKey {
+		if p.Timestamp, err = parseTimestamp(rest[0].value); err != nil {
+			return MetricPoint{}, fmt.Errorf("%s: %q", err, line)
+		}
+		rest = rest[1:]
+	}
+	if p.Source, p.Tags, err = pointTags(rest); err != nil {
+		return MetricPoint{}, fmt.Errorf("%s: %q", err, line)
+	}
+	return p, nil
+}
+
+// ParseHistogramLine parses a line of the Wavefront histogram data format:
+//
+//	{!M | !H | !D} [<timestamp>] #<count> <mean> [centroids] <histogramName> source=<source> [pointTags]
+//
+// Quoting and sources are handled as by ParseMetricLine.
+func ParseHistogramLine(line string) (HistogramPoint, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return HistogramPoint{}, err
+	}
+	if len(tokens) == 0 {
+		return HistogramPoint{}, errors.New("empty histogram line")
+	}
+	var p HistogramPoint
+	switch tokens[0].value {
+	case "!M":
+		p.Granularity = histogram.MINUTE
+	case "!H":
+		p.Granularity = histogram.HOUR
+	case "!D":
+		p.Granularity = histogram.DAY
+	default:
+		return HistogramPoint{}, fmt.Errorf("invalid histogram granularity %q: %q", tokens[0].value, line)
+	}
+	rest := tokens[1:]
+	if len(rest) > 0 && !rest[0].hasKey && !strings.HasPrefix(rest[0].value, "#") {
+		if p.Timestamp, err = parseTimestamp(rest[0].value); err != nil {
+			return HistogramPoint{}, fmt.Errorf("%s: %q", err, line)
+		}
+		rest = rest[1:]
+	}
+	for len(rest) >= 2 && !rest[0].hasKey && strings.HasPrefix(rest[0].value, "#") {
+		count, err := strconv.Atoi(rest[0].value[1:])
+		if err != nil || count <= 0 {
+			return HistogramPoint{}, fmt.Errorf("invalid centroid count %q: %q", rest[0].value, line)
+		}
+		value, err := strconv.ParseFloat(rest[1].value, 64)
+		if err != nil {
+			return HistogramPoint{}, fmt.Errorf("invalid centroid value %q: %q", rest[1].value, line)
+		}
+		p.Centroids = append(p.Centroids, histogram.Centroid{Value: value, Count: count})
+		rest = rest[2:]
+	}
+	if len(p.Centroids) == 0 {
+		return HistogramPoint{}, fmt.Errorf("invalid histogram line, expecting centroids: %q", line)
+	}
+	if len(rest) == 0 || rest[0].hasKey {
+		return HistogramPoint{}, fmt.Errorf("invalid histogram line, expecting a name: %q", line)
+	}
+	p.Name = rest[0].value
+	if p.Source, p.Tags, err = pointTags(rest[1:]); err != nil {
+		return HistogramPoint{}, fmt.Errorf("%s: %q", err, line)
+	}
+	return p, nil
+}
+
+// ParseSpanLine parses a line of the Wavefront span data format:
+//
+//	<tracingSpanName> source=<source> traceId=<uuid> spanId=<uuid> [parent=<uuid>] [followsFrom=<uuid>] [spanTags] <start_millis> <duration_millis>
+//
+// Span tags are kept in order, including repeated keys and the _spanLogs tag of spans with logs.
+func ParseSpanLine(line string) (Span, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return Span{}, err
+	}
+	if len(tokens) < 3 || tokens[0].hasKey {
+		return Span{}, fmt.Errorf("invalid span line, expecting a name, a start and a duration: %q", line)
+	}
+	s := Span{Name: tokens[0].value}
+	start, duration := tokens[len(tokens)-2], tokens[len(tokens)-1]
+	if start.hasKey || duration.hasKey {
+		return Span{}, fmt.Errorf("invalid span line, expecting a start and a duration: %q", line)
+	}
+	if s.StartMillis, err = parseTimestamp(start.value); err != nil {
+		return Span{}, fmt.Errorf("%s: %q", err, line)
+	}
+	if s.DurationMillis, err = strconv.ParseInt(duration.value, 10, 64); err != nil {
+		return Span{}, fmt.Errorf("invalid span duration %q: %q", duration.value, line)
+	}
+	for _, t := range tokens[1 : len(tokens)-2] {
+		if !t.hasKey {
+			return Span{}, fmt.Errorf("invalid span tag %q: %q", t.value, line)
+		}
+		switch t.key {
+		case "source", "host":
+			s.Source = t.value
+		case "traceId":
+			s.TraceID = t.value
+		case "spanId":
+			s.SpanID = t.value
+		case "parent":
+			s.Parents = append(s.Parents, t.value)
+		case "followsFrom":
+			s.FollowsFrom = append(s.FollowsFrom, t.value)
+		default:
+			s.Tags = append(s.Tags, SpanTag{Key: t.key, Value: t.value})
+		}
+	}
+	if s.TraceID == "" || s.SpanID == "" {
+		return Span{}, fmt.Errorf("invalid span line, expecting a traceId and a spanId: %q", line)
+	}
+	return s, nil
+}
+
+// HistogramLine formats p in the Wavefront histogram data format, as ParseHistogramLine parses it.
+func HistogramLine(p HistogramPoint) (string, error) {
+	return histogramInternal.Line(p.Name, p.Centroids, map[histogram.Granularity]bool{p.Granularity: true},
+		p.Timestamp, p.Source, p.Tags, hostname)
+}
+
+// SpanLine formats s in the Wavefront span data format, as ParseSpanLine parses it.
+func SpanLine(s Span) (string, error) {
+	tags := make([]span.Tag, len(s.Tags))
+	for i, tag := range s.Tags {
+		tags[i] = span.Tag{Key: tag.Key, Value: tag.Value}
+	}
+	return span.Line(s.Name, s.StartMillis, s.DurationMillis, s.Source, s.TraceID, s.SpanID, s.Parents,
+		s.FollowsFrom, tags, nil, hostname)
+}
+
+func parseTimestamp(value string) (int64, error) {
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return ts, nil
+}
+
+// pointTags returns the source and the tags of the key=value tokens of a metric or histogram line.
+func pointTags(tokens []token) (string, map[string]string, error) {
+	var source string
+	var tags map[string]string
+	for _, t := range tokens {
+		if !t.hasKey {
+			return "", nil, fmt.Errorf("invalid tag %q", t.value)
+		}
+		if t.key == "source" || t.key == "host" {
+			source = t.value
+			continue
+		}
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[t.key] = t.value
+	}
+	return source, tags, nil
+}
+
+// tokenize splits line into its space separated tokens, unquoting their keys and values.
+func tokenize(line string) ([]token, error) {
+	line = strings.TrimRight(line, "\r\n")
+	var tokens []token
+	pos := 0
+	for {
+		for pos < len(line) && (line[pos] == ' ' || line[pos] == '\t') {
+			pos++
+		}
+		if pos == len(line) {
+			return tokens, nil
+		}
+		var t token
+		var err error
+		if t.value, pos, err = scan(line, pos, true); err != nil {
+			return nil, err
+		}
+		if pos < len(line) && line[pos] == '=' {
+			t.key, t.hasKey = t.value, true
+			if t.value, pos, err = scan(line, pos+1, false); err != nil {
+				return nil, err
+			}
+		}
+		if pos < len(line) && line[pos] != ' ' && line[pos] != '\t' {
+			return nil, fmt.Errorf("unexpected %q at offset %d: %q", line[pos], pos, line)
+		}
+		tokens = append(tokens, t)
+	}
+}
+
+// scan returns the unquoted key, or value, starting at pos, and the offset following it. Unquoted
+// keys end at the first =. Quoted ones may hold \" for a quote and \n for a line break.
+func scan(line string, pos int, key bool) (string, int, error) {
+	if pos >= len(line) || line[pos] != '"' {
+		end := pos
+		for end < len(line) && line[end] != ' ' && line[end] != '\t' && !(key && line[end] == '=') {
+			end++
+		}
+		return line[pos:end], end, nil
+	}
+	var sb strings.Builder
+	for i := pos + 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			return sb.String(), i + 1, nil
+		case c == '\\' && i+1 < len(line) && line[i+1] == '"':
+			sb.WriteByte('"')
+			i++
+		case c == '\\' && i+1 < len(line) && line[i+1] == 'n':
+			sb.WriteByte('\n')
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote at offset %d: %q", pos, line)
+}
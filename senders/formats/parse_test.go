@@ -0,0 +1,112 @@
+package formats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestParseMetricLine(t *testing.T) {
+	p, err := ParseMetricLine("\"new-york.power.usage\" 42422.5 1533531013 source=\"localhost\" \"datacenter\"=\"dc \\\"1\\\"\" env=prod\n")
+	require.NoError(t, err)
+	assert.Equal(t, MetricPoint{
+		Name:      "new-york.power.usage",
+		Value:     42422.5,
+		Timestamp: 1533531013,
+		Source:    "localhost",
+		Tags:      map[string]string{"datacenter": "dc \"1\"", "env": "prod"},
+	}, p)
+
+	p, err = ParseMetricLine("cpu.idle -1e3 host=web-01 url=/a?b=c")
+	require.NoError(t, err)
+	assert.Equal(t, MetricPoint{Name: "cpu.idle", Value: -1000, Source: "web-01", Tags: map[string]string{"url": "/a?b=c"}}, p)
+
+	for _, line := range []string{
+		"",
+		"cpu.idle",
+		"cpu.idle abc source=a",
+		"cpu.idle 1 abc source=a",
+		"cpu.idle 1 source=a orphan",
+		"\"cpu.idle 1 source=a",
+		"cpu.idle 1 source=\"a\"b",
+	} {
+		_, err := ParseMetricLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestParseMetricLine_RoundTrip(t *testing.T) {
+	tags := map[string]string{"env": "prod", "note": "a \"quoted\"\nvalue"}
+	line, err := AppendMetricLine(nil, "http.requests", 12.25, 1533531013, "web-01", tags)
+	require.NoError(t, err)
+	p, err := ParseMetricLine(string(line))
+	require.NoError(t, err)
+	assert.Equal(t, MetricPoint{Name: "http.requests", Value: 12.25, Timestamp: 1533531013, Source: "web-01", Tags: tags}, p)
+}
+
+func TestParseHistogramLine(t *testing.T) {
+	p, err := ParseHistogramLine("!H 1533531013 #20 30.0 #10 5.1 \"request.latency\" source=\"appServer1\" region=us-west")
+	require.NoError(t, err)
+	assert.Equal(t, HistogramPoint{
+		Name:        "request.latency",
+		Granularity: histogram.HOUR,
+		Timestamp:   1533531013,
+		Centroids:   []histogram.Centroid{{Value: 30, Count: 20}, {Value: 5.1, Count: 10}},
+		Source:      "appServer1",
+		Tags:        map[string]string{"region": "us-west"},
+	}, p)
+
+	line, err := HistogramLine(p)
+	require.NoError(t, err)
+	again, err := ParseHistogramLine(line)
+	require.NoError(t, err)
+	assert.Equal(t, p, again)
+
+	p, err = ParseHistogramLine("!M #1 2 latency source=s")
+	require.NoError(t, err)
+	assert.Zero(t, p.Timestamp)
+
+	for _, line := range []string{"", "!X #1 2 latency", "!M 1533531013 latency source=s", "!M #0 2 latency", "!M #1 x latency", "!M #1 2"} {
+		_, err := ParseHistogramLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestParseSpanLine(t *testing.T) {
+	line := "\"getAllUsers\" source=\"localhost\" traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 " +
+		"spanId=0313bafe-9457-11e8-9eb6-529269fb1459 parent=2f64e538-9457-11e8-9eb6-529269fb1459 " +
+		"\"application\"=\"Wavefront\" \"http.method\"=\"GET\" \"http.method\"=\"POST\" 1533531013 343500\n"
+	s, err := ParseSpanLine(line)
+	require.NoError(t, err)
+	assert.Equal(t, Span{
+		Name:           "getAllUsers",
+		StartMillis:    1533531013,
+		DurationMillis: 343500,
+		Source:         "localhost",
+		TraceID:        "7b3bf470-9456-11e8-9eb6-529269fb1459",
+		SpanID:         "0313bafe-9457-11e8-9eb6-529269fb1459",
+		Parents:        []string{"2f64e538-9457-11e8-9eb6-529269fb1459"},
+		Tags: []SpanTag{
+			{Key: "application", Value: "Wavefront"},
+			{Key: "http.method", Value: "GET"},
+			{Key: "http.method", Value: "POST"},
+		},
+	}, s)
+
+	formatted, err := SpanLine(s)
+	require.NoError(t, err)
+	assert.Equal(t, line, formatted)
+
+	for _, line := range []string{
+		"",
+		"span 1 2",
+		"span source=a traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 1 2",
+		"span source=a traceId=x spanId=y orphan 1 2",
+		"span source=a traceId=x spanId=y 1 x",
+	} {
+		_, err := ParseSpanLine(line)
+		assert.Error(t, err, line)
+	}
+}
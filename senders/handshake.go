@@ -0,0 +1,94 @@
+package senders
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// the line formats declared in handshakes, as named in the X-WF-SDK-Formats header.
+const (
+	formatWavefront    = "wavefront"
+	formatDeltaCounter = "deltaCounter"
+	formatHistogram    = "histogram"
+	formatTrace        = "trace"
+	formatSpanLogs     = "spanLogs"
+	formatEvent        = "event"
+)
+
+var (
+	metricsFormats = []string{formatWavefront, formatDeltaCounter, formatHistogram, formatEvent}
+	tracesFormats  = []string{formatTrace, formatSpanLogs}
+)
+
+var errDeltaCountersUnsupported = errors.New("delta counters are not supported by the endpoint")
+
+// Handshake makes NewSender ask the endpoint which line formats it accepts before sending anything,
+// declaring those of the SDK in an OPTIONS request to the report path. Endpoints listing the formats
+// they accept in the X-WF-Formats header of the response, e.g. older proxies without span logs, get
+// only those: distributions, spans and events of the other formats are discarded and counted as if
+// disabled, span logs are dropped from their span, and delta counters return an error.
+//
+// Endpoints that do not answer, or do not advertise formats, are assumed to accept them all.
+// Handshakes are made over HTTP only, and not again when the sender is reconfigured.
+func Handshake() Option {
+	return func(cfg *configuration) {
+		cfg.Handshake = true
+	}
+}
+
+// handshake restricts the sender to the formats accepted by the endpoints of its reporters.
+func (sender *realSender) handshake(metricsReporter, tracesReporter internal.Reporter) {
+	accepted := map[string]bool{}
+	for _, format := range append(append([]string{}, metricsFormats...), tracesFormats...) {
+		accepted[format] = true
+	}
+	restrict := func(reporter internal.Reporter, formats []string) {
+		handshaker, ok := reporter.(internal.Handshaker)
+		if !ok {
+			return
+		}
+		advertised, err := handshaker.Handshake(formats)
+		if err != nil {
+			log.Printf("handshake failed, assuming every format is accepted: %s\n", err)
+			return
+		}
+		if advertised == nil {
+			return
+		}
+		supported := map[string]bool{}
+		for _, format := range advertised {
+			supported[format] = true
+		}
+		for _, format := range formats {
+			accepted[format] = accepted[format] && supported[format]
+		}
+	}
+	restrict(metricsReporter, metricsFormats)
+	restrict(tracesReporter, tracesFormats)
+
+	var unsupported []string
+	for _, format := range append(append([]string{}, metricsFormats...), tracesFormats...) {
+		if !accepted[format] {
+			unsupported = append(unsupported, format)
+		}
+	}
+	if len(unsupported) == 0 {
+		return
+	}
+	log.Printf("endpoint does not accept the formats: %s\n", strings.Join(unsupported, ", "))
+
+	if !accepted[formatHistogram] && sender.disabledDistributions == nil {
+		sender.disabledDistributions = sender.internalRegistry.NewDeltaCounter(InternalMetricHistogramsDisabled)
+	}
+	if !accepted[formatTrace] && sender.disabledSpans == nil {
+		sender.disabledSpans = sender.internalRegistry.NewDeltaCounter(InternalMetricSpansDisabled)
+	}
+	if !accepted[formatEvent] && sender.disabledEvents == nil {
+		sender.disabledEvents = sender.internalRegistry.NewDeltaCounter(InternalMetricEventsDisabled)
+	}
+	sender.noSpanLogs = !accepted[formatSpanLogs]
+	sender.noDeltaCounters = !accepted[formatDeltaCounter]
+}
@@ -0,0 +1,71 @@
+package senders
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestHandshake(t *testing.T) {
+	var mtx sync.Mutex
+	var handshakes int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if r.Method == http.MethodOptions {
+			handshakes++
+			w.Header().Set("X-WF-Formats", "wavefront,trace")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.URL.Query().Get("f")+": "+string(body))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, Handshake(), Compression(false), SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	assert.Equal(t, 2, handshakes)
+
+	require.NoError(t, sender.SendMetric("my.metric", 1, 0, "localhost", nil))
+	assert.ErrorIs(t, sender.SendDeltaCounter("my.counter", 1, "localhost", nil), errDeltaCountersUnsupported)
+	require.NoError(t, sender.SendDistribution("my.distribution", []histogram.Centroid{{Value: 1, Count: 2}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "localhost", nil))
+	require.NoError(t, sender.SendSpan("my.span", 0, 10, "localhost",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459",
+		nil, nil, nil, []SpanLog{{Timestamp: 1, Fields: map[string]string{"event": "error"}}}))
+	require.NoError(t, sender.Flush())
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[0], "wavefront: \"my.metric\" 1")
+	assert.Contains(t, bodies[1], "trace: \"my.span\"")
+	assert.NotContains(t, bodies[1], "_spanLogs")
+
+	real := sender.(*realSender)
+	assert.NotNil(t, real.disabledDistributions)
+	assert.NotNil(t, real.disabledEvents)
+	assert.Nil(t, real.disabledSpans)
+}
+
+func TestHandshake_NotAdvertised(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, Handshake(), SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	real := sender.(*realSender)
+	assert.False(t, real.noSpanLogs)
+	assert.False(t, real.noDeltaCounters)
+	assert.Nil(t, real.disabledDistributions)
+	assert.NoError(t, sender.SendDeltaCounter("my.counter", 1, "localhost", nil))
+}
@@ -0,0 +1,67 @@
+package senders
+
+import (
+	"time"
+)
+
+// Health is the status of the reports of a sender, see HealthReporter.
+type Health struct {
+	// LastSuccess is the time of the last successful report, zero until the first one.
+	LastSuccess time.Time
+	// ConsecutiveFailures is the number of reports that failed since the last successful one.
+	ConsecutiveFailures int
+	// LastError is the error of the last failed report, nil once a report succeeds.
+	LastError error
+	// Connected reports whether the connections of a sender created with a tcp:// URL are open,
+	// and is always true for other senders.
+	Connected bool
+}
+
+// HealthReporter Interface for checking whether the data of a sender is getting through, e.g.
+// in a readiness probe:
+//
+//	health := sender.Health()
+//	if health.ConsecutiveFailures > 3 {
+//		return fmt.Errorf("wavefront unreachable since %s: %s", health.LastSuccess, health.LastError)
+//	}
+//
+// Senders of several senders report the health of their least healthy sender, but a
+// FailoverSender, which reports the health of the sender it currently sends to.
+type HealthReporter interface {
+	Health() Health
+}
+
+// connectionChecker is implemented by the transports holding connections, such as tcp://.
+type connectionChecker interface {
+	Connected() bool
+}
+
+func (sender *realSender) Health() Health {
+	health := sender.lifecycle.health()
+	health.Connected = true
+	sender.endpointMtx.Lock()
+	checker, ok := sender.endpoint.transport.(connectionChecker)
+	sender.endpointMtx.Unlock()
+	if ok {
+		health.Connected = checker.Connected()
+	}
+	return health
+}
+
+// leastHealthy returns the health of the least healthy of senders: the oldest LastSuccess, and
+// the most ConsecutiveFailures along with their LastError.
+func leastHealthy(senders []Sender) Health {
+	combined := Health{Connected: true}
+	for i, sender := range senders {
+		health := sender.Health()
+		if i == 0 || health.LastSuccess.Before(combined.LastSuccess) {
+			combined.LastSuccess = health.LastSuccess
+		}
+		if health.ConsecutiveFailures > combined.ConsecutiveFailures {
+			combined.ConsecutiveFailures = health.ConsecutiveFailures
+			combined.LastError = health.LastError
+		}
+		combined.Connected = combined.Connected && health.Connected
+	}
+	return combined
+}
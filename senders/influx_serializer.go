@@ -0,0 +1,164 @@
+package senders
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// InfluxSerializer returns the serializer of the InfluxDB line protocol:
+//
+//	<name>,source=<source>[,<tag>=<value>...] value=<value> [<timestamp>]
+//
+// Sources and point tags become tags, and timestamps are converted to nanoseconds.
+// Distributions are summarized in count, sum, min, max and mean fields, whatever their
+// granularities. Spans have start_millis and duration_millis fields, with their ids in tags.
+// Span logs are left out.
+func InfluxSerializer() LineSerializer {
+	return influxSerializer{}
+}
+
+type influxSerializer struct{}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", " ")
+var influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", " ")
+
+func (influxSerializer) MetricLine(name string, value float64, ts int64, source string, tags map[string]string) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("empty metric name")
+	}
+	sb := internal.GetBuffer()
+	defer internal.PutBuffer(sb)
+	if err := writeInfluxSeries(sb, name, source, tags); err != nil {
+		return nil, err
+	}
+	sb.WriteString(" value=")
+	if err := writeInfluxFloat(sb, value); err != nil {
+		return nil, fmt.Errorf("metric %s: %s", name, err)
+	}
+	writeInfluxTimestamp(sb, unixNano(ts))
+	return []byte(sb.String()), nil
+}
+
+func (influxSerializer) DistributionLine(name string, centroids []histogram.Centroid, _ map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("empty distribution name")
+	}
+	if len(centroids) == 0 {
+		return nil, fmt.Errorf("distribution should have at least one centroid: histogram=%s", name)
+	}
+	var count int
+	var sum float64
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, centroid := range centroids {
+		count += centroid.Count
+		sum += centroid.Value * float64(centroid.Count)
+		min = math.Min(min, centroid.Value)
+		max = math.Max(max, centroid.Value)
+	}
+
+	sb := internal.GetBuffer()
+	defer internal.PutBuffer(sb)
+	if err := writeInfluxSeries(sb, name, source, tags); err != nil {
+		return nil, err
+	}
+	sb.WriteString(" count=")
+	sb.WriteString(strconv.Itoa(count))
+	sb.WriteString("i")
+	for _, field := range []struct {
+		key   string
+		value float64
+	}{{"sum", sum}, {"min", min}, {"max", max}, {"mean", sum / float64(count)}} {
+		sb.WriteString(",")
+		sb.WriteString(field.key)
+		sb.WriteString("=")
+		if err := writeInfluxFloat(sb, field.value); err != nil {
+			return nil, fmt.Errorf("distribution %s: %s", name, err)
+		}
+	}
+	writeInfluxTimestamp(sb, unixNano(ts))
+	return []byte(sb.String()), nil
+}
+
+func (influxSerializer) SpanLine(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, _ []SpanLog) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("span name cannot be empty")
+	}
+	tagMap := make(map[string]string, len(tags)+4)
+	for _, tag := range tags {
+		if tag.Key == "" {
+			return nil, fmt.Errorf("tag keys cannot be empty: span=%s", name)
+		}
+		tagMap[tag.Key] = tag.Value
+	}
+	tagMap["traceId"] = traceID
+	tagMap["spanId"] = spanID
+	if len(parents) > 0 {
+		tagMap["parent"] = strings.Join(parents, ",")
+	}
+	if len(followsFrom) > 0 {
+		tagMap["followsFrom"] = strings.Join(followsFrom, ",")
+	}
+
+	sb := internal.GetBuffer()
+	defer internal.PutBuffer(sb)
+	if err := writeInfluxSeries(sb, name, source, tagMap); err != nil {
+		return nil, err
+	}
+	sb.WriteString(" start_millis=")
+	sb.WriteString(strconv.FormatInt(startMillis, 10))
+	sb.WriteString("i,duration_millis=")
+	sb.WriteString(strconv.FormatInt(durationMillis, 10))
+	sb.WriteString("i")
+	writeInfluxTimestamp(sb, startMillis*int64(time.Millisecond))
+	return []byte(sb.String()), nil
+}
+
+// writeInfluxSeries writes the measurement and tags of a line, tags sorted by key as InfluxDB recommends.
+func writeInfluxSeries(sb *bytes.Buffer, name string, source string, tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v == "" {
+			return fmt.Errorf("tag values cannot be empty: metric=%s tag=%s", name, k)
+		}
+		if k != "source" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	sb.WriteString(influxMeasurementEscaper.Replace(name))
+	sb.WriteString(",source=")
+	sb.WriteString(influxEscaper.Replace(source))
+	for _, k := range keys {
+		sb.WriteString(",")
+		sb.WriteString(influxEscaper.Replace(k))
+		sb.WriteString("=")
+		sb.WriteString(influxEscaper.Replace(tags[k]))
+	}
+	return nil
+}
+
+func writeInfluxFloat(sb *bytes.Buffer, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("value %v is not supported by the InfluxDB line protocol", value)
+	}
+	sb.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	return nil
+}
+
+func writeInfluxTimestamp(sb *bytes.Buffer, nanos int64) {
+	if nanos != 0 {
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatInt(nanos, 10))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,217 @@
+package instrument
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// Gauge is the current value of something, e.g. the depth of a queue, sent as a metric.
+type Gauge[T Number] struct {
+	name  string
+	tags  map[string]string
+	value atomic.Uint64
+}
+
+// NewGauge creates a Gauge reported by registry.
+func NewGauge[T Number](registry *Registry, name string) *Gauge[T] {
+	return newGauge[T](registry, name, nil)
+}
+
+func newGauge[T Number](registry *Registry, name string, tags map[string]string) *Gauge[T] {
+	g := &Gauge[T]{name: registry.name(name), tags: registry.tagsWith(tags)}
+	registry.add(g)
+	return g
+}
+
+// Set sets the value of the gauge.
+func (g *Gauge[T]) Set(value T) {
+	g.value.Store(math.Float64bits(float64(value)))
+}
+
+// Add adds delta, which may be negative, to the value of the gauge.
+func (g *Gauge[T]) Add(delta T) {
+	addFloat(&g.value, float64(delta))
+}
+
+// Value returns the value of the gauge.
+func (g *Gauge[T]) Value() T {
+	return T(math.Float64frombits(g.value.Load()))
+}
+
+func (g *Gauge[T]) report(sender senders.MetricSender, source string) error {
+	return sender.SendMetric(g.name, math.Float64frombits(g.value.Load()), 0, source, g.tags)
+}
+
+// Counter counts occurrences of something, e.g. requests, sent as a delta counter of the
+// increments since the previous report.
+type Counter[T Number] struct {
+	name    string
+	tags    map[string]string
+	pending atomic.Uint64
+}
+
+// NewCounter creates a Counter reported by registry.
+func NewCounter[T Number](registry *Registry, name string) *Counter[T] {
+	return newCounter[T](registry, name, nil)
+}
+
+func newCounter[T Number](registry *Registry, name string, tags map[string]string) *Counter[T] {
+	c := &Counter[T]{name: registry.name(name), tags: registry.tagsWith(tags)}
+	registry.add(c)
+	return c
+}
+
+// Inc increments the counter by one.
+func (c *Counter[T]) Inc() {
+	addFloat(&c.pending, 1)
+}
+
+// Add increments the counter by delta. Negative deltas are ignored.
+func (c *Counter[T]) Add(delta T) {
+	if delta > 0 {
+		addFloat(&c.pending, float64(delta))
+	}
+}
+
+// report sends the increments since the previous report, which are kept for the next one if
+// they fail to be sent.
+func (c *Counter[T]) report(sender senders.MetricSender, source string) error {
+	delta := math.Float64frombits(c.pending.Swap(0))
+	if delta == 0 {
+		return nil
+	}
+	if err := sender.SendDeltaCounter(c.name, delta, source, c.tags); err != nil {
+		addFloat(&c.pending, delta)
+		return err
+	}
+	return nil
+}
+
+func addFloat(u *atomic.Uint64, delta float64) {
+	for {
+		old := u.Load()
+		if u.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// GaugeVec is a family of gauges with the same name, one per value of their tags L: a struct whose
+// exported fields are strings, keyed by their `tag:"key"` struct tag or else their name. Fields tagged
+// `tag:"-"` are not tags.
+type GaugeVec[T Number, L comparable] struct {
+	vec vec[L, *Gauge[T]]
+}
+
+// NewGaugeVec creates a GaugeVec reported by registry. It panics if L is not a struct of string fields.
+func NewGaugeVec[T Number, L comparable](registry *Registry, name string) *GaugeVec[T, L] {
+	return &GaugeVec[T, L]{newVec[L](registry, name, newGauge[T])}
+}
+
+// With returns the gauge tagged with labels, created on first use.
+func (v *GaugeVec[T, L]) With(labels L) *Gauge[T] {
+	return v.vec.with(labels)
+}
+
+// CounterVec is a family of counters with the same name, one per value of their tags L, a struct
+// as for GaugeVec.
+type CounterVec[T Number, L comparable] struct {
+	vec vec[L, *Counter[T]]
+}
+
+// NewCounterVec creates a CounterVec reported by registry. It panics if L is not a struct of string fields.
+func NewCounterVec[T Number, L comparable](registry *Registry, name string) *CounterVec[T, L] {
+	return &CounterVec[T, L]{newVec[L](registry, name, newCounter[T])}
+}
+
+// With returns the counter tagged with labels, created on first use.
+func (v *CounterVec[T, L]) With(labels L) *Counter[T] {
+	return v.vec.with(labels)
+}
+
+// vec holds the series S of a vector by their tags L, created on first use.
+type vec[L comparable, S any] struct {
+	registry *Registry
+	name     string
+	fields   []tagField
+	create   func(registry *Registry, name string, tags map[string]string) S
+
+	mtx    sync.RWMutex
+	series map[L]S
+}
+
+func newVec[L comparable, S any](registry *Registry, name string, create func(*Registry, string, map[string]string) S) vec[L, S] {
+	return vec[L, S]{
+		registry: registry,
+		name:     name,
+		fields:   tagFieldsOf(reflect.TypeOf((*L)(nil)).Elem()),
+		create:   create,
+		series:   map[L]S{},
+	}
+}
+
+// with returns the series tagged with labels.
+func (v *vec[L, S]) with(labels L) S {
+	v.mtx.RLock()
+	s, ok := v.series[labels]
+	v.mtx.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	if s, ok := v.series[labels]; ok {
+		return s
+	}
+	s = v.create(v.registry, v.name, tagsOf(v.fields, reflect.ValueOf(labels)))
+	v.series[labels] = s
+	return s
+}
+
+// tagField is a field of a tags struct.
+type tagField struct {
+	index int
+	key   string
+}
+
+// tagFieldsOf returns the tag fields of the tags struct t: its exported fields, which must be
+// strings, keyed by their `tag:"key"` struct tag or else their name. Fields tagged `tag:"-"` are
+// skipped. It panics if t is not such a struct, a programming error.
+func tagFieldsOf(t reflect.Type) []tagField {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("instrument: tags must be a struct of string fields, not %s", t))
+	}
+	var fields []tagField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := f.Tag.Get("tag")
+		if !f.IsExported() || key == "-" {
+			continue
+		}
+		if f.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("instrument: tags must be a struct of string fields, %s.%s is %s", t, f.Name, f.Type))
+		}
+		if key == "" {
+			key = f.Name
+		}
+		fields = append(fields, tagField{index: i, key: key})
+	}
+	return fields
+}
+
+// tagsOf returns the tags of the tags struct v. Empty values, which Wavefront rejects, are left out.
+func tagsOf(fields []tagField, v reflect.Value) map[string]string {
+	tags := make(map[string]string, len(fields))
+	for _, f := range fields {
+		if value := v.Field(f.index).String(); value != "" {
+			tags[f.key] = value
+		}
+	}
+	return tags
+}
@@ -0,0 +1,98 @@
+package instrument
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+type route struct {
+	Method   string `tag:"method"`
+	Status   string `tag:"status"`
+	Internal string `tag:"-"`
+	Region   string
+}
+
+func TestRegistry(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	registry := NewRegistry(sender, Prefix("app"), Source("host"), Tags(map[string]string{"env": "prod"}))
+
+	depth := NewGauge[int64](registry, "queue.depth")
+	depth.Set(40)
+	depth.Add(2)
+	assert.Equal(t, int64(42), depth.Value())
+	requests := NewCounterVec[int, route](registry, "requests")
+	requests.With(route{Method: "GET", Status: "200", Internal: "x"}).Inc()
+	requests.With(route{Method: "GET", Status: "200", Internal: "x"}).Add(2)
+	assert.Same(t, requests.With(route{Method: "GET", Status: "200", Internal: "x"}),
+		requests.With(route{Method: "GET", Status: "200", Internal: "x"}))
+
+	require.NoError(t, registry.Report())
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"app.queue.depth" 42 source="host"`)
+	assert.Contains(t, lines[0], `"env"="prod"`)
+	assert.Contains(t, lines[1], `"∆app.requests" 3 source="host"`)
+	assert.Contains(t, lines[1], `"method"="GET"`)
+	assert.Contains(t, lines[1], `"status"="200"`)
+	assert.NotContains(t, lines[1], "Internal")
+	assert.NotContains(t, lines[1], "Region")
+
+	// counters only send their increments.
+	sender.Reset()
+	require.NoError(t, registry.Report())
+	assert.Len(t, sender.Lines(), 1)
+}
+
+func TestGaugeVec(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	registry := NewRegistry(sender)
+	temperatures := NewGaugeVec[float64, route](registry, "temperature")
+	temperatures.With(route{Region: "us"}).Set(21.5)
+	temperatures.With(route{Region: "eu"}).Set(19)
+
+	require.NoError(t, registry.Report())
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"temperature" 21.5`)
+	assert.Contains(t, lines[0], `"Region"="us"`)
+	assert.Contains(t, lines[1], `"Region"="eu"`)
+}
+
+func TestCounterKeptOnError(t *testing.T) {
+	sender := &failingSender{}
+	registry := NewRegistry(sender)
+	counter := NewCounter[uint32](registry, "errors")
+	counter.Add(2)
+
+	sender.err = errors.New("unavailable")
+	assert.EqualError(t, registry.Report(), "unable to send 1 of 1 series: unavailable")
+	sender.err = nil
+	require.NoError(t, registry.Report())
+	assert.Equal(t, []float64{2}, sender.deltas)
+}
+
+func TestInvalidTags(t *testing.T) {
+	registry := NewRegistry(&failingSender{})
+	assert.Panics(t, func() { NewCounterVec[int, string](registry, "c") })
+	assert.Panics(t, func() { NewCounterVec[int, struct{ Code int }](registry, "c") })
+}
+
+type failingSender struct {
+	senders.MetricSender
+	err    error
+	deltas []float64
+}
+
+func (s *failingSender) SendDeltaCounter(_ string, value float64, _ string, _ map[string]string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.deltas = append(s.deltas, value)
+	return nil
+}
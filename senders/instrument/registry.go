@@ -0,0 +1,184 @@
+// Package instrument provides typed gauges and counters, reported through a sender on an interval.
+// Values are typed with generics, and the tags of gauge and counter vectors are Go structs, so that
+// a wrong value type, a misspelled tag key or a missing tag is a compile error rather than a broken
+// series, and the tags of each series are built once instead of on every update.
+//
+//	registry := instrument.NewRegistry(sender, instrument.Prefix("checkout"))
+//	registry.Start()
+//	defer registry.Stop()
+//
+//	depth := instrument.NewGauge[int64](registry, "queue.depth")
+//	depth.Set(42)
+//
+//	type route struct {
+//		Method string `tag:"method"`
+//		Status string `tag:"status"`
+//	}
+//	requests := instrument.NewCounterVec[int64, route](registry, "requests")
+//	requests.With(route{Method: "GET", Status: "200"}).Inc()
+//
+// Gauges are sent as metrics, counters as delta counters of the increments since the previous report.
+package instrument
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+const defaultInterval = time.Minute
+
+// Number is the constraint of the values of gauges and counters.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// series is a gauge or a counter, with its name and tags.
+type series interface {
+	report(sender senders.MetricSender, source string) error
+}
+
+// Registry reports the gauges and counters created with it through a sender, on an interval.
+type Registry struct {
+	sender   senders.MetricSender
+	interval time.Duration
+	prefix   string
+	source   string
+	tags     map[string]string
+
+	mtx    sync.Mutex
+	series []series
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// Interval sets the time between two reports. Defaults to 1 minute.
+func Interval(interval time.Duration) Option {
+	return func(r *Registry) {
+		r.interval = interval
+	}
+}
+
+// Prefix is prepended, with a dot, to the names of the gauges and counters.
+func Prefix(prefix string) Option {
+	return func(r *Registry) {
+		r.prefix = prefix
+	}
+}
+
+// Source sets the source of the metrics. Defaults to the default source of the sender.
+func Source(source string) Option {
+	return func(r *Registry) {
+		r.source = source
+	}
+}
+
+// Tags adds tags to all the metrics. Tags of vectors take precedence.
+func Tags(tags map[string]string) Option {
+	return func(r *Registry) {
+		r.tags = tags
+	}
+}
+
+// NewRegistry creates a Registry reporting through sender.
+func NewRegistry(sender senders.MetricSender, setters ...Option) *Registry {
+	r := &Registry{
+		sender:   sender,
+		interval: defaultInterval,
+		stop:     make(chan struct{}),
+	}
+	for _, set := range setters {
+		set(r)
+	}
+	return r
+}
+
+// Start reports the gauges and counters on every interval, in the background, until Stop is called.
+func (r *Registry) Start() {
+	if r.ticker != nil {
+		return
+	}
+	r.ticker = time.NewTicker(r.interval)
+	go func() {
+		for {
+			select {
+			case <-r.ticker.C:
+				if err := r.Report(); err != nil {
+					log.Printf("instrument registry: %s\n", err)
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the reports started by Start, after a last report.
+func (r *Registry) Stop() {
+	if r.ticker == nil {
+		return
+	}
+	r.ticker.Stop()
+	r.stop <- struct{}{}
+	if err := r.Report(); err != nil {
+		log.Printf("instrument registry: %s\n", err)
+	}
+}
+
+// Report sends the gauges and counters once. Series that fail to be sent do not stop the others.
+func (r *Registry) Report() error {
+	r.mtx.Lock()
+	all := append([]series(nil), r.series...)
+	r.mtx.Unlock()
+
+	var failed int
+	var firstErr error
+	for _, s := range all {
+		if err := s.report(r.sender, r.source); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to send %d of %d series: %s", failed, len(all), firstErr)
+	}
+	return nil
+}
+
+func (r *Registry) add(s series) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.series = append(r.series, s)
+}
+
+func (r *Registry) name(name string) string {
+	if r.prefix == "" {
+		return name
+	}
+	return r.prefix + "." + name
+}
+
+// tagsWith returns the tags of the registry overridden by tags.
+func (r *Registry) tagsWith(tags map[string]string) map[string]string {
+	if len(r.tags) == 0 {
+		return tags
+	}
+	merged := make(map[string]string, len(r.tags)+len(tags))
+	for k, v := range r.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return merged
+}
@@ -1,11 +1,30 @@
 package senders
 
 import (
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/sdkmetrics"
 )
 
 func TestEndToEnd(t *testing.T) {
@@ -37,6 +56,273 @@ func TestEndToEndWithPath(t *testing.T) {
 	assert.Equal(t, "/test-path/report?f=wavefront", testServer.RequestURLs[0])
 }
 
+func TestEndToEndWithReportEndpointOptions(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	sender, err := NewSender(testServer.URL+"/test-path",
+		ReportPath("/v1/ingest"),
+		ReportQueryParam("source", "sdk"),
+		ReportQueryTemplate("lines", "{{.Lines}}"),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendMetric("my metric", 21, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, 2, len(testServer.MetricLines))
+	assert.Equal(t, "/test-path/v1/ingest?f=wavefront&lines=2&source=sdk", testServer.RequestURLs[0])
+
+	_, err = NewSender(testServer.URL, ReportQueryTemplate("lines", "{{.Lines"))
+	assert.Error(t, err)
+}
+
+func TestEndToEndWithBatchMetadataHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, BatchMetadataHeaders(), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 1533531013, "localhost", nil))
+	require.NoError(t, sender.SendMetric("my metric", 21, 1533531073, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	header := <-headers
+	assert.Equal(t, "2", header.Get("X-WF-Line-Count"))
+	assert.Equal(t, "1533531013", header.Get("X-WF-TS-Min"))
+	assert.Equal(t, "1533531073", header.Get("X-WF-TS-Max"))
+}
+
+func TestEndToEndWithPayloadDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Digest"), "SHA-256=") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Query().Get("f") == "histogram" {
+			w.Header().Set("Want-Digest", "SHA-512")
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, PayloadDigest(), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendDistribution("my distribution", []histogram.Centroid{{Value: 1, Count: 2}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "localhost", nil))
+
+	report := sender.FlushWithReport()
+	assert.NoError(t, report.Points.Err)
+	assert.Equal(t, 1, report.Points.Sent)
+	var digestErr *DigestError
+	require.True(t, errors.As(report.Err(), &digestErr))
+	assert.Equal(t, "SHA-512", digestErr.WantDigest)
+	assert.Equal(t, 1, report.Histograms.Buffered)
+}
+
+func TestEndToEndFlushWithReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("f") == "histogram" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, BatchSize(2), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendMetric("my metric", 21, 0, "localhost", nil))
+	require.NoError(t, sender.SendMetric("my metric", 22, 0, "localhost", nil))
+	require.NoError(t, sender.SendDistribution("my dist", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "localhost", nil))
+
+	report := sender.FlushWithReport()
+	assert.Equal(t, FlushResult{Sent: 3}, report.Points)
+	assert.Equal(t, 1, report.Histograms.Buffered)
+	assert.Error(t, report.Histograms.Err)
+	assert.Equal(t, FlushResult{}, report.Spans)
+	assert.True(t, report.Failed())
+	assert.Error(t, report.Err())
+
+	multi := NewMultiSender(sender, sender).FlushWithReport()
+	assert.Equal(t, 2, multi.Histograms.Buffered)
+	assert.False(t, NewMultiSender().FlushWithReport().Failed())
+}
+
+type fakeZstd struct{}
+
+func (fakeZstd) Encoding() string {
+	return "zstd"
+}
+
+func (fakeZstd) Compress(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func TestEndToEndWithCompressors(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") == "zstd" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		testServer.ReportEndpoint(w, r)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, Compressors(fakeZstd{}, compression.GzipLevel(gzip.BestSpeed)),
+		SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	require.NoError(t, sender.SendMetric("my metric", 21, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, []string{"zstd", "gzip", "gzip"}, encodings)
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\"", "\"my-metric\" 21 source=\"localhost\""},
+		testServer.MetricLines)
+}
+
+func TestEndToEndWithTenantID(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	sender, err := NewSender(testServer.URL, TenantID("16"))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendEvent("dramatic event", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, []string{"16", "16"}, testServer.TenantHeaders)
+}
+
+func TestEndToEndIPv6(t *testing.T) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %s", err)
+	}
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	ipv6Server := httptest.NewUnstartedServer(testServer.httpServer.Config.Handler)
+	_ = ipv6Server.Listener.Close()
+	ipv6Server.Listener = listener
+	ipv6Server.Start()
+	defer ipv6Server.Close()
+
+	sender, err := NewSender(ipv6Server.URL, DualStackFallbackDelay(50*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\""}, testServer.MetricLines)
+}
+
+func TestEndToEndWithPersistentBuffer(t *testing.T) {
+	dir := t.TempDir()
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	sender, err := NewSender(unavailable.URL, PersistentBuffer(dir, 0), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.Error(t, sender.Flush())
+	sender.Close()
+
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	sender, err = NewSender(testServer.URL, PersistentBuffer(dir, 0), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.Flush())
+	sender.Close()
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\""}, testServer.MetricLines)
+}
+
+func TestMutualTLSEndToEnd(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := writeClientCert(t, dir)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	testServer := startMutualTLSTestServer(clientCAs)
+	defer testServer.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", testServer.httpServer.Certificate().Raw)
+
+	sender, err := NewSender(testServer.URL, TLSClientCert(certFile, keyFile), CACert(caFile), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\""}, testServer.MetricLines)
+
+	sender, err = NewSender(testServer.URL, CACert(caFile), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	assert.Error(t, sender.Flush())
+	sender.Close()
+
+	_, err = NewSender(testServer.URL, TLSClientCert(filepath.Join(dir, "missing.pem"), keyFile))
+	assert.ErrorContains(t, err, "unable to load TLS client certificate")
+	_, err = NewSender(testServer.URL, CACert(keyFile))
+	assert.ErrorContains(t, err, "no PEM encoded CA certificate found")
+}
+
+// writeClientCert writes a self-signed client certificate and its key to PEM files in dir.
+func writeClientCert(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sender"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return cert, certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
+
+func TestEndToEndAuditJournal(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	sender, err := NewSender(testServer.URL, AuditJournal(path, 0, 0), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendMetric("my other metric", 21, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	entries, err := ReadAuditJournal(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, testServer.URL+"/report?f=wavefront", entries[0].Endpoint)
+	assert.Equal(t, "wavefront", entries[0].Format)
+	assert.Equal(t, 2, entries[0].Lines)
+	assert.Equal(t, 200, entries[0].Status)
+	assert.Empty(t, entries[0].Error)
+}
+
 func TestTLSEndToEnd(t *testing.T) {
 	testServer := startTestServer(true)
 	defer testServer.Close()
@@ -64,9 +350,52 @@ func TestEndToEndWithInternalMetrics(t *testing.T) {
 	metricLines := testServer.MetricLines
 
 	assert.Equal(t, true, testServer.hasReceivedLine("points.valid"))
-	assert.Equal(t, 12, len(metricLines))
+	assert.Equal(t, true, testServer.hasReceivedLine("startup"))
+	assert.Equal(t, 13, len(metricLines))
 	assert.Equal(t, "\"my-metric\" 20 source=\"localhost\"", metricLines[0])
 	assert.Equal(t, "/report?f=wavefront", testServer.RequestURLs[0])
+
+	// the report metrics are only registered by the handlers that reported.
+	sender.(*realSender).internalRegistry.Flush()
+	require.NoError(t, sender.Flush())
+	assert.True(t, testServer.hasReceivedLine("points.flush.latency_ms"))
+	assert.False(t, testServer.hasReceivedLine("spans.flush.latency_ms"))
+}
+
+func TestEndToEndWithInternalMetricsRegistry(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+
+	var mtx sync.Mutex
+	gauges := map[string]float64{}
+	counters := map[string]float64{}
+	registry := sdkmetrics.Funcs{
+		GaugeFunc: func(name string, value float64, tags map[string]string) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			gauges[name] = value
+		},
+		CounterFunc: func(name string, delta float64, tags map[string]string) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			counters[name] += delta
+		},
+	}
+	sender, err := NewSender(testServer.URL, InternalMetricsRegistry(registry), InternalMetricsPrefix("my.sdk"))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	sender.(*realSender).internalRegistry.Flush()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, 1.0, counters["my.sdk."+InternalMetricPointsValid])
+	assert.Equal(t, 1.0, counters["my.sdk."+InternalMetricPointsSent])
+	assert.NotContains(t, counters, "my.sdk."+InternalMetricPointsReportErrors)
+	assert.Contains(t, gauges, "my.sdk."+InternalMetricPointsFlushLatency)
+	assert.Equal(t, 0.0, gauges["my.sdk."+InternalMetricPointsQueueSize])
+	assert.Equal(t, 1.0, gauges["my.sdk."+InternalMetricStartup])
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\""}, testServer.MetricLines)
 }
 
 func TestEndToEndWithoutInternalMetrics(t *testing.T) {
@@ -101,3 +430,102 @@ func TestEndToEndDirect(t *testing.T) {
 	assert.Equal(t, "/report?f=wavefront", testServer.RequestURLs[0])
 	assert.Equal(t, "/api/v2/event", testServer.RequestURLs[1])
 }
+
+func TestEndToEndWithDisabledTypes(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+
+	sender, err := NewSender(testServer.URL, DisableDistributions(), DisableSpans(), DisableEvents())
+	require.NoError(t, err)
+	defer sender.Close()
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+	require.NoError(t, sender.SendDistribution("my distribution", []histogram.Centroid{{Value: 1, Count: 2}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "localhost", nil))
+	require.NoError(t, sender.SendSpan("my span", 0, 10, "localhost",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil,
+		[]SpanLog{{Timestamp: 1, Fields: map[string]string{"k": "v"}}}))
+	require.NoError(t, sender.SendEvent("my event", 20, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, []string{"\"my-metric\" 20 source=\"localhost\""}, testServer.MetricLines)
+	assert.Empty(t, testServer.EventLines)
+	rs := sender.(*realSender)
+	assert.Equal(t, int64(1), rs.disabledDistributions.Count())
+	assert.Equal(t, int64(1), rs.disabledSpans.Count())
+	assert.Equal(t, int64(1), rs.disabledEvents.Count())
+}
+
+func TestEndToEndWithStrictValidation(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+
+	sender, err := NewSender(testServer.URL, StrictValidation(), SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	var validationErr *ValidationError
+	err = sender.SendMetric("my metric", 20, 0, "localhost", nil)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "name", validationErr.Field)
+	assert.EqualError(t, err, `invalid name "my metric": invalid character ' '`)
+
+	err = sender.SendDeltaCounter("my.counter", 1, "localhost", map[string]string{"bad key": "v"})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "tag", validationErr.Field)
+
+	err = sender.SendDistribution("my.distribution", []histogram.Centroid{{Value: 1, Count: 2}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, strings.Repeat("s", 129), nil)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "source", validationErr.Field)
+
+	err = sender.SendSpan("my.span", 0, 10, "localhost",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil,
+		[]SpanTag{{Key: "empty", Value: ""}}, nil)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "empty value", validationErr.Reason)
+
+	require.NoError(t, sender.SendMetric("~my.metric", 20, 0, "localhost", map[string]string{"env": "dev"}))
+	require.NoError(t, sender.SendDeltaCounter("my.counter", 1, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	assert.Len(t, testServer.MetricLines, 2)
+}
+
+func TestEndToEndFlushErrors(t *testing.T) {
+	tooLarge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer tooLarge.Close()
+
+	sender, err := NewSender(tooLarge.URL, SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	require.NoError(t, sender.SendMetric("my metric", 20, 0, "localhost", nil))
+
+	err = sender.Flush()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+	assert.False(t, errors.Is(err, ErrThrottled))
+	var reportErr *ReportError
+	require.True(t, errors.As(err, &reportErr))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, reportErr.StatusCode)
+}
+
+func TestEndToEndOnDropped(t *testing.T) {
+	testServer := startTestServer(false)
+	defer testServer.Close()
+
+	var dropped []string
+	var reasons []error
+	sender, err := NewSender(testServer.URL, MaxBufferSize(1), SendInternalMetrics(false),
+		OnDropped(func(lines []string, reason error) {
+			dropped = append(dropped, lines...)
+			reasons = append(reasons, reason)
+		}))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("my metric", 1, 0, "localhost", nil))
+	require.Error(t, sender.SendMetric("my metric", 2, 0, "localhost", nil))
+	assert.Equal(t, []string{"\"my-metric\" 2 source=\"localhost\"\n"}, dropped)
+	assert.Equal(t, []error{ErrBufferFull}, reasons)
+}
@@ -0,0 +1,212 @@
+package senders
+
+import "github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+
+// Prefixes of the internal metrics, depending on whether the sender reports
+// directly to Wavefront or through a Wavefront proxy.
+const (
+	InternalMetricPrefixDirect = "~sdk.go.core.sender.direct"
+	InternalMetricPrefixProxy  = "~sdk.go.core.sender.proxy"
+)
+
+// Names of the internal metrics, relative to InternalMetricPrefixDirect or InternalMetricPrefixProxy,
+// e.g. ~sdk.go.core.sender.proxy.points.valid. See InternalMetricNames.
+//
+// For each data type, Valid, Invalid and Dropped count the data accepted, rejected as invalid and
+// dropped for lack of room in the buffer. QueueSize and QueueRemainingCapacity are gauges of the
+// buffer. FlushBudgetExceeded is only reported with FlushLatencyBudget, BufferEvicted only with
+// BufferOverflow(DropOldest). QuotaExceeded counts the requests rejected because the tenant quota
+// was exceeded, see QuotaExceededError, and CircuitBreakerOpened the openings of the
+// CircuitBreaker. Disabled counts the data discarded because its type was disabled, see
+// DisableDistributions, DisableSpans and DisableEvents. Sent counts the data accepted by the
+// server, ReportErrors the report requests that failed, and FlushLatency is the duration of the last
+// report request, in milliseconds.
+const (
+	InternalMetricPointsValid                  = sdkmetrics.PointsPrefix + sdkmetrics.ValidSuffix
+	InternalMetricPointsInvalid                = sdkmetrics.PointsPrefix + sdkmetrics.InvalidSuffix
+	InternalMetricPointsDropped                = sdkmetrics.PointsPrefix + sdkmetrics.DroppedSuffix
+	InternalMetricPointsQueueSize              = sdkmetrics.PointsPrefix + sdkmetrics.QueueSizeSuffix
+	InternalMetricPointsQueueRemainingCapacity = sdkmetrics.PointsPrefix + sdkmetrics.QueueRemainingCapacitySuffix
+	InternalMetricPointsFlushBudgetExceeded    = sdkmetrics.PointsPrefix + sdkmetrics.FlushBudgetExceededSuffix
+	InternalMetricPointsBufferEvicted          = sdkmetrics.PointsPrefix + sdkmetrics.BufferEvictedSuffix
+	InternalMetricPointsQuotaExceeded          = sdkmetrics.PointsPrefix + sdkmetrics.QuotaExceededSuffix
+	InternalMetricPointsCircuitBreakerOpened   = sdkmetrics.PointsPrefix + sdkmetrics.CircuitOpenedSuffix
+	InternalMetricPointsSent                   = sdkmetrics.PointsPrefix + sdkmetrics.SentSuffix
+	InternalMetricPointsReportErrors           = sdkmetrics.PointsPrefix + sdkmetrics.ReportErrorsSuffix
+	InternalMetricPointsFlushLatency           = sdkmetrics.PointsPrefix + sdkmetrics.FlushLatencySuffix
+
+	InternalMetricHistogramsValid                  = sdkmetrics.HistogramsPrefix + sdkmetrics.ValidSuffix
+	InternalMetricHistogramsInvalid                = sdkmetrics.HistogramsPrefix + sdkmetrics.InvalidSuffix
+	InternalMetricHistogramsDropped                = sdkmetrics.HistogramsPrefix + sdkmetrics.DroppedSuffix
+	InternalMetricHistogramsQueueSize              = sdkmetrics.HistogramsPrefix + sdkmetrics.QueueSizeSuffix
+	InternalMetricHistogramsQueueRemainingCapacity = sdkmetrics.HistogramsPrefix + sdkmetrics.QueueRemainingCapacitySuffix
+	InternalMetricHistogramsFlushBudgetExceeded    = sdkmetrics.HistogramsPrefix + sdkmetrics.FlushBudgetExceededSuffix
+	InternalMetricHistogramsBufferEvicted          = sdkmetrics.HistogramsPrefix + sdkmetrics.BufferEvictedSuffix
+	InternalMetricHistogramsQuotaExceeded          = sdkmetrics.HistogramsPrefix + sdkmetrics.QuotaExceededSuffix
+	InternalMetricHistogramsCircuitBreakerOpened   = sdkmetrics.HistogramsPrefix + sdkmetrics.CircuitOpenedSuffix
+	InternalMetricHistogramsSent                   = sdkmetrics.HistogramsPrefix + sdkmetrics.SentSuffix
+	InternalMetricHistogramsReportErrors           = sdkmetrics.HistogramsPrefix + sdkmetrics.ReportErrorsSuffix
+	InternalMetricHistogramsFlushLatency           = sdkmetrics.HistogramsPrefix + sdkmetrics.FlushLatencySuffix
+	InternalMetricHistogramsDisabled               = sdkmetrics.HistogramsPrefix + sdkmetrics.DisabledSuffix
+
+	InternalMetricSpansValid                  = sdkmetrics.SpansPrefix + sdkmetrics.ValidSuffix
+	InternalMetricSpansInvalid                = sdkmetrics.SpansPrefix + sdkmetrics.InvalidSuffix
+	InternalMetricSpansDropped                = sdkmetrics.SpansPrefix + sdkmetrics.DroppedSuffix
+	InternalMetricSpansQueueSize              = sdkmetrics.SpansPrefix + sdkmetrics.QueueSizeSuffix
+	InternalMetricSpansQueueRemainingCapacity = sdkmetrics.SpansPrefix + sdkmetrics.QueueRemainingCapacitySuffix
+	InternalMetricSpansFlushBudgetExceeded    = sdkmetrics.SpansPrefix + sdkmetrics.FlushBudgetExceededSuffix
+	InternalMetricSpansBufferEvicted          = sdkmetrics.SpansPrefix + sdkmetrics.BufferEvictedSuffix
+	InternalMetricSpansQuotaExceeded          = sdkmetrics.SpansPrefix + sdkmetrics.QuotaExceededSuffix
+	InternalMetricSpansCircuitBreakerOpened   = sdkmetrics.SpansPrefix + sdkmetrics.CircuitOpenedSuffix
+	InternalMetricSpansSent                   = sdkmetrics.SpansPrefix + sdkmetrics.SentSuffix
+	InternalMetricSpansReportErrors           = sdkmetrics.SpansPrefix + sdkmetrics.ReportErrorsSuffix
+	InternalMetricSpansFlushLatency           = sdkmetrics.SpansPrefix + sdkmetrics.FlushLatencySuffix
+	InternalMetricSpansDisabled               = sdkmetrics.SpansPrefix + sdkmetrics.DisabledSuffix
+
+	InternalMetricSpanLogsValid                  = sdkmetrics.SpanLogsPrefix + sdkmetrics.ValidSuffix
+	InternalMetricSpanLogsInvalid                = sdkmetrics.SpanLogsPrefix + sdkmetrics.InvalidSuffix
+	InternalMetricSpanLogsDropped                = sdkmetrics.SpanLogsPrefix + sdkmetrics.DroppedSuffix
+	InternalMetricSpanLogsQueueSize              = sdkmetrics.SpanLogsPrefix + sdkmetrics.QueueSizeSuffix
+	InternalMetricSpanLogsQueueRemainingCapacity = sdkmetrics.SpanLogsPrefix + sdkmetrics.QueueRemainingCapacitySuffix
+	InternalMetricSpanLogsFlushBudgetExceeded    = sdkmetrics.SpanLogsPrefix + sdkmetrics.FlushBudgetExceededSuffix
+	InternalMetricSpanLogsBufferEvicted          = sdkmetrics.SpanLogsPrefix + sdkmetrics.BufferEvictedSuffix
+	InternalMetricSpanLogsQuotaExceeded          = sdkmetrics.SpanLogsPrefix + sdkmetrics.QuotaExceededSuffix
+	InternalMetricSpanLogsCircuitBreakerOpened   = sdkmetrics.SpanLogsPrefix + sdkmetrics.CircuitOpenedSuffix
+	InternalMetricSpanLogsSent                   = sdkmetrics.SpanLogsPrefix + sdkmetrics.SentSuffix
+	InternalMetricSpanLogsReportErrors           = sdkmetrics.SpanLogsPrefix + sdkmetrics.ReportErrorsSuffix
+	InternalMetricSpanLogsFlushLatency           = sdkmetrics.SpanLogsPrefix + sdkmetrics.FlushLatencySuffix
+
+	InternalMetricEventsValid                  = sdkmetrics.EventsPrefix + sdkmetrics.ValidSuffix
+	InternalMetricEventsInvalid                = sdkmetrics.EventsPrefix + sdkmetrics.InvalidSuffix
+	InternalMetricEventsDropped                = sdkmetrics.EventsPrefix + sdkmetrics.DroppedSuffix
+	InternalMetricEventsQueueSize              = sdkmetrics.EventsPrefix + sdkmetrics.QueueSizeSuffix
+	InternalMetricEventsQueueRemainingCapacity = sdkmetrics.EventsPrefix + sdkmetrics.QueueRemainingCapacitySuffix
+	InternalMetricEventsFlushBudgetExceeded    = sdkmetrics.EventsPrefix + sdkmetrics.FlushBudgetExceededSuffix
+	InternalMetricEventsBufferEvicted          = sdkmetrics.EventsPrefix + sdkmetrics.BufferEvictedSuffix
+	InternalMetricEventsQuotaExceeded          = sdkmetrics.EventsPrefix + sdkmetrics.QuotaExceededSuffix
+	InternalMetricEventsCircuitBreakerOpened   = sdkmetrics.EventsPrefix + sdkmetrics.CircuitOpenedSuffix
+	InternalMetricEventsSent                   = sdkmetrics.EventsPrefix + sdkmetrics.SentSuffix
+	InternalMetricEventsReportErrors           = sdkmetrics.EventsPrefix + sdkmetrics.ReportErrorsSuffix
+	InternalMetricEventsFlushLatency           = sdkmetrics.EventsPrefix + sdkmetrics.FlushLatencySuffix
+	InternalMetricEventsDisabled               = sdkmetrics.EventsPrefix + sdkmetrics.DisabledSuffix
+
+	// Non-finite metric values and values out of bounds, see RejectNonFiniteValues and ValueBounds.
+	InternalMetricPointsNonFinite   = sdkmetrics.PointsNonFinite
+	InternalMetricPointsOutOfBounds = sdkmetrics.PointsOutOfBounds
+
+	// Data dropped on purpose before being buffered, by reason: non-finite values refused or dropped,
+	// see RejectNonFiniteValues and DropNonFiniteValues, values out of ValueBounds, data of disabled
+	// types, see DisableDistributions, DisableSpans and DisableEvents, and data failing
+	// StrictValidation.
+	InternalMetricPointsDroppedNonFinite       = sdkmetrics.PointsPrefix + sdkmetrics.DroppedNonFiniteSuffix
+	InternalMetricPointsDroppedOutOfBounds     = sdkmetrics.PointsPrefix + sdkmetrics.DroppedOutOfBoundsSuffix
+	InternalMetricPointsDroppedInvalid         = sdkmetrics.PointsPrefix + sdkmetrics.DroppedInvalidSuffix
+	InternalMetricHistogramsDroppedNonFinite   = sdkmetrics.HistogramsPrefix + sdkmetrics.DroppedNonFiniteSuffix
+	InternalMetricHistogramsDroppedOutOfBounds = sdkmetrics.HistogramsPrefix + sdkmetrics.DroppedOutOfBoundsSuffix
+	InternalMetricHistogramsDroppedDisabled    = sdkmetrics.HistogramsPrefix + sdkmetrics.DroppedDisabledSuffix
+	InternalMetricHistogramsDroppedInvalid     = sdkmetrics.HistogramsPrefix + sdkmetrics.DroppedInvalidSuffix
+	InternalMetricSpansDroppedDisabled         = sdkmetrics.SpansPrefix + sdkmetrics.DroppedDisabledSuffix
+	InternalMetricSpansDroppedInvalid          = sdkmetrics.SpansPrefix + sdkmetrics.DroppedInvalidSuffix
+	InternalMetricEventsDroppedDisabled        = sdkmetrics.EventsPrefix + sdkmetrics.DroppedDisabledSuffix
+
+	// Size of report payloads before and after compression.
+	InternalMetricBytesUncompressed = sdkmetrics.BytesUncompressed
+	InternalMetricBytesCompressed   = sdkmetrics.BytesCompressed
+
+	// Report requests not retried because the retry budget was exhausted, see RetryBudget.
+	InternalMetricRetriesShed = sdkmetrics.RetriesShed
+
+	// Spans whose span logs were truncated to MaxSpanLogBytes.
+	InternalMetricSpanLogsTruncated = sdkmetrics.SpanLogsTruncated
+
+	// Sent once by each new sender, tagged with a hash of its configuration and its main settings.
+	InternalMetricStartup = sdkmetrics.Startup
+)
+
+// InternalMetricNames returns the names of all the internal metrics the SDK may report, relative to
+// InternalMetricPrefixDirect or InternalMetricPrefixProxy, so that alerts can be built without
+// hardcoding them. Some are only reported when the option they relate to is used.
+func InternalMetricNames() []string {
+	return []string{
+		InternalMetricPointsValid,
+		InternalMetricPointsInvalid,
+		InternalMetricPointsDropped,
+		InternalMetricPointsQueueSize,
+		InternalMetricPointsQueueRemainingCapacity,
+		InternalMetricPointsFlushBudgetExceeded,
+		InternalMetricPointsBufferEvicted,
+		InternalMetricPointsQuotaExceeded,
+		InternalMetricPointsCircuitBreakerOpened,
+		InternalMetricPointsSent,
+		InternalMetricPointsReportErrors,
+		InternalMetricPointsFlushLatency,
+		InternalMetricHistogramsValid,
+		InternalMetricHistogramsInvalid,
+		InternalMetricHistogramsDropped,
+		InternalMetricHistogramsQueueSize,
+		InternalMetricHistogramsQueueRemainingCapacity,
+		InternalMetricHistogramsFlushBudgetExceeded,
+		InternalMetricHistogramsBufferEvicted,
+		InternalMetricHistogramsQuotaExceeded,
+		InternalMetricHistogramsCircuitBreakerOpened,
+		InternalMetricHistogramsSent,
+		InternalMetricHistogramsReportErrors,
+		InternalMetricHistogramsFlushLatency,
+		InternalMetricHistogramsDisabled,
+		InternalMetricSpansValid,
+		InternalMetricSpansInvalid,
+		InternalMetricSpansDropped,
+		InternalMetricSpansQueueSize,
+		InternalMetricSpansQueueRemainingCapacity,
+		InternalMetricSpansFlushBudgetExceeded,
+		InternalMetricSpansBufferEvicted,
+		InternalMetricSpansQuotaExceeded,
+		InternalMetricSpansCircuitBreakerOpened,
+		InternalMetricSpansSent,
+		InternalMetricSpansReportErrors,
+		InternalMetricSpansFlushLatency,
+		InternalMetricSpansDisabled,
+		InternalMetricSpanLogsValid,
+		InternalMetricSpanLogsInvalid,
+		InternalMetricSpanLogsDropped,
+		InternalMetricSpanLogsQueueSize,
+		InternalMetricSpanLogsQueueRemainingCapacity,
+		InternalMetricSpanLogsFlushBudgetExceeded,
+		InternalMetricSpanLogsBufferEvicted,
+		InternalMetricSpanLogsQuotaExceeded,
+		InternalMetricSpanLogsCircuitBreakerOpened,
+		InternalMetricSpanLogsSent,
+		InternalMetricSpanLogsReportErrors,
+		InternalMetricSpanLogsFlushLatency,
+		InternalMetricSpanLogsTruncated,
+		InternalMetricEventsValid,
+		InternalMetricEventsInvalid,
+		InternalMetricEventsDropped,
+		InternalMetricEventsQueueSize,
+		InternalMetricEventsQueueRemainingCapacity,
+		InternalMetricEventsFlushBudgetExceeded,
+		InternalMetricEventsBufferEvicted,
+		InternalMetricEventsQuotaExceeded,
+		InternalMetricEventsCircuitBreakerOpened,
+		InternalMetricEventsSent,
+		InternalMetricEventsReportErrors,
+		InternalMetricEventsFlushLatency,
+		InternalMetricEventsDisabled,
+		InternalMetricPointsNonFinite,
+		InternalMetricPointsOutOfBounds,
+		InternalMetricPointsDroppedNonFinite,
+		InternalMetricPointsDroppedOutOfBounds,
+		InternalMetricPointsDroppedInvalid,
+		InternalMetricHistogramsDroppedNonFinite,
+		InternalMetricHistogramsDroppedOutOfBounds,
+		InternalMetricHistogramsDroppedDisabled,
+		InternalMetricHistogramsDroppedInvalid,
+		InternalMetricSpansDroppedDisabled,
+		InternalMetricSpansDroppedInvalid,
+		InternalMetricEventsDroppedDisabled,
+		InternalMetricBytesUncompressed,
+		InternalMetricBytesCompressed,
+		InternalMetricStartup,
+		InternalMetricRetriesShed,
+	}
+}
@@ -0,0 +1,55 @@
+package senders
+
+import (
+	"github.com/wavefronthq/wavefront-sdk-go/sdkmetrics"
+)
+
+// InternalMetricsRegistry routes the internal SDK metrics to registry instead of sending them
+// to Wavefront, e.g. to report them to another monitoring system. The metrics are named and
+// tagged as when sent to Wavefront. Use SendInternalMetrics(false) to turn them off.
+func InternalMetricsRegistry(registry sdkmetrics.Registry) Option {
+	return func(cfg *configuration) {
+		cfg.InternalMetricsRegistry = registry
+	}
+}
+
+// InternalMetricsPrefix replaces InternalMetricPrefixDirect or InternalMetricPrefixProxy as the
+// prefix of the internal SDK metrics.
+func InternalMetricsPrefix(prefix string) Option {
+	return func(cfg *configuration) {
+		cfg.InternalMetricsPrefix = prefix
+	}
+}
+
+// internalMetricsSender is what internal SDK metrics are reported through.
+type internalMetricsSender interface {
+	SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error
+	SendDeltaCounter(name string, value float64, source string, tags map[string]string) error
+}
+
+// internalMetricsSender returns the sender itself, or an adapter to InternalMetricsRegistry when set.
+func (sender *realSender) internalMetricsSender(cfg *configuration) internalMetricsSender {
+	if cfg.InternalMetricsRegistry != nil {
+		return registrySender{registry: cfg.InternalMetricsRegistry}
+	}
+	return sender
+}
+
+// registrySender reports internal SDK metrics to a sdkmetrics.Registry: metrics as gauges and
+// delta counters as counters.
+type registrySender struct {
+	registry sdkmetrics.Registry
+}
+
+func (s registrySender) SendMetric(name string, value float64, _ int64, _ string, tags map[string]string) error {
+	s.registry.Gauge(name, value, tags)
+	return nil
+}
+
+func (s registrySender) SendDeltaCounter(name string, value float64, _ string, tags map[string]string) error {
+	// zero deltas are left out, as they are when sent to Wavefront.
+	if value != 0 {
+		s.registry.Counter(name, value, tags)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package senders
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalMetricNames(t *testing.T) {
+	names := InternalMetricNames()
+	assert.Len(t, names, 80)
+	assert.Contains(t, names, "points.valid")
+	assert.Contains(t, names, "span_logs.queue.remaining_capacity")
+	assert.Contains(t, names, "bytes.compressed")
+
+	seen := map[string]bool{}
+	for _, name := range names {
+		assert.False(t, seen[name], "duplicate name %s", name)
+		seen[name] = true
+	}
+
+	readme, err := os.ReadFile("../README.md")
+	require.NoError(t, err)
+	for _, name := range names {
+		assert.Contains(t, string(readme), "`"+name+"`", "%s is not documented in the README", name)
+	}
+}
@@ -0,0 +1,205 @@
+package senders
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// stateChangesBuffer is the number of changes a subscriber of StateChanges can lag behind.
+const stateChangesBuffer = 16
+
+// State is the lifecycle state of a sender, see Lifecycle.
+type State int
+
+const (
+	// StateStarting is the state of a sender being created.
+	StateStarting State = iota
+	// StateHealthy is the state of a sender whose last report of each data type succeeded, or
+	// that has not reported yet.
+	StateHealthy
+	// StateBuffering is the state of a sender whose last report of a data type failed, and whose
+	// lines are buffered to be reported again.
+	StateBuffering
+	// StateDegraded is the state of a sender whose last report of a data type failed and whose lines
+	// were dropped, e.g. for lack of room in the buffer or because they were rejected for good.
+	StateDegraded
+	// StateDraining is the state of a sender flushing its buffers after Close was called.
+	StateDraining
+	// StateClosed is the state of a closed sender.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "Starting"
+	case StateHealthy:
+		return "Healthy"
+	case StateBuffering:
+		return "Buffering"
+	case StateDegraded:
+		return "Degraded"
+	case StateDraining:
+		return "Draining"
+	case StateClosed:
+		return "Closed"
+	}
+	return "Unknown"
+}
+
+// StateChange is a transition of a sender from one State to another.
+type StateChange struct {
+	From State
+	To   State
+	Time time.Time
+	// Err is the reporting error that caused a change to StateBuffering or StateDegraded.
+	Err error
+}
+
+// Lifecycle is implemented by the senders created by NewSender and NewOTelReportSender, so that
+// agents embedding them can reflect the state of their telemetry pipeline in their own status:
+//
+//	if l, ok := sender.(senders.Lifecycle); ok {
+//		go func() {
+//			for change := range l.StateChanges() {
+//				log.Printf("wavefront sender %s -> %s", change.From, change.To)
+//			}
+//		}()
+//	}
+//
+// The state of a sender is the worst state of its data types: a sender whose points are sent but
+// whose spans are buffered is Buffering.
+type Lifecycle interface {
+	// State returns the current state of the sender.
+	State() State
+
+	// StateChanges returns a new channel receiving the state changes of the sender, closed after
+	// the change to StateClosed. Changes are dropped when the channel is full, so that a slow
+	// subscriber never blocks the sender; State always returns the current state.
+	StateChanges() <-chan StateChange
+}
+
+func (sender *realSender) State() State {
+	return sender.lifecycle.State()
+}
+
+func (sender *realSender) StateChanges() <-chan StateChange {
+	return sender.lifecycle.StateChanges()
+}
+
+// lifecycle tracks the state of a sender from the outcome of the reports of each data type.
+type lifecycle struct {
+	mtx         sync.Mutex
+	state       State
+	formats     map[string]State
+	subscribers []chan StateChange
+
+	lastSuccess time.Time
+	failures    int
+	lastErr     error
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{state: StateStarting, formats: map[string]State{}}
+}
+
+func (l *lifecycle) State() State {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.state
+}
+
+func (l *lifecycle) StateChanges() <-chan StateChange {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	ch := make(chan StateChange, stateChangesBuffer)
+	if l.state == StateClosed {
+		close(ch)
+		return ch
+	}
+	l.subscribers = append(l.subscribers, ch)
+	return ch
+}
+
+// health returns the outcome of the reports so far.
+func (l *lifecycle) health() Health {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return Health{LastSuccess: l.lastSuccess, ConsecutiveFailures: l.failures, LastError: l.lastErr}
+}
+
+// observe updates the state of format from the outcome of one of its reports.
+func (l *lifecycle) observe(format string, result internal.FlushResult) {
+	state := StateHealthy
+	switch {
+	case result.Dropped > 0:
+		state = StateDegraded
+	case result.Err != nil:
+		state = StateBuffering
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if result.Err != nil {
+		l.failures++
+		l.lastErr = result.Err
+	} else {
+		l.lastSuccess = time.Now()
+		l.failures = 0
+		l.lastErr = nil
+	}
+	l.formats[format] = state
+	switch l.state {
+	case StateStarting, StateDraining, StateClosed:
+		// reports do not change the state of senders being started or closed.
+		return
+	}
+	worst := StateHealthy
+	for _, s := range l.formats {
+		if s > worst {
+			worst = s
+		}
+	}
+	l.set(worst, result.Err)
+}
+
+// transition moves the sender to state.
+func (l *lifecycle) transition(state State) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if state == StateHealthy {
+		// the data types that failed before the sender started, if any, keep it from being Healthy.
+		for _, s := range l.formats {
+			if s > state {
+				state = s
+			}
+		}
+	}
+	l.set(state, nil)
+}
+
+// set changes the state and notifies the subscribers. It must be called with mtx held.
+func (l *lifecycle) set(state State, err error) {
+	if state == l.state {
+		return
+	}
+	change := StateChange{From: l.state, To: state, Time: time.Now()}
+	if state == StateBuffering || state == StateDegraded {
+		change.Err = err
+	}
+	l.state = state
+	for _, ch := range l.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+	if state == StateClosed {
+		for _, ch := range l.subscribers {
+			close(ch)
+		}
+		l.subscribers = nil
+	}
+}
@@ -0,0 +1,99 @@
+package senders
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+func TestLifecycle(t *testing.T) {
+	l := newLifecycle()
+	changes := l.StateChanges()
+	assert.Equal(t, StateStarting, l.State())
+
+	l.observe("wavefront", internal.FlushResult{Err: errors.New("refused"), Buffered: 1})
+	assert.Equal(t, StateStarting, l.State())
+	// the data types that failed while starting keep the sender from being Healthy.
+	l.transition(StateHealthy)
+	assert.Equal(t, StateBuffering, l.State())
+
+	l.observe("trace", internal.FlushResult{Err: errors.New("rejected"), Dropped: 1})
+	assert.Equal(t, StateDegraded, l.State())
+	l.observe("trace", internal.FlushResult{Sent: 1})
+	assert.Equal(t, StateBuffering, l.State())
+	l.observe("wavefront", internal.FlushResult{Sent: 2})
+	assert.Equal(t, StateHealthy, l.State())
+
+	l.transition(StateDraining)
+	l.observe("wavefront", internal.FlushResult{Err: errors.New("refused"), Buffered: 1})
+	l.transition(StateClosed)
+
+	var got []string
+	for change := range changes {
+		got = append(got, change.From.String()+"->"+change.To.String())
+	}
+	assert.Equal(t, []string{
+		"Starting->Buffering",
+		"Buffering->Degraded",
+		"Degraded->Buffering",
+		"Buffering->Healthy",
+		"Healthy->Draining",
+		"Draining->Closed",
+	}, got)
+
+	_, open := <-l.StateChanges()
+	assert.False(t, open)
+}
+
+func TestLifecycle_SlowSubscriber(t *testing.T) {
+	l := newLifecycle()
+	l.transition(StateHealthy)
+	changes := l.StateChanges()
+	for i := 0; i < stateChangesBuffer; i++ {
+		l.observe("wavefront", internal.FlushResult{Err: errors.New("refused")})
+		l.observe("wavefront", internal.FlushResult{Sent: 1})
+	}
+	l.observe("wavefront", internal.FlushResult{Err: errors.New("refused")})
+	assert.Len(t, changes, stateChangesBuffer)
+	assert.Equal(t, StateBuffering, l.State())
+}
+
+func TestLifecycle_Sender(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, SendInternalMetrics(false))
+	require.NoError(t, err)
+	l, ok := sender.(Lifecycle)
+	require.True(t, ok)
+	assert.Equal(t, StateHealthy, l.State())
+	changes := l.StateChanges()
+
+	fail.Store(true)
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "", nil))
+	assert.Error(t, sender.Flush())
+	change := <-changes
+	assert.Equal(t, StateHealthy, change.From)
+	assert.Equal(t, StateBuffering, change.To)
+	assert.Error(t, change.Err)
+
+	fail.Store(false)
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, StateHealthy, (<-changes).To)
+
+	sender.Close()
+	assert.Equal(t, StateDraining, (<-changes).To)
+	assert.Equal(t, StateClosed, (<-changes).To)
+	assert.Equal(t, StateClosed, l.State())
+}
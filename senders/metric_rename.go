@@ -0,0 +1,93 @@
+package senders
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+type metricRename struct {
+	from    string
+	pattern string
+	to      string
+}
+
+// RenameMetric sends the metrics, delta counters and distributions named from as to instead,
+// e.g. to migrate dashboards to a new name without changing the instrumented code.
+// Delta counters are matched and renamed without their delta prefix.
+// Exact renames take precedence over the RenameMetrics ones.
+func RenameMetric(from, to string) Option {
+	return func(cfg *configuration) {
+		cfg.MetricRenames = append(cfg.MetricRenames, metricRename{from: from, to: to})
+	}
+}
+
+// RenameMetrics renames the metrics, delta counters and distributions whose name matches the
+// regular expression pattern to replacement, in which $1 stands for the first submatch, see
+// regexp.Regexp.Expand, e.g.
+//
+//	RenameMetrics(`^app\.(.*)$`, "service.$1")
+//
+// Delta counters are matched and renamed without their delta prefix. When several patterns
+// match a name, the first one given is used. An invalid pattern makes NewSender fail.
+func RenameMetrics(pattern, replacement string) Option {
+	return func(cfg *configuration) {
+		cfg.MetricRenames = append(cfg.MetricRenames, metricRename{pattern: pattern, to: replacement})
+	}
+}
+
+type regexpRename struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// metricRenamer applies the rename rules of a sender.
+type metricRenamer struct {
+	exact    map[string]string
+	patterns []regexpRename
+}
+
+func newMetricRenamer(renames []metricRename) (*metricRenamer, error) {
+	if len(renames) == 0 {
+		return nil, nil
+	}
+	r := &metricRenamer{exact: map[string]string{}}
+	for _, rename := range renames {
+		if rename.pattern == "" {
+			r.exact[rename.from] = rename.to
+			continue
+		}
+		re, err := regexp.Compile(rename.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric rename pattern '%s': %s", rename.pattern, err)
+		}
+		r.patterns = append(r.patterns, regexpRename{re: re, replacement: rename.to})
+	}
+	return r, nil
+}
+
+// rename returns the name name is sent as. A nil renamer keeps all names.
+func (r *metricRenamer) rename(name string) string {
+	if r == nil {
+		return name
+	}
+	prefix := ""
+	for _, delta := range []string{internal.DeltaPrefix, internal.AltDeltaPrefix} {
+		if strings.HasPrefix(name, delta) {
+			prefix, name = delta, name[len(delta):]
+			break
+		}
+	}
+	if to, ok := r.exact[name]; ok {
+		return prefix + to
+	}
+	for _, p := range r.patterns {
+		if match := p.re.FindStringSubmatchIndex(name); match != nil {
+			renamed := p.re.ExpandString(nil, p.replacement, name, match)
+			return prefix + name[:match[0]] + string(renamed) + name[match[1]:]
+		}
+	}
+	return prefix + name
+}
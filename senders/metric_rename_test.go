@@ -0,0 +1,52 @@
+package senders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+func TestMetricRenamer(t *testing.T) {
+	cfg, err := createConfig("https://localhost",
+		RenameMetrics(`^app\.(\w+)\.count$`, "service.$1.total"),
+		RenameMetric("old.name", "new.name"),
+		RenameMetrics(`^old\.`, "legacy."))
+	require.NoError(t, err)
+	r := cfg.metricRenamer
+
+	assert.Equal(t, "new.name", r.rename("old.name"))
+	assert.Equal(t, "legacy.other", r.rename("old.other"))
+	assert.Equal(t, "service.requests.total", r.rename("app.requests.count"))
+	assert.Equal(t, "app.requests.sum", r.rename("app.requests.sum"))
+	assert.Equal(t, internal.DeltaCounterName("new.name"), r.rename(internal.DeltaCounterName("old.name")))
+	assert.Equal(t, internal.AltDeltaPrefix+"new.name", r.rename(internal.AltDeltaPrefix+"old.name"))
+
+	var none *metricRenamer
+	assert.Equal(t, "old.name", none.rename("old.name"))
+}
+
+func TestMetricRenamerInvalidPattern(t *testing.T) {
+	_, err := createConfig("https://localhost", RenameMetrics(`(`, "x"))
+	assert.Error(t, err)
+}
+
+func TestSendRenamedMetrics(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	var err error
+	sender.renamer, err = newMetricRenamer([]metricRename{{from: "old.name", to: "new.name"}})
+	require.NoError(t, err)
+
+	require.NoError(t, sender.SendMetric("old.name", 1, 0, "test", nil))
+	require.NoError(t, sender.SendDeltaCounter("old.name", 2, "test", nil))
+	require.NoError(t, sender.SendDistribution("old.name", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "test", nil))
+	assert.Equal(t, []string{
+		"\"new.name\" 1 source=\"test\"\n",
+		"\"∆new.name\" 2 source=\"test\"\n",
+	}, pointHandler.Lines)
+	assert.Equal(t, []string{"!M #1 1 \"new.name\" source=\"test\"\n"}, sender.histoHandler.(*mockHandler).Lines)
+}
@@ -0,0 +1,65 @@
+package senders
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// metricValue converts any Go numeric type, bool or time.Duration to a metric value.
+// Common types are converted without reflection; named types such as `type Celsius float32`
+// fall back to reflection. time.Duration values are sent in milliseconds.
+func metricValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case bool:
+		return boolValue(v), nil
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond), nil
+	case nil:
+		return 0, fmt.Errorf("metric value cannot be nil")
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Bool:
+		return boolValue(rv.Bool()), nil
+	}
+	return 0, fmt.Errorf("unsupported metric value type %T", value)
+}
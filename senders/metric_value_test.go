@@ -0,0 +1,47 @@
+package senders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type celsius float32
+
+func TestMetricValue(t *testing.T) {
+	for _, value := range []interface{}{
+		float64(42), float32(42), 42, int64(42), int32(42), int16(42), int8(42),
+		uint(42), uint64(42), uint32(42), uint16(42), uint8(42), celsius(42),
+		42 * time.Millisecond,
+	} {
+		v, err := metricValue(value)
+		assert.NoError(t, err, "%T", value)
+		assert.Equal(t, 42.0, v, "%T", value)
+	}
+
+	v, err := metricValue(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v)
+
+	_, err = metricValue("42")
+	assert.Error(t, err)
+	_, err = metricValue(nil)
+	assert.Error(t, err)
+}
+
+func TestWavefrontSender_TypedMetrics(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+
+	assert.NoError(t, sender.SendIntMetric("foo", 7, 0, "test", nil))
+	assert.NoError(t, sender.SendBoolMetric("foo", false, 0, "test", nil))
+	assert.NoError(t, sender.SendAny("foo", uint16(3), 0, "test", nil))
+	assert.Error(t, sender.SendAny("foo", struct{}{}, 0, "test", nil))
+	assert.Equal(t, []string{
+		"\"foo\" 7 source=\"test\"\n",
+		"\"foo\" 0 source=\"test\"\n",
+		"\"foo\" 3 source=\"test\"\n",
+	}, pointHandler.Lines)
+	assert.Equal(t, 1, sender.internalRegistry.PointsTracker().(*simpleTracker).invalid)
+}
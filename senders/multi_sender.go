@@ -1,8 +1,11 @@
 package senders
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/wavefronthq/wavefront-sdk-go/event"
 	"github.com/wavefronthq/wavefront-sdk-go/histogram"
@@ -36,6 +39,26 @@ func (m *multiError) Error() string {
 	}
 }
 
+// As finds the first of the errors matching target, see errors.As.
+func (m *multiError) As(target interface{}) bool {
+	for _, err := range m.errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is reports whether any of the errors matches target, see errors.Is.
+func (m *multiError) Is(target error) bool {
+	for _, err := range m.errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *multiError) add(es ...error) {
 	m.errors = append(m.errors, es...)
 }
@@ -47,7 +70,32 @@ func (m *multiError) get() error {
 	return nil
 }
 
-// NewMultiSender creates a new Wavefront MultiClient
+// SenderError is the error of one of the senders of a MultiSender, Sender being its index in the
+// senders given to NewMultiSender, or of the endpoints given to NewShardedSender. For a
+// RouterSender, 0 is the fallback sender, followed by the senders of the routes in their order.
+// The errors of these senders hold one SenderError per sender that failed, so that the failures
+// of each backend can be told apart:
+//
+//	var senderErr *senders.SenderError
+//	if errors.As(err, &senderErr) {
+//		log.Printf("sender %d failed: %s", senderErr.Sender, senderErr.Err)
+//	}
+type SenderError struct {
+	Sender int
+	Err    error
+}
+
+func (e *SenderError) Error() string {
+	return fmt.Sprintf("sender %d: %s", e.Sender, e.Err)
+}
+
+func (e *SenderError) Unwrap() error {
+	return e.Err
+}
+
+// NewMultiSender creates a Sender duplicating all the data it is given to each of senders, e.g.
+// to send to both Wavefront and an OpenTelemetry collector while migrating. Each sender buffers,
+// retries and drops data on its own, so that a failing backend does not hold back the others.
 func NewMultiSender(senders ...Sender) MultiSender {
 	ms := &multiSender{}
 	ms.senders = append(ms.senders, senders...)
@@ -57,70 +105,143 @@ func NewMultiSender(senders ...Sender) MultiSender {
 func (ms *multiSender) private() {
 }
 
-func (ms *multiSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+// each calls send with every sender, and returns the errors of those that failed as SenderErrors.
+func (ms *multiSender) each(send func(Sender) error) error {
 	var errors multiError
-	for _, sender := range ms.senders {
-		err := sender.SendMetric(name, value, ts, source, tags)
-		if err != nil {
-			errors.add(err)
+	for i, sender := range ms.senders {
+		if err := send(sender); err != nil {
+			errors.add(&SenderError{Sender: i, Err: err})
 		}
 	}
 	return errors.get()
 }
 
+func (ms *multiSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendMetric(name, value, ts, source, tags)
+	})
+}
+
+func (ms *multiSender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return ms.SendMetric(name, float64(value), ts, source, tags)
+}
+
+func (ms *multiSender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	return ms.SendMetric(name, boolValue(value), ts, source, tags)
+}
+
+func (ms *multiSender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendAny(name, value, ts, source, tags)
+	})
+}
+
 func (ms *multiSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
-	var errors multiError
-	for _, sender := range ms.senders {
-		err := sender.SendDeltaCounter(name, value, source, tags)
-		if err != nil {
-			errors.add(err)
-		}
-	}
-	return errors.get()
+	return ms.each(func(sender Sender) error {
+		return sender.SendDeltaCounter(name, value, source, tags)
+	})
+}
+
+func (ms *multiSender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendDeltaCounterWithTimestamp(name, value, ts, source, tags)
+	})
 }
 
 func (ms *multiSender) SendDistribution(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
-	var errors multiError
-	for _, sender := range ms.senders {
-		err := sender.SendDistribution(name, centroids, hgs, ts, source, tags)
-		if err != nil {
-			errors.add(err)
-		}
-	}
-	return errors.get()
+	return ms.each(func(sender Sender) error {
+		return sender.SendDistribution(name, centroids, hgs, ts, source, tags)
+	})
+}
+
+func (ms *multiSender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.RecordDistribution(name, value, source, tags)
+	})
+}
+
+func (ms *multiSender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendDuration(name, d, source, tags)
+	})
 }
 
 func (ms *multiSender) SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
-	var errors multiError
-	for _, sender := range ms.senders {
-		err := sender.SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
-		if err != nil {
-			errors.add(err)
-		}
-	}
-	return errors.get()
+	return ms.each(func(sender Sender) error {
+		return sender.SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+	})
 }
 
 func (ms *multiSender) SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
-	var errors multiError
-	for _, sender := range ms.senders {
-		err := sender.SendEvent(name, startMillis, endMillis, source, tags, setters...)
-		if err != nil {
-			errors.add(err)
-		}
-	}
-	return errors.get()
+	return ms.each(func(sender Sender) error {
+		return sender.SendEvent(name, startMillis, endMillis, source, tags, setters...)
+	})
+}
+
+func (ms *multiSender) SendMetrics(points []MetricPoint) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendMetrics(points)
+	})
+}
+
+func (ms *multiSender) SendDistributions(distributions []Distribution) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendDistributions(distributions)
+	})
+}
+
+func (ms *multiSender) SendSpans(spans []Span) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendSpans(spans)
+	})
+}
+
+func (ms *multiSender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendMetricCtx(ctx, name, value, ts, source, tags)
+	})
+}
+
+func (ms *multiSender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendDeltaCounterCtx(ctx, name, value, source, tags)
+	})
+}
+
+func (ms *multiSender) SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendDistributionCtx(ctx, name, centroids, hgs, ts, source, tags)
+	})
+}
+
+func (ms *multiSender) SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendSpanCtx(ctx, name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+	})
+}
+
+func (ms *multiSender) SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return ms.each(func(sender Sender) error {
+		return sender.SendEventCtx(ctx, name, startMillis, endMillis, source, tags, setters...)
+	})
 }
 
 func (ms *multiSender) Flush() error {
-	var errors multiError
+	return ms.each(func(sender Sender) error {
+		return sender.Flush()
+	})
+}
+
+func (ms *multiSender) FlushWithReport() FlushReport {
+	var report FlushReport
 	for _, sender := range ms.senders {
-		err := sender.Flush()
-		if err != nil {
-			errors.add(err)
-		}
+		report.add(sender.FlushWithReport())
 	}
-	return errors.get()
+	return report
+}
+
+func (ms *multiSender) Health() Health {
+	return leastHealthy(ms.senders)
 }
 
 func (ms *multiSender) GetFailureCount() int64 {
@@ -0,0 +1,62 @@
+package senders
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackendDown = errors.New("backend down")
+
+type failingSender struct {
+	noOpSender
+}
+
+func (failingSender) SendMetric(string, float64, int64, string, map[string]string) error {
+	return errBackendDown
+}
+
+func TestMultiSender(t *testing.T) {
+	first, err := NewValidatingSender()
+	require.NoError(t, err)
+	second, err := NewValidatingSender()
+	require.NoError(t, err)
+	multi := NewMultiSender(first, &failingSender{}, second)
+	defer multi.Close()
+
+	err = multi.SendMetric("requests", 1, 0, "test", nil)
+	assert.ErrorIs(t, err, errBackendDown)
+	var senderErr *SenderError
+	require.ErrorAs(t, err, &senderErr)
+	assert.Equal(t, 1, senderErr.Sender)
+	assert.Equal(t, "sender 1: backend down", err.Error())
+
+	// the failure of one sender does not hold back the others.
+	require.NoError(t, multi.Flush())
+	assert.Equal(t, []string{"\"requests\" 1 source=\"test\"\n"}, first.Lines())
+	assert.Equal(t, first.Lines(), second.Lines())
+
+	assert.NoError(t, multi.SendDeltaCounter("hits", 1, "test", nil))
+
+	// invalid values are reported per sender too.
+	err = NewMultiSender(first, second).SendAny("requests", "one", 0, "test", nil)
+	require.ErrorAs(t, err, &senderErr)
+	assert.Equal(t, 0, senderErr.Sender)
+	assert.Contains(t, err.Error(), "2 errors")
+}
+
+func TestMultiSender_Health(t *testing.T) {
+	healthy := &noOpSender{}
+	unhealthy := &unhealthySender{}
+	unhealthy.setFailures(2)
+	multi := NewMultiSender(healthy, unhealthy)
+	defer multi.Close()
+
+	health := multi.Health()
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+	assert.EqualError(t, health.LastError, "connection refused")
+	assert.True(t, health.LastSuccess.IsZero())
+	assert.False(t, health.Connected)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 )
 
@@ -14,34 +15,178 @@ func NewSender(wfURL string, setters ...Option) (Sender, error) {
 		return nil, fmt.Errorf("unable to create sender config: %s", err)
 	}
 
+	newEndpoint := directEndpoint
+	switch {
+	case cfg.CustomTransport:
+		newEndpoint = transportEndpoint
+	case cfg.Protocol == ProtocolOTLP:
+		newEndpoint = otlpEndpoint
+	case cfg.Protocol == ProtocolOTLPGRPC:
+		newEndpoint = otlpGRPCEndpoint
+	}
+	ep, err := newEndpoint(wfURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sender := newRealSender(cfg, ep, newEndpoint)
+	sender.Start()
+	sender.sendStartupMetric(cfg, cfg.metricsURL())
+	return sender, nil
+}
+
+// reportersFunc creates the metrics and traces reporters of a sender with the given options.
+type reportersFunc func(options ...internal.ReporterOption) (metricsReporter, tracesReporter internal.Reporter)
+
+// endpoint is where a sender reports to.
+type endpoint struct {
+	tokenService auth.Service
+	newReporters reportersFunc
+	// transport is set for URLs whose scheme was registered with Register.
+	transport Transport
+}
+
+// endpointFunc creates the endpoint for a sender URL and its configuration.
+type endpointFunc func(url string, cfg *configuration) (endpoint, error)
+
+func directEndpoint(_ string, cfg *configuration) (endpoint, error) {
 	tokenService := tokenServiceForCfg(cfg)
 	client := cfg.HTTPClient
-	metricsReporter := internal.NewReporter(cfg.metricsURL(), tokenService, client)
-	tracesReporter := internal.NewReporter(cfg.tracesURL(), tokenService, client)
+	return endpoint{
+		tokenService: tokenService,
+		newReporters: func(options ...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			metricsReporter := internal.NewReporter(cfg.metricsURL(), tokenService, client, options...)
+			tracesReporter := internal.NewReporter(cfg.tracesURL(), tokenService, client, options...)
+			return metricsReporter, tracesReporter
+		},
+	}, nil
+}
 
+func newRealSender(cfg *configuration, ep endpoint, newEndpoint endpointFunc) *realSender {
 	sender := &realSender{
-		defaultSource: internal.GetHostname("wavefront_direct_sender"),
-		proxy:         !cfg.Direct(),
+		defaultSource:     internal.GetHostname("wavefront_direct_sender"),
+		proxy:             !cfg.Direct(),
+		enrichers:         cfg.Enrichers,
+		transformers:      cfg.PointTransformers,
+		spanTransformers:  cfg.SpanTransformers,
+		eventTransformers: cfg.EventTransformers,
+		endpoint:          ep,
+		lifecycle:         newLifecycle(),
+		newEndpoint:       newEndpoint,
+	}
+	if cfg.SourceResolver != nil {
+		interval := defaultSourceRefreshInterval
+		if cfg.SourceRefreshInterval != nil {
+			interval = *cfg.SourceRefreshInterval
+		}
+		sender.sourceResolver = newSourceResolver(cfg.SourceResolver, interval, sender.defaultSource)
 	}
 	if cfg.SendInternalMetrics {
 		sender.internalRegistry = sender.realInternalRegistry(cfg)
 	} else {
 		sender.internalRegistry = sdkmetrics.NewNoOpRegistry()
 	}
+	sender.valueGuard = newValueGuard(cfg, sender.internalRegistry)
+	sender.pointsDropped = newDropCounters(sender.internalRegistry, sdkmetrics.PointsPrefix)
+	sender.histogramsDropped = newDropCounters(sender.internalRegistry, sdkmetrics.HistogramsPrefix)
+	sender.spansDropped = newDropCounters(sender.internalRegistry, sdkmetrics.SpansPrefix)
+	sender.eventsDropped = newDropCounters(sender.internalRegistry, sdkmetrics.EventsPrefix)
+	sender.serializer = cfg.Serializer
+	sender.protocol = cfg.Protocol
+	if isOTLP(cfg.Protocol) {
+		sender.serializer = otlpSerializer{encode: otlpProtobuf}
+	}
+	sender.schemaRegistry = cfg.SchemaRegistry
+	sender.validation = cfg.Validation
+	if cfg.NameSanitizer != nil {
+		sender.quoteKey = internal.NewKeyQuoter(cfg.NameSanitizer)
+	}
+	sender.deltaCounterSkew = cfg.DeltaCounterSkew
+	sender.timestampUnit = cfg.TimestampUnit
+	sender.renamer = cfg.metricRenamer
+	if cfg.DisableDistributions {
+		sender.disabledDistributions = sender.internalRegistry.NewDeltaCounter(InternalMetricHistogramsDisabled)
+	}
+	if cfg.DisableSpans {
+		sender.disabledSpans = sender.internalRegistry.NewDeltaCounter(InternalMetricSpansDisabled)
+	}
+	if cfg.DisableEvents {
+		sender.disabledEvents = sender.internalRegistry.NewDeltaCounter(InternalMetricEventsDisabled)
+	}
+	if cfg.MaxSpanLogBytes > 0 {
+		sender.maxSpanLogBytes = cfg.MaxSpanLogBytes
+		sender.spanLogsTruncated = sender.internalRegistry.NewDeltaCounter(InternalMetricSpanLogsTruncated)
+	}
+	if cfg.TopK > 0 {
+		sender.topK = newTopKAnalyzer(cfg.TopK, cfg.TopKInterval, func(name string, value float64, tags map[string]string) error {
+			return sender.SendMetric(name, value, 0, "", tags)
+		})
+	}
+	if cfg.AuditJournalPath != "" {
+		sender.journal = internal.NewJournal(cfg.AuditJournalPath, cfg.AuditJournalMaxBytes, cfg.AuditJournalMaxFiles)
+	}
+	sender.durationUnit = cfg.DurationUnit
+	sender.distributions = newDistributionAccumulator(cfg.DistributionGranularities, cfg.DistributionBackend, cfg.FlushInterval, sender.SendDistribution)
+	if cfg.DeltaCounterBucket > 0 || cfg.AggregateDeltaCounters {
+		sender.deltaAggregator = internal.NewDeltaAggregator(cfg.DeltaCounterBucket, cfg.FlushInterval, sender.emitDeltaPoint)
+	}
 
+	metricsReporter, tracesReporter := ep.newReporters(sender.reporterOptions(cfg)...)
+	if cfg.Handshake {
+		sender.handshake(metricsReporter, tracesReporter)
+	}
+	sender.metricsReporter = internal.NewSwitchableReporter(metricsReporter)
+	sender.tracesReporter = internal.NewSwitchableReporter(tracesReporter)
+	var lineHandlerOptions []internal.LineHandlerOption
+	if cfg.PersistenceDir != "" {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetPersistentBuffer(cfg.PersistenceDir, cfg.PersistenceMaxBytes))
+	}
+	if cfg.OverflowPolicy != DropNewest {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetOverflowPolicy(cfg.OverflowPolicy.policy, cfg.OverflowPolicy.timeout))
+	}
+	if cfg.OnDropped != nil {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetDropHandler(cfg.OnDropped))
+	}
+	lineHandlerOptions = append(lineHandlerOptions, internal.SetReportHandler(sender.lifecycle.observe))
+	if cfg.FlushThreshold > 0 {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetFlushThreshold(cfg.FlushThreshold))
+	}
+	if cfg.MaxPayloadBytes > 0 {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetMaxPayloadBytes(cfg.MaxPayloadBytes))
+	}
+	if cfg.CircuitBreakerFailures > 0 {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetCircuitBreaker(cfg.CircuitBreakerFailures, cfg.CircuitBreakerCoolDown))
+	}
+	if cfg.FlushLatencyBudget > 0 {
+		lineHandlerOptions = append(lineHandlerOptions, internal.SetLatencyBudget(cfg.FlushLatencyBudget, cfg.AdaptiveBatchSize))
+	}
 	hf := internal.NewHandlerFactory(
-		metricsReporter,
-		tracesReporter,
+		sender.metricsReporter,
+		sender.tracesReporter,
 		cfg.FlushInterval,
 		cfg.MaxBufferSize,
 		sender.internalRegistry,
+		lineHandlerOptions...,
 	)
+	hf.SetFlushIntervals(cfg.MetricsFlushInterval, cfg.DistributionsFlushInterval, cfg.TracesFlushInterval, cfg.EventsFlushInterval)
+	if cfg.RateLimit > 0 {
+		hf.SetRateLimiter(internal.NewRateLimiter(cfg.RateLimit))
+	}
 
 	sender.pointHandler = hf.NewPointHandler(cfg.BatchSize)
 	sender.histoHandler = hf.NewHistogramHandler(cfg.BatchSize)
 	sender.spanHandler = hf.NewSpanHandler(cfg.BatchSize)
 	sender.spanLogHandler = hf.NewSpanLogHandler(cfg.BatchSize)
 	sender.eventHandler = hf.NewEventHandler()
-	sender.Start()
-	return sender, nil
+	return sender
+}
+
+func (sender *realSender) reporterOptions(cfg *configuration) []internal.ReporterOption {
+	if sender.reporterCounters == nil {
+		sender.reporterCounters = internal.NewReporterCounters(sender.internalRegistry)
+	}
+	options := append(cfg.reporterOptions(), internal.SetReporterCounters(sender.reporterCounters))
+	if sender.journal != nil {
+		options = append(options, internal.SetJournal(sender.journal))
+	}
+	return options
 }
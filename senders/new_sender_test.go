@@ -4,11 +4,15 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
 )
 
@@ -84,6 +88,17 @@ func TestMetricsURLWithPortAndPath(t *testing.T) {
 	assert.Equal(t, "http://localhost:8071/wavefront", cfg.tracesURL())
 }
 
+func TestIPv6URL(t *testing.T) {
+	cfg, err := createConfig("http://[::1]:8071/wavefront")
+	require.NoError(t, err)
+	assert.Equal(t, 8071, cfg.MetricsPort)
+	assert.Equal(t, "http://[::1]:8071/wavefront", cfg.metricsURL())
+
+	cfg, err = createConfig("http://[2001:db8::1]")
+	require.NoError(t, err)
+	assert.Equal(t, "http://[2001:db8::1]:2878", cfg.metricsURL())
+}
+
 func TestTokenInUrl(t *testing.T) {
 	cfg, err := createConfig("https://my-api-token@localhost")
 	require.NoError(t, err)
@@ -183,6 +198,149 @@ func TestTLSConfigOptions(t *testing.T) {
 	assert.Equal(t, caCertPool, cfg.HTTPClient.Transport.(*http.Transport).TLSClientConfig.RootCAs)
 }
 
+func TestDualStackFallbackDelay(t *testing.T) {
+	cfg, err := createConfig("https://localhost", DualStackFallbackDelay(50*time.Millisecond))
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.HTTPClient.Transport.(*http.Transport).DialContext)
+}
+
+func TestFlushLatencyBudget(t *testing.T) {
+	cfg, err := createConfig("https://localhost", FlushLatencyBudget(100*time.Millisecond, true))
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, cfg.FlushLatencyBudget)
+	assert.True(t, cfg.AdaptiveBatchSize)
+
+	sender, err := NewSender("https://localhost", FlushLatencyBudget(100*time.Millisecond, true))
+	require.NoError(t, err)
+	sender.Close()
+}
+
+func TestFlushThreshold(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, FlushInterval(time.Hour), FlushThreshold(3), SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("a", 1, 0, "test", nil))
+	require.NoError(t, sender.SendMetric("b", 1, 0, "test", nil))
+	assert.Zero(t, atomic.LoadInt64(&requests))
+	require.NoError(t, sender.SendMetric("c", 1, 0, "test", nil))
+	assert.Eventually(t, func() bool { return atomic.LoadInt64(&requests) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestPerTypeFlushIntervals(t *testing.T) {
+	cfg, err := createConfig("https://localhost", MetricsFlushInterval(5*time.Second),
+		DistributionsFlushInterval(time.Minute), TracesFlushInterval(100*time.Millisecond),
+		EventsFlushInterval(10*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.FlushInterval)
+	assert.Equal(t, 5*time.Second, cfg.MetricsFlushInterval)
+	assert.Equal(t, time.Minute, cfg.DistributionsFlushInterval)
+	assert.Equal(t, 100*time.Millisecond, cfg.TracesFlushInterval)
+	assert.Equal(t, 10*time.Second, cfg.EventsFlushInterval)
+
+	sender, err := NewSender("https://localhost", TracesFlushInterval(100*time.Millisecond))
+	require.NoError(t, err)
+	sender.Close()
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg, err := createConfig("https://localhost", RateLimit(1000))
+	require.NoError(t, err)
+	assert.Equal(t, 1000, cfg.RateLimit)
+
+	sender, err := NewSender("https://localhost", RateLimit(1000))
+	require.NoError(t, err)
+	sender.Close()
+}
+
+func TestBufferOverflow(t *testing.T) {
+	cfg, err := createConfig("https://localhost")
+	require.NoError(t, err)
+	assert.Equal(t, DropNewest, cfg.OverflowPolicy)
+
+	cfg, err = createConfig("https://localhost", BufferOverflow(BlockWithTimeout(time.Second)))
+	require.NoError(t, err)
+	assert.Equal(t, OverflowPolicy{policy: internal.OverflowBlock, timeout: time.Second}, cfg.OverflowPolicy)
+
+	sender, err := NewSender("https://localhost", MaxBufferSize(1), FlushIntervalSeconds(60),
+		BufferOverflow(DropOldest), SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	assert.NoError(t, sender.SendMetric("my metric", 1, 0, "localhost", nil))
+	assert.NoError(t, sender.SendMetric("my metric", 2, 0, "localhost", nil))
+}
+
+type headerRoundTripper struct {
+	header string
+	next   http.RoundTripper
+}
+
+func (rt headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Trace", rt.header)
+	return rt.next.RoundTrip(req)
+}
+
+func TestRoundTripper(t *testing.T) {
+	rt := headerRoundTripper{header: "trace-1", next: http.DefaultTransport}
+	cfg, err := createConfig("https://localhost", RoundTripper(rt), Timeout(5*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, rt, cfg.HTTPClient.Transport)
+	assert.Equal(t, 5*time.Second, cfg.HTTPClient.Timeout)
+
+	var traces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traces = append(traces, r.Header.Get("X-Trace"))
+	}))
+	defer server.Close()
+	sender, err := NewSender(server.URL, RoundTripper(rt), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 2, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+	assert.Equal(t, []string{"trace-1"}, traces)
+}
+
+func TestProxyURL(t *testing.T) {
+	cfg, err := createConfig("https://localhost")
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.HTTPClient.Transport.(*http.Transport).Proxy)
+
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	sender, err := NewSender("http://collector.example.com:2878", ProxyURL(proxyURL), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my metric", 2, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+	assert.Equal(t, []string{"http://collector.example.com:2878/report?f=wavefront"}, proxied)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	cfg, err := createConfig("https://localhost", Timeout(time.Second), RequestTimeout(5*time.Second, 10*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, cfg.HTTPClient.Timeout)
+	assert.Len(t, cfg.reporterOptions(), 1)
+
+	cfg, err = createConfig("https://localhost", Timeout(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.HTTPClient.Timeout)
+	assert.Empty(t, cfg.reporterOptions())
+}
+
 func TestHTTPClient(t *testing.T) {
 	client := &http.Client{}
 	cfg, err := createConfig("https://localhost", HTTPClient(client))
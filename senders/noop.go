@@ -1,6 +1,9 @@
 package senders
 
 import (
+	"context"
+	"time"
+
 	"github.com/wavefronthq/wavefront-sdk-go/event"
 	"github.com/wavefronthq/wavefront-sdk-go/histogram"
 )
@@ -28,14 +31,38 @@ func (sender *noOpSender) SendMetric(string, float64, int64, string, map[string]
 	return nil
 }
 
+func (sender *noOpSender) SendIntMetric(string, int64, int64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendBoolMetric(string, bool, int64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendAny(string, interface{}, int64, string, map[string]string) error {
+	return nil
+}
+
 func (sender *noOpSender) SendDeltaCounter(string, float64, string, map[string]string) error {
 	return nil
 }
 
+func (sender *noOpSender) SendDeltaCounterWithTimestamp(string, float64, int64, string, map[string]string) error {
+	return nil
+}
+
 func (sender *noOpSender) SendDistribution(string, []histogram.Centroid, map[histogram.Granularity]bool, int64, string, map[string]string) error {
 	return nil
 }
 
+func (sender *noOpSender) RecordDistribution(string, float64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendDuration(string, time.Duration, string, map[string]string) error {
+	return nil
+}
+
 func (sender *noOpSender) SendSpan(string, int64, int64, string, string, string, []string, []string, []SpanTag, []SpanLog) error {
 	return nil
 }
@@ -44,6 +71,38 @@ func (sender *noOpSender) SendEvent(string, int64, int64, string, map[string]str
 	return nil
 }
 
+func (sender *noOpSender) SendMetrics([]MetricPoint) error {
+	return nil
+}
+
+func (sender *noOpSender) SendDistributions([]Distribution) error {
+	return nil
+}
+
+func (sender *noOpSender) SendSpans([]Span) error {
+	return nil
+}
+
+func (sender *noOpSender) SendMetricCtx(context.Context, string, float64, int64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendDeltaCounterCtx(context.Context, string, float64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendDistributionCtx(context.Context, string, []histogram.Centroid, map[histogram.Granularity]bool, int64, string, map[string]string) error {
+	return nil
+}
+
+func (sender *noOpSender) SendSpanCtx(context.Context, string, int64, int64, string, string, string, []string, []string, []SpanTag, []SpanLog) error {
+	return nil
+}
+
+func (sender *noOpSender) SendEventCtx(context.Context, string, int64, int64, string, map[string]string, ...event.Option) error {
+	return nil
+}
+
 func (sender *noOpSender) Close() {
 	// no-op
 }
@@ -52,6 +111,14 @@ func (sender *noOpSender) Flush() error {
 	return nil
 }
 
+func (sender *noOpSender) FlushWithReport() FlushReport {
+	return FlushReport{}
+}
+
+func (sender *noOpSender) Health() Health {
+	return Health{Connected: true}
+}
+
 func (sender *noOpSender) GetFailureCount() int64 {
 	return 0
 }
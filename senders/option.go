@@ -2,10 +2,16 @@ package senders
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"time"
 
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
 )
 
@@ -15,6 +21,69 @@ type Option func(*configuration)
 type httpClientConfiguration struct {
 	Timeout         time.Duration
 	TLSClientConfig *tls.Config
+	FallbackDelay   time.Duration
+	KeepAlive       time.Duration
+	ClientCertFile  string
+	ClientKeyFile   string
+	CACertFile      string
+	RoundTripper    http.RoundTripper
+	ProxyURL        *url.URL
+}
+
+// transport returns RoundTripper, or a transport with the proxy, TLS and dialer settings.
+func (c *httpClientConfiguration) transport() (http.RoundTripper, error) {
+	if c.RoundTripper != nil {
+		return c.RoundTripper, nil
+	}
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsCfg,
+	}
+	if c.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(c.ProxyURL)
+	}
+	if c.FallbackDelay != 0 || c.KeepAlive != 0 {
+		transport.DialContext = (&net.Dialer{
+			FallbackDelay: c.FallbackDelay,
+			KeepAlive:     c.KeepAlive,
+		}).DialContext
+	}
+	return transport, nil
+}
+
+// tlsConfig returns TLSClientConfig with the client certificate and the CA certificates of
+// TLSClientCert and CACert, loaded from their files.
+func (c *httpClientConfiguration) tlsConfig() (*tls.Config, error) {
+	if c.ClientCertFile == "" && c.CACertFile == "" {
+		return c.TLSClientConfig, nil
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.TLSClientConfig != nil {
+		tlsCfg = c.TLSClientConfig.Clone()
+	}
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load TLS client certificate: %s", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.CACertFile != "" {
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM encoded CA certificate found in %s", c.CACertFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
 }
 
 // APIToken configures the sender to use a Wavefront API Token for authentication
@@ -45,7 +114,7 @@ func CSPBaseURL(baseURL string) CSPOption {
 func CSPOrgID(orgID string) CSPOption {
 	return func(authentication any) {
 		switch a := authentication.(type) {
-		case auth.CSPClientCredentials:
+		case *auth.CSPClientCredentials:
 			a.OrgID = &orgID
 		}
 	}
@@ -80,6 +149,39 @@ func CSPClientCredentials(clientID string, clientSecret string, options ...CSPOp
 	}
 }
 
+// TokenService fetches the access tokens of direct ingestion from an identity provider other than
+// CSP, see CustomTokenService.
+type TokenService interface {
+	// Token returns a new access token, and how long it is valid for.
+	Token() (token string, expiresIn time.Duration, err error)
+}
+
+// CustomTokenService configures the sender to authenticate direct ingestion with the access
+// tokens of service. As with CSP authentication, tokens are fetched on the first report and
+// refreshed in the background before they expire: 3 minutes before for tokens valid 10 minutes
+// or more, 30 seconds before for shorter ones. Tokens valid less than 30 seconds, and failed
+// fetches, are refreshed every minute.
+func CustomTokenService(service TokenService) Option {
+	return func(c *configuration) {
+		c.Authentication = service
+	}
+}
+
+// Authorizer authorizes the requests of a sender, see CustomAuthorizer.
+type Authorizer = auth.Authorizer
+
+// CustomAuthorizer configures the sender to authenticate direct ingestion with authorizer, e.g.
+// to plug bearer tokens, AWS SigV4 or HMAC signatures in. Authorize is called on every request
+// once its URL and body are final, the body being readable again with GetBody, and before the
+// headers of ExtraHeaders and TenantID are set and those of AuthHeaders and TenantHeaders are
+// renamed. Requests Authorize fails on are not retried, as for any authentication error.
+// authorizer is closed with the sender if it has a Close method.
+func CustomAuthorizer(authorizer Authorizer) Option {
+	return func(c *configuration) {
+		c.Authentication = auth.CustomAuthorizer{Authorizer: authorizer}
+	}
+}
+
 // BatchSize set max batch of data sent per flush interval. Defaults to 10,000. recommended not to exceed 40,000.
 func BatchSize(n int) Option {
 	return func(cfg *configuration) {
@@ -94,6 +196,117 @@ func MaxBufferSize(n int) Option {
 	}
 }
 
+// OverflowPolicy decides what happens to data sent while the internal buffer is full,
+// see BufferOverflow.
+type OverflowPolicy struct {
+	policy  internal.OverflowPolicy
+	timeout time.Duration
+}
+
+var (
+	// DropNewest rejects the data being sent with an error. This is the default policy.
+	DropNewest = OverflowPolicy{policy: internal.OverflowDropNewest}
+	// DropOldest discards the oldest buffered data to make room for the data being sent.
+	// Discarded lines are counted in the <type>.buffer.evicted internal metric, e.g. points.buffer.evicted.
+	DropOldest = OverflowPolicy{policy: internal.OverflowDropOldest}
+	// Block makes Send* calls wait until a flush makes room in the buffer.
+	Block = OverflowPolicy{policy: internal.OverflowBlock}
+)
+
+// BlockWithTimeout makes Send* calls wait up to timeout for room in the buffer,
+// and reject the data being sent with an error after that.
+func BlockWithTimeout(timeout time.Duration) OverflowPolicy {
+	return OverflowPolicy{policy: internal.OverflowBlock, timeout: timeout}
+}
+
+// BufferOverflow sets what happens to data sent while the internal buffer of its type is full,
+// so that critical pipelines can apply backpressure instead of losing data. Defaults to DropNewest.
+// Context-aware Send*Ctx calls always wait until their context is done.
+func BufferOverflow(policy OverflowPolicy) Option {
+	return func(cfg *configuration) {
+		cfg.OverflowPolicy = policy
+	}
+}
+
+// ErrBufferFull is the reason given to OnDropped for the lines dropped for lack of room in a buffer.
+var ErrBufferFull = internal.ErrBufferFull
+
+// OnDropped calls fn with the lines the sender drops, so that applications can log or
+// dead-letter them. reason is ErrBufferFull when a buffer is full, see BufferOverflow, or the
+// error of a report rejected for good, e.g. an authentication error, or of the last flush of Close
+// when the lines cannot be persisted. Lines are formatted, each ending with a newline. fn is
+// called from the goroutine dropping the lines, possibly while a flush is in progress, so it
+// should return quickly and must not flush the sender.
+func OnDropped(fn func(lines []string, reason error)) Option {
+	return func(cfg *configuration) {
+		cfg.OnDropped = fn
+	}
+}
+
+// RateLimit limits the number of metric, distribution and span lines reported per second,
+// across data types, so that backfills and replays do not overwhelm a proxy or trip the
+// throttling of a collector. Flushes wait for the rate to allow their batch, and lines
+// queue up in the internal buffers meanwhile. Span logs and events are not limited.
+func RateLimit(pointsPerSecond int) Option {
+	return func(cfg *configuration) {
+		cfg.RateLimit = pointsPerSecond
+	}
+}
+
+// MetricsFlushInterval sets the interval at which metrics and delta counters are flushed,
+// instead of FlushInterval.
+func MetricsFlushInterval(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.MetricsFlushInterval = interval
+	}
+}
+
+// DistributionsFlushInterval sets the interval at which distributions are flushed, instead of FlushInterval.
+func DistributionsFlushInterval(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.DistributionsFlushInterval = interval
+	}
+}
+
+// TracesFlushInterval sets the interval at which spans and span logs are flushed, instead of
+// FlushInterval, e.g. to flush traces more often than metrics.
+func TracesFlushInterval(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.TracesFlushInterval = interval
+	}
+}
+
+// EventsFlushInterval sets the interval at which events are flushed, instead of FlushInterval.
+func EventsFlushInterval(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.EventsFlushInterval = interval
+	}
+}
+
+// DisableDistributions makes SendDistribution a no-op returning nil, so that no distribution
+// leaves the process. Discarded distributions are counted in the histograms.disabled internal metric.
+func DisableDistributions() Option {
+	return func(cfg *configuration) {
+		cfg.DisableDistributions = true
+	}
+}
+
+// DisableSpans makes SendSpan a no-op returning nil, so that no span nor span log leaves the
+// process. Discarded spans are counted in the spans.disabled internal metric.
+func DisableSpans() Option {
+	return func(cfg *configuration) {
+		cfg.DisableSpans = true
+	}
+}
+
+// DisableEvents makes SendEvent a no-op returning nil, so that no event leaves the process.
+// Discarded events are counted in the events.disabled internal metric.
+func DisableEvents() Option {
+	return func(cfg *configuration) {
+		cfg.DisableEvents = true
+	}
+}
+
 // FlushIntervalSeconds set the interval (in seconds) at which to flush data to Wavefront. Defaults to 1 Second.
 func FlushIntervalSeconds(n int) Option {
 	return func(cfg *configuration) {
@@ -108,6 +321,78 @@ func FlushInterval(interval time.Duration) Option {
 	}
 }
 
+// FlushThreshold flushes the buffer of a data type as soon as it holds n lines, and keeps flushing
+// it while it does, instead of waiting for the next flush interval, so that bursts of data are
+// sent before the buffer fills up. Zero, the default, flushes on the interval only.
+func FlushThreshold(n int) Option {
+	return func(cfg *configuration) {
+		cfg.FlushThreshold = n
+	}
+}
+
+// MaxPayloadBytes splits flushes into report requests of at most n bytes of lines each, before
+// compression, for endpoints rejecting larger payloads with a 413 status. When a request fails,
+// the lines of the next ones are buffered for a later flush. A line longer than n is sent on its
+// own. Zero, the default, sends each batch of BatchSize lines in a single request.
+func MaxPayloadBytes(n int) Option {
+	return func(cfg *configuration) {
+		cfg.MaxPayloadBytes = n
+	}
+}
+
+// MinuteBucketedDeltaCounters aggregates delta counters client-side in buckets aligned to minute
+// boundaries. One line per series per minute is sent once the minute is over, or when the sender
+// is closed, timestamped with the start of the minute, instead of one line per SendDeltaCounter
+// call.
+func MinuteBucketedDeltaCounters() Option {
+	return func(cfg *configuration) {
+		cfg.DeltaCounterBucket = time.Minute
+	}
+}
+
+// AggregateDeltaCounters sums the increments of each delta counter series client-side, and sends
+// one line per series per flush interval instead of one line per SendDeltaCounter call. Unlike
+// MinuteBucketedDeltaCounters, the lines are timestamped by the server, unless the increments
+// were sent with SendDeltaCounterWithTimestamp.
+func AggregateDeltaCounters() Option {
+	return func(cfg *configuration) {
+		cfg.AggregateDeltaCounters = true
+	}
+}
+
+// DeltaCounterTimestampSkew sets how far from now, in the past or the future, the timestamps
+// passed to SendDeltaCounterWithTimestamp may be. Defaults to 1 hour; backfill tools replaying
+// older data should raise it.
+func DeltaCounterTimestampSkew(skew time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.DeltaCounterSkew = skew
+	}
+}
+
+// PersistentBuffer stores the batches that could not be delivered in dir, instead of in memory,
+// so that they survive process restarts. Each data type (points, histograms, spans, span logs
+// and events) is stored in its own subdirectory, holding up to maxBytes; zero means no limit.
+// Stored batches are replayed, oldest first, once the endpoint is reachable again.
+// Senders must not share a directory.
+func PersistentBuffer(dir string, maxBytes int64) Option {
+	return func(cfg *configuration) {
+		cfg.PersistenceDir = dir
+		cfg.PersistenceMaxBytes = maxBytes
+	}
+}
+
+// FlushLatencyBudget sets how long a single flush of each data type may take. Flushes taking
+// longer are counted in the <type>.flush.budget_exceeded internal metric, e.g. points.flush.budget_exceeded.
+// With adaptiveBatchSize set, the batch size of a data type is halved each time its flush goes over
+// budget, and grows back towards BatchSize once flushes are fast again, giving latency-sensitive
+// applications a predictable worst-case flush duration at the expense of more requests.
+func FlushLatencyBudget(budget time.Duration, adaptiveBatchSize bool) Option {
+	return func(cfg *configuration) {
+		cfg.FlushLatencyBudget = budget
+		cfg.AdaptiveBatchSize = adaptiveBatchSize
+	}
+}
+
 // MetricsPort sets the port on which to report metrics. Default is 2878.
 func MetricsPort(port int) Option {
 	return func(cfg *configuration) {
@@ -133,6 +418,90 @@ func Timeout(timeout time.Duration) Option {
 	}
 }
 
+// RequestTimeout scales the timeout of report requests with their size, instead of the flat Timeout:
+// each attempt times out after base plus perMB for every MiB of its compressed body, so that small
+// batches fail fast while large ones over slow links are not cut short, e.g.
+//
+//	RequestTimeout(5*time.Second, 10*time.Second)
+//
+// times out empty batches after 5 seconds and 4 MiB ones after 45 seconds. A zero base keeps the flat
+// Timeout. The Timeout of a client given with HTTPClient still applies.
+func RequestTimeout(base, perMB time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.RequestTimeoutBase = base
+		cfg.RequestTimeoutPerMB = perMB
+	}
+}
+
+// DualStackFallbackDelay sets how long to wait for a connection over the preferred address family
+// of a dual-stack (IPv4 and IPv6) endpoint before racing a connection over the other family,
+// as in RFC 6555 "happy eyeballs". Defaults to 300ms. A negative delay disables the fallback race.
+func DualStackFallbackDelay(delay time.Duration) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using DualStackFallbackDelay after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set the dialer on the HTTPClient transport directly")
+		}
+		cfg.httpClientConfiguration.FallbackDelay = delay
+	}
+}
+
+// KeepAlive sets the interval between TCP keep-alive probes of the connections to Wavefront,
+// which keep idle connections open through NATs and load balancers between flushes.
+// Defaults to 15 seconds. A negative interval disables keep-alive probes.
+func KeepAlive(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using KeepAlive after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set the dialer on the HTTPClient transport directly")
+		}
+		cfg.httpClientConfiguration.KeepAlive = interval
+	}
+}
+
+// MaxRetries sets how many times a report request failing with a transient error (a 429 or 503
+// status, or a connection reset) is retried before its lines are buffered for the next flush.
+// Defaults to 0, no retries.
+func MaxRetries(n int) Option {
+	return func(cfg *configuration) {
+		cfg.MaxRetries = n
+	}
+}
+
+// RetryBackoff sets the delay before the first retry. It doubles on each retry, with jitter,
+// up to 30 seconds. A Retry-After header sent by the server takes precedence. Defaults to 500ms.
+func RetryBackoff(backoff time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.RetryBackoff = backoff
+	}
+}
+
+// RetryBudget bounds the retries of MaxRetries across all the report requests of the sender, so that
+// a flapping endpoint does not see its load multiplied by retries: within each minute, at most
+// maxPerMinute requests are retried, and at most maxRatio of the requests, e.g. 0.1 for 10%.
+// Zero disables either limit. Requests over budget are not retried: their lines are buffered, or
+// persisted with PersistentBuffer, for a later flush, and counted in the retries.shed internal metric.
+func RetryBudget(maxPerMinute int, maxRatio float64) Option {
+	return func(cfg *configuration) {
+		cfg.RetryBudgetPerMinute = maxPerMinute
+		cfg.RetryBudgetRatio = maxRatio
+	}
+}
+
+// CircuitBreaker stops reporting a data type after failures consecutive failed report requests, so
+// that a dead endpoint does not hold flushes and their goroutines up on requests bound to fail. The
+// breaker then stays open for coolDown: flushes are skipped, returning an error matching
+// ErrCircuitOpen, and new lines are dropped, unless PersistentBuffer is used, in which case
+// buffered lines are spooled to disk instead. After coolDown, the next flush sends a probe batch,
+// closing the breaker if it succeeds and opening it again for coolDown otherwise. Openings are
+// counted in the circuit_breaker.opened internal metrics.
+func CircuitBreaker(failures int, coolDown time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.CircuitBreakerFailures = failures
+		cfg.CircuitBreakerCoolDown = coolDown
+	}
+}
+
 // HTTPClient sets the http.Client used to send data to Wavefront.
 // Overrides TLSConfigOptions and Timeout.
 func HTTPClient(client *http.Client) Option {
@@ -141,6 +510,33 @@ func HTTPClient(client *http.Client) Option {
 	}
 }
 
+// ProxyURL sends the requests through the HTTP or HTTPS proxy at proxyURL, e.g. an egress proxy,
+// instead of the proxy of the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables, which
+// are honored by default.
+func ProxyURL(proxyURL *url.URL) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using ProxyURL after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set the proxy on the HTTPClient transport directly")
+		}
+		cfg.httpClientConfiguration.ProxyURL = proxyURL
+	}
+}
+
+// RoundTripper sets the http.RoundTripper requests are sent with, e.g. a transport with its own
+// connection pool, or wrapping http.DefaultTransport with tracing middleware. Unlike HTTPClient,
+// Timeout still applies. Overrides TLSConfigOptions, TLSClientCert, CACert, ProxyURL,
+// DualStackFallbackDelay and KeepAlive, which configure the default transport.
+func RoundTripper(rt http.RoundTripper) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using RoundTripper after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set Transport on the HTTPClient directly")
+		}
+		cfg.httpClientConfiguration.RoundTripper = rt
+	}
+}
+
 // TLSConfigOptions sets the tls.Config used by the HTTP Client to send data to Wavefront.
 func TLSConfigOptions(tlsCfg *tls.Config) Option {
 	tlsCfgCopy := tlsCfg.Clone()
@@ -153,6 +549,32 @@ func TLSConfigOptions(tlsCfg *tls.Config) Option {
 	}
 }
 
+// TLSClientCert authenticates the sender with the client certificate and key of the PEM encoded
+// files certFile and keyFile, for endpoints requiring mutual TLS. Applies on top of
+// TLSConfigOptions, to the report requests of NewSender, NewOTelReportSender and NewOTLPSender.
+func TLSClientCert(certFile, keyFile string) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using TLSClientCert after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set TLSClientConfig on the HTTPClient directly")
+		}
+		cfg.httpClientConfiguration.ClientCertFile = certFile
+		cfg.httpClientConfiguration.ClientKeyFile = keyFile
+	}
+}
+
+// CACert verifies the certificate of the endpoint against the PEM encoded CA certificates of the
+// file at path, instead of the system roots, e.g. for collectors with certificates of a private CA.
+func CACert(path string) Option {
+	return func(cfg *configuration) {
+		if cfg.HTTPClient != nil {
+			log.Println("using CACert after setting the HTTPClient is not supported." +
+				"If you are using the HTTPClient Option, set TLSClientConfig on the HTTPClient directly")
+		}
+		cfg.httpClientConfiguration.CACertFile = path
+	}
+}
+
 // SendInternalMetrics turns sending of internal SDK metrics on/off.
 func SendInternalMetrics(enabled bool) Option {
 	return func(cfg *configuration) {
@@ -0,0 +1,156 @@
+//go:build e2e
+
+package senders_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+	"github.com/wavefronthq/wavefront-sdk-go/senders/replay"
+)
+
+// The e2e tests run against an OTel collector with the Wavefront receiver. Unless
+// WAVEFRONT_OTEL_URL points at a running collector's report endpoint, TestMain starts
+// one in a container, using WAVEFRONT_OTEL_IMAGE if set, and removes it afterwards.
+//
+// The summaries of the replays are written to WAVEFRONT_REPLAY_RESULTS if set. When
+// WAVEFRONT_REPLAY_BASELINE names the results file of a previous run, the run fails on
+// throughput, error rate or p99 latency regressions beyond replay.DefaultThresholds.
+//
+//	go test -tags e2e ./senders
+const (
+	defaultCollectorImage = "otel/opentelemetry-collector-contrib:latest"
+	collectorConfig       = "testdata/otel-collector.yaml"
+	collectorPort         = "8085/tcp"
+	collectorStartTimeout = 30 * time.Second
+)
+
+func TestMain(m *testing.M) {
+	if url := os.Getenv("WAVEFRONT_OTEL_URL"); url != "" {
+		otelServerURL = url
+		os.Exit(checkReplayResults(m.Run()))
+	}
+
+	container, addr, err := startCollector()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: unable to start the OTel collector: %s\n", err)
+		os.Exit(1)
+	}
+	otelServerURL = "http://" + addr + "/report"
+
+	code := m.Run()
+	_ = exec.Command("docker", "rm", "-f", container).Run()
+	os.Exit(checkReplayResults(code))
+}
+
+// checkReplayResults stores the replay summaries of the run and compares them to the baseline,
+// returning the exit code of the run.
+func checkReplayResults(code int) int {
+	if path := os.Getenv("WAVEFRONT_REPLAY_RESULTS"); path != "" {
+		if err := replay.WriteResults(path, replayResults); err != nil {
+			fmt.Fprintf(os.Stderr, "e2e: unable to write the replay results: %s\n", err)
+			return 1
+		}
+	}
+	path := os.Getenv("WAVEFRONT_REPLAY_BASELINE")
+	if path == "" {
+		return code
+	}
+	baseline, err := replay.ReadResults(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: unable to read the replay baseline: %s\n", err)
+		return 1
+	}
+	regressions := replay.CompareResults(baseline, replayResults, replay.DefaultThresholds())
+	for _, regression := range regressions {
+		fmt.Fprintf(os.Stderr, "e2e: replay regression: %s\n", regression)
+	}
+	if len(regressions) > 0 {
+		return 1
+	}
+	return code
+}
+
+// startCollector runs the collector container and waits for its report port to accept
+// connections. It returns the container ID and the host address the port is published on.
+func startCollector() (string, string, error) {
+	image := os.Getenv("WAVEFRONT_OTEL_IMAGE")
+	if image == "" {
+		image = defaultCollectorImage
+	}
+	config, err := filepath.Abs(collectorConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"-p", "127.0.0.1::"+collectorPort,
+		"-v", config+":/etc/otelcol-contrib/config.yaml:ro",
+		image).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("docker run: %s", err)
+	}
+	container := strings.TrimSpace(string(out))
+
+	out, err = exec.Command("docker", "port", container, collectorPort).Output()
+	if err != nil {
+		_ = exec.Command("docker", "rm", "-f", container).Run()
+		return "", "", fmt.Errorf("docker port: %s", err)
+	}
+	addr := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	deadline := time.Now().Add(collectorStartTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return container, addr, nil
+		}
+		if time.Now().After(deadline) {
+			_ = exec.Command("docker", "rm", "-f", container).Run()
+			return "", "", fmt.Errorf("collector not listening on %s after %s: %s", addr, collectorStartTimeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func TestOTelCollector_Replay(t *testing.T) {
+	for _, contentType := range otelContentTypes() {
+		t.Run(contentType, func(t *testing.T) {
+			replayOTelReport(t, "testdata/e2e", contentType,
+				replay.ReplayCount(2), replay.BatchSize(2), replay.RewriteTimestamps())
+		})
+	}
+}
+
+func TestOTelCollector_Sender(t *testing.T) {
+	for _, contentType := range otelContentTypes() {
+		t.Run(contentType, func(t *testing.T) {
+			sender, err := senders.NewOTelReportSender(otelServerURL,
+				senders.OTelContentType(contentType),
+				senders.TenantID(tenantID),
+			)
+			require.NoError(t, err)
+			defer sender.Close()
+
+			require.NoError(t, sender.SendMetric("e2e.sender.metric", 1, 0, "e2e-host",
+				map[string]string{"content_type": contentType}))
+			require.NoError(t, sender.SendDeltaCounter("e2e.sender.counter", 2, "e2e-host", nil))
+			require.NoError(t, sender.Flush())
+			assert.Equal(t, int64(0), sender.GetFailureCount())
+		})
+	}
+}
+
+func otelContentTypes() []string {
+	return []string{"text/plain", "application/octet-stream", "application/x-www-form-urlencoded"}
+}
@@ -0,0 +1,92 @@
+package senders
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+const defaultOTelContentType = "application/octet-stream"
+
+var otelContentTypes = []string{
+	"text/plain",
+	"application/octet-stream",
+	"application/x-www-form-urlencoded",
+}
+
+// OTelContentType sets the Content-Type used by an OTel report sender. It must be one of
+// text/plain, application/octet-stream (the default) or application/x-www-form-urlencoded.
+// Lines are sent raw whatever the Content-Type, as the collector expects.
+func OTelContentType(contentType string) Option {
+	return func(cfg *configuration) {
+		cfg.OTelContentType = contentType
+	}
+}
+
+// NewOTelReportSender creates a Sender that reports to the /report endpoint of an OTel collector,
+// e.g. http://localhost:8085/report. Unlike NewSender, reportURL is used as is: no port is
+// defaulted and no path is appended. Metrics, distributions, spans and events are batched and
+// flushed like with NewSender, and posted in the Wavefront data format, uncompressed
+// unless Compression(true) is given.
+// Use TenantID to identify the tenant.
+func NewOTelReportSender(reportURL string, setters ...Option) (Sender, error) {
+	cfg, err := createConfig(reportURL, setters...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create sender config: %s", err)
+	}
+
+	// the credentials of reportURL were moved to cfg.Authentication.
+	reportURL = withoutUserInfo(reportURL)
+	ep, err := otelEndpoint(reportURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sender := newRealSender(cfg, ep, otelEndpoint)
+	// the collector parses events as lines, even when authenticating with a token.
+	sender.lineEvents = true
+	sender.Start()
+	sender.sendStartupMetric(cfg, reportURL)
+	return sender, nil
+}
+
+func otelEndpoint(reportURL string, cfg *configuration) (endpoint, error) {
+	reportURL = withoutUserInfo(reportURL)
+	contentType := cfg.OTelContentType
+	if contentType == "" {
+		contentType = defaultOTelContentType
+	}
+	if !supportedOTelContentType(contentType) {
+		return endpoint{}, fmt.Errorf("unsupported Content-Type '%s', expected one of %s",
+			contentType, strings.Join(otelContentTypes, ", "))
+	}
+
+	tokenService := tokenServiceForCfg(cfg)
+	return endpoint{
+		tokenService: tokenService,
+		newReporters: func(options ...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			reporter := internal.NewOTelReporter(reportURL, contentType, tokenService, cfg.HTTPClient, options...)
+			return reporter, reporter
+		},
+	}, nil
+}
+
+// withoutUserInfo removes the credentials from rawURL, keeping the rest of it as is.
+func withoutUserInfo(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func supportedOTelContentType(contentType string) bool {
+	for _, supported := range otelContentTypes {
+		if contentType == supported {
+			return true
+		}
+	}
+	return false
+}
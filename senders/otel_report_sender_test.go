@@ -0,0 +1,170 @@
+package senders
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTelReportSender(t *testing.T) {
+	var mtx sync.Mutex
+	var lines []string
+	var headers []http.Header
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mtx.Lock()
+		defer mtx.Unlock()
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		headers = append(headers, r.Header)
+		paths = append(paths, r.URL.String())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender, err := NewOTelReportSender(server.URL+"/report",
+		TenantID("16"),
+		OTelContentType("text/plain"),
+		SendInternalMetrics(false),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("cpu.usage", 85.5, 1234567890, "server-01", map[string]string{"env": "prod"}))
+	require.NoError(t, sender.SendMetric("memory.used", 4096, 1234567890, "server-01", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Equal(t, []string{
+		"\"cpu.usage\" 85.5 1234567890 source=\"server-01\" \"env\"=\"prod\"",
+		"\"memory.used\" 4096 1234567890 source=\"server-01\"",
+	}, lines)
+	require.Len(t, headers, 1)
+	assert.Equal(t, "/report", paths[0])
+	assert.Equal(t, "16", headers[0].Get("dx_tenant_id"))
+	assert.Equal(t, "text/plain", headers[0].Get("Content-Type"))
+	assert.Empty(t, headers[0].Get("Content-Encoding"))
+}
+
+func TestOTelReportSender_UserInfo(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer server.Close()
+
+	sender, err := NewOTelReportSender(strings.Replace(server.URL, "http://", "http://token@", 1)+"/report",
+		AuthHeaders("X-API-Key"),
+		SendInternalMetrics(false),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("cpu.usage", 85.5, 1234567890, "server-01", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	// the credentials are only sent as the token, not as basic auth.
+	r := <-requests
+	assert.Equal(t, "Bearer token", r.Header.Get("X-API-Key"))
+	assert.Empty(t, r.Header.Get("Authorization"))
+}
+
+func TestOTelReportSender_HeaderNames(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	sender, err := NewOTelReportSender(server.URL+"/report",
+		APIToken("secret"),
+		AuthHeaders("X-API-Key"),
+		TenantID("16"),
+		TenantHeaders("X-Tenant", "dx_tenant_id"),
+		SendInternalMetrics(false),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "bar", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	header := <-headers
+	assert.Equal(t, "Bearer secret", header.Get("X-API-Key"))
+	assert.Empty(t, header.Get("Authorization"))
+	assert.Equal(t, "16", header.Get("X-Tenant"))
+	assert.Equal(t, "16", header.Get("dx_tenant_id"))
+}
+
+func TestOTelReportSender_ExtraHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	sender, err := NewOTelReportSender(server.URL+"/report",
+		ExtraHeaders(map[string]string{"X-Route": "blue", "dx_tenant_id": "1"}),
+		ExtraHeaders(map[string]string{"X-Scope-OrgID": "acme"}),
+		TenantID("16"),
+		SendInternalMetrics(false),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "bar", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	header := <-headers
+	assert.Equal(t, "blue", header.Get("X-Route"))
+	assert.Equal(t, "acme", header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "16", header.Get("dx_tenant_id"))
+}
+
+func TestOTelReportSender_Compression(t *testing.T) {
+	received := make(chan []string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		lines, err := decodeLines(r)
+		assert.NoError(t, err)
+		received <- lines
+	}))
+	defer server.Close()
+
+	sender, err := NewOTelReportSender(server.URL+"/report", Compression(true), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "bar", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	assert.Equal(t, []string{"\"foo\" 1 source=\"bar\""}, <-received)
+}
+
+func TestOTelReportSender_InvalidOptions(t *testing.T) {
+	_, err := NewOTelReportSender("http://localhost:8085/report", OTelContentType("application/json"))
+	assert.Error(t, err)
+
+	_, err = NewOTelReportSender("localhost:8085/report")
+	assert.Error(t, err)
+}
+
+func TestOTelReportSender_Events(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	// events are sent as lines, even with a token.
+	sender, err := NewOTelReportSender(server.URL+"/report", APIToken("token"), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendEvent("deploy", 1700000000, 1700000060, "web-01", nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	assert.Equal(t, "@Event 1700000000000 1700000060000 \"deploy\" host=\"web-01\"\n", <-bodies)
+}
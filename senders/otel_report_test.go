@@ -1,3 +1,5 @@
+//go:build e2e
+
 package senders_test
 
 import (
@@ -8,34 +10,21 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders/replay"
 )
 
+// otelServerURL is the collector's report endpoint, set up by TestMain.
+var otelServerURL = "http://localhost:8085/report"
+
 const (
-	otelServerURL     = "http://localhost:8085/report"
 	tenantID          = "16"
 	metricsDataFolder = "./test/wf-dumps/" // Base folder for test metrics data
 )
 
-// ReplayConfig holds configuration for replaying metrics
-type ReplayConfig struct {
-	ReplayCount  int           // Number of times to replay each file (default: 1)
-	SleepBetween time.Duration // Sleep duration between file replays (default: 1 second)
-	BatchSize    int           // Number of lines per batch (default: 5000)
-	ContentType  string        // HTTP Content-Type header (default: application/octet-stream)
-}
-
-// DefaultReplayConfig returns the default replay configuration
-func DefaultReplayConfig() ReplayConfig {
-	return ReplayConfig{
-		ReplayCount:  1,
-		SleepBetween: 1 * time.Second,
-		BatchSize:    5000,
-		ContentType:  "application/octet-stream",
-	}
-}
-
 // TestOTelReport_SingleMetric tests sending a single metric to the OTel server
 func TestOTelReport_SingleMetric(t *testing.T) {
 	metric := `"test.metric" 100 1234567890 source="test-host"`
@@ -154,203 +143,53 @@ func TestOTelReport_5000Lines(t *testing.T) {
 }
 
 func TestOTelReport__OctetStream_AllFiles(t *testing.T) {
-	metricsDir := metricsDataFolder + "locust-loadgen-0"
-	config := DefaultReplayConfig()
-	sendOTelReport_UsingAllFiles(t, metricsDir, config)
+	replayOTelReport(t, metricsDataFolder+"locust-loadgen-0", "application/octet-stream")
 }
 
 func TestOTelReport__FormUrlEncoded_AllFiles(t *testing.T) {
-	metricsDir := metricsDataFolder + "locust-loadgen-0"
-	config := DefaultReplayConfig()
-	config.ContentType = "application/x-www-form-urlencoded"
-	sendOTelReport_UsingAllFiles(t, metricsDir, config)
-}
-
-// sendOTelReport_UsingAllFiles sends all metrics from all files in batches
-func sendOTelReport_UsingAllFiles(t *testing.T, metricsDir string, config ReplayConfig) {
-	files, err := os.ReadDir(metricsDir)
-	if err != nil {
-		t.Fatalf("Failed to read metrics directory: %v", err)
-	}
-
-	totalLines := 0
-	totalFiles := 0
-	totalBatches := 0
-	totalReplays := 0
-
-	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".txt.log") {
-			continue
-		}
-
-		filePath := fmt.Sprintf("%s/%s", metricsDir, file.Name())
-
-		t.Run(file.Name(), func(t *testing.T) {
-			lines, err := readAllLines(filePath)
-			if err != nil {
-				t.Errorf("Failed to read file %s: %v", file.Name(), err)
-				return
-			}
-
-			fileLines := 0
-			fileBatches := 0
-
-			// Replay the file multiple times
-			for replay := 1; replay <= config.ReplayCount; replay++ {
-				if config.ReplayCount > 1 {
-					t.Logf("Replay %d/%d for %s", replay, config.ReplayCount, file.Name())
-				}
-
-				// Send in batches
-				for i := 0; i < len(lines); i += config.BatchSize {
-					end := i + config.BatchSize
-					if end > len(lines) {
-						end = len(lines)
-					}
-
-					batch := lines[i:end]
-					payload := strings.Join(batch, "\n")
-
-					start := time.Now()
-					err = sendToOTel(payload, config.ContentType)
-					duration := time.Since(start)
-
-					if err != nil {
-						t.Errorf("Failed to send batch from %s (replay %d): %v", file.Name(), replay, err)
-						return
-					}
-
-					fileBatches++
-					fileLines += len(batch)
-					t.Logf("Sent batch %d: %d lines in %v", fileBatches, len(batch), duration)
-				}
-
-				// Sleep between replays (except after the last replay)
-				if replay < config.ReplayCount {
-					t.Logf("Sleeping for %v before next replay...", config.SleepBetween)
-					time.Sleep(config.SleepBetween)
-				}
-			}
-
-			t.Logf("Successfully sent %d lines from %s in %d batches (%d replays)",
-				fileLines, file.Name(), fileBatches, config.ReplayCount)
-			totalLines += fileLines
-			totalFiles++
-			totalBatches += fileBatches
-			totalReplays += config.ReplayCount
-		})
-	}
-
-	t.Logf("Total: Sent %d lines from %d files in %d batches (%d total replays)",
-		totalLines, totalFiles, totalBatches, totalReplays)
+	replayOTelReport(t, metricsDataFolder+"locust-loadgen-0", "application/x-www-form-urlencoded")
 }
 
 // TestOTelReport_Batched tests sending metrics in batches
 func TestOTelReport_Batched(t *testing.T) {
-	metricsFile := metricsDataFolder + "locust-loadgen-0/dxotel-metrics-0.txt.log"
-	batchSize := 1000
-	contentType := "application/octet-stream"
-
-	lines, err := readAllLines(metricsFile)
-	if err != nil {
-		t.Fatalf("Failed to read metrics file: %v", err)
-	}
-
-	t.Logf("Read %d lines, sending in batches of %d", len(lines), batchSize)
-
-	totalBatches := 0
-	totalSent := 0
-
-	for i := 0; i < len(lines); i += batchSize {
-		end := i + batchSize
-		if end > len(lines) {
-			end = len(lines)
-		}
-
-		batch := lines[i:end]
-		payload := strings.Join(batch, "\n")
-
-		start := time.Now()
-		err := sendToOTel(payload, contentType)
-		duration := time.Since(start)
-
-		if err != nil {
-			t.Errorf("Failed to send batch %d: %v", totalBatches+1, err)
-		} else {
-			t.Logf("Batch %d: Sent %d lines in %v", totalBatches+1, len(batch), duration)
-			totalSent += len(batch)
-			totalBatches++
-		}
-	}
-
-	t.Logf("Total: Sent %d lines in %d batches", totalSent, totalBatches)
+	replayOTelReport(t, metricsDataFolder+"locust-loadgen-0/dxotel-metrics-0.txt.log", "application/octet-stream",
+		replay.BatchSize(1000))
 }
 
 // TestOTelReport_ReplayWithConfig tests replaying a file multiple times with custom config
 func TestOTelReport_ReplayWithConfig(t *testing.T) {
-	metricsFile := metricsDataFolder + "locust-loadgen-0/dxotel-metrics-2mb.txt.log"
-
-	// Custom configuration: replay 3 times with 2 second sleep
-	config := ReplayConfig{
-		ReplayCount:  3,
-		SleepBetween: 2 * time.Second,
-		BatchSize:    1000,
-		ContentType:  "application/octet-stream",
-	}
+	replayOTelReport(t, metricsDataFolder+"locust-loadgen-0/dxotel-metrics-2mb.txt.log", "application/octet-stream",
+		replay.ReplayCount(3), replay.SleepBetween(2*time.Second), replay.BatchSize(1000))
+}
 
-	lines, err := readAllLines(metricsFile)
+// replayOTelReport replays the dump file, or directory of dump files, at path to the collector
+// with the given content type, fails the test if any batch fails, and records the summary of the replay.
+func replayOTelReport(t *testing.T, path, contentType string, options ...replay.Option) {
+	target := replay.NewHTTPTarget(otelServerURL, replay.ContentType(contentType), replay.Header("dx_tenant_id", tenantID))
+	r := replay.New(target, options...)
+	summary, err := r.Replay(path)
 	if err != nil {
-		t.Fatalf("Failed to read metrics file: %v", err)
+		t.Fatalf("Failed to replay %s: %v", path, err)
 	}
-
-	t.Logf("Read %d lines, replaying %d times with %v sleep between replays",
-		len(lines), config.ReplayCount, config.SleepBetween)
-
-	totalBatches := 0
-	totalSent := 0
-
-	// Replay the file multiple times
-	for replay := 1; replay <= config.ReplayCount; replay++ {
-		t.Logf("=== Starting replay %d/%d ===", replay, config.ReplayCount)
-		replayStart := time.Now()
-
-		// Send in batches
-		for i := 0; i < len(lines); i += config.BatchSize {
-			end := i + config.BatchSize
-			if end > len(lines) {
-				end = len(lines)
-			}
-
-			batch := lines[i:end]
-			payload := strings.Join(batch, "\n")
-
-			start := time.Now()
-			err := sendToOTel(payload, config.ContentType)
-			duration := time.Since(start)
-
-			if err != nil {
-				t.Errorf("Failed to send batch (replay %d): %v", replay, err)
-				return
-			}
-
-			totalBatches++
-			totalSent += len(batch)
-			t.Logf("Replay %d, Batch %d: Sent %d lines in %v",
-				replay, (i/config.BatchSize)+1, len(batch), duration)
-		}
-
-		replayDuration := time.Since(replayStart)
-		t.Logf("=== Completed replay %d/%d in %v ===", replay, config.ReplayCount, replayDuration)
-
-		// Sleep between replays (except after the last replay)
-		if replay < config.ReplayCount {
-			t.Logf("Sleeping for %v before next replay...", config.SleepBetween)
-			time.Sleep(config.SleepBetween)
-		}
+	if summary.FailedBatches > 0 {
+		t.Errorf("%d of %d batches failed replaying %s", summary.FailedBatches, summary.Batches, path)
 	}
+	recordReplayResult(t, summary)
+}
+
+// replayResults holds the summaries of the replays of the run, by test name.
+var (
+	replayResultsMtx sync.Mutex
+	replayResults    = replay.Results{}
+)
 
-	t.Logf("Total: Sent %d lines in %d batches across %d replays",
-		totalSent, totalBatches, config.ReplayCount)
+// recordReplayResult stores the summary of the replay of the test t.
+func recordReplayResult(t *testing.T, summary replay.Summary) {
+	t.Logf("Replay run %s summary: %d lines in %d batches (%d failed), %.0f lines/s, p99 latency %s",
+		summary.RunID, summary.Lines, summary.Batches, summary.FailedBatches, summary.LinesPerSecond, summary.P99Latency)
+	replayResultsMtx.Lock()
+	defer replayResultsMtx.Unlock()
+	replayResults[t.Name()] = summary
 }
 
 // Helper function to send data to OTel server
@@ -412,32 +251,3 @@ func readLines(filePath string, maxLines int) ([]string, error) {
 
 	return lines, nil
 }
-
-// Helper function to read all lines from a file
-func readAllLines(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-
-	// Increase buffer size for long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line != "" {
-			lines = append(lines, line)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return lines, nil
-}
@@ -0,0 +1,152 @@
+package senders
+
+import (
+	"encoding/hex"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal/protobuf"
+)
+
+// Encoding of the OTLP messages in the protobuf wire format, with the field numbers of
+// opentelemetry/proto/collector/{metrics,trace}/v1 and the messages they depend on.
+
+func (r otlpMetricsRequest) marshalProto(e *protobuf.Encoder) {
+	for _, rm := range r.ResourceMetrics {
+		e.Message(1, rm.marshalProto)
+	}
+}
+
+func (r otlpResourceMetrics) marshalProto(e *protobuf.Encoder) {
+	e.Message(1, r.Resource.marshalProto)
+	for _, sm := range r.ScopeMetrics {
+		e.Message(2, sm.marshalProto)
+	}
+}
+
+func (r otlpResource) marshalProto(e *protobuf.Encoder) {
+	marshalProtoAttributes(e, 1, r.Attributes)
+}
+
+func (s otlpScope) marshalProto(e *protobuf.Encoder) {
+	e.String(1, s.Name)
+	e.String(2, s.Version)
+}
+
+func (kv otlpKeyValue) marshalProto(e *protobuf.Encoder) {
+	e.String(1, kv.Key)
+	e.Message(2, func(v *protobuf.Encoder) {
+		v.OneofString(1, kv.Value.StringValue)
+	})
+}
+
+func marshalProtoAttributes(e *protobuf.Encoder, field int, attributes []otlpKeyValue) {
+	for _, kv := range attributes {
+		e.Message(field, kv.marshalProto)
+	}
+}
+
+func (s otlpScopeMetrics) marshalProto(e *protobuf.Encoder) {
+	e.Message(1, s.Scope.marshalProto)
+	for _, m := range s.Metrics {
+		e.Message(2, m.marshalProto)
+	}
+}
+
+func (m otlpMetric) marshalProto(e *protobuf.Encoder) {
+	e.String(1, m.Name)
+	if m.Gauge != nil {
+		e.Message(5, func(g *protobuf.Encoder) {
+			for _, dp := range m.Gauge.DataPoints {
+				g.Message(1, dp.marshalProto)
+			}
+		})
+	}
+	if m.Sum != nil {
+		e.Message(7, func(s *protobuf.Encoder) {
+			for _, dp := range m.Sum.DataPoints {
+				s.Message(1, dp.marshalProto)
+			}
+			s.Varint(2, uint64(m.Sum.AggregationTemporality))
+			s.Bool(3, m.Sum.IsMonotonic)
+		})
+	}
+	if m.Summary != nil {
+		e.Message(11, func(s *protobuf.Encoder) {
+			for _, dp := range m.Summary.DataPoints {
+				s.Message(1, dp.marshalProto)
+			}
+		})
+	}
+}
+
+func (dp otlpNumberDataPoint) marshalProto(e *protobuf.Encoder) {
+	e.Fixed64(3, dp.TimeUnixNano)
+	e.OneofDouble(4, dp.AsDouble)
+	marshalProtoAttributes(e, 7, dp.Attributes)
+}
+
+func (dp otlpSummaryDataPoint) marshalProto(e *protobuf.Encoder) {
+	e.Fixed64(3, dp.TimeUnixNano)
+	e.Fixed64(4, dp.Count)
+	e.Double(5, dp.Sum)
+	for _, q := range dp.QuantileValues {
+		q := q
+		e.Message(6, func(v *protobuf.Encoder) {
+			v.Double(1, q.Quantile)
+			v.Double(2, q.Value)
+		})
+	}
+	marshalProtoAttributes(e, 7, dp.Attributes)
+}
+
+func (r otlpTracesRequest) marshalProto(e *protobuf.Encoder) {
+	for _, rs := range r.ResourceSpans {
+		e.Message(1, rs.marshalProto)
+	}
+}
+
+func (r otlpResourceSpans) marshalProto(e *protobuf.Encoder) {
+	e.Message(1, r.Resource.marshalProto)
+	for _, ss := range r.ScopeSpans {
+		e.Message(2, ss.marshalProto)
+	}
+}
+
+func (s otlpScopeSpans) marshalProto(e *protobuf.Encoder) {
+	e.Message(1, s.Scope.marshalProto)
+	for _, span := range s.Spans {
+		e.Message(2, span.marshalProto)
+	}
+}
+
+func (s otlpSpan) marshalProto(e *protobuf.Encoder) {
+	e.BytesField(1, hexBytes(s.TraceID))
+	e.BytesField(2, hexBytes(s.SpanID))
+	e.BytesField(4, hexBytes(s.ParentSpanID))
+	e.String(5, s.Name)
+	e.Fixed64(7, s.StartTimeUnixNano)
+	e.Fixed64(8, s.EndTimeUnixNano)
+	marshalProtoAttributes(e, 9, s.Attributes)
+	for _, event := range s.Events {
+		e.Message(11, event.marshalProto)
+	}
+	for _, link := range s.Links {
+		e.Message(13, link.marshalProto)
+	}
+}
+
+func (ev otlpEvent) marshalProto(e *protobuf.Encoder) {
+	e.Fixed64(1, ev.TimeUnixNano)
+	e.String(2, ev.Name)
+	marshalProtoAttributes(e, 3, ev.Attributes)
+}
+
+func (l otlpLink) marshalProto(e *protobuf.Encoder) {
+	e.BytesField(1, hexBytes(l.TraceID))
+	e.BytesField(2, hexBytes(l.SpanID))
+}
+
+// hexBytes decodes an id validated by uuidHex.
+func hexBytes(id string) []byte {
+	b, _ := hex.DecodeString(id)
+	return b
+}
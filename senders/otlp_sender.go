@@ -0,0 +1,112 @@
+package senders
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// Protocols of NewSender.
+const (
+	ProtocolWavefront = "wavefront"
+	ProtocolOTLP      = "otlp"
+	ProtocolOTLPGRPC  = "otlp-grpc"
+)
+
+const (
+	otlpGRPCMetricsMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	otlpGRPCTracesMethod  = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+)
+
+const otlpContentType = "application/x-protobuf"
+
+var errOTLPEvents = errors.New("events are not supported by the OTLP protocols")
+
+// Protocol sets the protocol NewSender speaks: ProtocolWavefront (the default), ProtocolOTLP
+// or ProtocolOTLPGRPC.
+//
+// With ProtocolOTLP, metrics, delta counters and distributions are converted to OTLP gauges,
+// monotonic delta sums and summaries, and spans, along with their span logs, to OTLP spans, as
+// described for OTLPSerializer. They are batched and flushed as usual, and posted in protobuf,
+// gzipped unless Compression(false) is given, to the /v1/metrics and /v1/traces paths of the
+// sender URL, e.g. http://localhost:4318 for an OpenTelemetry Collector. The URL is used as is:
+// no port is defaulted. Events are not supported and rejected by SendEvent.
+//
+// ProtocolOTLPGRPC sends the same export requests as unary gRPC calls to the collector's OTLP/gRPC
+// endpoint, e.g. https://localhost:4317, over a single HTTP/2 connection reused across flushes.
+// As the SDK relies on the HTTP/2 support of net/http, which requires TLS, the URL must be https;
+// use TLSConfigOptions for the TLS credentials, and KeepAlive to keep idle connections alive.
+//
+// The protocol takes precedence over LineFormat.
+func Protocol(protocol string) Option {
+	return func(cfg *configuration) {
+		cfg.Protocol = protocol
+	}
+}
+
+func (c *configuration) validateProtocol() error {
+	switch c.Protocol {
+	case "", ProtocolWavefront, ProtocolOTLP, ProtocolOTLPGRPC:
+		return nil
+	}
+	return fmt.Errorf("unsupported protocol '%s', expected %s, %s or %s",
+		c.Protocol, ProtocolWavefront, ProtocolOTLP, ProtocolOTLPGRPC)
+}
+
+// isOTLP reports whether protocol is one of the OTLP protocols.
+func isOTLP(protocol string) bool {
+	return protocol == ProtocolOTLP || protocol == ProtocolOTLPGRPC
+}
+
+func otlpEndpoint(collectorURL string, cfg *configuration) (endpoint, error) {
+	u, err := url.Parse(collectorURL)
+	if err != nil {
+		return endpoint{}, err
+	}
+	u.User = nil
+	base := strings.TrimSuffix(u.String(), "/")
+
+	tokenService := tokenServiceForCfg(cfg)
+	return endpoint{
+		tokenService: tokenService,
+		newReporters: func(options ...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			options = append([]internal.ReporterOption{internal.SetCompression(true)}, options...)
+			metricsReporter := internal.NewOTelReporter(base+"/v1/metrics", otlpContentType, tokenService, cfg.HTTPClient, options...)
+			tracesReporter := internal.NewOTelReporter(base+"/v1/traces", otlpContentType, tokenService, cfg.HTTPClient, options...)
+			return metricsReporter, tracesReporter
+		},
+	}, nil
+}
+
+func otlpGRPCEndpoint(collectorURL string, cfg *configuration) (endpoint, error) {
+	u, err := url.Parse(collectorURL)
+	if err != nil {
+		return endpoint{}, err
+	}
+	if !strings.EqualFold(u.Scheme, "https") {
+		return endpoint{}, fmt.Errorf("the %s protocol requires an https URL, got '%s'", ProtocolOTLPGRPC, u.Redacted())
+	}
+	u.User = nil
+	base := strings.TrimSuffix(u.String(), "/")
+
+	client := *cfg.HTTPClient
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		// custom TLS configurations and dialers turn HTTP/2 off unless forced.
+		transport = transport.Clone()
+		transport.ForceAttemptHTTP2 = true
+		client.Transport = transport
+	}
+	tokenService := tokenServiceForCfg(cfg)
+	return endpoint{
+		tokenService: tokenService,
+		newReporters: func(options ...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			metricsReporter := internal.NewGRPCReporter(base+otlpGRPCMetricsMethod, tokenService, &client, options...)
+			tracesReporter := internal.NewGRPCReporter(base+otlpGRPCTracesMethod, tokenService, &client, options...)
+			return metricsReporter, tracesReporter
+		},
+	}, nil
+}
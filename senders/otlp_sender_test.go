@@ -0,0 +1,165 @@
+package senders
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type protoField struct {
+	number  int
+	payload []byte
+	value   uint64
+}
+
+// decodeProto returns the top-level fields of a protobuf message.
+func decodeProto(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		b = b[n:]
+		field := protoField{number: int(tag >> 3)}
+		switch tag & 7 {
+		case 0:
+			field.value, n = binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			b = b[n:]
+		case 1:
+			field.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			require.Greater(t, n, 0)
+			field.payload = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// protoPath returns the payloads of the fields at path, taking the first occurrence of each intermediate field.
+func protoPath(t *testing.T, b []byte, path ...int) [][]byte {
+	var payloads [][]byte
+	for _, field := range decodeProto(t, b) {
+		if field.number != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			payloads = append(payloads, field.payload)
+			continue
+		}
+		return protoPath(t, field.payload, path[1:]...)
+	}
+	return payloads
+}
+
+func TestOTLPProtocol(t *testing.T) {
+	var mtx sync.Mutex
+	bodies := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		zr, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		mtx.Lock()
+		bodies[r.URL.Path] = body
+		mtx.Unlock()
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(server.URL, Protocol(ProtocolOTLP), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my.metric", 20, 1533531013, "localhost", map[string]string{"env": "dev"}))
+	require.NoError(t, sender.SendMetric("my.other.metric", 0, 1533531013, "localhost", nil))
+	require.NoError(t, sender.SendSpan("getAllUsers", 1533531013000, 343, "localhost", testTraceID, testSpanID,
+		nil, nil, nil, []SpanLog{{Timestamp: 1533531013100000, Fields: map[string]string{"event": "error"}}}))
+	assert.ErrorIs(t, sender.SendEvent("event", 0, 0, "localhost", nil), errOTLPEvents)
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	metrics := bodies["/v1/metrics"]
+	resourceMetrics := protoPath(t, metrics, 1)
+	require.Len(t, resourceMetrics, 2)
+	metric := protoPath(t, resourceMetrics[1], 2, 2)[0]
+	assert.Equal(t, []byte("my.other.metric"), protoPath(t, metric, 1)[0])
+	dataPoint := protoPath(t, metric, 5, 1)[0]
+	fields := decodeProto(t, dataPoint)
+	// time and a zero value, encoded as the value oneof is set.
+	assert.Equal(t, []protoField{{number: 3, value: 1533531013000000000}, {number: 4, value: 0}}, fields)
+
+	span := protoPath(t, bodies["/v1/traces"], 1, 2, 2)[0]
+	assert.Equal(t, []byte("getAllUsers"), protoPath(t, span, 5)[0])
+	assert.Len(t, protoPath(t, span, 1)[0], 16)
+	assert.Len(t, protoPath(t, span, 2)[0], 8)
+	event := protoPath(t, span, 11)[0]
+	assert.Equal(t, []byte("error"), protoPath(t, event, 2)[0])
+}
+
+func TestOTLPProtocolInvalid(t *testing.T) {
+	_, err := NewSender("http://localhost:4318", Protocol("carrier-pigeon"))
+	assert.Error(t, err)
+}
+
+func TestOTLPGRPCProtocol(t *testing.T) {
+	var mtx sync.Mutex
+	messages := map[string][]byte{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, 2, r.ProtoMajor)
+		assert.Equal(t, "gzip", r.Header.Get("Grpc-Encoding"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, byte(1), body[0])
+		zr, err := gzip.NewReader(bytes.NewReader(body[5:]))
+		require.NoError(t, err)
+		message, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		mtx.Lock()
+		messages[r.URL.Path] = message
+		mtx.Unlock()
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(server.Certificate())
+	sender, err := NewSender(server.URL, Protocol(ProtocolOTLPGRPC), TLSConfigOptions(&tls.Config{RootCAs: certPool}),
+		KeepAlive(time.Minute), SendInternalMetrics(false))
+	require.NoError(t, err)
+	require.NoError(t, sender.SendMetric("my.metric", 20, 1533531013, "localhost", nil))
+	require.NoError(t, sender.SendSpan("getAllUsers", 1533531013000, 343, "localhost", testTraceID, testSpanID,
+		nil, nil, nil, nil))
+	require.NoError(t, sender.Flush())
+	sender.Close()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	metric := protoPath(t, messages[otlpGRPCMetricsMethod], 1, 2, 2)[0]
+	assert.Equal(t, []byte("my.metric"), protoPath(t, metric, 1)[0])
+	span := protoPath(t, messages[otlpGRPCTracesMethod], 1, 2, 2)[0]
+	assert.Equal(t, []byte("getAllUsers"), protoPath(t, span, 5)[0])
+
+	_, err = NewSender("http://localhost:4317", Protocol(ProtocolOTLPGRPC))
+	assert.Error(t, err)
+}
@@ -0,0 +1,353 @@
+package senders
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/protobuf"
+	"github.com/wavefronthq/wavefront-sdk-go/version"
+)
+
+const otlpScopeName = "github.com/wavefronthq/wavefront-sdk-go"
+
+// OTLPSerializer returns the serializer of OTLP/JSON lines, one export request per line,
+// as read by the OpenTelemetry Collector's otlpjsonfile receiver. Sources become the source
+// resource attribute, and point tags data point attributes. Metrics are gauges, delta counters
+// monotonic delta sums, and distributions summaries with their min and max as the 0 and 1 quantiles.
+// Trace and span ids are taken from their UUIDs, the span id from the last 8 bytes, and span logs
+// become span events.
+func OTLPSerializer() LineSerializer {
+	return otlpSerializer{encode: otlpJSONLine}
+}
+
+// otlpSerializer serializes points as OTLP export requests, encoded by encode.
+type otlpSerializer struct {
+	encode func(request otlpRequest) ([]byte, error)
+}
+
+// otlpRequest is an OTLP export request, encodable in the protobuf wire format.
+type otlpRequest interface {
+	marshalProto(e *protobuf.Encoder)
+}
+
+// otlpJSONLine encodes request in OTLP/JSON, on a line of its own.
+func otlpJSONLine(request otlpRequest) ([]byte, error) {
+	line, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// otlpProtobuf encodes request in the OTLP protobuf format. The concatenation of encoded requests
+// is itself an encoded request holding all of their resource metrics or spans, so that batches
+// of them can be posted as is.
+func otlpProtobuf(request otlpRequest) ([]byte, error) {
+	var e protobuf.Encoder
+	request.marshalProto(&e)
+	return e.Bytes(), nil
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Gauge   *otlpGauge   `json:"gauge,omitempty"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	TimeUnixNano uint64         `json:"timeUnixNano,string"`
+	AsDouble     float64        `json:"asDouble"`
+}
+
+type otlpSummary struct {
+	DataPoints []otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpSummaryDataPoint struct {
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	TimeUnixNano   uint64         `json:"timeUnixNano,string"`
+	Count          uint64         `json:"count,string"`
+	Sum            float64        `json:"sum"`
+	QuantileValues []otlpQuantile `json:"quantileValues"`
+}
+
+type otlpQuantile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	StartTimeUnixNano uint64         `json:"startTimeUnixNano,string"`
+	EndTimeUnixNano   uint64         `json:"endTimeUnixNano,string"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Links             []otlpLink     `json:"links,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano uint64         `json:"timeUnixNano,string"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+const otlpDeltaTemporality = 1
+
+func (s otlpSerializer) MetricLine(name string, value float64, ts int64, source string, tags map[string]string) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("empty metric name")
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("value %v is not supported by OTLP/JSON: metric=%s", value, name)
+	}
+	attributes, err := otlpTagAttributes(name, tags)
+	if err != nil {
+		return nil, err
+	}
+	dataPoints := []otlpNumberDataPoint{{
+		Attributes:   attributes,
+		TimeUnixNano: otlpTime(ts),
+		AsDouble:     value,
+	}}
+	metric := otlpMetric{Name: name}
+	if internal.HasDeltaPrefix(name) {
+		metric.Name = trimDeltaPrefix(name)
+		metric.Sum = &otlpSum{DataPoints: dataPoints, AggregationTemporality: otlpDeltaTemporality, IsMonotonic: true}
+	} else {
+		metric.Gauge = &otlpGauge{DataPoints: dataPoints}
+	}
+	return s.encode(otlpMetricsFor(source, metric))
+}
+
+func (s otlpSerializer) DistributionLine(name string, centroids []histogram.Centroid, _ map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("empty distribution name")
+	}
+	if len(centroids) == 0 {
+		return nil, fmt.Errorf("distribution should have at least one centroid: histogram=%s", name)
+	}
+	attributes, err := otlpTagAttributes(name, tags)
+	if err != nil {
+		return nil, err
+	}
+	var count int
+	var sum float64
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, centroid := range centroids {
+		count += centroid.Count
+		sum += centroid.Value * float64(centroid.Count)
+		min = math.Min(min, centroid.Value)
+		max = math.Max(max, centroid.Value)
+	}
+	return s.encode(otlpMetricsFor(source, otlpMetric{
+		Name: name,
+		Summary: &otlpSummary{DataPoints: []otlpSummaryDataPoint{{
+			Attributes:     attributes,
+			TimeUnixNano:   otlpTime(ts),
+			Count:          uint64(count),
+			Sum:            sum,
+			QuantileValues: []otlpQuantile{{Quantile: 0, Value: min}, {Quantile: 1, Value: max}},
+		}}},
+	}))
+}
+
+func (s otlpSerializer) SpanLine(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) ([]byte, error) {
+	if name == "" {
+		return nil, errors.New("span name cannot be empty")
+	}
+	traceHex, err := uuidHex(traceID, 16)
+	if err != nil {
+		return nil, fmt.Errorf("traceId is not in UUID format: span=%s traceId=%s", name, traceID)
+	}
+	spanHex, err := uuidHex(spanID, 8)
+	if err != nil {
+		return nil, fmt.Errorf("spanId is not in UUID format: span=%s spanId=%s", name, spanID)
+	}
+
+	span := otlpSpan{
+		TraceID:           traceHex,
+		SpanID:            spanHex,
+		Name:              name,
+		StartTimeUnixNano: uint64(startMillis * int64(time.Millisecond)),
+		EndTimeUnixNano:   uint64((startMillis + durationMillis) * int64(time.Millisecond)),
+	}
+	for i, parent := range parents {
+		parentHex, err := uuidHex(parent, 8)
+		if err != nil {
+			return nil, fmt.Errorf("parent is not in UUID format: span=%s parent=%s", name, parent)
+		}
+		if i == 0 {
+			span.ParentSpanID = parentHex
+		} else {
+			span.Links = append(span.Links, otlpLink{TraceID: traceHex, SpanID: parentHex})
+		}
+	}
+	for _, item := range followsFrom {
+		itemHex, err := uuidHex(item, 8)
+		if err != nil {
+			return nil, fmt.Errorf("followsFrom is not in UUID format: span=%s followsFrom=%s", name, item)
+		}
+		span.Links = append(span.Links, otlpLink{TraceID: traceHex, SpanID: itemHex})
+	}
+	for _, tag := range tags {
+		if tag.Key == "" {
+			return nil, fmt.Errorf("tag keys cannot be empty: span=%s", name)
+		}
+		if tag.Value == "" {
+			return nil, fmt.Errorf("tag values cannot be empty: span=%s tag=%s", name, tag.Key)
+		}
+		span.Attributes = append(span.Attributes, otlpAttribute(tag.Key, tag.Value))
+	}
+	for _, spanLog := range spanLogs {
+		event := otlpEvent{
+			TimeUnixNano: uint64(spanLog.Timestamp * int64(time.Microsecond)),
+			Name:         "log",
+		}
+		for _, k := range sortedKeys(spanLog.Fields) {
+			if k == "event" {
+				event.Name = spanLog.Fields[k]
+				continue
+			}
+			event.Attributes = append(event.Attributes, otlpAttribute(k, spanLog.Fields[k]))
+		}
+		span.Events = append(span.Events, event)
+	}
+
+	return s.encode(otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpSourceResource(source),
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpSDKScope(), Spans: []otlpSpan{span}}},
+	}}})
+}
+
+func otlpMetricsFor(source string, metric otlpMetric) otlpMetricsRequest {
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpSourceResource(source),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpSDKScope(), Metrics: []otlpMetric{metric}}},
+	}}}
+}
+
+func otlpSourceResource(source string) otlpResource {
+	return otlpResource{Attributes: []otlpKeyValue{otlpAttribute("source", source)}}
+}
+
+func otlpSDKScope() otlpScope {
+	return otlpScope{Name: otlpScopeName, Version: version.Version}
+}
+
+func otlpAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+func otlpTagAttributes(name string, tags map[string]string) ([]otlpKeyValue, error) {
+	attributes := make([]otlpKeyValue, 0, len(tags))
+	for _, k := range sortedKeys(tags) {
+		if tags[k] == "" {
+			return nil, fmt.Errorf("tag values cannot be empty: metric=%s tag=%s", name, k)
+		}
+		attributes = append(attributes, otlpAttribute(k, tags[k]))
+	}
+	return attributes, nil
+}
+
+// otlpTime returns the time of a point in nanoseconds, the current time for a zero timestamp.
+func otlpTime(ts int64) uint64 {
+	if ts == 0 {
+		return uint64(time.Now().UnixNano())
+	}
+	return uint64(unixNano(ts))
+}
+
+// uuidHex returns the last n bytes of a UUID, hex encoded.
+func uuidHex(uuid string, n int) (string, error) {
+	id := strings.ReplaceAll(uuid, "-", "")
+	if len(uuid) != 36 || len(id) != 32 {
+		return "", errors.New("invalid UUID")
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", err
+	}
+	return strings.ToLower(id[32-2*n:]), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
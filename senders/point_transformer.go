@@ -0,0 +1,79 @@
+package senders
+
+// Point is a metric, delta counter or distribution as given to PointTransformers.
+type Point struct {
+	// Name of the point, with the delta prefix for delta counters.
+	Name   string
+	Source string
+	// Tags of the point. They are a copy of the tags given to the sender, which transformers may
+	// modify but must not keep: the map is reused once the point is serialized.
+	Tags map[string]string
+}
+
+// PointTransformer returns the point to send in place of point, e.g. with tags added, renamed or
+// scrubbed of personal data.
+type PointTransformer func(point Point) Point
+
+// SpanTransformer returns the span to send in place of span. The Tags and SpanLogs slices of span
+// are copies the transformer may modify, but the Fields of its span logs are those given to the
+// sender and must be replaced rather than modified.
+type SpanTransformer func(span Span) Span
+
+// TransformPoints runs transformers, in order, on every metric, delta counter and distribution,
+// after the tags of Enrichment and CaptureTags are added and before the point is validated and
+// serialized, so that tags can be injected, renamed or scrubbed without wrapping the sender.
+// Spans, whose tag keys may repeat, are transformed by TransformSpans, and events by
+// TransformEvents. The source is the default source of the sender when none is given.
+// Multiple TransformPoints options are applied in the order they were given.
+func TransformPoints(transformers ...PointTransformer) Option {
+	return func(cfg *configuration) {
+		cfg.PointTransformers = append(cfg.PointTransformers, transformers...)
+	}
+}
+
+// TransformSpans runs transformers, in order, on every span, after the tags of Enrichment and
+// CaptureTags are added and before the span is validated and serialized, e.g. to scrub personal
+// data from span tags. Multiple TransformSpans options are applied in the order they were given.
+func TransformSpans(transformers ...SpanTransformer) Option {
+	return func(cfg *configuration) {
+		cfg.SpanTransformers = append(cfg.SpanTransformers, transformers...)
+	}
+}
+
+// TransformEvents runs transformers, in order, on the name, source and tags of every event before
+// it is serialized. Unlike metrics, events keep an empty source, e.g. when their hosts are set
+// with event.Host. Multiple TransformEvents options are applied in the order they were given.
+func TransformEvents(transformers ...PointTransformer) Option {
+	return func(cfg *configuration) {
+		cfg.EventTransformers = append(cfg.EventTransformers, transformers...)
+	}
+}
+
+// transform runs the transformers of the sender on a point whose tags are a copy the transformers
+// may modify, see pointTags.
+func (sender *realSender) transform(name, source string, tags map[string]string) (string, string, map[string]string) {
+	point := Point{Name: name, Source: sender.sourceOrDefault(source), Tags: tags}
+	for _, t := range sender.transformers {
+		point = t(point)
+	}
+	return point.Name, point.Source, point.Tags
+}
+
+// transformSpan runs the span transformers of the sender on a copy of the tags and span logs of s.
+func (sender *realSender) transformSpan(s Span) Span {
+	s.Tags = append([]SpanTag(nil), s.Tags...)
+	s.SpanLogs = append([]SpanLog(nil), s.SpanLogs...)
+	for _, t := range sender.spanTransformers {
+		s = t(s)
+	}
+	return s
+}
+
+// transformEvent runs the event transformers of the sender on a copy of tags.
+func (sender *realSender) transformEvent(name, source string, tags map[string]string) (string, string, map[string]string) {
+	point := Point{Name: name, Source: source, Tags: copyTags(tags)}
+	for _, t := range sender.eventTransformers {
+		point = t(point)
+	}
+	return point.Name, point.Source, point.Tags
+}
@@ -0,0 +1,96 @@
+package senders
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestTransformPoints(t *testing.T) {
+	inject := func(p Point) Point {
+		p.Tags["region"] = "us-west"
+		return p
+	}
+	scrub := func(p Point) Point {
+		if _, ok := p.Tags["email"]; ok {
+			p.Tags["email"] = "redacted"
+		}
+		if user, ok := p.Tags["usr"]; ok {
+			delete(p.Tags, "usr")
+			p.Tags["user"] = user
+		}
+		p.Name = strings.TrimPrefix(p.Name, "legacy.")
+		return p
+	}
+	sender, err := NewValidatingSender(TransformPoints(inject), TransformPoints(scrub))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	tags := map[string]string{"email": "jane@example.com", "usr": "jane"}
+	require.NoError(t, sender.SendMetric("legacy.logins", 1, 0, "web-01", tags))
+	assert.Equal(t, map[string]string{"email": "jane@example.com", "usr": "jane"}, tags, "caller tags must not be modified")
+	require.NoError(t, sender.SendDeltaCounter("legacy.requests", 2, "web-01", nil))
+	require.NoError(t, sender.SendDistribution("latency", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "web-01", nil))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "\"logins\" 1 source=\"web-01\"")
+	assert.Contains(t, lines[0], "\"email\"=\"redacted\"")
+	assert.Contains(t, lines[0], "\"user\"=\"jane\"")
+	assert.NotContains(t, lines[0], "usr")
+	assert.Contains(t, lines[0], "\"region\"=\"us-west\"")
+	// delta counters are given with their delta prefix.
+	assert.Contains(t, lines[1], "\"∆legacy.requests\" 2")
+	assert.Contains(t, lines[1], "\"region\"=\"us-west\"")
+	assert.Contains(t, lines[2], "\"region\"=\"us-west\"")
+}
+
+func TestTransformPoints_DefaultSource(t *testing.T) {
+	var sources []string
+	sender, err := NewValidatingSender(TransformPoints(func(p Point) Point {
+		sources = append(sources, p.Source)
+		p.Source = "renamed"
+		return p
+	}))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "", nil))
+	assert.NotEqual(t, []string{""}, sources)
+	assert.Contains(t, sender.Lines()[0], "source=\"renamed\"")
+}
+
+func TestTransformSpansAndEvents(t *testing.T) {
+	scrubSpan := func(s Span) Span {
+		for i, tag := range s.Tags {
+			if tag.Key == "email" {
+				s.Tags[i].Value = "redacted"
+			}
+		}
+		return s
+	}
+	scrubEvent := func(p Point) Point {
+		delete(p.Tags, "email")
+		return p
+	}
+	sender, err := NewValidatingSender(TransformSpans(scrubSpan), TransformEvents(scrubEvent))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	spanTags := []SpanTag{{Key: "email", Value: "jane@example.com"}}
+	require.NoError(t, sender.SendSpan("checkout", 0, 1, "web-01", "7b3bf470-9456-11e8-9eb6-529269fb1459",
+		"0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, spanTags, nil))
+	assert.Equal(t, "jane@example.com", spanTags[0].Value, "caller tags must not be modified")
+	eventTags := map[string]string{"email": "jane@example.com"}
+	require.NoError(t, sender.SendEvent("deploy", 0, 1, "web-01", eventTags))
+	assert.Len(t, eventTags, 1, "caller tags must not be modified")
+
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "\"email\"=\"redacted\"")
+	assert.NotContains(t, lines[1], "jane@example.com")
+}
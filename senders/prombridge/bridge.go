@@ -0,0 +1,264 @@
+// Package prombridge scrapes Prometheus metrics and forwards them to Wavefront through a sender,
+// so that applications and exporters instrumented with Prometheus can report to Wavefront
+// without running a Prometheus server.
+//
+//	sender, _ := senders.NewSender("http://localhost")
+//	bridge := prombridge.New(sender, "http://localhost:9100/metrics", prombridge.Interval(30*time.Second))
+//	bridge.Start()
+//	defer bridge.Stop()
+//
+// Counters, gauges, untyped metrics and summaries are sent as metrics, named after their samples.
+// Histograms are sent as Wavefront distributions of the observations made between two scrapes,
+// with their _sum and _count samples sent as metrics. A prometheus.Gatherer is scraped in process
+// with NewFromHandler and promhttp.HandlerFor.
+package prombridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultTimeout  = 10 * time.Second
+	acceptHeader    = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+)
+
+// Sender is the part of senders.Sender the bridge forwards metrics and distributions through.
+type Sender interface {
+	senders.MetricSender
+	senders.DistributionSender
+}
+
+// Bridge scrapes a Prometheus endpoint on an interval and forwards its samples to a Sender.
+type Bridge struct {
+	sender   Sender
+	target   string
+	scrape   func(ctx context.Context) (io.ReadCloser, error)
+	interval time.Duration
+	timeout  time.Duration
+	prefix   string
+	source   string
+	tags     map[string]string
+	client   *http.Client
+
+	mtx        sync.Mutex
+	histograms map[string]map[float64]float64
+
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// Option configures a Bridge.
+type Option func(*Bridge)
+
+// Interval sets the time between two scrapes. Defaults to 1 minute.
+func Interval(interval time.Duration) Option {
+	return func(b *Bridge) {
+		b.interval = interval
+	}
+}
+
+// Timeout bounds each scrape. Defaults to 10 seconds.
+func Timeout(timeout time.Duration) Option {
+	return func(b *Bridge) {
+		b.timeout = timeout
+	}
+}
+
+// Prefix is prepended, with a dot, to the names of the forwarded metrics and distributions.
+func Prefix(prefix string) Option {
+	return func(b *Bridge) {
+		b.prefix = prefix
+	}
+}
+
+// Source sets the source of the forwarded data. Defaults to the default source of the sender.
+func Source(source string) Option {
+	return func(b *Bridge) {
+		b.source = source
+	}
+}
+
+// Tags adds tags to all the forwarded data. Labels of the samples take precedence.
+func Tags(tags map[string]string) Option {
+	return func(b *Bridge) {
+		b.tags = tags
+	}
+}
+
+// HTTPClient sets the http.Client endpoints are scraped with.
+func HTTPClient(client *http.Client) Option {
+	return func(b *Bridge) {
+		b.client = client
+	}
+}
+
+// New creates a Bridge scraping the Prometheus endpoint at url, e.g. http://localhost:9100/metrics.
+func New(sender Sender, url string, setters ...Option) *Bridge {
+	b := newBridge(sender, url, setters)
+	b.scrape = func(ctx context.Context) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", acceptHeader)
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("status=%d", resp.StatusCode)
+		}
+		return resp.Body, nil
+	}
+	return b
+}
+
+// NewFromHandler creates a Bridge scraping handler in process, e.g. the handler
+// promhttp.HandlerFor returns for a prometheus.Gatherer.
+func NewFromHandler(sender Sender, handler http.Handler, setters ...Option) *Bridge {
+	b := newBridge(sender, "handler", setters)
+	b.scrape = func(ctx context.Context) (io.ReadCloser, error) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil).WithContext(ctx)
+		req.Header.Set("Accept", acceptHeader)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			return nil, fmt.Errorf("status=%d", rec.Code)
+		}
+		return io.NopCloser(rec.Body), nil
+	}
+	return b
+}
+
+func newBridge(sender Sender, target string, setters []Option) *Bridge {
+	b := &Bridge{
+		sender:     sender,
+		target:     target,
+		interval:   defaultInterval,
+		timeout:    defaultTimeout,
+		client:     &http.Client{},
+		histograms: map[string]map[float64]float64{},
+		stop:       make(chan struct{}),
+	}
+	for _, set := range setters {
+		set(b)
+	}
+	return b
+}
+
+// Start scrapes the endpoint on every interval, in the background, until Stop is called.
+func (b *Bridge) Start() {
+	if b.ticker != nil {
+		return
+	}
+	b.ticker = time.NewTicker(b.interval)
+	go func() {
+		for {
+			select {
+			case <-b.ticker.C:
+				if err := b.Scrape(); err != nil {
+					log.Printf("prometheus bridge: %s\n", err)
+				}
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scrapes started by Start.
+func (b *Bridge) Stop() {
+	if b.ticker == nil {
+		return
+	}
+	b.ticker.Stop()
+	b.stop <- struct{}{}
+}
+
+// Scrape scrapes the endpoint once and forwards its samples. Samples that fail to be sent do not
+// stop the others from being forwarded.
+func (b *Bridge) Scrape() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	body, err := b.scrape(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to scrape %s: %s", b.target, err)
+	}
+	defer body.Close()
+	families, err := parse(body)
+	if err != nil {
+		return fmt.Errorf("unable to parse the metrics of %s: %s", b.target, err)
+	}
+	return b.forward(families)
+}
+
+// forward sends the samples of families.
+func (b *Bridge) forward(families []*family) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	var failed int
+	var firstErr error
+	for _, f := range families {
+		var errs []error
+		if f.typ == typeHistogram {
+			errs = b.forwardHistogram(f)
+		} else {
+			for _, s := range f.samples {
+				if err := b.sendMetric(s.name, s); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		if len(errs) > 0 && firstErr == nil {
+			firstErr = errs[0]
+		}
+		failed += len(errs)
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to forward %d samples of %s: %s", failed, b.target, firstErr)
+	}
+	return nil
+}
+
+func (b *Bridge) sendMetric(name string, s sample) error {
+	// Wavefront rejects non-finite values, e.g. the NaN quantiles of empty summaries.
+	if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+		return nil
+	}
+	return b.sender.SendMetric(b.name(name), s.value, s.timestamp/1000, b.source, b.tagsOf(s.labels))
+}
+
+func (b *Bridge) name(name string) string {
+	if b.prefix == "" {
+		return name
+	}
+	return b.prefix + "." + name
+}
+
+// tagsOf returns the tags of a sample with the given labels.
+func (b *Bridge) tagsOf(labels map[string]string) map[string]string {
+	if len(b.tags) == 0 {
+		return labels
+	}
+	tags := make(map[string]string, len(b.tags)+len(labels))
+	for k, v := range b.tags {
+		tags[k] = v
+	}
+	for k, v := range labels {
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,90 @@
+package prombridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+func TestScrape(t *testing.T) {
+	body := `# TYPE up gauge
+up{job="node"} 1 1700000000000
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 10
+latency_seconds_bucket{le="1"} 15
+latency_seconds_bucket{le="+Inf"} 16
+latency_seconds_sum 4.5
+latency_seconds_count 16
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept"), "text/plain")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	b := New(sender, server.URL, Prefix("prom"), Source("node-1"), Tags(map[string]string{"job": "default"}))
+
+	require.NoError(t, b.Scrape())
+	assert.Equal(t, []string{
+		"\"prom.up\" 1 1700000000 source=\"node-1\" \"job\"=\"node\"\n",
+		"\"prom.latency_seconds_sum\" 4.5 source=\"node-1\" \"job\"=\"default\"\n",
+		"\"prom.latency_seconds_count\" 16 source=\"node-1\" \"job\"=\"default\"\n",
+	}, sender.Lines(), "the first scrape of a histogram only sets its baseline")
+
+	sender.Reset()
+	body = `# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 12
+latency_seconds_bucket{le="1"} 20
+latency_seconds_bucket{le="+Inf"} 22
+`
+	require.NoError(t, b.Scrape())
+	lines := sender.Lines()
+	require.Len(t, lines, 1)
+	assert.True(t, strings.HasPrefix(lines[0], "!M "))
+	for _, part := range []string{"#2 0.1 ", "#3 0.55 ", "#1 1 ", "\"prom.latency_seconds\" source=\"node-1\" \"job\"=\"default\""} {
+		assert.Contains(t, lines[0], part)
+	}
+}
+
+func TestScrape_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+
+	assert.EqualError(t, New(sender, server.URL).Scrape(), "unable to scrape "+server.URL+": status=503")
+
+	b := NewFromHandler(sender, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("bad metric name 1\n"))
+	}))
+	assert.Error(t, b.Scrape())
+}
+
+func TestNewFromHandler(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	b := NewFromHandler(sender, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("go_goroutines 42\n"))
+	}), Source("app"))
+
+	require.NoError(t, b.Scrape())
+	assert.Equal(t, []string{"\"go_goroutines\" 42 source=\"app\"\n"}, sender.Lines())
+}
+
+func TestCentroidsOf(t *testing.T) {
+	buckets := []bucket{{le: 1, count: 5}, {le: 2, count: 8}, {le: 4, count: 8}}
+	assert.Equal(t, []histogram.Centroid{{Value: 1, Count: 2}, {Value: 1.5, Count: 1}},
+		centroidsOf(buckets, map[float64]float64{1: 3, 2: 5, 4: 5}))
+	assert.Equal(t, []histogram.Centroid{{Value: 1, Count: 5}, {Value: 1.5, Count: 3}},
+		centroidsOf(buckets, map[float64]float64{1: 9, 2: 12, 4: 12}), "counter reset")
+}
@@ -0,0 +1,136 @@
+package prombridge
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// bucket is a bucket of a Prometheus histogram series: the number of observations up to le.
+type bucket struct {
+	le    float64
+	count float64
+}
+
+// series is a Prometheus histogram series: its buckets, sorted by bound, and their labels.
+type series struct {
+	labels    map[string]string
+	timestamp int64
+	buckets   []bucket
+}
+
+// forwardHistogram sends the _sum and _count samples of a histogram family as metrics, and its
+// buckets as a distribution of the observations made since the previous scrape. Series seen for
+// the first time only set the baseline of the next scrape, since their buckets count all the
+// observations made since the process started.
+func (b *Bridge) forwardHistogram(f *family) []error {
+	var errs []error
+	bySeries := map[string]*series{}
+	var keys []string
+	for _, s := range f.samples {
+		if s.name != f.name+"_bucket" {
+			if err := b.sendMetric(s.name, s); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		le, err := strconv.ParseFloat(s.labels["le"], 64)
+		if err != nil {
+			continue
+		}
+		labels := make(map[string]string, len(s.labels))
+		for k, v := range s.labels {
+			if k != "le" {
+				labels[k] = v
+			}
+		}
+		key := seriesKey(f.name, labels)
+		ser, ok := bySeries[key]
+		if !ok {
+			ser = &series{labels: labels, timestamp: s.timestamp}
+			bySeries[key] = ser
+			keys = append(keys, key)
+		}
+		ser.buckets = append(ser.buckets, bucket{le: le, count: s.value})
+	}
+
+	for _, key := range keys {
+		ser := bySeries[key]
+		sort.Slice(ser.buckets, func(i, j int) bool { return ser.buckets[i].le < ser.buckets[j].le })
+		previous, seen := b.histograms[key]
+		current := make(map[float64]float64, len(ser.buckets))
+		for _, bk := range ser.buckets {
+			current[bk.le] = bk.count
+		}
+		b.histograms[key] = current
+		if !seen {
+			continue
+		}
+		centroids := centroidsOf(ser.buckets, previous)
+		if len(centroids) == 0 {
+			continue
+		}
+		err := b.sender.SendDistribution(b.name(f.name), centroids, map[histogram.Granularity]bool{histogram.MINUTE: true},
+			ser.timestamp/1000, b.source, b.tagsOf(ser.labels))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// centroidsOf returns the observations counted by buckets since the previous counts, one centroid
+// per bucket at the middle of its bounds. The first bucket is centered on its upper bound and the
+// +Inf one on its lower bound. After a counter reset, all the observations of buckets are returned.
+func centroidsOf(buckets []bucket, previous map[float64]float64) []histogram.Centroid {
+	deltas := make([]float64, len(buckets))
+	for i, bk := range buckets {
+		deltas[i] = bk.count - previous[bk.le]
+		if deltas[i] < 0 {
+			return centroidsOf(buckets, nil)
+		}
+	}
+
+	var centroids []histogram.Centroid
+	var below float64
+	for i, bk := range buckets {
+		count := deltas[i] - below
+		below = deltas[i]
+		if count <= 0 {
+			continue
+		}
+		value := bk.le
+		switch {
+		case math.IsInf(bk.le, 1):
+			if i == 0 {
+				continue
+			}
+			value = buckets[i-1].le
+		case i > 0:
+			value = (buckets[i-1].le + bk.le) / 2
+		}
+		centroids = append(centroids, histogram.Centroid{Value: value, Count: int(count)})
+	}
+	return centroids
+}
+
+// seriesKey identifies the series of a histogram with the given labels.
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, k := range keys {
+		sb.WriteString("\x00")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
@@ -0,0 +1,180 @@
+package prombridge
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Metric types of the Prometheus text exposition format.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+	typeSummary   = "summary"
+	typeUntyped   = "untyped"
+)
+
+const maxLineSize = 1024 * 1024
+
+// sample is a line of the text exposition format.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+	// timestamp in milliseconds, 0 when the line has none.
+	timestamp int64
+}
+
+// family is a metric and its samples, e.g. the _bucket, _sum and _count samples of a histogram.
+type family struct {
+	name    string
+	typ     string
+	samples []sample
+}
+
+// parse reads metric families in the Prometheus text exposition format, in the order they appear.
+func parse(r io.Reader) ([]*family, error) {
+	var families []*family
+	byName := map[string]*family{}
+	familyOf := func(name string) *family {
+		f, ok := byName[name]
+		if !ok {
+			f = &family{name: name, typ: typeUntyped}
+			byName[name] = f
+			families = append(families, f)
+		}
+		return f
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				familyOf(fields[2]).typ = fields[3]
+			}
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sample on line %d: %s", lineNo, err)
+		}
+		f, ok := byName[s.name]
+		if !ok {
+			f = parentFamily(byName, s.name)
+		}
+		if f == nil {
+			f = familyOf(s.name)
+		}
+		f.samples = append(f.samples, s)
+	}
+	return families, scanner.Err()
+}
+
+// parentFamily returns the histogram or summary family name is a _bucket, _sum or _count sample of.
+func parentFamily(byName map[string]*family, name string) *family {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		f, ok := byName[strings.TrimSuffix(name, suffix)]
+		if ok && (f.typ == typeHistogram || f.typ == typeSummary) {
+			return f
+		}
+	}
+	return nil
+}
+
+// parseSample parses a line like `name{label="value",...} value [timestamp]`.
+func parseSample(line string) (sample, error) {
+	s := sample{}
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return s, fmt.Errorf("missing value: %s", line)
+	}
+	s.name = line[:end]
+	rest := line[end:]
+	if rest[0] == '{' {
+		labels, n, err := parseLabels(rest)
+		if err != nil {
+			return s, err
+		}
+		s.labels = labels
+		rest = rest[n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return s, fmt.Errorf("expected a value and an optional timestamp: %s", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid value: %s", err)
+	}
+	s.value = value
+	if len(fields) == 2 {
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid timestamp: %s", err)
+		}
+		s.timestamp = ts
+	}
+	return s, nil
+}
+
+// parseLabels parses the `{label="value",...}` at the start of s and returns the labels and
+// the number of bytes read.
+func parseLabels(s string) (map[string]string, int, error) {
+	labels := map[string]string{}
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("unterminated labels: %s", s)
+		}
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return nil, 0, fmt.Errorf("invalid label: %s", s[i:])
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, fmt.Errorf("unquoted value of label %s", name)
+		}
+		var value strings.Builder
+		for i++; ; i++ {
+			if i >= len(s) {
+				return nil, 0, fmt.Errorf("unterminated value of label %s", name)
+			}
+			c := s[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					c = '\n'
+				default:
+					c = s[i]
+				}
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+	}
+}
@@ -0,0 +1,74 @@
+package prombridge
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exposition = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+
+# Escaping in label values:
+msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9
+
+# Minimalistic line:
+metric_without_timestamp_and_labels 12.47
+
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.05"} 24054
+http_request_duration_seconds_bucket{le="0.1"} 33444
+http_request_duration_seconds_bucket{le="+Inf"} 144320
+http_request_duration_seconds_sum 53423
+http_request_duration_seconds_count 144320
+
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} NaN
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+`
+
+func TestParse(t *testing.T) {
+	families, err := parse(strings.NewReader(exposition))
+	require.NoError(t, err)
+	require.Len(t, families, 5)
+
+	assert.Equal(t, "http_requests_total", families[0].name)
+	assert.Equal(t, typeCounter, families[0].typ)
+	assert.Equal(t, []sample{
+		{name: "http_requests_total", labels: map[string]string{"method": "post", "code": "200"}, value: 1027, timestamp: 1395066363000},
+		{name: "http_requests_total", labels: map[string]string{"method": "post", "code": "400"}, value: 3, timestamp: 1395066363000},
+	}, families[0].samples)
+
+	assert.Equal(t, typeUntyped, families[1].typ)
+	assert.Equal(t, map[string]string{"path": `C:\DIR\FILE.TXT`, "error": "Cannot find file:\n\"FILE.TXT\""},
+		families[1].samples[0].labels)
+
+	assert.Equal(t, "metric_without_timestamp_and_labels", families[2].name)
+	assert.Equal(t, 12.47, families[2].samples[0].value)
+
+	assert.Equal(t, typeHistogram, families[3].typ)
+	assert.Len(t, families[3].samples, 5)
+
+	assert.Equal(t, typeSummary, families[4].typ)
+	require.Len(t, families[4].samples, 3)
+	assert.True(t, math.IsNaN(families[4].samples[0].value))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, text := range []string{
+		"no_value",
+		"bad_value abc",
+		`unterminated{a="b" 1`,
+		`unquoted{a=b} 1`,
+		"too_many 1 2 3",
+	} {
+		_, err := parse(strings.NewReader(text))
+		assert.Error(t, err, text)
+	}
+}
@@ -0,0 +1,176 @@
+package senders
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// proxyConfigOptions translates the settings of the Wavefront proxy config to Options.
+var proxyConfigOptions = map[string]func(value string) (Option, error){
+	"pushFlushInterval": func(value string) (Option, error) {
+		ms, err := positiveInt(value)
+		return FlushInterval(time.Duration(ms) * time.Millisecond), err
+	},
+	"pushFlushMaxPoints": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return BatchSize(n), err
+	},
+	"pushMemoryBufferLimit": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return MaxBufferSize(n), err
+	},
+	"pushRateLimit": func(value string) (Option, error) {
+		n, err := positiveInt(value)
+		return RateLimit(n), err
+	},
+	"pushListenerPorts": func(value string) (Option, error) {
+		port, err := firstPort(value)
+		return MetricsPort(port), err
+	},
+	"traceListenerPorts": func(value string) (Option, error) {
+		port, err := firstPort(value)
+		return TracesPort(port), err
+	},
+	"token": func(value string) (Option, error) {
+		return APIToken(value), nil
+	},
+	"gzipCompression": func(value string) (Option, error) {
+		enabled, err := strconv.ParseBool(value)
+		return Compression(enabled), err
+	},
+	"httpRequestTimeout": func(value string) (Option, error) {
+		ms, err := positiveInt(value)
+		return Timeout(time.Duration(ms) * time.Millisecond), err
+	},
+	"buffer": func(value string) (Option, error) {
+		return PersistentBuffer(value, 0), nil
+	},
+}
+
+// proxyConfigIgnored lists the settings of the Wavefront proxy config that have no equivalent in
+// senders, such as the number of flush threads: senders flush each data type from a goroutine of
+// its own. They are accepted, and logged.
+var proxyConfigIgnored = map[string]bool{
+	"server":                   true,
+	"hostname":                 true,
+	"flushThreads":             true,
+	"flushThreadsHistograms":   true,
+	"flushThreadsSourceTags":   true,
+	"flushThreadsEvents":       true,
+	"retryThreads":             true,
+	"pushFlushMaxHistograms":   true,
+	"pushFlushMaxSpans":        true,
+	"pushFlushMaxSpanLogs":     true,
+	"pushFlushMaxEvents":       true,
+	"splitPushWhenRateLimited": true,
+	"httpConnectTimeout":       true,
+	"ephemeral":                true,
+}
+
+// ProxyConfigOptions returns the Options equivalent to settings named as in the config of the
+// Wavefront proxy, e.g. wavefront.conf, so that configs of proxy-based setups can be carried over:
+//
+//	pushFlushInterval     FlushInterval, in milliseconds
+//	pushFlushMaxPoints    BatchSize
+//	pushMemoryBufferLimit MaxBufferSize
+//	pushRateLimit         RateLimit
+//	pushListenerPorts     MetricsPort, the first port of the list
+//	traceListenerPorts    TracesPort, the first port of the list
+//	token                 APIToken
+//	gzipCompression       Compression
+//	httpRequestTimeout    Timeout, in milliseconds
+//	buffer                PersistentBuffer, without size limit
+//	proxyHost, proxyPort  ProxyURL
+//
+// Settings without equivalent, e.g. flushThreads, are ignored and logged. Unknown settings and
+// invalid values return an error.
+func ProxyConfigOptions(settings map[string]string) ([]Option, error) {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var options []Option
+	var ignored []string
+	for _, key := range keys {
+		value := strings.TrimSpace(settings[key])
+		if proxyConfigIgnored[key] {
+			ignored = append(ignored, key)
+			continue
+		}
+		if key == "proxyHost" || key == "proxyPort" {
+			continue
+		}
+		translate, ok := proxyConfigOptions[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown proxy config setting '%s'", key)
+		}
+		option, err := translate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy config setting %s=%s: %s", key, value, err)
+		}
+		options = append(options, option)
+	}
+
+	if host := settings["proxyHost"]; host != "" {
+		port := 8080
+		if value, ok := settings["proxyPort"]; ok {
+			var err error
+			if port, err = positiveInt(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid proxy config setting proxyPort=%s: %s", value, err)
+			}
+		}
+		hostPort := net.JoinHostPort(strings.TrimSpace(host), strconv.Itoa(port))
+		options = append(options, ProxyURL(&url.URL{Scheme: "http", Host: hostPort}))
+	}
+	if len(ignored) > 0 {
+		log.Printf("ignoring proxy config settings without equivalent: %s\n", strings.Join(ignored, ", "))
+	}
+	return options, nil
+}
+
+// ParseProxyConfig reads settings in the format of the config file of the Wavefront proxy:
+// key=value lines, where blank lines and lines starting with # are ignored.
+func ParseProxyConfig(r io.Reader) (map[string]string, error) {
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid proxy config line %d: %q", line, text)
+		}
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return settings, scanner.Err()
+}
+
+func positiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return n, nil
+}
+
+// firstPort returns the first port of a comma separated list of ports.
+func firstPort(value string) (int, error) {
+	first, _, _ := strings.Cut(value, ",")
+	return positiveInt(strings.TrimSpace(first))
+}
@@ -0,0 +1,69 @@
+package senders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+const proxyConfig = `
+# Wavefront proxy config
+server=https://example.wavefront.com/api/
+pushListenerPorts=2878,2879
+traceListenerPorts=30001
+pushFlushInterval=1500
+pushFlushMaxPoints=4000
+pushMemoryBufferLimit=64000
+pushRateLimit=10000
+flushThreads=4
+gzipCompression=false
+httpRequestTimeout=20000
+proxyHost=egress.example.com
+proxyPort=3128
+`
+
+func TestProxyConfigOptions(t *testing.T) {
+	settings, err := ParseProxyConfig(strings.NewReader(proxyConfig))
+	require.NoError(t, err)
+	assert.Equal(t, "4", settings["flushThreads"])
+
+	options, err := ProxyConfigOptions(settings)
+	require.NoError(t, err)
+	cfg, err := createConfig("http://localhost", options...)
+	require.NoError(t, err)
+	assert.Equal(t, 2878, cfg.MetricsPort)
+	assert.Equal(t, 30001, cfg.TracesPort)
+	assert.Equal(t, 1500*time.Millisecond, cfg.FlushInterval)
+	assert.Equal(t, 4000, cfg.BatchSize)
+	assert.Equal(t, 64000, cfg.MaxBufferSize)
+	assert.Equal(t, 10000, cfg.RateLimit)
+	require.NotNil(t, cfg.Compression)
+	assert.False(t, *cfg.Compression)
+	assert.Equal(t, 20*time.Second, cfg.HTTPClient.Timeout)
+	assert.Equal(t, "http://egress.example.com:3128", cfg.httpClientConfiguration.ProxyURL.String())
+}
+
+func TestProxyConfigOptions_Token(t *testing.T) {
+	options, err := ProxyConfigOptions(map[string]string{"token": " 0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e "})
+	require.NoError(t, err)
+	cfg, err := createConfig("https://example.wavefront.com", options...)
+	require.NoError(t, err)
+	assert.Equal(t, auth.APIToken{Token: "0f2b4cd6-7e1a-4b3c-9d8e-5f6a7b8c9d0e"}, cfg.Authentication)
+	assert.True(t, cfg.Direct())
+}
+
+func TestProxyConfigOptions_Invalid(t *testing.T) {
+	_, err := ProxyConfigOptions(map[string]string{"pushFlushIntervall": "1000"})
+	assert.EqualError(t, err, "unknown proxy config setting 'pushFlushIntervall'")
+	_, err = ProxyConfigOptions(map[string]string{"pushFlushMaxPoints": "-1"})
+	assert.EqualError(t, err, "invalid proxy config setting pushFlushMaxPoints=-1: must be positive")
+	_, err = ProxyConfigOptions(map[string]string{"proxyHost": "egress", "proxyPort": "http"})
+	assert.Error(t, err)
+
+	_, err = ParseProxyConfig(strings.NewReader("pushFlushInterval 1000"))
+	assert.EqualError(t, err, "invalid proxy config line 1: \"pushFlushInterval 1000\"")
+}
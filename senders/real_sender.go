@@ -1,16 +1,18 @@
 package senders
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/wavefronthq/wavefront-sdk-go/event"
 	"github.com/wavefronthq/wavefront-sdk-go/histogram"
 	"github.com/wavefronthq/wavefront-sdk-go/internal"
 	eventInternal "github.com/wavefronthq/wavefront-sdk-go/internal/event"
-	histogramInternal "github.com/wavefronthq/wavefront-sdk-go/internal/histogram"
-	"github.com/wavefronthq/wavefront-sdk-go/internal/metric"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/span"
 	"github.com/wavefronthq/wavefront-sdk-go/version"
@@ -19,23 +21,78 @@ import (
 // Sender Interface for sending metrics, distributions and spans to Wavefront
 type Sender interface {
 	MetricSender
+	TypedMetricSender
+	ContextSender
 	DistributionSender
+	DistributionRecorder
 	SpanSender
 	EventSender
+	BatchSender
 	internal.Flusher
+	FlushReporter
+	HealthReporter
 	Close()
 	private()
 }
 
 type realSender struct {
-	defaultSource    string
-	pointHandler     internal.LineHandler
-	histoHandler     internal.LineHandler
-	spanHandler      internal.LineHandler
-	spanLogHandler   internal.LineHandler
-	eventHandler     internal.LineHandler
-	internalRegistry sdkmetrics.Registry
-	proxy            bool
+	defaultSource     string
+	sourceResolver    *sourceResolver
+	pointHandler      internal.LineHandler
+	histoHandler      internal.LineHandler
+	spanHandler       internal.LineHandler
+	spanLogHandler    internal.LineHandler
+	eventHandler      internal.LineHandler
+	internalRegistry  sdkmetrics.Registry
+	proxy             bool
+	lineEvents        bool
+	lifecycle         *lifecycle
+	enrichers         []*lookupEnricher
+	transformers      []PointTransformer
+	spanTransformers  []SpanTransformer
+	eventTransformers []PointTransformer
+	valueGuard        *valueGuard
+	deltaAggregator   *internal.DeltaAggregator
+	distributions     *distributionAccumulator
+	durationUnit      time.Duration
+	serializer        LineSerializer
+	protocol          string
+
+	schemaRegistry    SchemaRegistry
+	registeredSchemas sync.Map
+	validation        ValidationMode
+	quoteKey          func(string) string
+	deltaCounterSkew  time.Duration
+	renamer           *metricRenamer
+	topK              *topKAnalyzer
+	journal           *internal.Journal
+	// counters of the reporters, kept across Reconfigure.
+	reporterCounters *internal.ReporterCounters
+
+	// counters of the data discarded for each disabled type, nil when the type is enabled.
+	disabledDistributions *sdkmetrics.DeltaCounter
+	disabledSpans         *sdkmetrics.DeltaCounter
+	disabledEvents        *sdkmetrics.DeltaCounter
+
+	// counters of the data of each type dropped on purpose, by reason.
+	pointsDropped     *dropCounters
+	histogramsDropped *dropCounters
+	spansDropped      *dropCounters
+	eventsDropped     *dropCounters
+
+	// formats the endpoint does not accept, as learnt in the handshake.
+	noSpanLogs      bool
+	noDeltaCounters bool
+
+	timestampUnit     TimestampUnit
+	maxSpanLogBytes   int
+	spanLogsTruncated *sdkmetrics.DeltaCounter
+
+	metricsReporter *internal.SwitchableReporter
+	tracesReporter  *internal.SwitchableReporter
+	endpointMtx     sync.Mutex
+	endpoint        endpoint
+	newEndpoint     endpointFunc
 }
 
 func (sender *realSender) Start() {
@@ -45,14 +102,63 @@ func (sender *realSender) Start() {
 	sender.spanLogHandler.Start()
 	sender.internalRegistry.Start()
 	sender.eventHandler.Start()
+	for _, enricher := range sender.enrichers {
+		enricher.Start()
+	}
+	if sender.deltaAggregator != nil {
+		sender.deltaAggregator.Start()
+	}
+	sender.topK.Start()
+	sender.sourceResolver.Start()
+	if sender.lifecycle != nil {
+		sender.lifecycle.transition(StateHealthy)
+	}
 }
 
 func (sender *realSender) private() {
 }
 
 func (sender *realSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
-	line, err := metric.Line(name, value, ts, source, tags, sender.defaultSource)
+	return sender.sendMetric(handleLine, name, value, ts, source, tags)
+}
+
+func (sender *realSender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	return sender.sendMetric(handleLineCtx(ctx), name, value, ts, source, tags)
+}
+
+func (sender *realSender) sendMetric(enqueue enqueueFunc, name string, value float64, ts int64, source string, tags map[string]string) error {
+	if err := sender.timestampUnit.check(ts); err != nil {
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return err
+	}
+	name = sender.renamer.rename(name)
+	value, send, err := sender.valueGuard.apply(name, value, sender.pointsDropped)
+	if err != nil {
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return err
+	}
+	if !send {
+		return nil
+	}
+	name, source, tags, pooled := sender.pointTags(name, source, tags)
+	defer putTagMap(pooled)
+	if err := sender.validate(name, source, tags); err != nil {
+		sender.pointsDropped.inc(dropInvalid)
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return err
+	}
+	kind := SchemaKindMetric
+	if internal.HasDeltaPrefix(name) {
+		kind = SchemaKindDeltaCounter
+	}
+	if err := sender.registerSchema(name, kind, tags); err != nil {
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return err
+	}
+	sender.topK.observe(name, tags)
+	line, err := sender.metricLine(name, value, ts, source, tags)
 	return trySendWith(
+		enqueue,
 		line,
 		err,
 		sender.pointHandler,
@@ -60,20 +166,120 @@ func (sender *realSender) SendMetric(name string, value float64, ts int64, sourc
 	)
 }
 
+func (sender *realSender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return sender.SendMetric(name, float64(value), ts, source, tags)
+}
+
+func (sender *realSender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	return sender.SendMetric(name, boolValue(value), ts, source, tags)
+}
+
+func (sender *realSender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	v, err := metricValue(value)
+	if err != nil {
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return err
+	}
+	return sender.SendMetric(name, v, ts, source, tags)
+}
+
 func (sender *realSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	return sender.sendDeltaCounter(handleLine, name, value, 0, source, tags)
+}
+
+func (sender *realSender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	return sender.sendDeltaCounter(handleLineCtx(ctx), name, value, 0, source, tags)
+}
+
+func (sender *realSender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	if ts != 0 {
+		if err := sender.timestampUnit.check(ts); err != nil {
+			sender.internalRegistry.PointsTracker().IncInvalid()
+			return err
+		}
+		skew := sender.deltaCounterSkew
+		if skew == 0 {
+			skew = defaultDeltaCounterSkew
+		}
+		if d := time.Since(sender.timestampUnit.time(ts)); d > skew || d < -skew {
+			sender.internalRegistry.PointsTracker().IncInvalid()
+			return fmt.Errorf("delta counter timestamp %d is more than %s away from now", ts, skew)
+		}
+	}
+	return sender.sendDeltaCounter(handleLine, name, value, ts, source, tags)
+}
+
+func (sender *realSender) sendDeltaCounter(enqueue enqueueFunc, name string, value float64, ts int64, source string, tags map[string]string) error {
 	if name == "" {
 		sender.internalRegistry.PointsTracker().IncInvalid()
 		return fmt.Errorf("empty metric name")
 	}
+	if sender.noDeltaCounters {
+		sender.internalRegistry.PointsTracker().IncInvalid()
+		return errDeltaCountersUnsupported
+	}
 	if !internal.HasDeltaPrefix(name) {
 		name = internal.DeltaCounterName(name)
 	}
+	if sender.deltaAggregator != nil {
+		// the checks of the aggregated point are run on each increment, so that their errors
+		// are returned here rather than logged once the bucket is emitted.
+		var err error
+		var send bool
+		if value, send, err = sender.checkMetric(name, value, source, tags); err != nil {
+			sender.internalRegistry.PointsTracker().IncInvalid()
+			return err
+		}
+		if !send {
+			return nil
+		}
+	}
 	if value > 0 {
-		return sender.SendMetric(name, value, 0, source, tags)
+		if sender.deltaAggregator != nil {
+			if ts != 0 {
+				sender.deltaAggregator.AddAt(sender.timestampUnit.time(ts), name, value, source, tags)
+			} else {
+				sender.deltaAggregator.Add(name, value, source, tags)
+			}
+			return nil
+		}
+		return sender.sendMetric(enqueue, name, value, ts, source, tags)
 	}
 	return nil
 }
 
+// checkMetric runs the checks of sendMetric on a point without sending it, and returns the value
+// the value guard replaced it with, and whether it is to be sent.
+func (sender *realSender) checkMetric(name string, value float64, source string, tags map[string]string) (float64, bool, error) {
+	name = sender.renamer.rename(name)
+	value, send, err := sender.valueGuard.apply(name, value, sender.pointsDropped)
+	if err != nil || !send {
+		return value, send, err
+	}
+	name, source, tags, pooled := sender.pointTags(name, source, tags)
+	defer putTagMap(pooled)
+	if err := sender.validate(name, source, tags); err != nil {
+		sender.pointsDropped.inc(dropInvalid)
+		return value, false, err
+	}
+	if err := sender.registerSchema(name, SchemaKindDeltaCounter, tags); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+func (sender *realSender) emitDeltaPoint(point internal.DeltaPoint) {
+	// aggregated points are timestamped in seconds, the start of their bucket.
+	ts := point.Timestamp
+	if sender.timestampUnit == TimestampMillis {
+		ts *= 1000
+	}
+	err := sender.SendMetric(point.Name, point.Value, ts, point.Source, point.Tags)
+	if err != nil {
+		log.Printf("unable to send aggregated delta counter %s: %s\n", point.Name, err)
+	}
+}
+
 func (sender *realSender) SendDistribution(
 	name string,
 	centroids []histogram.Centroid,
@@ -82,8 +288,61 @@ func (sender *realSender) SendDistribution(
 	source string,
 	tags map[string]string,
 ) error {
-	line, err := histogramInternal.Line(name, centroids, hgs, ts, source, tags, sender.defaultSource)
+	return sender.sendDistribution(handleLine, name, centroids, hgs, ts, source, tags)
+}
+
+func (sender *realSender) SendDistributionCtx(
+	ctx context.Context,
+	name string,
+	centroids []histogram.Centroid,
+	hgs map[histogram.Granularity]bool,
+	ts int64,
+	source string,
+	tags map[string]string,
+) error {
+	return sender.sendDistribution(handleLineCtx(ctx), name, centroids, hgs, ts, source, tags)
+}
+
+func (sender *realSender) sendDistribution(
+	enqueue enqueueFunc,
+	name string,
+	centroids []histogram.Centroid,
+	hgs map[histogram.Granularity]bool,
+	ts int64,
+	source string,
+	tags map[string]string,
+) error {
+	if discard(sender.disabledDistributions, sender.histogramsDropped) {
+		return nil
+	}
+	if err := sender.timestampUnit.check(ts); err != nil {
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return err
+	}
+	name = sender.renamer.rename(name)
+	centroids, send, err := sender.valueGuard.applyCentroids(name, centroids, sender.histogramsDropped)
+	if err != nil {
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return err
+	}
+	if !send {
+		return nil
+	}
+	name, source, tags, pooled := sender.pointTags(name, source, tags)
+	defer putTagMap(pooled)
+	if err := sender.validate(name, source, tags); err != nil {
+		sender.histogramsDropped.inc(dropInvalid)
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return err
+	}
+	if err := sender.registerSchema(name, SchemaKindDistribution, tags); err != nil {
+		sender.internalRegistry.HistogramsTracker().IncInvalid()
+		return err
+	}
+	sender.topK.observe(name, tags)
+	line, err := sender.distributionLine(name, centroids, hgs, ts, source, tags)
 	return trySendWith(
+		enqueue,
 		line,
 		err,
 		sender.histoHandler,
@@ -91,14 +350,54 @@ func (sender *realSender) SendDistribution(
 	)
 }
 
-func trySendWith(line string, err error, handler internal.LineHandler, tracker sdkmetrics.SuccessTracker) error {
+// discard counts data of a disabled type in its counters and reports whether the type is disabled.
+func discard(disabled *sdkmetrics.DeltaCounter, dropped *dropCounters) bool {
+	if disabled == nil {
+		return false
+	}
+	disabled.Inc()
+	dropped.inc(dropDisabled)
+	return true
+}
+
+func (sender *realSender) sourceOrDefault(source string) string {
+	if source == "" {
+		return sender.defaultSourceName()
+	}
+	return source
+}
+
+// defaultSourceName returns the source of the data sent without one.
+func (sender *realSender) defaultSourceName() string {
+	if sender.sourceResolver != nil {
+		return sender.sourceResolver.get()
+	}
+	return sender.defaultSource
+}
+
+// enqueueFunc hands a line to a handler.
+type enqueueFunc func(handler internal.LineHandler, line string) error
+
+// handleLine fails right away when the handler's buffer is full.
+func handleLine(handler internal.LineHandler, line string) error {
+	return handler.HandleLine(line)
+}
+
+// handleLineCtx waits for room in the handler's buffer until ctx is done.
+func handleLineCtx(ctx context.Context) enqueueFunc {
+	return func(handler internal.LineHandler, line string) error {
+		return handler.HandleLineCtx(ctx, line)
+	}
+}
+
+func trySendWith(enqueue enqueueFunc, line string, err error, handler internal.LineHandler, tracker sdkmetrics.SuccessTracker) error {
 	if err != nil {
 		tracker.IncInvalid()
 		return err
 	}
 
 	tracker.IncValid()
-	err = handler.HandleLine(line)
+	err = enqueue(handler, line)
 	if err != nil {
 		tracker.IncDropped()
 	}
@@ -113,9 +412,67 @@ func (sender *realSender) SendSpan(
 	tags []SpanTag,
 	spanLogs []SpanLog,
 ) error {
+	return sender.sendSpan(handleLine, name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (sender *realSender) SendSpanCtx(
+	ctx context.Context,
+	name string,
+	startMillis, durationMillis int64,
+	source, traceID, spanID string,
+	parents, followsFrom []string,
+	tags []SpanTag,
+	spanLogs []SpanLog,
+) error {
+	return sender.sendSpan(handleLineCtx(ctx), name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (sender *realSender) sendSpan(
+	enqueue enqueueFunc,
+	name string,
+	startMillis, durationMillis int64,
+	source, traceID, spanID string,
+	parents, followsFrom []string,
+	tags []SpanTag,
+	spanLogs []SpanLog,
+) error {
+	if discard(sender.disabledSpans, sender.spansDropped) {
+		return nil
+	}
+	startMillis, err := sender.timestampUnit.millis(startMillis)
+	if err != nil {
+		sender.internalRegistry.SpansTracker().IncInvalid()
+		return err
+	}
+	if sender.noSpanLogs {
+		spanLogs = nil
+	}
+	spanLogs = sender.truncateSpanLogs(spanLogs)
+	tags = enrichSpanTags(sender.enrichers, sender.sourceOrDefault(source), tags)
+	if len(sender.spanTransformers) > 0 {
+		s := sender.transformSpan(Span{
+			Name:           name,
+			StartMillis:    startMillis,
+			DurationMillis: durationMillis,
+			Source:         source,
+			TraceID:        traceID,
+			SpanID:         spanID,
+			Parents:        parents,
+			FollowsFrom:    followsFrom,
+			Tags:           tags,
+			SpanLogs:       spanLogs,
+		})
+		name, startMillis, durationMillis, source = s.Name, s.StartMillis, s.DurationMillis, s.Source
+		traceID, spanID, parents, followsFrom = s.TraceID, s.SpanID, s.Parents, s.FollowsFrom
+		tags, spanLogs = s.Tags, s.SpanLogs
+	}
+	if err := sender.validateSpan(name, source, tags); err != nil {
+		sender.spansDropped.inc(dropInvalid)
+		sender.internalRegistry.SpansTracker().IncInvalid()
+		return err
+	}
 
-	logs := makeSpanLogs(spanLogs)
-	line, err := span.Line(
+	line, err := sender.spanLine(
 		name,
 		startMillis,
 		durationMillis,
@@ -124,11 +481,11 @@ func (sender *realSender) SendSpan(
 		spanID,
 		parents,
 		followsFrom,
-		makeSpanTags(tags),
-		logs,
-		sender.defaultSource,
+		tags,
+		spanLogs,
 	)
 	err = trySendWith(
+		enqueue,
 		line,
 		err,
 		sender.spanHandler,
@@ -137,9 +494,10 @@ func (sender *realSender) SendSpan(
 		return err
 	}
 
-	if len(spanLogs) > 0 {
-		logJSON, logJSONErr := span.LogJSON(traceID, spanID, logs, line)
+	if len(spanLogs) > 0 && sender.sendsSpanLogs() {
+		logJSON, logJSONErr := span.LogJSON(traceID, spanID, makeSpanLogs(spanLogs), line)
 		return trySendWith(
+			enqueue,
 			logJSON,
 			logJSONErr,
 			sender.spanLogHandler,
@@ -171,15 +529,56 @@ func (sender *realSender) SendEvent(
 	tags map[string]string,
 	setters ...event.Option,
 ) error {
+	return sender.sendEvent(handleLine, name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (sender *realSender) SendEventCtx(
+	ctx context.Context,
+	name string,
+	startMillis, endMillis int64,
+	source string,
+	tags map[string]string,
+	setters ...event.Option,
+) error {
+	return sender.sendEvent(handleLineCtx(ctx), name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (sender *realSender) sendEvent(
+	enqueue enqueueFunc,
+	name string,
+	startMillis, endMillis int64,
+	source string,
+	tags map[string]string,
+	setters ...event.Option,
+) error {
+	if discard(sender.disabledEvents, sender.eventsDropped) {
+		return nil
+	}
+	if isOTLP(sender.protocol) {
+		sender.internalRegistry.EventsTracker().IncInvalid()
+		return errOTLPEvents
+	}
+	startMillis, err := sender.timestampUnit.millis(startMillis)
+	if err == nil {
+		endMillis, err = sender.timestampUnit.millis(endMillis)
+	}
+	if err != nil {
+		sender.internalRegistry.EventsTracker().IncInvalid()
+		return err
+	}
+	if len(sender.eventTransformers) > 0 {
+		name, source, tags = sender.transformEvent(name, source, tags)
+	}
+
 	var line string
-	var err error
-	if sender.proxy {
+	if sender.proxy || sender.lineEvents {
 		line, err = eventInternal.Line(name, startMillis, endMillis, source, tags, setters...)
 	} else {
 		line, err = eventInternal.LineJSON(name, startMillis, endMillis, source, tags, setters...)
 	}
 
 	return trySendWith(
+		enqueue,
 		line,
 		err,
 		sender.eventHandler,
@@ -188,40 +587,71 @@ func (sender *realSender) SendEvent(
 }
 
 func (sender *realSender) Close() {
+	if sender.lifecycle != nil {
+		sender.lifecycle.transition(StateDraining)
+		defer sender.lifecycle.transition(StateClosed)
+	}
+	if sender.deltaAggregator != nil {
+		sender.deltaAggregator.Stop()
+	}
+	if sender.distributions != nil {
+		sender.distributions.close()
+	}
 	sender.pointHandler.Stop()
 	sender.histoHandler.Stop()
 	sender.spanHandler.Stop()
 	sender.spanLogHandler.Stop()
 	sender.internalRegistry.Stop()
 	sender.eventHandler.Stop()
+	sender.endpointMtx.Lock()
+	closeTransport(sender.endpoint)
+	sender.endpointMtx.Unlock()
+	for _, enricher := range sender.enrichers {
+		enricher.Stop()
+	}
+	sender.topK.Stop()
+	sender.sourceResolver.Stop()
+	if sender.journal != nil {
+		_ = sender.journal.Close()
+	}
 }
 
 func (sender *realSender) Flush() error {
-	errStr := ""
-	err := sender.pointHandler.Flush()
-	if err != nil {
-		errStr = errStr + err.Error() + "\n"
+	if sender.deltaAggregator != nil {
+		sender.deltaAggregator.Flush()
 	}
-	err = sender.histoHandler.Flush()
-	if err != nil {
-		errStr = errStr + err.Error() + "\n"
+	if sender.distributions != nil {
+		sender.distributions.drain()
 	}
-	err = sender.spanHandler.Flush()
-	if err != nil {
-		errStr = errStr + err.Error()
+	var errors multiError
+	for _, handler := range []internal.LineHandler{
+		sender.pointHandler,
+		sender.histoHandler,
+		sender.spanHandler,
+		sender.spanLogHandler,
+		sender.eventHandler,
+	} {
+		if err := handler.Flush(); err != nil {
+			errors.add(err)
+		}
 	}
-	err = sender.spanLogHandler.Flush()
-	if err != nil {
-		errStr = errStr + err.Error()
+	return errors.get()
+}
+
+func (sender *realSender) FlushWithReport() FlushReport {
+	if sender.deltaAggregator != nil {
+		sender.deltaAggregator.Flush()
 	}
-	err = sender.eventHandler.Flush()
-	if err != nil {
-		errStr = errStr + err.Error()
+	if sender.distributions != nil {
+		sender.distributions.drain()
 	}
-	if errStr != "" {
-		return fmt.Errorf(errStr)
+	return FlushReport{
+		Points:     flushResult(sender.pointHandler),
+		Histograms: flushResult(sender.histoHandler),
+		Spans:      flushResult(sender.spanHandler),
+		SpanLogs:   flushResult(sender.spanLogHandler),
+		Events:     flushResult(sender.eventHandler),
 	}
-	return nil
 }
 
 func (sender *realSender) GetFailureCount() int64 {
@@ -244,7 +674,7 @@ func (sender *realSender) realInternalRegistry(cfg *configuration) sdkmetrics.Re
 	}
 
 	return sdkmetrics.NewMetricRegistry(
-		sender,
+		sender.internalMetricsSender(cfg),
 		setters...,
 	)
 }
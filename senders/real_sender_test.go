@@ -1,9 +1,13 @@
 package senders
 
 import (
+	"fmt"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,6 +98,52 @@ func TestSendCSPAPIToken(t *testing.T) {
 	assert.Equal(t, []string{"Bearer abc", "Bearer abc", "Bearer abc"}, wavefrontServer.AuthHeaders)
 }
 
+type staticTokenService string
+
+func (s staticTokenService) Token() (string, time.Duration, error) {
+	return string(s), time.Hour, nil
+}
+
+func TestSendCustomTokenService(t *testing.T) {
+	wavefrontServer := startTestServer(false)
+	defer wavefrontServer.Close()
+
+	wf, err := NewSender(wavefrontServer.URL, CustomTokenService(staticTokenService("xyz")))
+	require.NoError(t, err)
+	testSender(t, wf, wavefrontServer)
+	assert.Equal(t, []string{"Bearer xyz", "Bearer xyz", "Bearer xyz"}, wavefrontServer.AuthHeaders)
+}
+
+// digestAuthorizer authorizes requests with the length of their body and their format.
+type digestAuthorizer struct{}
+
+func (digestAuthorizer) Authorize(r *http.Request) error {
+	body, err := r.GetBody()
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(io.Discard, body)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", fmt.Sprintf("Digest %d %s", n, r.URL.Query().Get("f")))
+	return nil
+}
+
+func TestSendCustomAuthorizer(t *testing.T) {
+	wavefrontServer := startTestServer(false)
+	defer wavefrontServer.Close()
+
+	wf, err := NewSender(wavefrontServer.URL, CustomAuthorizer(digestAuthorizer{}), Compression(false))
+	require.NoError(t, err)
+	require.NoError(t, wf.SendMetric("new-york.power.usage", 42422.0, 0, "localhost", nil))
+	require.NoError(t, wf.Flush())
+	wf.Close()
+
+	require.NotEmpty(t, wavefrontServer.AuthHeaders)
+	assert.Regexp(t, `^Digest \d+ wavefront$`, wavefrontServer.AuthHeaders[0])
+}
+
 func testSender(t *testing.T, wf Sender, server *testServer) {
 	assert.NoError(t, wf.SendMetric(
 		"new-york.power.usage",
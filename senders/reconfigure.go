@@ -0,0 +1,55 @@
+package senders
+
+import "fmt"
+
+// Reconfigurable is implemented by the senders created by NewSender and NewOTelReportSender,
+// whose endpoint can be changed at runtime, e.g. on a configuration hot reload:
+//
+//	if r, ok := sender.(senders.Reconfigurable); ok {
+//		err = r.Reconfigure("https://new-proxy:2878")
+//	}
+type Reconfigurable interface {
+	// Reconfigure switches the sender to the endpoint described by url and setters, which are
+	// interpreted as by the function that created the sender. Reports in flight complete against
+	// the previous endpoint, and batches they fail to deliver are buffered for the new one.
+	// Every batch flushed after Reconfigure returns goes to the new endpoint, so buffered points
+	// are neither dropped nor sent twice.
+	// Only the options describing the endpoint take effect: URL, ports, authentication, HTTP client,
+	// tenant, headers, compression and retries. Buffering, batching and internal metrics are kept.
+	// Switching between a proxy and direct ingestion is not supported.
+	Reconfigure(url string, setters ...Option) error
+}
+
+func (sender *realSender) Reconfigure(url string, setters ...Option) error {
+	if sender.newEndpoint == nil {
+		return fmt.Errorf("sender cannot be reconfigured")
+	}
+	cfg, err := createConfig(url, setters...)
+	if err != nil {
+		return fmt.Errorf("unable to create sender config: %s", err)
+	}
+	if cfg.Direct() == sender.proxy {
+		return fmt.Errorf("unable to switch between proxy and direct ingestion")
+	}
+	sender.endpointMtx.Lock()
+	customTransport := sender.endpoint.transport != nil
+	sender.endpointMtx.Unlock()
+	if cfg.CustomTransport != customTransport {
+		return fmt.Errorf("unable to switch between http and registered transports")
+	}
+	ep, err := sender.newEndpoint(url, cfg)
+	if err != nil {
+		return err
+	}
+	metricsReporter, tracesReporter := ep.newReporters(sender.reporterOptions(cfg)...)
+
+	sender.endpointMtx.Lock()
+	defer sender.endpointMtx.Unlock()
+	sender.metricsReporter.Swap(metricsReporter)
+	sender.tracesReporter.Swap(tracesReporter)
+	previous := sender.endpoint
+	sender.endpoint = ep
+	previous.tokenService.Close()
+	closeTransport(previous)
+	return nil
+}
@@ -0,0 +1,62 @@
+package senders
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+func TestReconfigure(t *testing.T) {
+	oldServer := startTestServer(false)
+	defer oldServer.Close()
+	newServer := startTestServer(false)
+	defer newServer.Close()
+
+	sender, err := NewSender(oldServer.URL, SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	require.NoError(t, sender.SendMetric("my metric", 1, 0, "localhost", nil))
+	require.NoError(t, sender.Flush())
+
+	require.NoError(t, sender.SendMetric("my metric", 2, 0, "localhost", nil))
+	require.NoError(t, sender.(Reconfigurable).Reconfigure(newServer.URL+"/test-path"))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, []string{"\"my-metric\" 1 source=\"localhost\""}, oldServer.MetricLines)
+	assert.Equal(t, []string{"\"my-metric\" 2 source=\"localhost\""}, newServer.MetricLines)
+	assert.Equal(t, "/test-path/report?f=wavefront", newServer.RequestURLs[0])
+
+	assert.Error(t, sender.(Reconfigurable).Reconfigure(newServer.URL, APIToken("token")))
+	assert.Error(t, sender.(Reconfigurable).Reconfigure("gopher://localhost"))
+}
+
+func TestReconfigure_InternalMetrics(t *testing.T) {
+	server := startTestServer(false)
+	defer server.Close()
+
+	sender, err := NewSender(server.URL)
+	require.NoError(t, err)
+	defer sender.Close()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sender.SendMetric("my metric", float64(i), 0, "localhost", nil))
+		require.NoError(t, sender.Flush())
+		require.NoError(t, sender.(Reconfigurable).Reconfigure(server.URL))
+	}
+	sender.(*realSender).internalRegistry.Flush()
+	require.NoError(t, sender.Flush())
+
+	// the reporters of every endpoint count into the same internal metrics.
+	var bytesLines []string
+	for _, line := range server.MetricLines {
+		if strings.Contains(line, "."+sdkmetrics.BytesUncompressed+"\"") {
+			bytesLines = append(bytesLines, line)
+		}
+	}
+	require.Len(t, bytesLines, 1)
+	reported := 2 * len("\"my-metric\" 0 source=\"localhost\"\n")
+	assert.Contains(t, bytesLines[0], fmt.Sprintf("\" %d source=", reported))
+}
@@ -0,0 +1,60 @@
+package replay
+
+import "strings"
+
+// Formats of the lines of a dump, named after the f query param of the /report endpoint of
+// Wavefront proxies.
+const (
+	FormatMetric    = "wavefront"
+	FormatHistogram = "histogram"
+	FormatSpan      = "trace"
+	FormatSpanLogs  = "spanLogs"
+)
+
+// FormatTarget is implemented by the targets that send each data format to its own endpoint,
+// such as the targets of NewHTTPTarget. The batches of mixed dumps are split by format for
+// them; other targets are sent the lines of all formats together.
+type FormatTarget interface {
+	Target
+	// SendFormat sends a batch of lines of the given format, one of the Format* constants.
+	SendFormat(format string, lines []string) error
+}
+
+// lineFormat returns the format of line. Events, and lines that are not histograms, spans
+// or span logs, are sent as metrics.
+func lineFormat(line string) string {
+	switch {
+	case strings.HasPrefix(line, "!M ") || strings.HasPrefix(line, "!H ") || strings.HasPrefix(line, "!D "):
+		return FormatHistogram
+	case strings.HasPrefix(line, "{"):
+		if strings.Contains(line, `"spanId"`) {
+			return FormatSpanLogs
+		}
+	case strings.Contains(line, " traceId=") && strings.Contains(line, " spanId="):
+		return FormatSpan
+	}
+	return FormatMetric
+}
+
+// batch is the lines of a batch of the same format.
+type batch struct {
+	format string
+	lines  []string
+}
+
+// splitByFormat splits lines into a batch per format, in the order the formats first appear.
+func splitByFormat(lines []string) []batch {
+	var batches []batch
+	index := map[string]int{}
+	for _, line := range lines {
+		format := lineFormat(line)
+		i, ok := index[format]
+		if !ok {
+			i = len(batches)
+			index[format] = i
+			batches = append(batches, batch{format: format})
+		}
+		batches[i].lines = append(batches[i].lines, line)
+	}
+	return batches
+}
@@ -0,0 +1,74 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	metricLine    = `"a" 1 1700000000 source="h"`
+	histogramLine = `!M 1700000000 #1 2.0 "h" source="s"`
+	spanLine      = `"span" source="s" traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 spanId=0313bafe-9457-11e8-9eb6-529269fb1459 1533531013 343500`
+	spanLogsLine  = `{"traceId":"7b3bf470-9456-11e8-9eb6-529269fb1459","spanId":"0313bafe-9457-11e8-9eb6-529269fb1459","logs":[]}`
+	eventLine     = `@Event 1700000000 1700000060 "deploy" "host"="h"`
+)
+
+func TestLineFormat(t *testing.T) {
+	assert.Equal(t, FormatMetric, lineFormat(metricLine))
+	assert.Equal(t, FormatHistogram, lineFormat(histogramLine))
+	assert.Equal(t, FormatHistogram, lineFormat(`!H 1700000000 #1 2.0 "h" source="s"`))
+	assert.Equal(t, FormatSpan, lineFormat(spanLine))
+	assert.Equal(t, FormatSpanLogs, lineFormat(spanLogsLine))
+	assert.Equal(t, FormatMetric, lineFormat(eventLine))
+	assert.Equal(t, FormatMetric, lineFormat(`"traceId" 1 source="h"`))
+}
+
+func TestSplitByFormat(t *testing.T) {
+	assert.Equal(t, []batch{
+		{FormatMetric, []string{metricLine, eventLine}},
+		{FormatSpan, []string{spanLine}},
+		{FormatHistogram, []string{histogramLine}},
+	}, splitByFormat([]string{metricLine, spanLine, histogramLine, eventLine}))
+}
+
+func TestReplayMixedDump(t *testing.T) {
+	var mtx sync.Mutex
+	received := map[string][]string{}
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mtx.Lock()
+			defer mtx.Unlock()
+			key := name
+			if f := r.URL.Query().Get("f"); f != "" {
+				key += "?f=" + f
+			}
+			received[key] = append(received[key], string(body))
+		}
+	}
+	proxy := httptest.NewServer(handler("proxy"))
+	defer proxy.Close()
+	tracing := httptest.NewServer(handler("tracing"))
+	defer tracing.Close()
+
+	path := writeDump(t, t.TempDir(), "mixed.txt.log", metricLine, histogramLine, spanLine, spanLogsLine, eventLine)
+	r := New(NewHTTPTarget(proxy.URL, Route(FormatSpan, tracing.URL)), RunIDTag(""))
+	summary, err := r.Replay(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"proxy":             {metricLine + "\n" + eventLine},
+		"proxy?f=histogram": {histogramLine},
+		"tracing":           {spanLine},
+		"proxy?f=spanLogs":  {spanLogsLine},
+	}, received)
+	assert.Equal(t, 5, summary.Lines)
+	assert.Equal(t, 4, summary.Batches)
+	assert.Equal(t, map[string]int{FormatMetric: 2, FormatHistogram: 1, FormatSpan: 1, FormatSpanLogs: 1}, summary.LinesByFormat)
+}
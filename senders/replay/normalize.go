@@ -0,0 +1,147 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Normalization selects how the lines of dumps are cleaned up before they are replayed,
+// for dumps captured on other platforms or by other tools. See Normalize.
+type Normalization int
+
+const (
+	// NormalizeLineEndings splits lines on CRLF and lone CR line endings, and not only on LF.
+	NormalizeLineEndings Normalization = 1 << iota
+	// StripBOM removes byte order marks, at the start of files and of concatenated dumps, and
+	// decodes UTF-16 dumps, recognized by their byte order mark, to UTF-8.
+	StripBOM
+	// StripControlChars removes control characters other than tabs, which are replaced with spaces,
+	// and invalid UTF-8 sequences.
+	StripControlChars
+
+	// DefaultNormalization applies all the normalizations.
+	DefaultNormalization = NormalizeLineEndings | StripBOM | StripControlChars
+)
+
+const bom = "\uFEFF"
+
+var (
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// Normalize sets how the lines of dumps are cleaned up. Defaults to DefaultNormalization;
+// Normalize(0) replays lines as they are, split on LF. The number of lines changed is reported
+// in Summary.NormalizedLines.
+func Normalize(n Normalization) Option {
+	return func(r *Replayer) {
+		r.normalization = n
+	}
+}
+
+// decode returns data as UTF-8, decoding it from UTF-16 if it starts with a UTF-16 byte order mark.
+func (n Normalization) decode(data []byte) []byte {
+	if n&StripBOM == 0 || len(data) < 2 {
+		return data
+	}
+	var order func([]byte) uint16
+	switch {
+	case bytes.HasPrefix(data, utf16LEBOM):
+		order = func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+	case bytes.HasPrefix(data, utf16BEBOM):
+		order = func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) }
+	default:
+		return data
+	}
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		units = append(units, order(data[i:i+2]))
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// lines returns the non-empty lines of data, normalized as enabled by n, and the number of them
+// normalization changed. Without NormalizeLineEndings, lines are split on LF only and the CR of
+// CRLF line endings is kept, or removed as a control character.
+func (n Normalization) lines(data []byte) ([]string, int) {
+	text := n.decode(data)
+	decoded := !bytes.Equal(text, data)
+	var lines []string
+	var normalized int
+	for _, raw := range strings.Split(string(text), "\n") {
+		parts := []string{raw}
+		changed := decoded
+		if n&NormalizeLineEndings != 0 && strings.Contains(raw, "\r") {
+			parts = strings.Split(strings.TrimSuffix(raw, "\r"), "\r")
+			changed = true
+		}
+		for _, part := range parts {
+			line := n.clean(part)
+			if line == "" {
+				continue
+			}
+			if changed || line != part {
+				normalized++
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, normalized
+}
+
+// clean returns line without its byte order marks and control characters, as enabled by n.
+func (n Normalization) clean(line string) string {
+	if n&StripBOM != 0 {
+		line = strings.ReplaceAll(line, bom, "")
+	}
+	if n&StripControlChars != 0 && needsCleaning(line) {
+		var sb strings.Builder
+		sb.Grow(len(line))
+		for i := 0; i < len(line); {
+			r, size := utf8.DecodeRuneInString(line[i:])
+			switch {
+			case r == utf8.RuneError && size == 1:
+			case r == '\t':
+				sb.WriteByte(' ')
+			case unicode.IsControl(r):
+			default:
+				sb.WriteString(line[i : i+size])
+			}
+			i += size
+		}
+		line = sb.String()
+	}
+	return line
+}
+
+func needsCleaning(line string) bool {
+	if !utf8.ValidString(line) {
+		return true
+	}
+	for _, r := range line {
+		if unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// serialize joins lines into a request body, one per line. Line breaks left within lines are
+// replaced with spaces, so that a line is never split in two by the receiving end.
+func serialize(lines []string) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.ContainsAny(line, "\r\n") {
+			line = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(line)
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizationLines(t *testing.T) {
+	data := []byte("\uFEFF\"a\" 1\r\n\"b\"\t2\x00\r\"c\" 3\n\xff\"d\" 4\n\n")
+
+	lines, normalized := DefaultNormalization.lines(data)
+	assert.Equal(t, []string{`"a" 1`, `"b" 2`, `"c" 3`, `"d" 4`}, lines)
+	assert.Equal(t, 4, normalized)
+
+	lines, normalized = Normalization(0).lines(data)
+	assert.Equal(t, []string{"\uFEFF\"a\" 1\r", "\"b\"\t2\x00\r\"c\" 3", "\xff\"d\" 4"}, lines)
+	assert.Equal(t, 0, normalized)
+
+	lines, normalized = StripControlChars.lines([]byte("\"a\" 1\r\n\"b\" 2\n"))
+	assert.Equal(t, []string{`"a" 1`, `"b" 2`}, lines)
+	assert.Equal(t, 1, normalized)
+}
+
+func TestNormalizationDecodesUTF16(t *testing.T) {
+	le := []byte{0xFF, 0xFE, '"', 0, 'a', 0, '"', 0, ' ', 0, '1', 0, '\r', 0, '\n', 0}
+	lines, normalized := DefaultNormalization.lines(le)
+	assert.Equal(t, []string{`"a" 1`}, lines)
+	assert.Equal(t, 1, normalized)
+
+	be := []byte{0xFE, 0xFF, 0, 'x', 0, ' ', 0, '2'}
+	lines, _ = DefaultNormalization.lines(be)
+	assert.Equal(t, []string{"x 2"}, lines)
+}
+
+func TestSerialize(t *testing.T) {
+	assert.Equal(t, "\"a\" 1\n\"b\" 2 \"c\" 3", serialize([]string{"\"a\" 1\r\n", "\"b\" 2\r\n\"c\" 3"}))
+}
+
+func TestReplayNormalizedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crlf.txt.log")
+	require.NoError(t, os.WriteFile(path, []byte("\"a\" 1\r\n\"b\" 2\r\n\"c\" 3\n"), 0o644))
+
+	var lines []string
+	r := New(TargetFunc(func(batch []string) error {
+		lines = append(lines, batch...)
+		return nil
+	}), ReplayCount(2), RunIDTag(""))
+
+	summary, err := r.Replay(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`"a" 1`, `"b" 2`, `"c" 3`, `"a" 1`, `"b" 2`, `"c" 3`}, lines)
+	assert.Equal(t, 2, summary.NormalizedLines)
+}
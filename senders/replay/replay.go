@@ -0,0 +1,203 @@
+// Package replay replays Wavefront data format dump files, e.g. captured by a proxy, against
+// a Wavefront proxy or an OTel collector, in batches, once or several times, to load test it
+// or to check a release against the data of a real workload. Dumps may mix metric, histogram,
+// span and span log lines, which are sent to the endpoint of their format, see FormatTarget. Replayed lines are tagged with
+// the ID of the run, so that the data of a run can be found, and deleted, on the collector side.
+// CRLF line endings, byte order marks and control characters of dumps are cleaned up, see Normalize.
+//
+//	r := replay.New(replay.NewHTTPTarget("http://localhost:8085/report"),
+//		replay.BatchSize(1000), replay.ReplayCount(3), replay.RewriteTimestamps())
+//	summary, err := r.Replay("dumps/")
+package replay
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	defaultBatchSize = 5000
+	defaultPattern   = "*.txt.log"
+)
+
+// Replayer sends the lines of dump files to a Target in batches.
+type Replayer struct {
+	target            Target
+	batchSize         int
+	replayCount       int
+	sleepBetween      time.Duration
+	rewriteTimestamps bool
+	pattern           string
+	runID             string
+	runIDTag          string
+	normalization     Normalization
+	now               func() time.Time
+	sleep             func(time.Duration)
+}
+
+// Option configures a Replayer.
+type Option func(*Replayer)
+
+// BatchSize sets the number of lines sent per batch. Defaults to 5,000.
+func BatchSize(n int) Option {
+	return func(r *Replayer) {
+		r.batchSize = n
+	}
+}
+
+// ReplayCount sets the number of times each file is replayed. Defaults to 1.
+func ReplayCount(n int) Option {
+	return func(r *Replayer) {
+		r.replayCount = n
+	}
+}
+
+// SleepBetween sets the pause between two replays of a file. Defaults to none.
+func SleepBetween(d time.Duration) Option {
+	return func(r *Replayer) {
+		r.sleepBetween = d
+	}
+}
+
+// RewriteTimestamps replaces the timestamps of metric and histogram lines with the time they
+// are sent at, so that old dumps are not rejected as too old and show up as recent data.
+// Timestamps in milliseconds are rewritten in milliseconds, others in seconds.
+func RewriteTimestamps() Option {
+	return func(r *Replayer) {
+		r.rewriteTimestamps = true
+	}
+}
+
+// FilePattern sets the pattern, see filepath.Match, of the names of the files replayed from
+// a directory. Defaults to "*.txt.log".
+func FilePattern(pattern string) Option {
+	return func(r *Replayer) {
+		r.pattern = pattern
+	}
+}
+
+// New creates a Replayer sending to target. Each Replayer has a run ID, see RunID.
+func New(target Target, setters ...Option) *Replayer {
+	r := &Replayer{
+		target:        target,
+		batchSize:     defaultBatchSize,
+		replayCount:   1,
+		pattern:       defaultPattern,
+		runIDTag:      DefaultRunIDTag,
+		normalization: DefaultNormalization,
+		now:           time.Now,
+		sleep:         time.Sleep,
+	}
+	for _, set := range setters {
+		set(r)
+	}
+	if r.runID == "" {
+		r.runID = newRunID(r.now())
+	}
+	if t, ok := target.(runIDTarget); ok {
+		t.setRunID(r.runID)
+	}
+	return r
+}
+
+// Replay replays the file at path, or the files of the directory at path matching the
+// file pattern, in name order. Failed batches are counted in the summary and do not stop
+// the replay; errors opening or reading files do.
+func (r *Replayer) Replay(path string) (Summary, error) {
+	files, err := r.files(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	rec := newRecorder(r.runID, r.now())
+	for _, file := range files {
+		if err := r.replayFile(file, rec); err != nil {
+			return rec.summary(r.now()), fmt.Errorf("replay run %s: %s", r.runID, err)
+		}
+	}
+	return rec.summary(r.now()), nil
+}
+
+func (r *Replayer) files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ok, err := filepath.Match(r.pattern, entry.Name()); err != nil {
+			return nil, fmt.Errorf("invalid file pattern '%s': %s", r.pattern, err)
+		} else if ok {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (r *Replayer) replayFile(path string, rec *recorder) error {
+	lines, normalized, err := readLines(path, r.normalization)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %s", path, err)
+	}
+	rec.normalized += normalized
+	if r.runIDTag != "" {
+		lines = tagLines(lines, r.runIDTag, r.runID)
+	}
+	for replay := 0; replay < r.replayCount; replay++ {
+		if replay > 0 && r.sleepBetween > 0 {
+			r.sleep(r.sleepBetween)
+		}
+		for i := 0; i < len(lines); i += r.batchSize {
+			end := i + r.batchSize
+			if end > len(lines) {
+				end = len(lines)
+			}
+			batch := lines[i:end]
+			if r.rewriteTimestamps {
+				batch = rewriteTimestamps(batch, r.now())
+			}
+			r.send(batch, rec)
+		}
+	}
+	return nil
+}
+
+// send sends batch to the target, split by format if the target is a FormatTarget.
+func (r *Replayer) send(lines []string, rec *recorder) {
+	ft, ok := r.target.(FormatTarget)
+	if !ok {
+		start := r.now()
+		err := r.target.Send(lines)
+		rec.record(splitByFormat(lines), r.now().Sub(start), err)
+		return
+	}
+	for _, b := range splitByFormat(lines) {
+		start := r.now()
+		err := ft.SendFormat(b.format, b.lines)
+		rec.record([]batch{b}, r.now().Sub(start), err)
+	}
+}
+
+// readLines returns the non-empty lines of the file at path, normalized as enabled by n,
+// and the number of them normalization changed.
+func readLines(path string, n Normalization) ([]string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	lines, normalized := n.lines(data)
+	return lines, normalized, nil
+}
@@ -0,0 +1,101 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDump(t *testing.T, dir, name string, lines ...string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n\n"), 0o644))
+	return path
+}
+
+func TestReplayFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeDump(t, dir, "a.txt.log", `"a" 1 source="h"`, `"b" 2 source="h"`, `"c" 3 source="h"`)
+
+	var batches [][]string
+	var slept []time.Duration
+	r := New(TargetFunc(func(lines []string) error {
+		batches = append(batches, lines)
+		if len(batches) == 2 {
+			return errors.New("rejected")
+		}
+		return nil
+	}), BatchSize(2), ReplayCount(2), SleepBetween(time.Second), RunIDTag(""))
+	r.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	summary, err := r.Replay(path)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{`"a" 1 source="h"`, `"b" 2 source="h"`}, {`"c" 3 source="h"`},
+		{`"a" 1 source="h"`, `"b" 2 source="h"`}, {`"c" 3 source="h"`},
+	}, batches)
+	assert.Equal(t, []time.Duration{time.Second}, slept)
+	assert.Equal(t, 5, summary.Lines)
+	assert.Equal(t, 4, summary.Batches)
+	assert.Equal(t, 1, summary.FailedBatches)
+	assert.Equal(t, 0.25, summary.ErrorRate)
+}
+
+func TestReplayDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeDump(t, dir, "b.txt.log", `"b" 1 1700000000 source="h"`)
+	writeDump(t, dir, "a.txt.log", `"a" 1 1700000000 source="h"`)
+	writeDump(t, dir, "ignored.json", `{}`)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.txt.log"), 0o755))
+
+	var lines []string
+	r := New(TargetFunc(func(batch []string) error {
+		lines = append(lines, batch...)
+		return nil
+	}), RewriteTimestamps(), RunID("r1"))
+	r.now = func() time.Time { return time.Unix(1800000000, 0) }
+
+	summary, err := r.Replay(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`"a" 1 1800000000 source="h" "_wf.replay.run_id"="r1"`,
+		`"b" 1 1800000000 source="h" "_wf.replay.run_id"="r1"`,
+	}, lines)
+	assert.Equal(t, 2, summary.Lines)
+	assert.Equal(t, "r1", summary.RunID)
+
+	_, err = r.Replay(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	_, err = New(TargetFunc(func([]string) error { return nil }), FilePattern("[")).Replay(dir)
+	assert.Error(t, err)
+}
+
+func TestHTTPTarget(t *testing.T) {
+	var body, contentType, tenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, contentType, tenant = string(data), r.Header.Get("Content-Type"), r.Header.Get("dx_tenant_id")
+		if strings.Contains(body, "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	target := NewHTTPTarget(server.URL, ContentType("text/plain"), Header("dx_tenant_id", "16"))
+	require.NoError(t, target.Send([]string{`"a" 1`, `"b" 2`}))
+	assert.Equal(t, "\"a\" 1\n\"b\" 2", body)
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, "16", tenant)
+
+	assert.EqualError(t, target.Send([]string{"bad"}), "unexpected status code: 400, body: ")
+}
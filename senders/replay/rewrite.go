@@ -0,0 +1,79 @@
+package replay
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestamps above this are in milliseconds.
+const maxSecondsTimestamp = 1e11
+
+// rewriteTimestamps returns a copy of lines with the timestamps of metric and histogram lines
+// set to now. Other lines are left as is.
+func rewriteTimestamps(lines []string, now time.Time) []string {
+	rewritten := make([]string, len(lines))
+	for i, line := range lines {
+		rewritten[i] = rewriteTimestamp(line, now)
+	}
+	return rewritten
+}
+
+// rewriteTimestamp sets the timestamp of a metric line, "name value [ts] source=...", or of
+// a histogram line, "!M [ts] #count centroid ... name source=...", to now.
+func rewriteTimestamp(line string, now time.Time) string {
+	pos := 0
+	var skip int
+	if strings.HasPrefix(line, "!") {
+		skip = 1 // the granularity
+	} else {
+		skip = 2 // the name and the value
+	}
+	for i := 0; i < skip; i++ {
+		end := tokenEnd(line, pos)
+		if end == pos {
+			return line
+		}
+		pos = skipSpaces(line, end)
+	}
+
+	end := tokenEnd(line, pos)
+	ts, err := strconv.ParseInt(line[pos:end], 10, 64)
+	if err != nil || ts <= 0 {
+		return line
+	}
+	newTs := now.Unix()
+	if ts > maxSecondsTimestamp {
+		newTs = now.UnixNano() / int64(time.Millisecond)
+	}
+	return line[:pos] + strconv.FormatInt(newTs, 10) + line[end:]
+}
+
+// tokenEnd returns the end of the token starting at pos, a double quoted one possibly holding spaces.
+func tokenEnd(line string, pos int) int {
+	if pos >= len(line) {
+		return pos
+	}
+	if line[pos] == '"' {
+		for i := pos + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1
+			}
+		}
+		return len(line)
+	}
+	if i := strings.IndexAny(line[pos:], " \t"); i >= 0 {
+		return pos + i
+	}
+	return len(line)
+}
+
+func skipSpaces(line string, pos int) int {
+	for pos < len(line) && (line[pos] == ' ' || line[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,29 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteTimestamp(t *testing.T) {
+	now := time.Unix(1800000000, 123000000)
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`"cpu.usage" 85.5 1700000000 source="host" "env"="prod"`, `"cpu.usage" 85.5 1800000000 source="host" "env"="prod"`},
+		{`"cpu usage" 85.5 1700000000000 source="host"`, `"cpu usage" 85.5 1800000000123 source="host"`},
+		{`cpu.usage 1 1700000000 source=host`, `cpu.usage 1 1800000000 source=host`},
+		{`"cpu.usage" 85.5 source="host"`, `"cpu.usage" 85.5 source="host"`},
+		{`!M 1700000000 #2 1.5 "latency" source="host"`, `!M 1800000000 #2 1.5 "latency" source="host"`},
+		{`!M #2 1.5 "latency" source="host"`, `!M #2 1.5 "latency" source="host"`},
+		{`"span" source=host traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 1700000000 10`,
+			`"span" source=host traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 1700000000 10`},
+		{`"unterminated 1 1700000000`, `"unterminated 1 1700000000`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, rewriteTimestamp(test.line, now), test.line)
+	}
+}
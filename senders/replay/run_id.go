@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRunIDTag is the tag the run ID is added to replayed lines under.
+	DefaultRunIDTag = "_wf.replay.run_id"
+	// RunIDHeader is the header the run ID is sent in by the targets of NewHTTPTarget.
+	RunIDHeader = "X-Replay-Run-Id"
+)
+
+// RunID sets the ID of the replay run, instead of a generated one, e.g. to tie the data of
+// several replays to the same test run.
+func RunID(id string) Option {
+	return func(r *Replayer) {
+		r.runID = id
+	}
+}
+
+// RunIDTag sets the tag the run ID is added to metric, histogram and span lines under.
+// Defaults to DefaultRunIDTag. An empty key leaves the lines untouched.
+func RunIDTag(key string) Option {
+	return func(r *Replayer) {
+		r.runIDTag = key
+	}
+}
+
+// RunID returns the ID of the replay run, which tags the replayed lines, so that the data of
+// a run can be told apart, and deleted, on the collector side.
+func (r *Replayer) RunID() string {
+	return r.runID
+}
+
+// newRunID returns an ID made of now and of random bytes, e.g. 20240102-150405-3f9a1c2b.
+func newRunID(now time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// runIDTarget is implemented by the targets that send the run ID along with the batches.
+type runIDTarget interface {
+	setRunID(id string)
+}
+
+// tagLines returns a copy of lines with the tag key=value added to metric, histogram and span
+// lines. Span logs and events are left as is.
+func tagLines(lines []string, key, value string) []string {
+	tag := " " + strconv.Quote(key) + "=" + strconv.Quote(value)
+	tagged := make([]string, len(lines))
+	for i, line := range lines {
+		tagged[i] = tagLine(line, tag)
+	}
+	return tagged
+}
+
+// tagLine adds tag to line: at the end of metric and histogram lines, and before the start
+// and duration of span lines.
+func tagLine(line, tag string) string {
+	if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "@") {
+		return line
+	}
+	if !strings.Contains(line, " traceId=") {
+		return line + tag
+	}
+	end := len(line)
+	for i := 0; i < 2; i++ {
+		end = strings.LastIndexAny(line[:end], " \t")
+		if end < 0 {
+			return line
+		}
+		for end > 0 && (line[end-1] == ' ' || line[end-1] == '\t') {
+			end--
+		}
+	}
+	return line[:end] + tag + line[end:]
+}
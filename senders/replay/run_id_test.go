@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunID(t *testing.T) {
+	target := TargetFunc(func([]string) error { return nil })
+	r := New(target)
+	assert.Regexp(t, regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{8}$`), r.RunID())
+	assert.NotEqual(t, r.RunID(), New(target).RunID())
+	assert.Equal(t, "load-test-7", New(target, RunID("load-test-7")).RunID())
+}
+
+func TestTagLines(t *testing.T) {
+	assert.Equal(t, []string{
+		`"a" 1 1700000000 source="h" "k"="v" "run"="r1"`,
+		`!M 1700000000 #1 2.0 "h" source="s" "run"="r1"`,
+		`"span" source="s" traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 spanId=0313bafe-9457-11e8-9eb6-529269fb1459 "app"="x" "run"="r1" 1533531013 343500`,
+		`{"traceId":"7b3bf470-9456-11e8-9eb6-529269fb1459","logs":[]}`,
+		`@Event 1700000000 1700000060 "deploy" "host"="h"`,
+	}, tagLines([]string{
+		`"a" 1 1700000000 source="h" "k"="v"`,
+		`!M 1700000000 #1 2.0 "h" source="s"`,
+		`"span" source="s" traceId=7b3bf470-9456-11e8-9eb6-529269fb1459 spanId=0313bafe-9457-11e8-9eb6-529269fb1459 "app"="x" 1533531013 343500`,
+		`{"traceId":"7b3bf470-9456-11e8-9eb6-529269fb1459","logs":[]}`,
+		`@Event 1700000000 1700000060 "deploy" "host"="h"`,
+	}, "run", "r1"))
+}
+
+func TestHTTPTarget_RunIDHeader(t *testing.T) {
+	var runID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runID = r.Header.Get(RunIDHeader)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	r := New(NewHTTPTarget(server.URL), RunID("r2"))
+	r.now = func() time.Time { return time.Unix(1800000000, 0) }
+	summary, err := r.Replay(writeDump(t, dir, "a.txt.log", `"a" 1 source="h"`))
+	require.NoError(t, err)
+	assert.Equal(t, 0, summary.FailedBatches)
+	assert.Equal(t, "r2", runID)
+}
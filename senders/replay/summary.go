@@ -0,0 +1,170 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Summary sums up the outcome of a replay: how fast lines were sent, how many batches
+// failed and how long the slowest ones took. Summaries are stored as JSON, so that a run
+// can be compared to a previous one, see CompareResults. RunID is the ID of the run, see
+// Replayer.RunID.
+type Summary struct {
+	RunID          string        `json:"run_id,omitempty"`
+	Lines          int           `json:"lines"`
+	Batches        int           `json:"batches"`
+	FailedBatches  int           `json:"failed_batches"`
+	Duration       time.Duration `json:"duration"`
+	LinesPerSecond float64       `json:"lines_per_second"`
+	ErrorRate      float64       `json:"error_rate"`
+	P99Latency     time.Duration `json:"p99_latency"`
+	// LinesByFormat is the number of lines sent of each format, see FormatMetric and the others.
+	LinesByFormat map[string]int `json:"lines_by_format,omitempty"`
+	// NormalizedLines is the number of lines of the replayed files changed by normalization, see
+	// Normalize. Lines are counted once, however many times their file is replayed.
+	NormalizedLines int `json:"normalized_lines,omitempty"`
+}
+
+// Thresholds are the regressions tolerated by Compare.
+type Thresholds struct {
+	// Throughput is the tolerated drop of lines per second, as a fraction of the previous one.
+	Throughput float64
+	// ErrorRate is the tolerated increase of the fraction of failed batches.
+	ErrorRate float64
+	// P99Latency is the tolerated increase of the p99 batch latency, as a fraction of the previous one.
+	P99Latency float64
+}
+
+// DefaultThresholds tolerates 10% less throughput, 1% more failed batches and a 20% higher p99 latency.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		Throughput: 0.1,
+		ErrorRate:  0.01,
+		P99Latency: 0.2,
+	}
+}
+
+// recorder collects the batches sent by a replay.
+type recorder struct {
+	runID      string
+	lines      int
+	byFormat   map[string]int
+	failed     int
+	latencies  []time.Duration
+	normalized int
+	start      time.Time
+}
+
+func newRecorder(runID string, start time.Time) *recorder {
+	return &recorder{runID: runID, byFormat: map[string]int{}, start: start}
+}
+
+// record adds a request sending batches in latency, failed if err is not nil.
+func (r *recorder) record(batches []batch, latency time.Duration, err error) {
+	r.latencies = append(r.latencies, latency)
+	if err != nil {
+		r.failed++
+		return
+	}
+	for _, b := range batches {
+		r.lines += len(b.lines)
+		r.byFormat[b.format] += len(b.lines)
+	}
+}
+
+func (r *recorder) summary(end time.Time) Summary {
+	s := summarize(r.lines, r.failed, r.latencies, end.Sub(r.start))
+	s.RunID = r.runID
+	s.NormalizedLines = r.normalized
+	if len(r.byFormat) > 0 {
+		s.LinesByFormat = r.byFormat
+	}
+	return s
+}
+
+func summarize(lines, failed int, latencies []time.Duration, duration time.Duration) Summary {
+	s := Summary{
+		Lines:         lines,
+		Batches:       len(latencies),
+		FailedBatches: failed,
+		Duration:      duration,
+	}
+	if duration > 0 {
+		s.LinesPerSecond = float64(lines) / duration.Seconds()
+	}
+	if len(latencies) > 0 {
+		s.ErrorRate = float64(failed) / float64(len(latencies))
+		sorted := append([]time.Duration(nil), latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.P99Latency = sorted[(len(sorted)*99+99)/100-1]
+	}
+	return s
+}
+
+// Compare returns the regressions of current from previous beyond thresholds.
+func Compare(previous, current Summary, thresholds Thresholds) []string {
+	var regressions []string
+	if previous.LinesPerSecond > 0 && current.LinesPerSecond < previous.LinesPerSecond*(1-thresholds.Throughput) {
+		regressions = append(regressions, fmt.Sprintf("throughput dropped from %.0f to %.0f lines/s",
+			previous.LinesPerSecond, current.LinesPerSecond))
+	}
+	if current.ErrorRate > previous.ErrorRate+thresholds.ErrorRate {
+		regressions = append(regressions, fmt.Sprintf("error rate rose from %.2f%% to %.2f%%",
+			previous.ErrorRate*100, current.ErrorRate*100))
+	}
+	if previous.P99Latency > 0 && float64(current.P99Latency) > float64(previous.P99Latency)*(1+thresholds.P99Latency) {
+		regressions = append(regressions, fmt.Sprintf("p99 latency rose from %s to %s",
+			previous.P99Latency, current.P99Latency))
+	}
+	return regressions
+}
+
+// Results holds the summaries of the replays of a run, by name.
+type Results map[string]Summary
+
+// WriteResults stores results as JSON in the file at path.
+func WriteResults(path string, results Results) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadResults reads results stored by WriteResults.
+func ReadResults(path string) (Results, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("invalid replay results file %s: %s", path, err)
+	}
+	return results, nil
+}
+
+// CompareResults diffs the replays of current against the ones of previous with the same
+// name, and returns the regressions beyond thresholds. Replays missing from previous are skipped.
+func CompareResults(previous, current Results, thresholds Thresholds) []string {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []string
+	for _, name := range names {
+		before, ok := previous[name]
+		if !ok {
+			continue
+		}
+		for _, regression := range Compare(before, current[name], thresholds) {
+			regressions = append(regressions, name+": "+regression)
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,49 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	latencies := make([]time.Duration, 200)
+	for i := range latencies {
+		latencies[i] = time.Duration(200-i) * time.Millisecond
+	}
+	s := summarize(10000, 2, latencies, 2*time.Second)
+	assert.Equal(t, 200, s.Batches)
+	assert.Equal(t, 5000.0, s.LinesPerSecond)
+	assert.Equal(t, 0.01, s.ErrorRate)
+	assert.Equal(t, 198*time.Millisecond, s.P99Latency)
+
+	assert.Equal(t, Summary{}, summarize(0, 0, nil, 0))
+}
+
+func TestCompareResults(t *testing.T) {
+	previous := Results{
+		"TestA": {LinesPerSecond: 1000, ErrorRate: 0, P99Latency: 100 * time.Millisecond},
+		"TestB": {LinesPerSecond: 1000, ErrorRate: 0.1, P99Latency: 100 * time.Millisecond},
+	}
+	current := Results{
+		"TestA": {LinesPerSecond: 850, ErrorRate: 0.05, P99Latency: 130 * time.Millisecond},
+		"TestB": {LinesPerSecond: 950, ErrorRate: 0.1, P99Latency: 110 * time.Millisecond},
+		"TestC": {LinesPerSecond: 1},
+	}
+	assert.Equal(t, []string{
+		"TestA: throughput dropped from 1000 to 850 lines/s",
+		"TestA: error rate rose from 0.00% to 5.00%",
+		"TestA: p99 latency rose from 100ms to 130ms",
+	}, CompareResults(previous, current, DefaultThresholds()))
+}
+
+func TestResultsFile(t *testing.T) {
+	path := t.TempDir() + "/results.json"
+	results := Results{"TestA": {Lines: 10, Batches: 2, LinesPerSecond: 5, P99Latency: time.Second}}
+	require.NoError(t, WriteResults(path, results))
+	read, err := ReadResults(path)
+	require.NoError(t, err)
+	assert.Equal(t, results, read)
+}
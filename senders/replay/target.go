@@ -0,0 +1,133 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Target receives the batches of a replay.
+type Target interface {
+	// Send sends a batch of lines, in the Wavefront data format.
+	Send(lines []string) error
+}
+
+// TargetFunc adapts a function to a Target.
+type TargetFunc func(lines []string) error
+
+// Send calls f(lines).
+func (f TargetFunc) Send(lines []string) error {
+	return f(lines)
+}
+
+type httpTarget struct {
+	url         string
+	contentType string
+	header      http.Header
+	client      *http.Client
+	routes      map[string]string
+}
+
+// HTTPOption configures the target created by NewHTTPTarget.
+type HTTPOption func(*httpTarget)
+
+// ContentType sets the Content-Type batches are posted with. Defaults to application/octet-stream.
+func ContentType(contentType string) HTTPOption {
+	return func(t *httpTarget) {
+		t.contentType = contentType
+	}
+}
+
+// Header adds a header to the requests, e.g. the dx_tenant_id of an OTel collector.
+func Header(key, value string) HTTPOption {
+	return func(t *httpTarget) {
+		t.header.Add(key, value)
+	}
+}
+
+// HTTPClient sets the client requests are sent with. Defaults to a client with a 30 seconds timeout.
+func HTTPClient(client *http.Client) HTTPOption {
+	return func(t *httpTarget) {
+		t.client = client
+	}
+}
+
+// Route sends the lines of format, one of the Format* constants, to url instead, e.g. to the
+// histogram or tracing listener port of a Wavefront proxy.
+func Route(format, url string) HTTPOption {
+	return func(t *httpTarget) {
+		t.routes[format] = url
+	}
+}
+
+// NewHTTPTarget creates a Target posting each batch, one line per row, to url,
+// e.g. the /report endpoint of a Wavefront proxy or of an OTel collector.
+// Responses other than 200 OK and 202 Accepted fail the batch. The run ID of the Replayer
+// the target is given to is sent in the RunIDHeader. The target is a FormatTarget: histogram, span
+// and span log lines are posted with their format in the f query param, or to their Route.
+func NewHTTPTarget(url string, setters ...HTTPOption) Target {
+	t := &httpTarget{
+		url:         url,
+		contentType: "application/octet-stream",
+		header:      http.Header{},
+		client:      &http.Client{Timeout: defaultTimeout},
+		routes:      map[string]string{},
+	}
+	for _, set := range setters {
+		set(t)
+	}
+	return t
+}
+
+// setRunID sends id in the RunIDHeader of the requests.
+func (t *httpTarget) setRunID(id string) {
+	t.header.Set(RunIDHeader, id)
+}
+
+func (t *httpTarget) Send(lines []string) error {
+	return t.post(t.url, lines)
+}
+
+func (t *httpTarget) SendFormat(format string, lines []string) error {
+	if route, ok := t.routes[format]; ok {
+		return t.post(route, lines)
+	}
+	if format == FormatMetric {
+		return t.post(t.url, lines)
+	}
+	u, err := url.Parse(t.url)
+	if err != nil {
+		return fmt.Errorf("invalid url: %s", err)
+	}
+	q := u.Query()
+	q.Set("f", format)
+	u.RawQuery = q.Encode()
+	return t.post(u.String(), lines)
+}
+
+func (t *httpTarget) post(endpoint string, lines []string) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(serialize(lines)))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %s", err)
+	}
+	for key, values := range t.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", t.contentType)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, body)
+	}
+	return nil
+}
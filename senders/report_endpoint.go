@@ -0,0 +1,257 @@
+package senders
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/compression"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// ReportPath sets the path points are posted to, relative to the sender URL. Defaults to /report.
+// Use it when the collector mounts its report handler at a different route.
+func ReportPath(path string) Option {
+	return func(cfg *configuration) {
+		cfg.ReportPath = path
+	}
+}
+
+// ReportQueryParam adds a static query param to every report request, e.g. ReportQueryParam("f", "wavefront").
+// A param named "f" overrides the data format the sender would otherwise set.
+func ReportQueryParam(key, value string) Option {
+	return func(cfg *configuration) {
+		if cfg.ReportQueryParams == nil {
+			cfg.ReportQueryParams = url.Values{}
+		}
+		cfg.ReportQueryParams.Add(key, value)
+	}
+}
+
+// Compression turns gzip compression of report payloads on/off. Payloads are compressed
+// by default, except for OTel report senders. The bytes.uncompressed and bytes.compressed
+// internal metrics track the payload sizes before and after compression.
+func Compression(enabled bool) Option {
+	return func(cfg *configuration) {
+		cfg.Compression = &enabled
+	}
+}
+
+// TenantID sets the dx_tenant_id header of every request to tenantID, for multi-tenant
+// endpoints. It applies to direct ingestion, proxy and OTel report senders alike.
+func TenantID(tenantID string) Option {
+	return func(cfg *configuration) {
+		cfg.TenantID = tenantID
+	}
+}
+
+// AuthHeaders sends the authentication normally carried by the Authorization header under each
+// of names instead, e.g. AuthHeaders("X-API-Key"). Include "Authorization" in names to send both.
+func AuthHeaders(names ...string) Option {
+	return func(cfg *configuration) {
+		cfg.AuthHeaders = names
+	}
+}
+
+// TenantHeaders sends the tenant normally carried by the dx_tenant_id header under each
+// of names instead, e.g. TenantHeaders("X-Tenant"). Include "dx_tenant_id" in names to send both.
+func TenantHeaders(names ...string) Option {
+	return func(cfg *configuration) {
+		cfg.TenantHeaders = names
+	}
+}
+
+// ExtraHeaders sets headers on every request of the sender, e.g. the auth tokens or routing hints
+// required by some OTel collector deployments. They take precedence over the headers the sender
+// sets, but for the dx_tenant_id header of TenantID, and are renamed by AuthHeaders and TenantHeaders.
+// Headers of several ExtraHeaders calls are merged.
+func ExtraHeaders(headers map[string]string) Option {
+	// prevent caller from accidentally mutating this option.
+	copiedHeaders := copyTags(headers)
+	return func(cfg *configuration) {
+		if cfg.ExtraHeaders == nil {
+			cfg.ExtraHeaders = map[string]string{}
+		}
+		for key, value := range copiedHeaders {
+			cfg.ExtraHeaders[key] = value
+		}
+	}
+}
+
+// Compressors compresses report payloads with the first of codecs, e.g. compression.GzipLevel(gzip.BestSpeed)
+// or a zstd Compressor. Each endpoint, such as the metrics and traces ports of a proxy, falls back to the next
+// codec for good when it rejects an encoding with a 415 Unsupported Media Type status, so ending codecs with
+// compression.None() lets endpoints that do not support compression still be reported to.
+// Compressors takes precedence over Compression.
+func Compressors(codecs ...compression.Compressor) Option {
+	return func(cfg *configuration) {
+		cfg.Compressors = codecs
+	}
+}
+
+// BatchMetadataHeaders adds headers describing each batch to its report request, so that
+// collectors can account for ingestion lag without parsing bodies: X-WF-Line-Count holds the
+// number of lines, X-WF-TS-Min and X-WF-TS-Max the oldest and newest point timestamps,
+// in epoch seconds. Timestamps are only set for metric and distribution batches with timestamped points.
+func BatchMetadataHeaders() Option {
+	return func(cfg *configuration) {
+		cfg.BatchMetadataHeaders = true
+	}
+}
+
+// PayloadDigest adds a Digest header holding the base64 SHA-256 digest of each request body, as sent,
+// e.g. "Digest: SHA-256=<digest>", so that gateways can verify
+// the integrity of payloads. A request whose digest is rejected, i.e. answered with a 4xx status
+// and a Want-Digest header, fails with a *DigestError, found with errors.As in the errors of
+// FlushWithReport. Its lines are buffered and sent again on a later flush.
+func PayloadDigest() Option {
+	return func(cfg *configuration) {
+		cfg.PayloadDigest = true
+	}
+}
+
+// DigestError is the error of a request whose Digest header was rejected, see PayloadDigest.
+type DigestError = internal.DigestError
+
+// ReportBatch is the data available to the templates given to ReportQueryTemplate.
+type ReportBatch struct {
+	// Format of the batch: wavefront, histogram, trace or spanLogs.
+	Format string
+	// Lines is the number of lines in the batch.
+	Lines int
+	// Time at which the batch is sent.
+	Time time.Time
+}
+
+// ReportQueryTemplate adds a query param whose value is rendered for each batch from the
+// text/template tmpl, executed with a ReportBatch. For example:
+//
+//	ReportQueryTemplate("sent", "{{.Time.Unix}}")
+//
+// Templates are parsed by NewSender, which fails on invalid templates.
+func ReportQueryTemplate(key, tmpl string) Option {
+	return func(cfg *configuration) {
+		if cfg.ReportQueryTemplates == nil {
+			cfg.ReportQueryTemplates = map[string]string{}
+		}
+		cfg.ReportQueryTemplates[key] = tmpl
+	}
+}
+
+func (c *configuration) parseReportQueryTemplates() error {
+	for key, text := range c.ReportQueryTemplates {
+		tmpl, err := template.New(key).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid report query template for param '%s': %s", key, err)
+		}
+		if c.reportQueryTemplates == nil {
+			c.reportQueryTemplates = map[string]*template.Template{}
+		}
+		c.reportQueryTemplates[key] = tmpl
+	}
+	return nil
+}
+
+func (c *configuration) reporterOptions() []internal.ReporterOption {
+	var options []internal.ReporterOption
+	if c.ReportPath != "" {
+		options = append(options, internal.SetReportPath(c.ReportPath))
+	}
+	if len(c.ReportQueryParams) > 0 {
+		options = append(options, internal.SetReportQueryParams(c.ReportQueryParams))
+	}
+	if len(c.reportQueryTemplates) > 0 {
+		options = append(options, internal.SetReportQueryFunc(renderQueryTemplates(c.reportQueryTemplates)))
+	}
+	if c.MaxRetries > 0 {
+		options = append(options, internal.SetRetries(c.MaxRetries, c.RetryBackoff))
+		if c.RetryBudgetPerMinute > 0 || c.RetryBudgetRatio > 0 {
+			options = append(options, internal.SetRetryBudget(internal.NewRetryBudget(c.RetryBudgetPerMinute, c.RetryBudgetRatio)))
+		}
+	}
+	if c.RequestTimeoutBase > 0 {
+		options = append(options, internal.SetRequestTimeout(c.RequestTimeoutBase, c.RequestTimeoutPerMB))
+	}
+	if c.Compression != nil {
+		options = append(options, internal.SetCompression(*c.Compression))
+	}
+	if len(c.Compressors) > 0 {
+		options = append(options, internal.SetCompressors(c.Compressors...))
+	}
+	if c.BatchMetadataHeaders {
+		options = append(options, internal.SetBatchMetadataHeaders())
+	}
+	if c.PayloadDigest {
+		options = append(options, internal.SetPayloadDigest())
+	}
+	if c.TenantID != "" {
+		options = append(options, internal.SetTenantID(c.TenantID))
+	}
+	if len(c.AuthHeaders) > 0 {
+		options = append(options, internal.SetAuthHeaders(c.AuthHeaders...))
+	}
+	if len(c.TenantHeaders) > 0 {
+		options = append(options, internal.SetTenantHeaders(c.TenantHeaders...))
+	}
+	if len(c.ExtraHeaders) > 0 {
+		options = append(options, internal.SetExtraHeaders(c.ExtraHeaders))
+	}
+	return options
+}
+
+func renderQueryTemplates(templates map[string]*template.Template) func(string, string) url.Values {
+	return func(format string, pointLines string) url.Values {
+		batch := ReportBatch{
+			Format: format,
+			Lines:  strings.Count(pointLines, "\n"),
+			Time:   time.Now(),
+		}
+		params := make(url.Values, len(templates))
+		var sb strings.Builder
+		for key, tmpl := range templates {
+			sb.Reset()
+			if err := tmpl.Execute(&sb, batch); err != nil {
+				continue
+			}
+			params.Set(key, sb.String())
+		}
+		return params
+	}
+}
+
+// QuotaExceededError is the error of a request rejected, with a 403 or 429 status, because the
+// quota of the tenant is exceeded. Its ResetAfter hint, from the Retry-After or X-RateLimit-Reset
+// header, sets how long the sender pauses reporting that data type, a minute when not given;
+// lines are buffered meanwhile and the pauses are counted in the quota_exceeded internal metrics.
+type QuotaExceededError = internal.QuotaError
+
+// ErrQuotaExceeded matches a *QuotaExceededError, as well as the errors of the flushes skipped
+// while reporting is paused, with errors.Is.
+var ErrQuotaExceeded = internal.ErrQuotaExceeded
+
+// ErrCircuitOpen matches the errors of the flushes skipped, and of the lines dropped, while the
+// circuit breaker of a data type is open, with errors.Is. See CircuitBreaker.
+var ErrCircuitOpen = internal.ErrCircuitOpen
+
+// Errors matched, with errors.Is, by the errors of Flush and FlushWithReport when report requests
+// are rejected, so that callers can branch on the failure mode, e.g. to implement their own retries.
+var (
+	// ErrThrottled matches requests rejected with a 406 or 429 status.
+	ErrThrottled = internal.ErrThrottled
+	// ErrUnauthorized matches requests rejected with a 401 or 403 status.
+	ErrUnauthorized = internal.ErrUnauthorized
+	// ErrPayloadTooLarge matches requests rejected with a 413 status.
+	ErrPayloadTooLarge = internal.ErrPayloadTooLarge
+	// ErrInvalidLine matches requests rejected with a 400 status.
+	ErrInvalidLine = internal.ErrInvalidLine
+)
+
+// ReportError is the error of a report request rejected with a 4xx or 5xx status. Its Err is
+// the *InvalidLineError of a 400 response.
+type ReportError = internal.ReportError
+
+// InvalidLineError describes the line of a batch rejected with a 400 status: Line is the line
+// quoted by the response, when it could be told, and Reason the response body.
+type InvalidLineError = internal.InvalidLineError
@@ -0,0 +1,226 @@
+package senders
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/event"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// Route sends the metrics, delta counters and distributions whose name starts with Prefix to
+// Sender, see NewRouterSender. A trailing "*", as in "kubernetes.*", is ignored.
+type Route struct {
+	Prefix string
+	Sender Sender
+}
+
+// RouterSender Interface for splitting metrics and distributions across senders by name
+type RouterSender interface {
+	Sender
+}
+
+type routerSender struct {
+	fallback Sender
+	// routes are sorted by decreasing prefix length, so that the first match is the longest.
+	routes []Route
+	// senders holds fallback and the sender of each route, once each.
+	senders []Sender
+}
+
+// NewRouterSender creates a Sender routing metrics, delta counters and distributions to the sender
+// of the route with the longest prefix of their name, and to fallback when no route matches, so
+// that a single sender splits traffic, e.g. kubernetes.* metrics to a proxy and the others
+// through direct ingestion:
+//
+//	router, err := senders.NewRouterSender(direct, senders.Route{Prefix: "kubernetes.*", Sender: proxy})
+//
+// Prefixes are matched against names without their delta counter prefix. Spans and events are
+// sent to fallback. Flushing, starting or closing the RouterSender flushes, starts or closes each
+// of the senders.
+func NewRouterSender(fallback Sender, routes ...Route) (RouterSender, error) {
+	if fallback == nil {
+		return nil, fmt.Errorf("router sender needs a fallback sender")
+	}
+	rs := &routerSender{fallback: fallback, senders: []Sender{fallback}}
+	for _, route := range routes {
+		if route.Sender == nil {
+			return nil, fmt.Errorf("no sender for the route of prefix %q", route.Prefix)
+		}
+		route.Prefix = strings.TrimSuffix(route.Prefix, "*")
+		rs.routes = append(rs.routes, route)
+		if !rs.has(route.Sender) {
+			rs.senders = append(rs.senders, route.Sender)
+		}
+	}
+	sort.SliceStable(rs.routes, func(i, j int) bool {
+		return len(rs.routes[i].Prefix) > len(rs.routes[j].Prefix)
+	})
+	return rs, nil
+}
+
+func (rs *routerSender) has(sender Sender) bool {
+	for _, s := range rs.senders {
+		if s == sender {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the sender of name.
+func (rs *routerSender) route(name string) Sender {
+	name = trimDeltaPrefix(name)
+	for _, route := range rs.routes {
+		if strings.HasPrefix(name, route.Prefix) {
+			return route.Sender
+		}
+	}
+	return rs.fallback
+}
+
+func (rs *routerSender) private() {
+}
+
+func (rs *routerSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendMetric(name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendIntMetric(name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendBoolMetric(name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendAny(name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	return rs.route(name).SendDeltaCounter(name, value, source, tags)
+}
+
+func (rs *routerSender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendDeltaCounterWithTimestamp(name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendDistribution(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendDistribution(name, centroids, hgs, ts, source, tags)
+}
+
+func (rs *routerSender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	return rs.route(name).RecordDistribution(name, value, source, tags)
+}
+
+func (rs *routerSender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	return rs.route(name).SendDuration(name, d, source, tags)
+}
+
+func (rs *routerSender) SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return rs.fallback.SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (rs *routerSender) SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return rs.fallback.SendEvent(name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (rs *routerSender) SendMetrics(points []MetricPoint) error {
+	batches := map[Sender][]MetricPoint{}
+	for _, p := range points {
+		sender := rs.route(p.Name)
+		batches[sender] = append(batches[sender], p)
+	}
+	var errors multiError
+	for sender, batch := range batches {
+		if err := sender.SendMetrics(batch); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (rs *routerSender) SendDistributions(distributions []Distribution) error {
+	batches := map[Sender][]Distribution{}
+	for _, d := range distributions {
+		sender := rs.route(d.Name)
+		batches[sender] = append(batches[sender], d)
+	}
+	var errors multiError
+	for sender, batch := range batches {
+		if err := sender.SendDistributions(batch); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (rs *routerSender) SendSpans(spans []Span) error {
+	return rs.fallback.SendSpans(spans)
+}
+
+func (rs *routerSender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendMetricCtx(ctx, name, value, ts, source, tags)
+}
+
+func (rs *routerSender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	return rs.route(name).SendDeltaCounterCtx(ctx, name, value, source, tags)
+}
+
+func (rs *routerSender) SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return rs.route(name).SendDistributionCtx(ctx, name, centroids, hgs, ts, source, tags)
+}
+
+func (rs *routerSender) SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return rs.fallback.SendSpanCtx(ctx, name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (rs *routerSender) SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return rs.fallback.SendEventCtx(ctx, name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (rs *routerSender) Flush() error {
+	var errors multiError
+	for i, sender := range rs.senders {
+		if err := sender.Flush(); err != nil {
+			errors.add(&SenderError{Sender: i, Err: err})
+		}
+	}
+	return errors.get()
+}
+
+func (rs *routerSender) FlushWithReport() FlushReport {
+	var report FlushReport
+	for _, sender := range rs.senders {
+		report.add(sender.FlushWithReport())
+	}
+	return report
+}
+
+func (rs *routerSender) Health() Health {
+	return leastHealthy(rs.senders)
+}
+
+func (rs *routerSender) GetFailureCount() int64 {
+	var fc int64
+	for _, sender := range rs.senders {
+		fc += sender.GetFailureCount()
+	}
+	return fc
+}
+
+func (rs *routerSender) Start() {
+	for _, sender := range rs.senders {
+		sender.Start()
+	}
+}
+
+func (rs *routerSender) Close() {
+	for _, sender := range rs.senders {
+		sender.Close()
+	}
+}
@@ -0,0 +1,53 @@
+package senders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterSender(t *testing.T) {
+	senders := make([]ValidatingSender, 3)
+	for i := range senders {
+		sender, err := NewValidatingSender()
+		require.NoError(t, err)
+		senders[i] = sender
+	}
+	direct, proxy, nodes := senders[0], senders[1], senders[2]
+	router, err := NewRouterSender(direct,
+		Route{Prefix: "kubernetes.*", Sender: proxy},
+		Route{Prefix: "kubernetes.node.", Sender: nodes},
+		Route{Prefix: "app.", Sender: direct},
+	)
+	require.NoError(t, err)
+	defer router.Close()
+
+	require.NoError(t, router.SendMetric("kubernetes.pod.cpu", 1, 0, "test", nil))
+	require.NoError(t, router.SendMetric("kubernetes.node.cpu", 2, 0, "test", nil))
+	require.NoError(t, router.SendDeltaCounter("kubernetes.restarts", 3, "test", nil))
+	require.NoError(t, router.SendMetrics([]MetricPoint{
+		{Name: "app.requests", Value: 4, Source: "test"},
+		{Name: "kubernetes.node.memory", Value: 5, Source: "test"},
+	}))
+	require.NoError(t, router.SendMetric("other", 6, 0, "test", nil))
+	require.NoError(t, router.Flush())
+
+	assert.Equal(t, []string{
+		"\"app.requests\" 4 source=\"test\"\n",
+		"\"other\" 6 source=\"test\"\n",
+	}, direct.Lines())
+	assert.Equal(t, []string{
+		"\"kubernetes.pod.cpu\" 1 source=\"test\"\n",
+		"\"∆kubernetes.restarts\" 3 source=\"test\"\n",
+	}, proxy.Lines())
+	assert.Equal(t, []string{
+		"\"kubernetes.node.cpu\" 2 source=\"test\"\n",
+		"\"kubernetes.node.memory\" 5 source=\"test\"\n",
+	}, nodes.Lines())
+
+	_, err = NewRouterSender(nil)
+	assert.Error(t, err)
+	_, err = NewRouterSender(direct, Route{Prefix: "app."})
+	assert.Error(t, err)
+}
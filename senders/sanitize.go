@@ -0,0 +1,25 @@
+package senders
+
+import (
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// SanitizeNames sets the function sanitizing the names and tag keys of metrics, delta counters
+// and distributions, instead of the rules of the Wavefront proxy, which replace the characters
+// other than letters, digits and "-_.,/" with "-". Names are given with their delta counter or
+// "~" prefix, if any. E.g. SanitizeNames(SanitizeAllowing(":")) keeps colons, for proxies
+// configured to accept them. Results are cached for the most common names and keys, so sanitize
+// must return the same result for the same input.
+// It does not apply to spans: span names are quoted as they are, like span tag values, and span
+// tag keys are sanitized with the default rules.
+func SanitizeNames(sanitize func(string) string) Option {
+	return func(cfg *configuration) {
+		cfg.NameSanitizer = sanitize
+	}
+}
+
+// SanitizeAllowing returns a function, for SanitizeNames, sanitizing with the rules of the
+// Wavefront proxy except for the characters of allowed, which are left as they are.
+func SanitizeAllowing(allowed string) func(string) string {
+	return internal.SanitizeAllowing(allowed)
+}
@@ -0,0 +1,98 @@
+package senders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Kinds of MetricSchema.
+const (
+	SchemaKindMetric       = "metric"
+	SchemaKindDeltaCounter = "delta_counter"
+	SchemaKindDistribution = "distribution"
+)
+
+// MetricSchema describes a metric series: its name, kind and the sorted keys of its point tags.
+type MetricSchema struct {
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	TagKeys []string `json:"tagKeys"`
+}
+
+// A SchemaRegistry is told about every new metric schema before the first point of the schema is sent.
+type SchemaRegistry interface {
+	// Register registers schema. An error rejects the point being sent; registration is attempted
+	// again on the next point of the schema. Register may be called more than once for a schema
+	// under concurrent sends, so it must be idempotent.
+	Register(schema MetricSchema) error
+}
+
+// SchemaRegistryFunc adapts a function to a SchemaRegistry.
+type SchemaRegistryFunc func(schema MetricSchema) error
+
+// Register calls f(schema).
+func (f SchemaRegistryFunc) Register(schema MetricSchema) error {
+	return f(schema)
+}
+
+// SchemaRegistryHook registers the schema of every new metric, delta counter and distribution series,
+// i.e. name and set of point tag keys, with registry before its first point is sent, for governance
+// workflows requiring metrics to be registered. Points whose registration fails are not sent.
+func SchemaRegistryHook(registry SchemaRegistry) Option {
+	return func(cfg *configuration) {
+		cfg.SchemaRegistry = registry
+	}
+}
+
+// NewHTTPSchemaRegistry returns a SchemaRegistry posting each new schema, as JSON, to url.
+// A 2xx status registers the schema, any other status rejects it.
+func NewHTTPSchemaRegistry(url string, client *http.Client) SchemaRegistry {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSchemaRegistry{url: url, client: client}
+}
+
+type httpSchemaRegistry struct {
+	url    string
+	client *http.Client
+}
+
+func (r *httpSchemaRegistry) Register(schema MetricSchema) error {
+	body, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("schema registry returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// registerSchema registers the schema of a series with the sender's schema registry,
+// the first time the series is sent.
+func (sender *realSender) registerSchema(name string, kind string, tags map[string]string) error {
+	if sender.schemaRegistry == nil || name == "" {
+		return nil
+	}
+	schema := MetricSchema{Name: name, Kind: kind, TagKeys: sortedKeys(tags)}
+	key := kind + "\n" + name + "\n" + strings.Join(schema.TagKeys, "\n")
+	if _, ok := sender.registeredSchemas.Load(key); ok {
+		return nil
+	}
+	if err := sender.schemaRegistry.Register(schema); err != nil {
+		return fmt.Errorf("unable to register schema of %s: %s", name, err)
+	}
+	sender.registeredSchemas.Store(key, struct{}{})
+	return nil
+}
@@ -0,0 +1,65 @@
+package senders
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestSchemaRegistryHook(t *testing.T) {
+	var schemas []MetricSchema
+	var rejectErr error
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.schemaRegistry = SchemaRegistryFunc(func(schema MetricSchema) error {
+		schemas = append(schemas, schema)
+		return rejectErr
+	})
+
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "", map[string]string{"env": "dev", "dc": "1"}))
+	require.NoError(t, sender.SendMetric("foo", 2, 0, "other", map[string]string{"env": "prod", "dc": "2"}))
+	require.NoError(t, sender.SendMetric("foo", 3, 0, "", map[string]string{"env": "dev"}))
+	require.NoError(t, sender.SendDeltaCounter("foo", 3, "", nil))
+	require.NoError(t, sender.SendDistribution("foo", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "", nil))
+	assert.Equal(t, []MetricSchema{
+		{Name: "foo", Kind: SchemaKindMetric, TagKeys: []string{"dc", "env"}},
+		{Name: "foo", Kind: SchemaKindMetric, TagKeys: []string{"env"}},
+		{Name: "∆foo", Kind: SchemaKindDeltaCounter, TagKeys: []string{}},
+		{Name: "foo", Kind: SchemaKindDistribution, TagKeys: []string{}},
+	}, schemas)
+	assert.Len(t, pointHandler.Lines, 4)
+
+	// rejected schemas are not sent, and registered again on the next point.
+	rejectErr = errors.New("unregistered")
+	schemas = nil
+	assert.Error(t, sender.SendMetric("bar", 1, 0, "", nil))
+	assert.Error(t, sender.SendMetric("bar", 1, 0, "", nil))
+	assert.Len(t, schemas, 2)
+	assert.Len(t, pointHandler.Lines, 4)
+}
+
+func TestHTTPSchemaRegistry(t *testing.T) {
+	var schemas []MetricSchema
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var schema MetricSchema
+		if err := json.NewDecoder(r.Body).Decode(&schema); err != nil || schema.Name == "forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		schemas = append(schemas, schema)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	registry := NewHTTPSchemaRegistry(server.URL, server.Client())
+	require.NoError(t, registry.Register(MetricSchema{Name: "foo", Kind: SchemaKindMetric, TagKeys: []string{"env"}}))
+	assert.Equal(t, []MetricSchema{{Name: "foo", Kind: SchemaKindMetric, TagKeys: []string{"env"}}}, schemas)
+	assert.Error(t, registry.Register(MetricSchema{Name: "forbidden", Kind: SchemaKindMetric}))
+}
@@ -0,0 +1,291 @@
+package sendertest
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders/formats"
+)
+
+// Payload is a report request received by a MockCollector.
+type Payload struct {
+	// Path is the path of the request, /report or /api/v2/event.
+	Path string
+	// Format is the f query parameter of the request, e.g. "wavefront", "histogram" or "trace",
+	// empty for events and requests without one.
+	Format string
+	Header http.Header
+	Lines  []string
+	// Status is the status the collector answered with.
+	Status int
+}
+
+// MockCollector is an HTTP server receiving reports as a Wavefront proxy, direct ingestion or an
+// OpenTelemetry collector report endpoint does, for integration tests not depending on a real
+// collector:
+//
+//	collector := sendertest.NewMockCollector()
+//	defer collector.Close()
+//	sender, _ := senders.NewSender(collector.URL)
+//	...
+//	sender.Flush()
+//	assert.Contains(t, collector.Lines("wavefront"), `"requests" 1 source="web"`)
+//
+// Lines are checked against the Wavefront data format of their f query parameter, and requests
+// holding invalid lines are answered with a 400 status. Other statuses, e.g. 406, 413, 429 or
+// 503, can be simulated with RespondWith, and slow or unreliable collectors with the
+// CollectorOption of NewMockCollector.
+type MockCollector struct {
+	// URL is the base URL of the collector, e.g. http://127.0.0.1:41555.
+	URL string
+
+	server   *httptest.Server
+	latency  time.Duration
+	dropRate float64
+
+	mtx       sync.Mutex
+	rand      *rand.Rand
+	payloads  []Payload
+	invalid   []string
+	responses []int
+}
+
+// DropConnection is the status of the requests the collector closed the connection of without
+// answering, see RespondWith and DropRate.
+const DropConnection = -1
+
+// CollectorOption sets an optional setting of NewMockCollector.
+type CollectorOption func(*MockCollector)
+
+// Latency delays the answer to each request by d, or until the client gives up on it.
+func Latency(d time.Duration) CollectorOption {
+	return func(c *MockCollector) {
+		c.latency = d
+	}
+}
+
+// DropRate closes the connection of the given ratio of the requests, e.g. 0.1 for 10%, without
+// answering them. Requests are picked pseudo-randomly, the same ones on each run for a given
+// FaultSeed.
+func DropRate(rate float64) CollectorOption {
+	return func(c *MockCollector) {
+		c.dropRate = rate
+	}
+}
+
+// FaultSeed seeds the picking of the requests dropped with DropRate, 1 by default.
+func FaultSeed(seed int64) CollectorOption {
+	return func(c *MockCollector) {
+		c.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// FailFirst answers the first n requests with status, which may be DropConnection, before
+// answering requests as usual, e.g. to check that a sender retries or opens its circuit breaker.
+// More failures can be scripted with RespondWith.
+func FailFirst(n int, status int) CollectorOption {
+	return func(c *MockCollector) {
+		for i := 0; i < n; i++ {
+			c.responses = append(c.responses, status)
+		}
+	}
+}
+
+// NewMockCollector starts a MockCollector, answering report requests to /report and event
+// requests to /api/v2/event. It must be closed when done.
+func NewMockCollector(setters ...CollectorOption) *MockCollector {
+	c := &MockCollector{rand: rand.New(rand.NewSource(1))}
+	for _, setter := range setters {
+		setter(c)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/report", c.handle)
+	mux.HandleFunc("/api/v2/event", c.handle)
+	c.server = httptest.NewServer(mux)
+	c.URL = c.server.URL
+	return c
+}
+
+// Close shuts the collector down.
+func (c *MockCollector) Close() {
+	c.server.Close()
+}
+
+// RespondWith makes the collector answer its next requests with the given statuses, one request
+// each, in order, without recording their lines unless the status is a 2xx one. DropConnection
+// closes the connection of the request instead. Once they are used, requests are answered as
+// usual again.
+func (c *MockCollector) RespondWith(statuses ...int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.responses = append(c.responses, statuses...)
+}
+
+// Payloads returns the requests received, in order, including the failed ones.
+func (c *MockCollector) Payloads() []Payload {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]Payload(nil), c.payloads...)
+}
+
+// Lines returns the lines of format accepted by the collector, in the order they were received,
+// or of every format when format is empty.
+func (c *MockCollector) Lines(format string) []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	var lines []string
+	for _, p := range c.payloads {
+		if p.Status/100 == 2 && (format == "" || p.Format == format) {
+			lines = append(lines, p.Lines...)
+		}
+	}
+	return lines
+}
+
+// InvalidLines returns the lines rejected for not being in the Wavefront data format, with the
+// reason they were rejected.
+func (c *MockCollector) InvalidLines() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]string(nil), c.invalid...)
+}
+
+// Reset discards the requests received so far, and the statuses set with RespondWith.
+func (c *MockCollector) Reset() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.payloads, c.invalid, c.responses = nil, nil, nil
+}
+
+func (c *MockCollector) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		// e.g. the OPTIONS requests of handshakes: every format is accepted.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	payload := Payload{Path: r.URL.Path, Format: r.URL.Query().Get("f"), Header: r.Header.Clone()}
+	lines, err := readLines(r)
+	if err != nil {
+		payload.Status = http.StatusUnsupportedMediaType
+		c.record(payload, nil)
+		http.Error(w, err.Error(), payload.Status)
+		return
+	}
+	payload.Lines = lines
+
+	c.mtx.Lock()
+	switch {
+	case len(c.responses) > 0:
+		payload.Status = c.responses[0]
+		c.responses = c.responses[1:]
+	case c.dropRate > 0 && c.rand.Float64() < c.dropRate:
+		payload.Status = DropConnection
+	}
+	c.mtx.Unlock()
+
+	if c.latency > 0 {
+		select {
+		case <-time.After(c.latency):
+		case <-r.Context().Done():
+		}
+	}
+	if payload.Status == DropConnection {
+		c.record(payload, nil)
+		// Aborting the handler closes the connection without a response.
+		panic(http.ErrAbortHandler)
+	}
+
+	var invalid []string
+	if payload.Status == 0 {
+		payload.Status = http.StatusOK
+		for _, line := range lines {
+			if err := validateLine(payload.Path, payload.Format, line); err != nil {
+				invalid = append(invalid, err.Error())
+			}
+		}
+		if len(invalid) > 0 {
+			payload.Status = http.StatusBadRequest
+		}
+	}
+	c.record(payload, invalid)
+	w.WriteHeader(payload.Status)
+	if len(invalid) > 0 {
+		_, _ = io.WriteString(w, strings.Join(invalid, "\n"))
+	}
+}
+
+func (c *MockCollector) record(payload Payload, invalid []string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.payloads = append(c.payloads, payload)
+	c.invalid = append(c.invalid, invalid...)
+}
+
+// readLines returns the non-empty lines of the body of r, gunzipped if need be.
+func readLines(r *http.Request) ([]string, error) {
+	defer r.Body.Close()
+	var body io.Reader = r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// validateLine checks line against the data format of format. Lines without a format, as those
+// of OpenTelemetry collector report endpoints, may be metric, histogram or span lines.
+func validateLine(path, format, line string) error {
+	var err error
+	switch {
+	case path == "/api/v2/event":
+		if strings.HasPrefix(line, "{") && !json.Valid([]byte(line)) {
+			err = fmt.Errorf("invalid JSON")
+		}
+	case format == "histogram" || format == "" && strings.HasPrefix(line, "!"):
+		_, err = formats.ParseHistogramLine(line)
+	case format == "trace":
+		_, err = formats.ParseSpanLine(line)
+	case format == "spanLogs" || format == "event":
+		if !json.Valid([]byte(line)) {
+			err = fmt.Errorf("invalid JSON")
+		}
+	case format == "":
+		if _, err = formats.ParseMetricLine(line); err != nil {
+			if _, spanErr := formats.ParseSpanLine(line); spanErr == nil {
+				err = nil
+			}
+		}
+	default:
+		_, err = formats.ParseMetricLine(line)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", line, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package sendertest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+func newCollectorSender(t *testing.T, collector *MockCollector) senders.Sender {
+	sender, err := senders.NewSender(collector.URL, senders.SendInternalMetrics(false))
+	require.NoError(t, err)
+	t.Cleanup(sender.Close)
+	return sender
+}
+
+func TestMockCollector(t *testing.T) {
+	collector := NewMockCollector()
+	defer collector.Close()
+	sender := newCollectorSender(t, collector)
+
+	require.NoError(t, sender.SendMetric("http.requests", 1, 1700000000, "web", map[string]string{"status": "200"}))
+	require.NoError(t, sender.SendDistribution("http.latency", []histogram.Centroid{{Value: 12, Count: 3}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 1700000000, "web", nil))
+	require.NoError(t, sender.SendSpan("GET /", 1700000000000, 5, "web",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+	require.NoError(t, sender.Flush())
+
+	assert.Equal(t, []string{`"http.requests" 1 1700000000 source="web" "status"="200"`}, collector.Lines("wavefront"))
+	assert.Equal(t, []string{`!M 1700000000 #3 12 "http.latency" source="web"`}, collector.Lines("histogram"))
+	require.Len(t, collector.Lines("trace"), 1)
+	assert.True(t, strings.HasPrefix(collector.Lines("trace")[0], `"GET /" source="web"`))
+	assert.Len(t, collector.Lines(""), 3)
+	assert.Empty(t, collector.InvalidLines())
+	for _, p := range collector.Payloads() {
+		assert.Equal(t, "/report", p.Path)
+		assert.Equal(t, http.StatusOK, p.Status)
+	}
+
+	collector.Reset()
+	assert.Empty(t, collector.Payloads())
+}
+
+func TestMockCollectorRespondWith(t *testing.T) {
+	collector := NewMockCollector()
+	defer collector.Close()
+	sender := newCollectorSender(t, collector)
+
+	for _, status := range []int{http.StatusNotAcceptable, http.StatusRequestEntityTooLarge,
+		http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		collector.Reset()
+		collector.RespondWith(status)
+		require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", nil))
+		assert.Error(t, sender.Flush(), "status %d", status)
+		assert.Empty(t, collector.Lines("wavefront"))
+		require.NotEmpty(t, collector.Payloads())
+		assert.Equal(t, status, collector.Payloads()[0].Status)
+	}
+
+	// The lines of the failed requests are reported again once the collector accepts them.
+	require.NoError(t, sender.Flush())
+	assert.NotEmpty(t, collector.Lines("wavefront"))
+}
+
+func TestMockCollectorValidation(t *testing.T) {
+	collector := NewMockCollector()
+	defer collector.Close()
+
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	_, _ = gz.Write([]byte("\"ok\" 1 source=\"web\"\nnot a metric line\n"))
+	require.NoError(t, gz.Close())
+	req, err := http.NewRequest(http.MethodPost, collector.URL+"/report?f=wavefront", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, collector.Lines("wavefront"))
+	require.Len(t, collector.InvalidLines(), 1)
+	assert.True(t, strings.HasPrefix(collector.InvalidLines()[0], "not a metric line: "))
+
+	resp, err = http.Post(collector.URL+"/report?f=histogram", "text/plain", strings.NewReader("!M #1 2 \"h\" source=\"web\"\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`!M #1 2 "h" source="web"`}, collector.Lines("histogram"))
+}
+
+func TestMockCollectorFailFirst(t *testing.T) {
+	collector := NewMockCollector(FailFirst(2, http.StatusServiceUnavailable))
+	defer collector.Close()
+	sender, err := senders.NewSender(collector.URL, senders.SendInternalMetrics(false),
+		senders.MaxRetries(2), senders.RetryBackoff(time.Millisecond))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", nil))
+	require.NoError(t, sender.Flush())
+
+	var statuses []int
+	for _, p := range collector.Payloads() {
+		statuses = append(statuses, p.Status)
+	}
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, statuses)
+	assert.Len(t, collector.Lines("wavefront"), 1)
+}
+
+func TestMockCollectorLatency(t *testing.T) {
+	collector := NewMockCollector(Latency(time.Second))
+	defer collector.Close()
+	sender, err := senders.NewSender(collector.URL, senders.SendInternalMetrics(false),
+		senders.Timeout(20*time.Millisecond))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", nil))
+	assert.Error(t, sender.Flush())
+}
+
+func TestMockCollectorDropRate(t *testing.T) {
+	collector := NewMockCollector(DropRate(1))
+	defer collector.Close()
+	sender := newCollectorSender(t, collector)
+
+	require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", nil))
+	assert.Error(t, sender.Flush())
+	require.NotEmpty(t, collector.Payloads())
+	assert.Equal(t, DropConnection, collector.Payloads()[0].Status)
+	assert.Empty(t, collector.Lines(""))
+
+	// The same requests are dropped for a given seed.
+	drops := func() []int {
+		collector := NewMockCollector(DropRate(0.5), FaultSeed(42))
+		defer collector.Close()
+		for i := 0; i < 20; i++ {
+			resp, err := http.Post(collector.URL+"/report?f=wavefront", "text/plain", strings.NewReader("\"m\" 1 source=\"s\"\n"))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+		var statuses []int
+		for _, p := range collector.Payloads() {
+			statuses = append(statuses, p.Status)
+		}
+		return statuses
+	}
+	first := drops()
+	assert.Contains(t, first, DropConnection)
+	assert.Contains(t, first, http.StatusOK)
+	assert.Equal(t, first, drops())
+}
@@ -0,0 +1,457 @@
+// Package sendertest provides a Sender recording what it is given in memory, to unit test
+// instrumentation without a Wavefront proxy or HTTP server:
+//
+//	func TestHandler(t *testing.T) {
+//		sender := sendertest.NewSender()
+//		handler := NewHandler(sender)
+//		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+//
+//		assert.Len(t, sender.MetricsNamed("http.requests"), 1)
+//		sender.AssertTagged(t, "http.requests", map[string]string{"status": "200"})
+//	}
+package sendertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/event"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// Metric is a metric or delta counter recorded by a Sender.
+type Metric struct {
+	Name      string
+	Value     float64
+	Timestamp int64
+	Source    string
+	Tags      map[string]string
+	// Delta is true for delta counters, whose Name starts with the delta counter prefix.
+	Delta bool
+}
+
+// Distribution is a distribution recorded by a Sender. The values given to RecordDistribution
+// and SendDuration are recorded as distributions of a single centroid, of granularity MINUTE.
+type Distribution = senders.Distribution
+
+// Span is a span recorded by a Sender.
+type Span = senders.Span
+
+// Event is an event recorded by a Sender, with the annotations of its options.
+type Event struct {
+	Name        string
+	StartMillis int64
+	EndMillis   int64
+	Source      string
+	Tags        map[string]string
+	Annotations map[string]string
+}
+
+// TestingT is the subset of testing.TB used by the assertions of Sender.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Sender is a senders.Sender recording in memory the data it is given. It is safe for
+// concurrent use. Sends with an empty name return an error, as with other senders, and sends to
+// a closed Sender are dropped.
+type Sender struct {
+	// Sender provides the unexported methods of senders.Sender.
+	senders.Sender
+
+	mtx           sync.Mutex
+	metrics       []Metric
+	distributions []Distribution
+	spans         []Span
+	events        []Event
+	flushes       int
+	closed        bool
+}
+
+// NewSender creates a Sender recording nothing yet.
+func NewSender() *Sender {
+	noop, _ := senders.NewWavefrontNoOpClient()
+	return &Sender{Sender: noop}
+}
+
+// Metrics returns the metrics and delta counters recorded, in the order they were sent.
+func (s *Sender) Metrics() []Metric {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Metric(nil), s.metrics...)
+}
+
+// MetricsNamed returns the metrics named name, in the order they were sent. Delta counters match
+// with or without their delta counter prefix.
+func (s *Sender) MetricsNamed(name string) []Metric {
+	var metrics []Metric
+	for _, m := range s.Metrics() {
+		if m.Name == name || m.Delta && m.Name == internal.DeltaCounterName(name) {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// Distributions returns the distributions recorded, in the order they were sent.
+func (s *Sender) Distributions() []Distribution {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Distribution(nil), s.distributions...)
+}
+
+// DistributionsNamed returns the distributions named name, in the order they were sent.
+func (s *Sender) DistributionsNamed(name string) []Distribution {
+	var distributions []Distribution
+	for _, d := range s.Distributions() {
+		if d.Name == name {
+			distributions = append(distributions, d)
+		}
+	}
+	return distributions
+}
+
+// Spans returns the spans recorded, in the order they were sent.
+func (s *Sender) Spans() []Span {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Span(nil), s.spans...)
+}
+
+// LastSpan returns the last span recorded, and false if there is none.
+func (s *Sender) LastSpan() (Span, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.spans) == 0 {
+		return Span{}, false
+	}
+	return s.spans[len(s.spans)-1], true
+}
+
+// Events returns the events recorded, in the order they were sent.
+func (s *Sender) Events() []Event {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// Flushes returns the number of calls to Flush and FlushWithReport.
+func (s *Sender) Flushes() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.flushes
+}
+
+// Closed reports whether Close was called.
+func (s *Sender) Closed() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.closed
+}
+
+// Reset discards everything recorded so far.
+func (s *Sender) Reset() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.metrics, s.distributions, s.spans, s.events = nil, nil, nil, nil
+	s.flushes = 0
+}
+
+// AssertTagged checks that a metric, delta counter, distribution or span named name was recorded
+// with all the given tags, and reports an error to t otherwise. It returns whether it did.
+func (s *Sender) AssertTagged(t TestingT, name string, tags map[string]string) bool {
+	t.Helper()
+	var seen []map[string]string
+	for _, m := range s.MetricsNamed(name) {
+		seen = append(seen, m.Tags)
+	}
+	for _, d := range s.DistributionsNamed(name) {
+		seen = append(seen, d.Tags)
+	}
+	for _, span := range s.Spans() {
+		if span.Name == name {
+			spanTags := map[string]string{}
+			for _, tag := range span.Tags {
+				spanTags[tag.Key] = tag.Value
+			}
+			seen = append(seen, spanTags)
+		}
+	}
+	if len(seen) == 0 {
+		t.Errorf("nothing named %q was sent", name)
+		return false
+	}
+	for _, got := range seen {
+		if hasTags(got, tags) {
+			return true
+		}
+	}
+	t.Errorf("%q was sent %d times, never with tags %v: got %v", name, len(seen), tags, seen)
+	return false
+}
+
+func hasTags(got, want map[string]string) bool {
+	for k, v := range want {
+		if gotV, ok := got[k]; !ok || gotV != v {
+			return false
+		}
+	}
+	return true
+}
+
+var errEmptyName = errors.New("empty name")
+
+// record runs add with the mutex held, unless name is empty or the Sender is closed.
+func (s *Sender) record(name string, add func()) error {
+	if name == "" {
+		return errEmptyName
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.closed {
+		add()
+	}
+	return nil
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
+
+func (s *Sender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return s.record(name, func() {
+		s.metrics = append(s.metrics, Metric{Name: name, Value: value, Timestamp: ts, Source: source, Tags: copyTags(tags)})
+	})
+}
+
+func (s *Sender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return s.SendMetric(name, float64(value), ts, source, tags)
+}
+
+func (s *Sender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	if value {
+		return s.SendMetric(name, 1, ts, source, tags)
+	}
+	return s.SendMetric(name, 0, ts, source, tags)
+}
+
+func (s *Sender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	var v float64
+	switch value := value.(type) {
+	case float64:
+		v = value
+	case float32:
+		v = float64(value)
+	case int:
+		v = float64(value)
+	case int8:
+		v = float64(value)
+	case int16:
+		v = float64(value)
+	case int32:
+		v = float64(value)
+	case int64:
+		v = float64(value)
+	case uint:
+		v = float64(value)
+	case uint8:
+		v = float64(value)
+	case uint16:
+		v = float64(value)
+	case uint32:
+		v = float64(value)
+	case uint64:
+		v = float64(value)
+	case time.Duration:
+		v = float64(value.Milliseconds())
+	case bool:
+		return s.SendBoolMetric(name, value, ts, source, tags)
+	default:
+		return errors.New("unsupported metric value type")
+	}
+	return s.SendMetric(name, v, ts, source, tags)
+}
+
+func (s *Sender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	return s.SendDeltaCounterWithTimestamp(name, value, 0, source, tags)
+}
+
+func (s *Sender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return s.record(name, func() {
+		if !internal.HasDeltaPrefix(name) {
+			name = internal.DeltaCounterName(name)
+		}
+		s.metrics = append(s.metrics, Metric{Name: name, Value: value, Timestamp: ts, Source: source, Tags: copyTags(tags), Delta: true})
+	})
+}
+
+func (s *Sender) SendDistribution(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return s.record(name, func() {
+		s.distributions = append(s.distributions, Distribution{
+			Name:          name,
+			Centroids:     append([]histogram.Centroid(nil), centroids...),
+			Granularities: hgs,
+			Timestamp:     ts,
+			Source:        source,
+			Tags:          copyTags(tags),
+		})
+	})
+}
+
+func (s *Sender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	return s.SendDistribution(name, []histogram.Centroid{{Value: value, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, source, tags)
+}
+
+func (s *Sender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	return s.RecordDistribution(name, float64(d)/float64(time.Millisecond), source, tags)
+}
+
+func (s *Sender) SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []senders.SpanTag, spanLogs []senders.SpanLog) error {
+	return s.record(name, func() {
+		s.spans = append(s.spans, Span{
+			Name:           name,
+			StartMillis:    startMillis,
+			DurationMillis: durationMillis,
+			Source:         source,
+			TraceID:        traceID,
+			SpanID:         spanID,
+			Parents:        parents,
+			FollowsFrom:    followsFrom,
+			Tags:           append([]senders.SpanTag(nil), tags...),
+			SpanLogs:       spanLogs,
+		})
+	})
+}
+
+func (s *Sender) SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	annotations := map[string]string{}
+	fields := map[string]interface{}{"annotations": annotations}
+	for _, setter := range setters {
+		setter(fields)
+	}
+	return s.record(name, func() {
+		s.events = append(s.events, Event{
+			Name:        name,
+			StartMillis: startMillis,
+			EndMillis:   endMillis,
+			Source:      source,
+			Tags:        copyTags(tags),
+			Annotations: annotations,
+		})
+	})
+}
+
+func (s *Sender) SendMetrics(points []senders.MetricPoint) error {
+	batchErr := &senders.BatchError{Errors: map[int]error{}}
+	for i, p := range points {
+		if err := s.SendMetric(p.Name, p.Value, p.Timestamp, p.Source, p.Tags); err != nil {
+			batchErr.Errors[i] = err
+		}
+	}
+	return batchError(batchErr)
+}
+
+func (s *Sender) SendDistributions(distributions []Distribution) error {
+	batchErr := &senders.BatchError{Errors: map[int]error{}}
+	for i, d := range distributions {
+		if err := s.SendDistribution(d.Name, d.Centroids, d.Granularities, d.Timestamp, d.Source, d.Tags); err != nil {
+			batchErr.Errors[i] = err
+		}
+	}
+	return batchError(batchErr)
+}
+
+func (s *Sender) SendSpans(spans []Span) error {
+	batchErr := &senders.BatchError{Errors: map[int]error{}}
+	for i, span := range spans {
+		if err := s.SendSpan(span.Name, span.StartMillis, span.DurationMillis, span.Source, span.TraceID, span.SpanID,
+			span.Parents, span.FollowsFrom, span.Tags, span.SpanLogs); err != nil {
+			batchErr.Errors[i] = err
+		}
+	}
+	return batchError(batchErr)
+}
+
+func batchError(err *senders.BatchError) error {
+	if len(err.Errors) == 0 {
+		return nil
+	}
+	return err
+}
+
+func (s *Sender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.SendMetric(name, value, ts, source, tags)
+}
+
+func (s *Sender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.SendDeltaCounter(name, value, source, tags)
+}
+
+func (s *Sender) SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.SendDistribution(name, centroids, hgs, ts, source, tags)
+}
+
+func (s *Sender) SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []senders.SpanTag, spanLogs []senders.SpanLog) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (s *Sender) SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.SendEvent(name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (s *Sender) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.flushes++
+	return nil
+}
+
+func (s *Sender) FlushWithReport() senders.FlushReport {
+	_ = s.Flush()
+	return senders.FlushReport{}
+}
+
+func (s *Sender) Health() senders.Health {
+	return senders.Health{Connected: true}
+}
+
+func (s *Sender) GetFailureCount() int64 {
+	return 0
+}
+
+func (s *Sender) Start() {
+}
+
+func (s *Sender) Close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+}
@@ -0,0 +1,69 @@
+package sendertest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/event"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+)
+
+// recordingT records the errors of the assertions.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestSender(t *testing.T) {
+	var sender senders.Sender = NewSender()
+	s := sender.(*Sender)
+
+	require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", map[string]string{"status": "200"}))
+	require.NoError(t, sender.SendDeltaCounter("http.errors", 2, "web", nil))
+	require.NoError(t, sender.SendDuration("http.latency", 1500*time.Microsecond, "web", nil))
+	require.NoError(t, sender.SendSpan("GET /", 1, 2, "web", "trace", "span", nil, nil,
+		[]senders.SpanTag{{Key: "http.method", Value: "GET"}}, nil))
+	require.NoError(t, sender.SendEvent("deploy", 1, 2, "web", nil, event.Severity("info")))
+	assert.Error(t, sender.SendMetric("", 1, 0, "web", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, sender.SendMetricCtx(ctx, "http.requests", 1, 0, "web", nil), context.Canceled)
+
+	assert.Equal(t, []Metric{{Name: "http.requests", Value: 1, Source: "web", Tags: map[string]string{"status": "200"}}},
+		s.MetricsNamed("http.requests"))
+	errors := s.MetricsNamed("http.errors")
+	require.Len(t, errors, 1)
+	assert.True(t, errors[0].Delta)
+	assert.Equal(t, 2.0, errors[0].Value)
+	assert.Equal(t, 1.5, s.DistributionsNamed("http.latency")[0].Centroids[0].Value)
+	span, ok := s.LastSpan()
+	require.True(t, ok)
+	assert.Equal(t, "GET /", span.Name)
+	assert.Equal(t, map[string]string{"severity": "info"}, s.Events()[0].Annotations)
+
+	assert.True(t, s.AssertTagged(t, "http.requests", map[string]string{"status": "200"}))
+	assert.True(t, s.AssertTagged(t, "GET /", map[string]string{"http.method": "GET"}))
+	rt := &recordingT{}
+	assert.False(t, s.AssertTagged(rt, "http.requests", map[string]string{"status": "500"}))
+	assert.False(t, s.AssertTagged(rt, "missing", nil))
+	assert.Len(t, rt.errors, 2)
+
+	s.Reset()
+	assert.Empty(t, s.Metrics())
+	_, ok = s.LastSpan()
+	assert.False(t, ok)
+
+	sender.Close()
+	require.NoError(t, sender.SendMetric("http.requests", 1, 0, "web", nil))
+	assert.Empty(t, s.Metrics())
+	assert.True(t, s.Closed())
+}
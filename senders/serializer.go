@@ -0,0 +1,116 @@
+package senders
+
+import (
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	histogramInternal "github.com/wavefronthq/wavefront-sdk-go/internal/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/metric"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/span"
+)
+
+// A LineSerializer turns metrics, distributions and spans into the lines of a data format.
+// The source passed to its methods is never empty: it defaults to the sender's default source.
+// Each line must end with a newline. Implementations must be safe for concurrent use.
+type LineSerializer interface {
+	MetricLine(name string, value float64, ts int64, source string, tags map[string]string) ([]byte, error)
+	DistributionLine(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) ([]byte, error)
+	SpanLine(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) ([]byte, error)
+}
+
+// LineFormat sets the serializer of the lines the sender sends, e.g. InfluxSerializer() or
+// OTLPSerializer() to send points to a non-Wavefront backend through a proxy or an OTel report
+// endpoint. Defaults to WavefrontSerializer(). Span logs are only sent separately in the Wavefront
+// data format; other serializers include them in span lines or leave them out. Events are always
+// sent in the Wavefront data format.
+func LineFormat(serializer LineSerializer) Option {
+	return func(cfg *configuration) {
+		cfg.Serializer = serializer
+	}
+}
+
+// WavefrontSerializer returns the serializer of the Wavefront data format.
+func WavefrontSerializer() LineSerializer {
+	return wavefrontSerializer{}
+}
+
+type wavefrontSerializer struct{}
+
+func (wavefrontSerializer) MetricLine(name string, value float64, ts int64, source string, tags map[string]string) ([]byte, error) {
+	return metric.AppendLine(nil, name, value, ts, source, tags, "")
+}
+
+func (wavefrontSerializer) DistributionLine(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) ([]byte, error) {
+	line, err := histogramInternal.Line(name, centroids, hgs, ts, source, tags, "")
+	return []byte(line), err
+}
+
+func (wavefrontSerializer) SpanLine(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) ([]byte, error) {
+	line, err := span.Line(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom,
+		makeSpanTags(tags), makeSpanLogs(spanLogs), "")
+	return []byte(line), err
+}
+
+func (sender *realSender) metricLine(name string, value float64, ts int64, source string, tags map[string]string) (string, error) {
+	if sender.serializer == nil {
+		// ValidationOff only applies to metric lines, the other point types are always sanitized.
+		if sender.validation == ValidationOff {
+			return metric.RawLine(name, value, ts, source, tags, sender.defaultSourceName())
+		}
+		if sender.quoteKey != nil {
+			return metric.LineWith(sender.quoteKey, name, value, ts, source, tags, sender.defaultSourceName())
+		}
+		return metric.Line(name, value, ts, source, tags, sender.defaultSourceName())
+	}
+	line, err := sender.serializer.MetricLine(name, value, ts, sender.sourceOrDefault(source), tags)
+	return string(line), err
+}
+
+func (sender *realSender) distributionLine(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) (string, error) {
+	if sender.serializer == nil {
+		if sender.quoteKey != nil {
+			return histogramInternal.LineWith(sender.quoteKey, name, centroids, hgs, ts, source, tags, sender.defaultSourceName())
+		}
+		return histogramInternal.Line(name, centroids, hgs, ts, source, tags, sender.defaultSourceName())
+	}
+	line, err := sender.serializer.DistributionLine(name, centroids, hgs, ts, sender.sourceOrDefault(source), tags)
+	return string(line), err
+}
+
+func (sender *realSender) spanLine(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) (string, error) {
+	if sender.serializer == nil {
+		return span.Line(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom,
+			makeSpanTags(tags), makeSpanLogs(spanLogs), sender.defaultSourceName())
+	}
+	line, err := sender.serializer.SpanLine(name, startMillis, durationMillis, sender.sourceOrDefault(source),
+		traceID, spanID, parents, followsFrom, tags, spanLogs)
+	return string(line), err
+}
+
+// sendsSpanLogs reports whether span logs are sent apart from spans, in the Wavefront span logs format.
+func (sender *realSender) sendsSpanLogs() bool {
+	switch sender.serializer.(type) {
+	case nil, wavefrontSerializer:
+		return true
+	}
+	return false
+}
+
+// unixNano converts a timestamp in seconds, milliseconds, microseconds or nanoseconds since
+// the epoch, as accepted by Wavefront, to nanoseconds.
+func unixNano(ts int64) int64 {
+	switch {
+	case ts < 1e11:
+		return ts * 1e9
+	case ts < 1e14:
+		return ts * 1e6
+	case ts < 1e17:
+		return ts * 1e3
+	}
+	return ts
+}
+
+func trimDeltaPrefix(name string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(name, internal.DeltaPrefix), internal.AltDeltaPrefix)
+}
@@ -0,0 +1,121 @@
+package senders
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+const (
+	testTraceID = "7b3bf470-9456-11e8-9eb6-529269fb1459"
+	testSpanID  = "0313bafe-9457-11e8-9eb6-529269fb1459"
+	testParent  = "2f64e538-9457-11e8-9eb6-529269fb1459"
+)
+
+func TestWavefrontSerializer(t *testing.T) {
+	line, err := WavefrontSerializer().MetricLine("foo.metric", 1.2, 1533529977, "test_source", map[string]string{"env": "test"})
+	require.NoError(t, err)
+	assert.Equal(t, "\"foo.metric\" 1.2 1533529977 source=\"test_source\" \"env\"=\"test\"\n", string(line))
+}
+
+func TestInfluxSerializer(t *testing.T) {
+	s := InfluxSerializer()
+	line, err := s.MetricLine("foo metric", 1.2, 1533529977, "test source", map[string]string{"env": "te,st", "dc": "dc=1"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo\\ metric,source=test\\ source,dc=dc\\=1,env=te\\,st value=1.2 1533529977000000000\n", string(line))
+
+	line, err = s.MetricLine("foo.metric", 2, 0, "test_source", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "foo.metric,source=test_source value=2\n", string(line))
+
+	_, err = s.MetricLine("foo.metric", math.NaN(), 0, "test_source", nil)
+	assert.Error(t, err)
+	_, err = s.MetricLine("", 2, 0, "test_source", nil)
+	assert.Error(t, err)
+
+	line, err = s.DistributionLine("foo.dist", []histogram.Centroid{{Value: 1, Count: 3}, {Value: 5, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 1533529977123, "test_source", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "foo.dist,source=test_source count=4i,sum=8,min=1,max=5,mean=2 1533529977123000000\n", string(line))
+
+	line, err = s.SpanLine("getAllUsers", 1533531013, 343, "localhost", testTraceID, testSpanID,
+		[]string{testParent}, nil, []SpanTag{{Key: "application", Value: "Wavefront"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "getAllUsers,source=localhost,application=Wavefront,parent="+testParent+
+		",spanId="+testSpanID+",traceId="+testTraceID+" start_millis=1533531013i,duration_millis=343i 1533531013000000\n", string(line))
+}
+
+func TestOTLPSerializer(t *testing.T) {
+	s := OTLPSerializer()
+	line, err := s.MetricLine("∆foo.count", 3, 1533529977, "test_source", map[string]string{"env": "test"})
+	require.NoError(t, err)
+	assert.Equal(t, byte('\n'), line[len(line)-1])
+	assert.Contains(t, string(line), `"timeUnixNano":"1533529977000000000"`)
+	var metrics otlpMetricsRequest
+	require.NoError(t, json.Unmarshal(line, &metrics))
+	resource := metrics.ResourceMetrics[0]
+	assert.Equal(t, []otlpKeyValue{otlpAttribute("source", "test_source")}, resource.Resource.Attributes)
+	metric := resource.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "foo.count", metric.Name)
+	require.NotNil(t, metric.Sum)
+	assert.Equal(t, otlpDeltaTemporality, metric.Sum.AggregationTemporality)
+	assert.Equal(t, otlpNumberDataPoint{
+		Attributes:   []otlpKeyValue{otlpAttribute("env", "test")},
+		TimeUnixNano: 1533529977000000000,
+		AsDouble:     3,
+	}, metric.Sum.DataPoints[0])
+
+	line, err = s.DistributionLine("foo.dist", []histogram.Centroid{{Value: 1, Count: 3}, {Value: 5, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 1533529977, "test_source", nil)
+	require.NoError(t, err)
+	metrics = otlpMetricsRequest{}
+	require.NoError(t, json.Unmarshal(line, &metrics))
+	summary := metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Summary
+	require.NotNil(t, summary)
+	assert.Equal(t, uint64(4), summary.DataPoints[0].Count)
+	assert.Equal(t, float64(8), summary.DataPoints[0].Sum)
+	assert.Equal(t, []otlpQuantile{{0, 1}, {1, 5}}, summary.DataPoints[0].QuantileValues)
+
+	line, err = s.SpanLine("getAllUsers", 1533531013000, 343, "localhost", testTraceID, testSpanID,
+		[]string{testParent}, nil, []SpanTag{{Key: "application", Value: "Wavefront"}},
+		[]SpanLog{{Timestamp: 1533531013100000, Fields: map[string]string{"event": "error", "message": "boom"}}})
+	require.NoError(t, err)
+	var traces otlpTracesRequest
+	require.NoError(t, json.Unmarshal(line, &traces))
+	span := traces.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	assert.Equal(t, "7b3bf470945611e89eb6529269fb1459", span.TraceID)
+	assert.Equal(t, "9eb6529269fb1459", span.SpanID)
+	assert.Equal(t, "9eb6529269fb1459", span.ParentSpanID)
+	assert.Equal(t, uint64(1533531013000000000), span.StartTimeUnixNano)
+	assert.Equal(t, uint64(1533531013343000000), span.EndTimeUnixNano)
+	assert.Equal(t, []otlpEvent{{
+		TimeUnixNano: 1533531013100000000,
+		Name:         "error",
+		Attributes:   []otlpKeyValue{otlpAttribute("message", "boom")},
+	}}, span.Events)
+
+	_, err = s.SpanLine("getAllUsers", 1533531013000, 343, "localhost", "not-a-uuid", testSpanID, nil, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestLineFormat(t *testing.T) {
+	pointHandler := &mockHandler{}
+	spanHandler := &mockHandler{}
+	spanLogHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.spanHandler = spanHandler
+	sender.spanLogHandler = spanLogHandler
+	sender.serializer = InfluxSerializer()
+
+	require.NoError(t, sender.SendMetric("foo.metric", 1.2, 1533529977, "", nil))
+	assert.Equal(t, []string{"foo.metric,source=test value=1.2 1533529977000000000\n"}, pointHandler.Lines)
+
+	require.NoError(t, sender.SendSpan("getAllUsers", 1533531013, 343, "localhost", testTraceID, testSpanID,
+		nil, nil, nil, []SpanLog{{Timestamp: 1533531013100, Fields: map[string]string{"event": "error"}}}))
+	assert.Len(t, spanHandler.Lines, 1)
+	assert.Empty(t, spanLogHandler.Lines)
+}
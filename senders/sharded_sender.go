@@ -0,0 +1,302 @@
+package senders
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavefronthq/wavefront-sdk-go/event"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// Shards are marked unhealthy after shardUnhealthyThreshold consecutive failed reports, and their
+// health is checked every shardCheckInterval.
+var (
+	shardUnhealthyThreshold = 3
+	shardCheckInterval      = 10 * time.Second
+)
+
+// ShardedSender Interface for spreading metrics, distributions and spans across several proxies
+type ShardedSender interface {
+	Sender
+}
+
+type shardedSender struct {
+	shards  []Sender
+	healthy []atomic.Bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewShardedSender creates a Sender spreading data across the proxies of endpoints, so that
+// proxies can be scaled horizontally: metrics, delta counters, distributions and events are sent
+// to a proxy picked by a consistent hash of their name and source, and spans to one picked by
+// their trace ID, so that a proxy gets every span of a trace. Each endpoint gets a sender
+// created with NewSender and setters.
+//
+// Proxies whose reports fail 3 times in a row are taken out of the hash until they report
+// successfully again, the data of their series moving to the other proxies meanwhile. The data
+// of the other series stays where it is.
+func NewShardedSender(endpoints []string, setters ...Option) (ShardedSender, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("sharded sender needs at least one endpoint")
+	}
+	shards := make([]Sender, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		sender, err := NewSender(endpoint, setters...)
+		if err != nil {
+			for _, shard := range shards {
+				shard.Close()
+			}
+			return nil, fmt.Errorf("unable to create the sender of %s: %s", redactURL(endpoint), err)
+		}
+		shards = append(shards, sender)
+	}
+	return newShardedSender(shards), nil
+}
+
+func newShardedSender(shards []Sender) *shardedSender {
+	ss := &shardedSender{
+		shards:  shards,
+		healthy: make([]atomic.Bool, len(shards)),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for i := range ss.healthy {
+		ss.healthy[i].Store(true)
+	}
+	go ss.monitor()
+	return ss
+}
+
+func (ss *shardedSender) monitor() {
+	defer close(ss.done)
+	ticker := time.NewTicker(shardCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ss.check()
+		case <-ss.stop:
+			return
+		}
+	}
+}
+
+// check updates the health of the shards from their reports.
+func (ss *shardedSender) check() {
+	for i, shard := range ss.shards {
+		health := shard.Health()
+		switch {
+		case ss.healthy[i].Load() && health.ConsecutiveFailures >= shardUnhealthyThreshold:
+			log.Printf("%d consecutive reports of shard %d failed, moving its series to the other shards: %s\n",
+				health.ConsecutiveFailures, i, health.LastError)
+			ss.healthy[i].Store(false)
+		case !ss.healthy[i].Load() && health.ConsecutiveFailures == 0:
+			log.Printf("shard %d healthy again, moving its series back\n", i)
+			ss.healthy[i].Store(true)
+		}
+	}
+}
+
+// shard returns the sender of key, by rendezvous hashing across the healthy shards, or across
+// all of them when none is healthy.
+func (ss *shardedSender) shard(key string) Sender {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	hash := h.Sum64()
+
+	best, bestScore := -1, uint64(0)
+	for _, healthyOnly := range []bool{true, false} {
+		for i := range ss.shards {
+			if healthyOnly && !ss.healthy[i].Load() {
+				continue
+			}
+			if score := mix(hash ^ uint64(i+1)*0x9e3779b97f4a7c15); best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best >= 0 {
+			break
+		}
+	}
+	return ss.shards[best]
+}
+
+// mix is the finalizer of splitmix64, spreading the scores of the shards of a key.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+func seriesShardKey(name, source string) string {
+	return name + "\x00" + source
+}
+
+func (ss *shardedSender) private() {
+}
+
+func (ss *shardedSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendMetric(name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendIntMetric(name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendBoolMetric(name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendAny(name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDeltaCounter(name, value, source, tags)
+}
+
+func (ss *shardedSender) SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDeltaCounterWithTimestamp(name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendDistribution(name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDistribution(name, centroids, hgs, ts, source, tags)
+}
+
+func (ss *shardedSender) RecordDistribution(name string, value float64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).RecordDistribution(name, value, source, tags)
+}
+
+func (ss *shardedSender) SendDuration(name string, d time.Duration, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDuration(name, d, source, tags)
+}
+
+func (ss *shardedSender) SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return ss.shard(traceID).SendSpan(name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (ss *shardedSender) SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return ss.shard(seriesShardKey(name, source)).SendEvent(name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (ss *shardedSender) SendMetrics(points []MetricPoint) error {
+	batches := map[Sender][]MetricPoint{}
+	for _, p := range points {
+		shard := ss.shard(seriesShardKey(p.Name, p.Source))
+		batches[shard] = append(batches[shard], p)
+	}
+	var errors multiError
+	for shard, batch := range batches {
+		if err := shard.SendMetrics(batch); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (ss *shardedSender) SendDistributions(distributions []Distribution) error {
+	batches := map[Sender][]Distribution{}
+	for _, d := range distributions {
+		shard := ss.shard(seriesShardKey(d.Name, d.Source))
+		batches[shard] = append(batches[shard], d)
+	}
+	var errors multiError
+	for shard, batch := range batches {
+		if err := shard.SendDistributions(batch); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (ss *shardedSender) SendSpans(spans []Span) error {
+	batches := map[Sender][]Span{}
+	for _, s := range spans {
+		shard := ss.shard(s.TraceID)
+		batches[shard] = append(batches[shard], s)
+	}
+	var errors multiError
+	for shard, batch := range batches {
+		if err := shard.SendSpans(batch); err != nil {
+			errors.add(err)
+		}
+	}
+	return errors.get()
+}
+
+func (ss *shardedSender) SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendMetricCtx(ctx, name, value, ts, source, tags)
+}
+
+func (ss *shardedSender) SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDeltaCounterCtx(ctx, name, value, source, tags)
+}
+
+func (ss *shardedSender) SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error {
+	return ss.shard(seriesShardKey(name, source)).SendDistributionCtx(ctx, name, centroids, hgs, ts, source, tags)
+}
+
+func (ss *shardedSender) SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error {
+	return ss.shard(traceID).SendSpanCtx(ctx, name, startMillis, durationMillis, source, traceID, spanID, parents, followsFrom, tags, spanLogs)
+}
+
+func (ss *shardedSender) SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error {
+	return ss.shard(seriesShardKey(name, source)).SendEventCtx(ctx, name, startMillis, endMillis, source, tags, setters...)
+}
+
+func (ss *shardedSender) Flush() error {
+	var errors multiError
+	for i, shard := range ss.shards {
+		if err := shard.Flush(); err != nil {
+			errors.add(&SenderError{Sender: i, Err: err})
+		}
+	}
+	return errors.get()
+}
+
+func (ss *shardedSender) FlushWithReport() FlushReport {
+	var report FlushReport
+	for _, shard := range ss.shards {
+		report.add(shard.FlushWithReport())
+	}
+	return report
+}
+
+func (ss *shardedSender) Health() Health {
+	return leastHealthy(ss.shards)
+}
+
+func (ss *shardedSender) GetFailureCount() int64 {
+	var fc int64
+	for _, shard := range ss.shards {
+		fc += shard.GetFailureCount()
+	}
+	return fc
+}
+
+func (ss *shardedSender) Start() {
+	for _, shard := range ss.shards {
+		shard.Start()
+	}
+}
+
+// Close closes the senders of every shard. It can be called more than once.
+func (ss *shardedSender) Close() {
+	ss.closeOnce.Do(func() {
+		close(ss.stop)
+		<-ss.done
+		for _, shard := range ss.shards {
+			shard.Close()
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package senders
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedSender(t *testing.T) {
+	shards := make([]Sender, 3)
+	for i := range shards {
+		shard, err := NewValidatingSender()
+		require.NoError(t, err)
+		shards[i] = shard
+	}
+	ss := newShardedSender(shards)
+	defer ss.Close()
+
+	owners := map[string]Sender{}
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("metric.%d", i)
+		owners[name] = ss.shard(seriesShardKey(name, "test"))
+		assert.Same(t, owners[name], ss.shard(seriesShardKey(name, "test")))
+		require.NoError(t, ss.SendMetric(name, 1, 0, "test", nil))
+	}
+	for _, shard := range shards {
+		lines := shard.(ValidatingSender).Lines()
+		assert.Greater(t, len(lines), 50, "series are spread across shards")
+	}
+
+	// the series of an unhealthy shard move to the others, the other series stay.
+	ss.healthy[1].Store(false)
+	for name, owner := range owners {
+		shard := ss.shard(seriesShardKey(name, "test"))
+		assert.NotSame(t, shards[1], shard)
+		if owner != shards[1] {
+			assert.Same(t, owner, shard)
+		}
+	}
+
+	// with no healthy shard, all of them are used.
+	ss.healthy[0].Store(false)
+	ss.healthy[2].Store(false)
+	for name, owner := range owners {
+		assert.Same(t, owner, ss.shard(seriesShardKey(name, "test")))
+	}
+
+	ss.Close()
+	assert.NotPanics(t, ss.Close)
+}
+
+func TestShardedSender_Check(t *testing.T) {
+	unhealthy := &unhealthySender{}
+	ss := newShardedSender([]Sender{&noOpSender{}, unhealthy})
+	defer ss.Close()
+
+	unhealthy.setFailures(3)
+	ss.check()
+	assert.True(t, ss.healthy[0].Load())
+	assert.False(t, ss.healthy[1].Load())
+
+	unhealthy.setFailures(0)
+	ss.check()
+	assert.True(t, ss.healthy[1].Load())
+}
+
+func TestNewShardedSender(t *testing.T) {
+	_, err := NewShardedSender(nil)
+	assert.Error(t, err)
+
+	ss, err := NewShardedSender([]string{"http://localhost:2878", "http://localhost:2879"}, SendInternalMetrics(false))
+	require.NoError(t, err)
+	ss.Close()
+}
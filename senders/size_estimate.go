@@ -0,0 +1,47 @@
+package senders
+
+import (
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/metric"
+)
+
+// MetricPoint is a single metric point, as given to Sender.SendMetric and Sender.SendMetrics.
+type MetricPoint struct {
+	Name      string
+	Value     float64
+	Timestamp int64
+	Source    string
+	Tags      map[string]string
+}
+
+// EstimateLineSize returns the size in bytes of the line a sender would write for point,
+// including the trailing newline. Points without a source are sized using the local hostname,
+// as the sender does by default. An error is returned for points the sender would refuse.
+func EstimateLineSize(point MetricPoint) (int, error) {
+	return estimateLineSize(point, internal.GetHostname("wavefront_direct_sender"))
+}
+
+// EstimateBatchSize returns the size in bytes of the uncompressed payload holding all of points.
+// Compression usually makes the request body smaller, but gzip headers can make the body of
+// a small payload larger than this estimate.
+// The first invalid point stops the estimation and its error is returned.
+func EstimateBatchSize(points []MetricPoint) (int, error) {
+	defaultSource := internal.GetHostname("wavefront_direct_sender")
+	total := 0
+	for _, point := range points {
+		size, err := estimateLineSize(point, defaultSource)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+func estimateLineSize(point MetricPoint, defaultSource string) (int, error) {
+	line, err := metric.Line(point.Name, point.Value, point.Timestamp, point.Source, point.Tags, defaultSource)
+	if err != nil {
+		return 0, err
+	}
+	return len(line), nil
+}
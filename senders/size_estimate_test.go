@@ -0,0 +1,30 @@
+package senders
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateLineSize(t *testing.T) {
+	size, err := EstimateLineSize(MetricPoint{Name: "foo", Value: 1.5, Timestamp: 1533531013, Source: "bar", Tags: map[string]string{"env": "prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, len("\"foo\" 1.5 1533531013 source=\"bar\" \"env\"=\"prod\"\n"), size)
+
+	_, err = EstimateLineSize(MetricPoint{Value: 1})
+	assert.Error(t, err)
+}
+
+func TestEstimateBatchSize(t *testing.T) {
+	points := []MetricPoint{
+		{Name: "foo", Value: 1, Source: "bar"},
+		{Name: "foo.bar", Value: 10, Source: "bar"},
+	}
+	size, err := EstimateBatchSize(points)
+	require.NoError(t, err)
+	assert.Equal(t, len("\"foo\" 1 source=\"bar\"\n\"foo.bar\" 10 source=\"bar\"\n"), size)
+
+	_, err = EstimateBatchSize(append(points, MetricPoint{Name: "foo", Value: 1, Tags: map[string]string{"env": ""}}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package senders
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSourceRefreshInterval = 5 * time.Minute
+	metadataTimeout              = 2 * time.Second
+)
+
+// endpoints of the EC2 and GCE instance metadata services, variables for tests.
+var (
+	ec2MetadataURL = "http://169.254.169.254/latest"
+	gceMetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+)
+
+// SourceResolver derives the default source of the sender, used for the data sent without a source,
+// from resolver instead of the hostname, e.g. KubernetesSource, EC2Source, GCESource or
+// FirstSource of several of them. The source is resolved when the sender is created, and again
+// every 5 minutes, see SourceRefreshInterval. The hostname is used while resolver returns an empty
+// source; a source that cannot be resolved anymore is kept.
+func SourceResolver(resolver func() string) Option {
+	return func(cfg *configuration) {
+		cfg.SourceResolver = resolver
+	}
+}
+
+// SourceRefreshInterval sets how often the SourceResolver is called. Defaults to 5 minutes.
+// Zero resolves the source only once.
+func SourceRefreshInterval(interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.SourceRefreshInterval = &interval
+	}
+}
+
+// FirstSource returns a source resolver returning the first non-empty source of resolvers.
+func FirstSource(resolvers ...func() string) func() string {
+	return func() string {
+		for _, resolve := range resolvers {
+			if source := resolve(); source != "" {
+				return source
+			}
+		}
+		return ""
+	}
+}
+
+// KubernetesSource resolves the source to the name of the pod, or else of the node, as exposed to
+// the container by the Kubernetes downward API in the POD_NAME and NODE_NAME environment variables:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.name
+func KubernetesSource() string {
+	for _, name := range []string{"POD_NAME", "NODE_NAME"} {
+		if source := os.Getenv(name); source != "" {
+			return source
+		}
+	}
+	return ""
+}
+
+// EC2Source resolves the source to the ID of the EC2 instance, from its instance metadata service,
+// with IMDSv2 session tokens. It returns an empty source outside of EC2.
+func EC2Source() string {
+	client := &http.Client{Timeout: metadataTimeout}
+	req, err := http.NewRequest(http.MethodPut, ec2MetadataURL+"/api/token", nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := metadata(client, req)
+	if err != nil {
+		return ""
+	}
+	req, err = http.NewRequest(http.MethodGet, ec2MetadataURL+"/meta-data/instance-id", nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	source, _ := metadata(client, req)
+	return source
+}
+
+// GCESource resolves the source to the name of the Compute Engine instance, from its metadata
+// server. It returns an empty source outside of Google Cloud.
+func GCESource() string {
+	req, err := http.NewRequest(http.MethodGet, gceMetadataURL+"/instance/name", nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	source, _ := metadata(&http.Client{Timeout: metadataTimeout}, req)
+	return source
+}
+
+// metadata returns the body of the response of a metadata service to req.
+func metadata(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata service returned status %d", resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// sourceResolver caches the source returned by a resolver, refreshed on an interval.
+type sourceResolver struct {
+	resolve         func() string
+	refreshInterval time.Duration
+
+	source atomic.Value
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// newSourceResolver creates a sourceResolver, resolving the source right away, to fallback if empty.
+func newSourceResolver(resolve func() string, refreshInterval time.Duration, fallback string) *sourceResolver {
+	r := &sourceResolver{
+		resolve:         resolve,
+		refreshInterval: refreshInterval,
+		stop:            make(chan struct{}),
+	}
+	r.source.Store(fallback)
+	r.refresh()
+	return r
+}
+
+func (r *sourceResolver) Start() {
+	if r == nil || r.refreshInterval <= 0 || r.ticker != nil {
+		return
+	}
+	r.ticker = time.NewTicker(r.refreshInterval)
+	go func() {
+		for {
+			select {
+			case <-r.ticker.C:
+				r.refresh()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (r *sourceResolver) Stop() {
+	if r == nil || r.ticker == nil {
+		return
+	}
+	r.ticker.Stop()
+	r.stop <- struct{}{}
+}
+
+// refresh resolves the source again. An empty source keeps the previous one.
+func (r *sourceResolver) refresh() {
+	if source := r.resolve(); source != "" {
+		r.source.Store(source)
+	}
+}
+
+func (r *sourceResolver) get() string {
+	return r.source.Load().(string)
+}
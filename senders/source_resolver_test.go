@@ -0,0 +1,82 @@
+package senders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceResolver(t *testing.T) {
+	var calls atomic.Int32
+	sources := []string{"pod-1", "", "pod-2"}
+	resolver := func() string {
+		n := calls.Add(1)
+		if int(n) > len(sources) {
+			return "pod-2"
+		}
+		return sources[n-1]
+	}
+	sender, err := NewValidatingSender(SourceResolver(resolver), SourceRefreshInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "", nil))
+	assert.Contains(t, sender.Lines()[0], "source=\"pod-1\"")
+	assert.Eventually(t, func() bool {
+		sender.Reset()
+		_ = sender.SendMetric("foo", 1, 0, "", nil)
+		return assert.ObjectsAreEqual([]string{"\"foo\" 1 source=\"pod-2\"\n"}, sender.Lines())
+	}, time.Second, 5*time.Millisecond)
+
+	sender.Reset()
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "explicit", nil))
+	assert.Contains(t, sender.Lines()[0], "source=\"explicit\"")
+}
+
+func TestSourceResolver_Fallback(t *testing.T) {
+	sender, err := NewValidatingSender(SourceResolver(func() string { return "" }), SourceRefreshInterval(0))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("foo", 1, 0, "", nil))
+	assert.NotContains(t, sender.Lines()[0], "source=\"\"")
+}
+
+func TestKubernetesSource(t *testing.T) {
+	t.Setenv("POD_NAME", "")
+	t.Setenv("NODE_NAME", "node-1")
+	assert.Equal(t, "node-1", KubernetesSource())
+	t.Setenv("POD_NAME", "checkout-7d9f")
+	assert.Equal(t, "checkout-7d9f", KubernetesSource())
+	assert.Equal(t, "checkout-7d9f", FirstSource(func() string { return "" }, KubernetesSource)())
+}
+
+func TestCloudSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("token"))
+		case r.URL.Path == "/latest/meta-data/instance-id" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+			_, _ = w.Write([]byte("i-0123456789abcdef0\n"))
+		case r.URL.Path == "/computeMetadata/v1/instance/name" && r.Header.Get("Metadata-Flavor") == "Google":
+			_, _ = w.Write([]byte("gce-instance"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(ec2, gce string) { ec2MetadataURL, gceMetadataURL = ec2, gce }(ec2MetadataURL, gceMetadataURL)
+	ec2MetadataURL = server.URL + "/latest"
+	gceMetadataURL = server.URL + "/computeMetadata/v1"
+
+	assert.Equal(t, "i-0123456789abcdef0", EC2Source())
+	assert.Equal(t, "gce-instance", GCESource())
+
+	gceMetadataURL = server.URL + "/missing"
+	assert.Equal(t, "", GCESource())
+}
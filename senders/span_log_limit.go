@@ -0,0 +1,95 @@
+package senders
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal/span"
+)
+
+// MaxSpanLogBytes limits the span logs of a span to maxBytes, as encoded in JSON, so that a span
+// with oversized logs, e.g. stack traces or request bodies, does not get a whole batch rejected
+// with a 413. The logs that do not fit are dropped, latest first, and the values of a first log
+// that does not fit on its own are truncated, longest first. Spans whose logs were truncated are
+// counted in the span_logs.truncated internal metric. Zero, the default, does not limit span logs.
+func MaxSpanLogBytes(maxBytes int) Option {
+	return func(cfg *configuration) {
+		cfg.MaxSpanLogBytes = maxBytes
+	}
+}
+
+// truncateSpanLogs returns the span logs of a span limited to the MaxSpanLogBytes of the sender,
+// counting the spans whose logs were truncated.
+func (sender *realSender) truncateSpanLogs(logs []SpanLog) []SpanLog {
+	logs, truncated := truncateSpanLogs(logs, sender.maxSpanLogBytes)
+	if truncated && sender.spanLogsTruncated != nil {
+		sender.spanLogsTruncated.Inc()
+	}
+	return logs
+}
+
+// truncateSpanLogs returns logs limited to maxBytes, and whether they had to be truncated.
+func truncateSpanLogs(logs []SpanLog, maxBytes int) ([]SpanLog, bool) {
+	if maxBytes <= 0 {
+		return logs, false
+	}
+	size := len("[]")
+	for i, log := range logs {
+		n := spanLogSize(log)
+		if i > 0 {
+			n++ // the comma before the log
+		}
+		if size+n <= maxBytes {
+			size += n
+			continue
+		}
+		if i > 0 {
+			return logs[:i], true
+		}
+		return []SpanLog{truncateSpanLog(log, maxBytes-size)}, true
+	}
+	return logs, false
+}
+
+// truncateSpanLog returns log with its values shortened, longest first, to fit in maxBytes.
+// Its keys and timestamp are kept.
+func truncateSpanLog(log SpanLog, maxBytes int) SpanLog {
+	fields := make(map[string]string, len(log.Fields))
+	keys := make([]string, 0, len(log.Fields))
+	for k, v := range log.Fields {
+		fields[k] = v
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(fields[keys[i]]) > len(fields[keys[j]]) })
+	truncated := SpanLog{Timestamp: log.Timestamp, Fields: fields}
+	for _, k := range keys {
+		// values escaped in JSON take more bytes than their length, so cut until they fit
+		for excess := spanLogSize(truncated) - maxBytes; excess > 0 && fields[k] != ""; excess = spanLogSize(truncated) - maxBytes {
+			fields[k] = truncateString(fields[k], len(fields[k])-excess)
+		}
+	}
+	return truncated
+}
+
+// truncateString returns the longest prefix of s of at most n bytes that does not split a rune.
+func truncateString(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func spanLogSize(log SpanLog) int {
+	data, err := json.Marshal(span.Log(log))
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
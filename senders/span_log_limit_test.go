@@ -0,0 +1,84 @@
+package senders
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func spanLogsSize(logs []SpanLog) int {
+	size := len("[]") + len(logs) - 1
+	for _, log := range logs {
+		size += spanLogSize(log)
+	}
+	return size
+}
+
+func TestTruncateSpanLogs_DropsLaterLogs(t *testing.T) {
+	logs := []SpanLog{
+		{Timestamp: 1, Fields: map[string]string{"event": "start"}},
+		{Timestamp: 2, Fields: map[string]string{"event": "retry"}},
+		{Timestamp: 3, Fields: map[string]string{"event": "end"}},
+	}
+	maxBytes := spanLogsSize(logs[:2])
+
+	truncated, ok := truncateSpanLogs(logs, maxBytes)
+	assert.True(t, ok)
+	assert.Equal(t, logs[:2], truncated)
+
+	truncated, ok = truncateSpanLogs(logs, maxBytes+100)
+	assert.False(t, ok)
+	assert.Equal(t, logs, truncated)
+}
+
+func TestTruncateSpanLogs_TruncatesFirstLog(t *testing.T) {
+	logs := []SpanLog{
+		{Timestamp: 1, Fields: map[string]string{
+			"event": "error",
+			"stack": strings.Repeat("é", 200),
+			"body":  strings.Repeat("\"", 50),
+		}},
+		{Timestamp: 2, Fields: map[string]string{"event": "end"}},
+	}
+
+	truncated, ok := truncateSpanLogs(logs, 120)
+	require.True(t, ok)
+	require.Len(t, truncated, 1)
+	assert.LessOrEqual(t, spanLogsSize(truncated), 120)
+	assert.Equal(t, int64(1), truncated[0].Timestamp)
+	assert.Equal(t, "error", truncated[0].Fields["event"])
+	assert.True(t, utf8.ValidString(truncated[0].Fields["stack"]))
+	assert.Len(t, logs[0].Fields["stack"], 400, "logs of the caller must not be modified")
+}
+
+func TestTruncateSpanLogs_Unlimited(t *testing.T) {
+	logs := []SpanLog{{Timestamp: 1, Fields: map[string]string{"stack": strings.Repeat("x", 10000)}}}
+	truncated, ok := truncateSpanLogs(logs, 0)
+	assert.False(t, ok)
+	assert.Equal(t, logs, truncated)
+}
+
+func TestMaxSpanLogBytes(t *testing.T) {
+	sender, err := NewValidatingSender(MaxSpanLogBytes(256))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	logs := []SpanLog{{Timestamp: 1, Fields: map[string]string{"stack": strings.Repeat("x", 10000)}}}
+	require.NoError(t, sender.SendSpan("get", 0, 10, "web-01",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459",
+		nil, nil, nil, logs))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "\"get\" source=\"web-01\"")
+	var spanLogs struct {
+		Logs json.RawMessage `json:"logs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &spanLogs))
+	assert.LessOrEqual(t, len(spanLogs.Logs), 256)
+	assert.Contains(t, string(spanLogs.Logs), "\"stack\":\"xxx")
+}
@@ -0,0 +1,124 @@
+package senders
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+// sendStartupMetric reports the configuration of a new sender once, with the first report of the
+// internal metrics, so that configuration drift across a fleet can be audited from Wavefront:
+// the startup internal metric, of value 1, is tagged with a hash of the whole configuration and
+// with its main settings.
+func (sender *realSender) sendStartupMetric(cfg *configuration, endpoint string) {
+	summary := configSummary(cfg, endpoint)
+	sender.internalRegistry.SendOnce(InternalMetricStartup, 1, map[string]string{
+		"config_hash":    configHash(summary),
+		"endpoint":       summary["endpoint"],
+		"batch_size":     summary["batch_size"],
+		"buffer_size":    summary["buffer_size"],
+		"flush_interval": summary["flush_interval"],
+	})
+}
+
+// configSummary describes the settings of cfg that shape what is sent and how, leaving secrets out.
+func configSummary(cfg *configuration, endpoint string) map[string]string {
+	return map[string]string{
+		"endpoint":                     redactURL(endpoint),
+		"direct":                       strconv.FormatBool(cfg.Direct()),
+		"protocol":                     cfg.Protocol,
+		"batch_size":                   strconv.Itoa(cfg.BatchSize),
+		"buffer_size":                  strconv.Itoa(cfg.MaxBufferSize),
+		"flush_interval":               cfg.FlushInterval.String(),
+		"flush_threshold":              strconv.Itoa(cfg.FlushThreshold),
+		"max_payload_bytes":            strconv.Itoa(cfg.MaxPayloadBytes),
+		"metrics_flush_interval":       cfg.MetricsFlushInterval.String(),
+		"distributions_flush_interval": cfg.DistributionsFlushInterval.String(),
+		"traces_flush_interval":        cfg.TracesFlushInterval.String(),
+		"events_flush_interval":        cfg.EventsFlushInterval.String(),
+		"timeout":                      cfg.HTTPClient.Timeout.String(),
+		"request_timeout":              fmt.Sprintf("%s+%s/MiB", cfg.RequestTimeoutBase, cfg.RequestTimeoutPerMB),
+		"rate_limit":                   strconv.Itoa(cfg.RateLimit),
+		"overflow_policy":              fmt.Sprintf("%d/%s", cfg.OverflowPolicy.policy, cfg.OverflowPolicy.timeout),
+		"persistent_buffer":            strconv.FormatBool(cfg.PersistenceDir != ""),
+		"audit_journal":                strconv.FormatBool(cfg.AuditJournalPath != ""),
+		"proxy_url":                    proxyURL(cfg),
+		"tls_client_cert":              strconv.FormatBool(cfg.httpClientConfiguration.ClientCertFile != ""),
+		"ca_cert":                      strconv.FormatBool(cfg.httpClientConfiguration.CACertFile != ""),
+		"flush_latency_budget":         cfg.FlushLatencyBudget.String(),
+		"distribution_granularities":   granularities(cfg.DistributionGranularities),
+		"distribution_backend":         cfg.DistributionBackend.String(),
+		"duration_unit":                cfg.DurationUnit.String(),
+		"delta_counter_bucket":         cfg.DeltaCounterBucket.String(),
+		"aggregate_delta_counters":     strconv.FormatBool(cfg.AggregateDeltaCounters),
+		"max_retries":                  strconv.Itoa(cfg.MaxRetries),
+		"circuit_breaker":              fmt.Sprintf("%d/%s", cfg.CircuitBreakerFailures, cfg.CircuitBreakerCoolDown),
+		"retry_budget":                 fmt.Sprintf("%d/%g", cfg.RetryBudgetPerMinute, cfg.RetryBudgetRatio),
+		"validation":                   cfg.Validation.String(),
+		"name_sanitizer":               strconv.FormatBool(cfg.NameSanitizer != nil),
+		"disabled_distributions":       strconv.FormatBool(cfg.DisableDistributions),
+		"disabled_spans":               strconv.FormatBool(cfg.DisableSpans),
+		"disabled_events":              strconv.FormatBool(cfg.DisableEvents),
+		"handshake":                    strconv.FormatBool(cfg.Handshake),
+		"metric_renames":               strconv.Itoa(len(cfg.MetricRenames)),
+		"top_k":                        strconv.Itoa(cfg.TopK),
+		"on_dropped":                   strconv.FormatBool(cfg.OnDropped != nil),
+		"extra_headers":                strconv.Itoa(len(cfg.ExtraHeaders)),
+		"timestamp_unit":               cfg.TimestampUnit.String(),
+		"max_span_log_bytes":           strconv.Itoa(cfg.MaxSpanLogBytes),
+		"source_resolver":              strconv.FormatBool(cfg.SourceResolver != nil),
+		"enrichers":                    strconv.Itoa(len(cfg.Enrichers)),
+		"point_transformers":           strconv.Itoa(len(cfg.PointTransformers)),
+		"span_transformers":            strconv.Itoa(len(cfg.SpanTransformers)),
+		"event_transformers":           strconv.Itoa(len(cfg.EventTransformers)),
+		"internal_metrics_registry":    strconv.FormatBool(cfg.InternalMetricsRegistry != nil),
+		"internal_metrics_prefix":      cfg.MetricPrefix(),
+	}
+}
+
+// proxyURL returns the redacted URL of the ProxyURL option, empty if not set.
+func proxyURL(cfg *configuration) string {
+	if cfg.httpClientConfiguration.ProxyURL == nil {
+		return ""
+	}
+	return redactURL(cfg.httpClientConfiguration.ProxyURL.String())
+}
+
+// configHash returns a short digest of summary, equal for equal summaries.
+func configHash(summary map[string]string) string {
+	keys := make([]string, 0, len(summary))
+	for key := range summary {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s=%s\n", key, summary[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// granularities returns the prefixes of hgs, comma separated.
+func granularities(hgs []histogram.Granularity) string {
+	prefixes := make([]string, len(hgs))
+	for i := range hgs {
+		prefixes[i] = hgs[i].String()
+	}
+	return strings.Join(prefixes, ",")
+}
+
+// redactURL removes the credentials from rawURL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.User = nil
+	return strings.TrimSuffix(u.String(), "/")
+}
@@ -0,0 +1,166 @@
+package senders
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// Limits of the Wavefront data format enforced by StrictValidation.
+const (
+	maxMetricNameLength = 256
+	maxSourceLength     = 128
+	maxTagLength        = 254 // of a point tag key and value combined
+)
+
+// ValidationMode is how Send* calls handle the names, sources and tags breaking the rules of the
+// Wavefront data format, see Validation.
+type ValidationMode int
+
+const (
+	// ValidationSanitize, the default, replaces the invalid characters of names and tag keys with
+	// "-", and escapes the quotes and line breaks of sources and tag values.
+	ValidationSanitize ValidationMode = iota
+	// ValidationStrict returns a *ValidationError right away, see StrictValidation.
+	ValidationStrict
+	// ValidationOff formats metric lines with names, sources and tags as given, sparing the cost
+	// of sanitizing them, for callers making sure they are valid. Only their quotes and line
+	// breaks are escaped, and invalid ones are rejected by Wavefront after the flush.
+	// It covers metrics only: distributions, spans and events are formatted as usual.
+	ValidationOff
+)
+
+// Validation sets how Send* calls handle invalid names, sources and tags, ValidationSanitize
+// by default.
+func Validation(mode ValidationMode) Option {
+	return func(cfg *configuration) {
+		cfg.Validation = mode
+	}
+}
+
+func (mode ValidationMode) String() string {
+	switch mode {
+	case ValidationStrict:
+		return "strict"
+	case ValidationOff:
+		return "off"
+	}
+	return "sanitize"
+}
+
+// StrictValidation makes Send* calls check names, sources and tags against the rules of the
+// Wavefront data format, and return a *ValidationError right away, instead of sanitizing
+// invalid characters or having the line rejected by Wavefront after the flush:
+//   - metric, distribution and span names may only hold letters, digits and "-_.,/" characters,
+//     after an optional "~" or delta counter prefix, and up to 256 characters;
+//   - sources hold up to 128 characters;
+//   - tag keys may only hold letters, digits and "-_." characters, tag values must not be empty,
+//     and a key and value combined hold up to 254 characters.
+//
+// It is short for Validation(ValidationStrict).
+func StrictValidation() Option {
+	return Validation(ValidationStrict)
+}
+
+// ValidationError describes data refused by StrictValidation.
+type ValidationError struct {
+	// Field is what is invalid: "name", "source" or "tag".
+	Field string
+	// Value is the invalid name, source or tag key.
+	Value string
+	// Reason tells why Value is invalid.
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// validate checks name, source and tags when strict validation is enabled.
+func (sender *realSender) validate(name, source string, tags map[string]string) error {
+	if sender.validation != ValidationStrict {
+		return nil
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := validateSource(source); err != nil {
+		return err
+	}
+	for k, v := range tags {
+		if err := validateTag(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSpan checks name, source and tags of a span when strict validation is enabled.
+func (sender *realSender) validateSpan(name, source string, tags []SpanTag) error {
+	if sender.validation != ValidationStrict {
+		return nil
+	}
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := validateSource(source); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := validateTag(tag.Key, tag.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Value: name, Reason: "empty"}
+	}
+	if len(name) > maxMetricNameLength {
+		return &ValidationError{Field: "name", Value: name, Reason: fmt.Sprintf("longer than %d characters", maxMetricNameLength)}
+	}
+	unprefixed := strings.TrimPrefix(strings.TrimPrefix(name, internal.DeltaPrefix), internal.AltDeltaPrefix)
+	unprefixed = strings.TrimPrefix(unprefixed, "~")
+	for _, c := range unprefixed {
+		if !isNameChar(c) {
+			return &ValidationError{Field: "name", Value: name, Reason: fmt.Sprintf("invalid character %q", c)}
+		}
+	}
+	return nil
+}
+
+func validateSource(source string) error {
+	if len(source) > maxSourceLength {
+		return &ValidationError{Field: "source", Value: source, Reason: fmt.Sprintf("longer than %d characters", maxSourceLength)}
+	}
+	return nil
+}
+
+func validateTag(key, value string) error {
+	if key == "" {
+		return &ValidationError{Field: "tag", Value: key, Reason: "empty key"}
+	}
+	for _, c := range key {
+		if !isTagKeyChar(c) {
+			return &ValidationError{Field: "tag", Value: key, Reason: fmt.Sprintf("invalid character %q", c)}
+		}
+	}
+	if value == "" {
+		return &ValidationError{Field: "tag", Value: key, Reason: "empty value"}
+	}
+	if len(key)+len(value) > maxTagLength {
+		return &ValidationError{Field: "tag", Value: key, Reason: fmt.Sprintf("key and value longer than %d characters", maxTagLength)}
+	}
+	return nil
+}
+
+func isNameChar(c rune) bool {
+	return isTagKeyChar(c) || c == ',' || c == '/'
+}
+
+func isTagKeyChar(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.'
+}
@@ -0,0 +1,74 @@
+package senders
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+func TestValidateName(t *testing.T) {
+	assert.NoError(t, validateName("my.metric_name-1,2/3"))
+	assert.NoError(t, validateName("~sdk.go.metric"))
+	assert.NoError(t, validateName(internal.DeltaCounterName("my.counter")))
+	assert.Error(t, validateName(""))
+	assert.Error(t, validateName("my metric"))
+	assert.Error(t, validateName("my\"metric"))
+	assert.Error(t, validateName(strings.Repeat("m", 257)))
+}
+
+func TestValidateTag(t *testing.T) {
+	assert.NoError(t, validateTag("env-1.a_b", "any value, \"quoted\""))
+	assert.Error(t, validateTag("", "v"))
+	assert.Error(t, validateTag("k/k", "v"))
+	assert.Error(t, validateTag("k", ""))
+	assert.Error(t, validateTag("k", strings.Repeat("v", 254)))
+}
+
+func TestValidationModes(t *testing.T) {
+	for _, tc := range []struct {
+		mode ValidationMode
+		want string
+	}{
+		{mode: ValidationSanitize, want: "\"my-metric\" 1 source=\"test\"\n"},
+		{mode: ValidationOff, want: "\"my metric\" 1 source=\"test\"\n"},
+	} {
+		sender := &realSender{validation: tc.mode}
+		line, err := sender.metricLine("my metric", 1, 0, "test", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, line, tc.mode.String())
+		assert.NoError(t, sender.validate("my metric", "test", nil))
+	}
+
+	sender := &realSender{validation: ValidationStrict}
+	var validationErr *ValidationError
+	assert.ErrorAs(t, sender.validate("my metric", "test", nil), &validationErr)
+}
+
+func TestSanitizeNames(t *testing.T) {
+	cfg, err := createConfig("http://localhost", SanitizeNames(SanitizeAllowing(":")))
+	require.NoError(t, err)
+	sender := &realSender{quoteKey: internal.NewKeyQuoter(cfg.NameSanitizer)}
+
+	line, err := sender.metricLine("kube:pod cpu", 1, 0, "test", map[string]string{"k8s:ns": "default"})
+	require.NoError(t, err)
+	assert.Equal(t, "\"kube:pod-cpu\" 1 source=\"test\" \"k8s:ns\"=\"default\"\n", line)
+
+	line, err = sender.distributionLine("kube:latency", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "!M #1 1 \"kube:latency\" source=\"test\"\n", line)
+
+	// ":" is replaced by the default rules, which spans keep.
+	line, err = (&realSender{}).metricLine("kube:pod cpu", 1, 0, "test", map[string]string{"k8s:ns": "default"})
+	require.NoError(t, err)
+	assert.Equal(t, "\"kube-pod-cpu\" 1 source=\"test\" \"k8s-ns\"=\"default\"\n", line)
+	line, err = sender.spanLine("kube:span", 0, 1, "test", "7b3bf470-9456-11e8-9eb6-529269fb1459",
+		"0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, []SpanTag{{Key: "k8s:ns", Value: "default"}}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, line, "\"kube:span\" source=\"test\"")
+	assert.Contains(t, line, "\"k8s-ns\"=\"default\"")
+}
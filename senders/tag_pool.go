@@ -0,0 +1,57 @@
+package senders
+
+import "sync"
+
+// maxPooledTags is the size over which tag maps are left to the garbage collector rather than
+// pooled, so that a few points with many tags do not keep large maps alive.
+const maxPooledTags = 64
+
+// tagMaps pools the tag maps copied while points are prepared, which services sending the same
+// tag sets over and over would otherwise allocate for every point.
+var tagMaps = sync.Pool{
+	New: func() interface{} {
+		return make(map[string]string, 8)
+	},
+}
+
+func getTagMap() map[string]string {
+	return tagMaps.Get().(map[string]string)
+}
+
+// putTagMap clears m and returns it to the pool. A nil m is ignored.
+func putTagMap(m map[string]string) {
+	if m == nil || len(m) > maxPooledTags {
+		return
+	}
+	for k := range m {
+		delete(m, k)
+	}
+	tagMaps.Put(m)
+}
+
+func copyTagsTo(dst, src map[string]string) map[string]string {
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// pointTags returns the name, source and tags of a point with the tags of the enrichers added and
+// the transformers run, and the tag map copied from the pool to do so, if any, to return to it
+// with putTagMap once the point is serialized. The caller's tags are never modified.
+func (sender *realSender) pointTags(name, source string, tags map[string]string) (string, string, map[string]string, map[string]string) {
+	enriched := enrich(sender.enrichers, sender.sourceOrDefault(source), tags)
+	var pooled map[string]string
+	// enrich only adds tags, and copies them to do so.
+	if len(enriched) != len(tags) {
+		pooled = enriched
+	}
+	if len(sender.transformers) == 0 {
+		return name, source, enriched, pooled
+	}
+	if pooled == nil {
+		pooled = copyTagsTo(getTagMap(), tags)
+	}
+	name, source, transformed := sender.transform(name, source, pooled)
+	return name, source, transformed, pooled
+}
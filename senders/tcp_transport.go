@@ -0,0 +1,209 @@
+package senders
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTCPKeepalive = 30 * time.Second
+	tcpDialTimeout      = 5 * time.Second
+	tcpWriteTimeout     = 10 * time.Second
+	tcpMinBackoff       = 100 * time.Millisecond
+	tcpMaxBackoff       = 30 * time.Second
+)
+
+func init() {
+	Register("tcp", newTCPTransport)
+}
+
+// tcpTransport sends lines to the plaintext listener of a proxy over TCP, for a sender created
+// with a tcp:// URL:
+//
+//	sender, err := senders.NewSender("tcp://proxy:2878?tracesPort=30001&keepalive=10s")
+//
+// Spans and span logs are sent to tracesPort, the port of the URL by default.
+//
+// Connections silently gone stale, e.g. after a restart of the proxy, are detected by probing them
+// every keepalive, 30s by default, and by failed writes, which time out after 10s. They are then opened again, in the
+// background and on the next Send, waiting between attempts from 100ms up to 30s as they keep
+// failing. Batches failing to be sent meanwhile are buffered by the sender as usual; lines of a
+// batch written in part before a failure may be sent twice.
+type tcpTransport struct {
+	metrics *tcpConn
+	traces  *tcpConn
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func newTCPTransport(u *url.URL) (Transport, error) {
+	if u.Port() == "" {
+		return nil, fmt.Errorf("missing port in '%s'", u)
+	}
+	query := u.Query()
+	keepalive := defaultTCPKeepalive
+	if value := query.Get("keepalive"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid keepalive '%s'", value)
+		}
+		keepalive = d
+	}
+
+	t := &tcpTransport{stop: make(chan struct{})}
+	t.metrics = newTCPConn(u.Host)
+	t.traces = t.metrics
+	if port := query.Get("tracesPort"); port != "" && port != u.Port() {
+		t.traces = newTCPConn(net.JoinHostPort(u.Hostname(), port))
+	}
+	if keepalive > 0 {
+		t.done.Add(1)
+		go t.probe(keepalive)
+	}
+	return t, nil
+}
+
+func (t *tcpTransport) Send(format string, lines []byte) error {
+	if format == "trace" || format == "spanLogs" {
+		return t.traces.send(lines)
+	}
+	return t.metrics.send(lines)
+}
+
+// Connected reports whether the connections to the proxy are open.
+func (t *tcpTransport) Connected() bool {
+	return t.metrics.connected() && t.traces.connected()
+}
+
+func (t *tcpTransport) Close() error {
+	close(t.stop)
+	t.done.Wait()
+	if t.traces != t.metrics {
+		_ = t.traces.close()
+	}
+	return t.metrics.close()
+}
+
+func (t *tcpTransport) probe(keepalive time.Duration) {
+	defer t.done.Done()
+	ticker := time.NewTicker(keepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.metrics.probe()
+			if t.traces != t.metrics {
+				t.traces.probe()
+			}
+		}
+	}
+}
+
+// tcpConn is a connection to a proxy, opened again with backoff once it fails.
+type tcpConn struct {
+	addr string
+
+	mtx      sync.Mutex
+	conn     net.Conn
+	failures int
+	retryAt  time.Time
+}
+
+func newTCPConn(addr string) *tcpConn {
+	c := &tcpConn{addr: addr}
+	// the proxy may be down for now: the connection is opened again on Send or probe.
+	_ = c.connect()
+	return c
+}
+
+func (c *tcpConn) send(lines []byte) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return err
+		}
+	}
+	// a proxy no longer reading the connection must not block the flush forever.
+	if err := c.conn.SetWriteDeadline(time.Now().Add(tcpWriteTimeout)); err != nil {
+		c.disconnect()
+		return err
+	}
+	if _, err := c.conn.Write(lines); err != nil {
+		c.disconnect()
+		return err
+	}
+	return nil
+}
+
+// connect opens the connection, unless waiting to try again. It must be called with mtx held.
+func (c *tcpConn) connect() error {
+	if wait := time.Until(c.retryAt); wait > 0 {
+		return fmt.Errorf("unable to connect to %s, trying again in %s", c.addr, wait.Round(time.Millisecond))
+	}
+	dialer := net.Dialer{Timeout: tcpDialTimeout, KeepAlive: defaultTCPKeepalive}
+	conn, err := dialer.Dial("tcp", c.addr)
+	if err != nil {
+		c.failures++
+		backoff := tcpMinBackoff << (c.failures - 1)
+		if backoff > tcpMaxBackoff || backoff <= 0 {
+			backoff = tcpMaxBackoff
+		}
+		c.retryAt = time.Now().Add(backoff)
+		return err
+	}
+	c.conn = conn
+	c.failures = 0
+	c.retryAt = time.Time{}
+	return nil
+}
+
+// disconnect closes a failed connection. It must be called with mtx held.
+func (c *tcpConn) disconnect() {
+	_ = c.conn.Close()
+	c.conn = nil
+}
+
+// probe checks that the proxy did not close the connection, and opens it again if it did. Proxies
+// never write to the connection, so that a read returns either EOF or an error once it is closed,
+// and times out as long as it is open.
+func (c *tcpConn) probe() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn != nil {
+		_ = c.conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+		var buf [64]byte
+		_, err := c.conn.Read(buf[:])
+		_ = c.conn.SetReadDeadline(time.Time{})
+		if err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			return
+		}
+		c.disconnect()
+	}
+	_ = c.connect()
+}
+
+func (c *tcpConn) connected() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.conn != nil
+}
+
+func (c *tcpConn) close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
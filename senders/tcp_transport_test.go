@@ -0,0 +1,122 @@
+package senders
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptLines accepts connections on l and sends the lines received over them to the returned
+// channel, and the connections to conns.
+func acceptLines(l net.Listener, conns chan<- net.Conn) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+func receive(t *testing.T, lines <-chan string) string {
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line received")
+		return ""
+	}
+}
+
+func TestTCPTransport_Reconnects(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	conns := make(chan net.Conn, 10)
+	lines := acceptLines(l, conns)
+
+	sender, err := NewSender("tcp://"+l.Addr().String()+"?keepalive=10ms", SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	health := sender
+	assert.True(t, health.Health().Connected)
+	assert.True(t, health.Health().LastSuccess.IsZero())
+
+	require.NoError(t, sender.SendMetric("cpu", 1, 1700000000, "web-01", nil))
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, "\"cpu\" 1 1700000000 source=\"web-01\"", receive(t, lines))
+	assert.False(t, health.Health().LastSuccess.IsZero())
+	assert.Zero(t, health.Health().ConsecutiveFailures)
+
+	// the proxy restarts, closing the connection: probes open a new one.
+	(<-conns).Close()
+	select {
+	case <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reconnection")
+	}
+	require.NoError(t, sender.SendMetric("cpu", 2, 1700000000, "web-01", nil))
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, "\"cpu\" 2 1700000000 source=\"web-01\"", receive(t, lines))
+}
+
+func TestTCPTransport_Backoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	transport, err := newTCPTransport(&url.URL{Scheme: "tcp", Host: addr, RawQuery: "keepalive=0"})
+	require.NoError(t, err)
+	defer transport.(*tcpTransport).Close()
+	assert.False(t, transport.(*tcpTransport).Connected())
+
+	// the first attempt failed when the transport was created: the next one waits.
+	err = transport.Send("wavefront", []byte("cpu 1\n"))
+	assert.ErrorContains(t, err, "trying again in")
+	c := transport.(*tcpTransport).metrics
+	c.mtx.Lock()
+	assert.Equal(t, 1, c.failures)
+	c.retryAt = time.Time{}
+	c.mtx.Unlock()
+	assert.Error(t, transport.Send("wavefront", []byte("cpu 1\n")))
+	c.mtx.Lock()
+	assert.Equal(t, 2, c.failures)
+	assert.Greater(t, time.Until(c.retryAt), tcpMinBackoff)
+	c.mtx.Unlock()
+}
+
+func TestHealth_Failures(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	sender, err := NewSender("tcp://"+addr+"?keepalive=0", SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sender.SendMetric("cpu", 1, 1700000000, "web-01", nil))
+		assert.Error(t, sender.Flush())
+	}
+	health := sender.Health()
+	assert.False(t, health.Connected)
+	assert.Equal(t, 2, health.ConsecutiveFailures)
+	assert.Error(t, health.LastError)
+	assert.True(t, health.LastSuccess.IsZero())
+}
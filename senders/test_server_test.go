@@ -14,9 +14,7 @@ import (
 
 func startTestServer(useTLS bool) *testServer {
 	ts := &testServer{}
-	handler := http.NewServeMux()
-	handler.HandleFunc("/api/v2/event", ts.EventAPIEndpoint)
-	handler.HandleFunc("/", ts.ReportEndpoint)
+	handler := ts.handler()
 	if useTLS {
 		ts.httpServer = httptest.NewTLSServer(handler)
 	} else {
@@ -26,13 +24,31 @@ func startTestServer(useTLS bool) *testServer {
 	return ts
 }
 
+// startMutualTLSTestServer starts a TLS test server requiring client certificates signed by clientCAs.
+func startMutualTLSTestServer(clientCAs *x509.CertPool) *testServer {
+	ts := &testServer{}
+	ts.httpServer = httptest.NewUnstartedServer(ts.handler())
+	ts.httpServer.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.httpServer.StartTLS()
+	ts.URL = ts.httpServer.URL
+	return ts
+}
+
+func (s *testServer) handler() http.Handler {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v2/event", s.EventAPIEndpoint)
+	handler.HandleFunc("/", s.ReportEndpoint)
+	return handler
+}
+
 type testServer struct {
-	MetricLines []string
-	EventLines  []string
-	AuthHeaders []string
-	httpServer  *httptest.Server
-	URL         string
-	RequestURLs []string
+	MetricLines   []string
+	EventLines    []string
+	AuthHeaders   []string
+	TenantHeaders []string
+	httpServer    *httptest.Server
+	URL           string
+	RequestURLs   []string
 }
 
 func (s *testServer) TLSConfig() *tls.Config {
@@ -51,6 +67,7 @@ func (s *testServer) ReportEndpoint(writer http.ResponseWriter, request *http.Re
 	}
 	s.MetricLines = append(s.MetricLines, newLines...)
 	s.AuthHeaders = append(s.AuthHeaders, request.Header.Get("Authorization"))
+	s.TenantHeaders = append(s.TenantHeaders, request.Header.Get("dx_tenant_id"))
 	s.RequestURLs = append(s.RequestURLs, request.URL.String())
 	writer.WriteHeader(200)
 }
@@ -64,6 +81,7 @@ func (s *testServer) EventAPIEndpoint(writer http.ResponseWriter, request *http.
 	}
 	s.EventLines = append(s.EventLines, newLines...)
 	s.AuthHeaders = append(s.AuthHeaders, request.Header.Get("Authorization"))
+	s.TenantHeaders = append(s.TenantHeaders, request.Header.Get("dx_tenant_id"))
 	s.RequestURLs = append(s.RequestURLs, request.URL.String())
 	writer.WriteHeader(200)
 }
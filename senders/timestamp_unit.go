@@ -0,0 +1,117 @@
+package senders
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// timestamps from this magnitude on are in milliseconds rather than seconds, and from
+// maxMillisTimestamp on in microseconds or nanoseconds.
+const (
+	minMillisTimestamp = 1e11
+	maxMillisTimestamp = 1e14
+)
+
+// TimestampUnit is the unit of the timestamps given to the Send APIs: the ts of metrics, delta
+// counters and distributions, and the start and end of spans and events. Span durations are always
+// in milliseconds.
+type TimestampUnit int
+
+const (
+	// TimestampAuto, the default, sends timestamps as they are given: the unit of metric and
+	// distribution timestamps is detected from their magnitude, by Wavefront as by the sender for
+	// OTLP and InfluxDB, while spans and events take milliseconds and delta counters seconds.
+	TimestampAuto TimestampUnit = iota
+	// TimestampSeconds takes all timestamps in seconds since the epoch.
+	TimestampSeconds
+	// TimestampMillis takes all timestamps in milliseconds since the epoch.
+	TimestampMillis
+)
+
+// Timestamps sets the unit of the timestamps given to the Send APIs. With TimestampSeconds or
+// TimestampMillis, timestamps whose magnitude is not that of the unit, e.g. milliseconds given
+// as seconds, are rejected rather than sent a thousand times off, and each timestamp is converted
+// to the unit its target expects, e.g. milliseconds for the start of spans. Zero timestamps still
+// mean now.
+func Timestamps(unit TimestampUnit) Option {
+	return func(cfg *configuration) {
+		cfg.TimestampUnit = unit
+	}
+}
+
+func (unit TimestampUnit) String() string {
+	switch unit {
+	case TimestampSeconds:
+		return "seconds"
+	case TimestampMillis:
+		return "milliseconds"
+	}
+	return "auto"
+}
+
+// Timestamp returns t as a timestamp in unit, for the Send APIs. TimestampAuto returns
+// milliseconds, which are detected as such for metrics and distributions.
+func (unit TimestampUnit) Timestamp(t time.Time) int64 {
+	if unit == TimestampSeconds {
+		return t.Unix()
+	}
+	return t.UnixMilli()
+}
+
+// ParseTimestamp parses s, either an RFC 3339 time, such as 2024-05-01T12:00:00Z, or an integer
+// timestamp, to a timestamp in unit. Integer timestamps are taken in unit, and must have its
+// magnitude; with TimestampAuto, their unit is detected from their magnitude.
+func (unit TimestampUnit) ParseTimestamp(s string) (int64, error) {
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if unit == TimestampAuto {
+			return unit.Timestamp(time.Unix(0, unixNano(ts))), nil
+		}
+		if err := unit.check(ts); err != nil {
+			return 0, err
+		}
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: neither an RFC 3339 time nor an integer", s)
+	}
+	return unit.Timestamp(t), nil
+}
+
+// check returns an error if the non-zero timestamp ts does not have the magnitude of unit.
+func (unit TimestampUnit) check(ts int64) error {
+	if ts == 0 {
+		return nil
+	}
+	switch unit {
+	case TimestampSeconds:
+		if ts < 0 || ts >= minMillisTimestamp {
+			return fmt.Errorf("timestamp %d is not in seconds", ts)
+		}
+	case TimestampMillis:
+		if ts < minMillisTimestamp || ts >= maxMillisTimestamp {
+			return fmt.Errorf("timestamp %d is not in milliseconds", ts)
+		}
+	}
+	return nil
+}
+
+// time returns the time of the delta counter timestamp ts, in unit.
+func (unit TimestampUnit) time(ts int64) time.Time {
+	if unit == TimestampMillis {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
+}
+
+// millis returns the span or event timestamp ts, in unit, in milliseconds.
+func (unit TimestampUnit) millis(ts int64) (int64, error) {
+	if err := unit.check(ts); err != nil {
+		return 0, err
+	}
+	if unit == TimestampSeconds {
+		return ts * 1000, nil
+	}
+	return ts, nil
+}
@@ -0,0 +1,107 @@
+package senders
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampUnit_ParseTimestamp(t *testing.T) {
+	ts, err := TimestampSeconds.ParseTimestamp("2024-05-01T12:00:00Z")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1714564800), ts)
+
+	ts, err = TimestampMillis.ParseTimestamp("2024-05-01T12:00:00.250+02:00")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1714557600250), ts)
+
+	ts, err = TimestampMillis.ParseTimestamp("1714564800000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1714564800000), ts)
+
+	_, err = TimestampMillis.ParseTimestamp("1714564800")
+	assert.EqualError(t, err, "timestamp 1714564800 is not in milliseconds")
+	_, err = TimestampSeconds.ParseTimestamp("1714564800000")
+	assert.EqualError(t, err, "timestamp 1714564800000 is not in seconds")
+
+	// auto-detected timestamps are returned in milliseconds.
+	ts, err = TimestampAuto.ParseTimestamp("1714564800")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1714564800000), ts)
+	ts, err = TimestampAuto.ParseTimestamp("1714564800000000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1714564800000), ts)
+
+	_, err = TimestampAuto.ParseTimestamp("2024-05-01 12:00:00")
+	assert.Error(t, err)
+}
+
+func TestTimestamps_Seconds(t *testing.T) {
+	sender, err := NewValidatingSender(Timestamps(TimestampSeconds))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	now := time.Now()
+	ts := TimestampSeconds.Timestamp(now)
+	require.NoError(t, sender.SendMetric("cpu", 1, ts, "web-01", nil))
+	require.NoError(t, sender.SendDeltaCounterWithTimestamp("requests", 1, ts, "web-01", nil))
+	require.NoError(t, sender.SendSpan("get", 1533529977, 10, "web-01",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+	require.NoError(t, sender.SendEvent("deploy", 1533529977, 1533529978, "web-01", nil))
+
+	assert.EqualError(t, sender.SendMetric("cpu", 1, now.UnixMilli(), "web-01", nil),
+		"timestamp "+itoa(now.UnixMilli())+" is not in seconds")
+	assert.Error(t, sender.SendSpan("get", 1533529977000, 10, "web-01",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "\"cpu\" 1 "+itoa(ts)+" ")
+	assert.Contains(t, lines[1], "\"∆requests\" 1 "+itoa(ts)+" ")
+	// spans and events are sent in milliseconds.
+	assert.Contains(t, lines[2], " 1533529977000 10\n")
+	assert.Contains(t, lines[3], "1533529977000")
+	assert.Contains(t, lines[3], "1533529978000")
+}
+
+func TestTimestamps_Millis(t *testing.T) {
+	sender, err := NewValidatingSender(Timestamps(TimestampMillis))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	now := time.Now()
+	ts := TimestampMillis.Timestamp(now)
+	require.NoError(t, sender.SendMetric("cpu", 1, ts, "web-01", nil))
+	require.NoError(t, sender.SendDeltaCounterWithTimestamp("requests", 1, ts, "web-01", nil))
+	require.NoError(t, sender.SendMetric("cpu", 1, 0, "web-01", nil))
+
+	assert.EqualError(t, sender.SendMetric("cpu", 1, now.Unix(), "web-01", nil),
+		"timestamp "+itoa(now.Unix())+" is not in milliseconds")
+	assert.Error(t, sender.SendDeltaCounterWithTimestamp("requests", 1, now.Unix(), "web-01", nil))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "\"cpu\" 1 "+itoa(ts)+" ")
+	assert.Contains(t, lines[1], "\"∆requests\" 1 "+itoa(ts)+" ")
+}
+
+func TestTimestamps_AutoKeepsTimestamps(t *testing.T) {
+	sender, err := NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("cpu", 1, 1533529977, "web-01", nil))
+	require.NoError(t, sender.SendMetric("cpu", 1, 1533529977000, "web-01", nil))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], " 1533529977 ")
+	assert.Contains(t, lines[1], " 1533529977000 ")
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
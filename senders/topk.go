@@ -0,0 +1,212 @@
+package senders
+
+import (
+	"container/heap"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics reported by TopK, tagged with the metric name or tag key and its rank, 1 being the
+// heaviest hitter.
+const (
+	TopKMetricPoints = "~sdk.topk.metric.points"
+	TopKTagKeyPoints = "~sdk.topk.tag_key.points"
+)
+
+const (
+	defaultTopKInterval = time.Minute
+	// the analyzer tracks topKCapacity times k candidates, for the counts of the top k to be accurate.
+	topKCapacity = 10
+)
+
+// TopK finds the instrumentation responsible for cost spikes: it tracks the k metric names, and
+// the k point tag keys, sending the most metric and distribution points, and reports them every
+// interval, 1 minute if zero, as the ~sdk.topk.metric.points and ~sdk.topk.tag_key.points
+// metrics, tagged with the metric name or the tag key, and its rank. Counts are estimated with
+// bounded memory, over each interval.
+func TopK(k int, interval time.Duration) Option {
+	return func(cfg *configuration) {
+		cfg.TopK = k
+		cfg.TopKInterval = interval
+	}
+}
+
+// topKAnalyzer counts the points of each metric name and tag key, and reports the top k.
+type topKAnalyzer struct {
+	k        int
+	interval time.Duration
+	send     func(name string, value float64, tags map[string]string) error
+
+	mtx     sync.Mutex
+	names   *spaceSaving
+	tagKeys *spaceSaving
+
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+func newTopKAnalyzer(k int, interval time.Duration, send func(string, float64, map[string]string) error) *topKAnalyzer {
+	if interval <= 0 {
+		interval = defaultTopKInterval
+	}
+	return &topKAnalyzer{
+		k:        k,
+		interval: interval,
+		send:     send,
+		names:    newSpaceSaving(k * topKCapacity),
+		tagKeys:  newSpaceSaving(k * topKCapacity),
+		stop:     make(chan struct{}),
+	}
+}
+
+func (a *topKAnalyzer) Start() {
+	if a == nil || a.ticker != nil {
+		return
+	}
+	a.ticker = time.NewTicker(a.interval)
+	go func() {
+		for {
+			select {
+			case <-a.ticker.C:
+				a.report()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (a *topKAnalyzer) Stop() {
+	if a == nil || a.ticker == nil {
+		return
+	}
+	a.ticker.Stop()
+	a.stop <- struct{}{}
+}
+
+// observe counts a point. The points of the internal metrics are left out.
+func (a *topKAnalyzer) observe(name string, tags map[string]string) {
+	if a == nil || strings.Contains(name, "~sdk.") {
+		return
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.names.add(name)
+	for key := range tags {
+		a.tagKeys.add(key)
+	}
+}
+
+// report sends the top k of the interval and starts a new one.
+func (a *topKAnalyzer) report() {
+	a.mtx.Lock()
+	names := a.names.top(a.k)
+	tagKeys := a.tagKeys.top(a.k)
+	a.names.reset()
+	a.tagKeys.reset()
+	a.mtx.Unlock()
+
+	for i, e := range names {
+		a.sendEntry(TopKMetricPoints, "metric", i+1, e)
+	}
+	for i, e := range tagKeys {
+		a.sendEntry(TopKTagKeyPoints, "tag_key", i+1, e)
+	}
+}
+
+func (a *topKAnalyzer) sendEntry(metric, tag string, rank int, e spaceSavingEntry) {
+	err := a.send(metric, float64(e.count), map[string]string{tag: e.key, "rank": strconv.Itoa(rank)})
+	if err != nil {
+		log.Printf("unable to report top %d %s: %s\n", a.k, tag, err)
+	}
+}
+
+// spaceSaving estimates the most frequent keys with a bounded number of counters, with the
+// Space-Saving algorithm: a new key takes over the counter of the least frequent key, and
+// inherits its count, so that counts are overestimated by at most the count of that key.
+type spaceSaving struct {
+	capacity int
+	entries  map[string]*spaceSavingEntry
+	heap     spaceSavingHeap
+}
+
+type spaceSavingEntry struct {
+	key   string
+	count int64
+	index int
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, entries: map[string]*spaceSavingEntry{}}
+}
+
+func (s *spaceSaving) add(key string) {
+	if e, ok := s.entries[key]; ok {
+		e.count++
+		heap.Fix(&s.heap, e.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		e := &spaceSavingEntry{key: key, count: 1}
+		heap.Push(&s.heap, e)
+		s.entries[key] = e
+		return
+	}
+	min := s.heap[0]
+	delete(s.entries, min.key)
+	min.key = key
+	min.count++
+	s.entries[key] = min
+	heap.Fix(&s.heap, 0)
+}
+
+// top returns the k most frequent keys, most frequent first.
+func (s *spaceSaving) top(k int) []spaceSavingEntry {
+	entries := make([]spaceSavingEntry, 0, len(s.heap))
+	for _, e := range s.heap {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].key < entries[j].key
+	})
+	if len(entries) > k {
+		entries = entries[:k]
+	}
+	return entries
+}
+
+func (s *spaceSaving) reset() {
+	s.entries = map[string]*spaceSavingEntry{}
+	s.heap = nil
+}
+
+// spaceSavingHeap is a min-heap of entries by count.
+type spaceSavingHeap []*spaceSavingEntry
+
+func (h spaceSavingHeap) Len() int           { return len(h) }
+func (h spaceSavingHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h spaceSavingHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *spaceSavingHeap) Push(x interface{}) {
+	e := x.(*spaceSavingEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *spaceSavingHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
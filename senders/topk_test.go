@@ -0,0 +1,64 @@
+package senders
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpaceSaving(t *testing.T) {
+	s := newSpaceSaving(10)
+	for i := 0; i < 100; i++ {
+		s.add("heavy")
+		if i%2 == 0 {
+			s.add("medium")
+		}
+		s.add(fmt.Sprintf("rare-%d", i))
+	}
+	top := s.top(2)
+	require.Len(t, top, 2)
+	assert.Equal(t, "heavy", top[0].key)
+	assert.Equal(t, int64(100), top[0].count)
+	assert.Equal(t, "medium", top[1].key)
+	assert.GreaterOrEqual(t, top[1].count, int64(50))
+
+	s.reset()
+	assert.Empty(t, s.top(2))
+}
+
+func TestTopKAnalyzer(t *testing.T) {
+	type point struct {
+		name  string
+		value float64
+		tags  map[string]string
+	}
+	var sent []point
+	a := newTopKAnalyzer(2, 0, func(name string, value float64, tags map[string]string) error {
+		sent = append(sent, point{name, value, tags})
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		a.observe("requests", map[string]string{"env": "prod", "user_id": fmt.Sprint(i)})
+	}
+	a.observe("errors", map[string]string{"env": "prod"})
+	a.observe("latency", nil)
+	a.observe("~sdk.go.core.sender.direct.points.valid", map[string]string{"pid": "1"})
+	a.report()
+
+	assert.Equal(t, []point{
+		{TopKMetricPoints, 3, map[string]string{"metric": "requests", "rank": "1"}},
+		{TopKMetricPoints, 1, map[string]string{"metric": "errors", "rank": "2"}},
+		{TopKTagKeyPoints, 4, map[string]string{"tag_key": "env", "rank": "1"}},
+		{TopKTagKeyPoints, 3, map[string]string{"tag_key": "user_id", "rank": "2"}},
+	}, sent)
+
+	sent = nil
+	a.report()
+	assert.Empty(t, sent, "counts are reset on each report")
+
+	var nilAnalyzer *topKAnalyzer
+	nilAnalyzer.observe("foo", nil)
+}
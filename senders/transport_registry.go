@@ -0,0 +1,127 @@
+package senders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/auth"
+)
+
+// Transport delivers batches of lines for a sender created with a URL whose scheme was registered
+// with Register. Senders keep batching, buffering and retrying as usual: a batch for which Send
+// returns an error is buffered and sent again on a later flush.
+//
+// format is one of "wavefront" (metrics and delta counters), "histogram", "trace", "spanLogs"
+// or "event", and lines holds newline terminated lines in that format. lines is reused once
+// Send returns and must not be retained. Send may be called concurrently for different formats.
+// A Transport also implementing io.Closer is closed when the sender is closed.
+type Transport interface {
+	Send(format string, lines []byte) error
+}
+
+// TransportFactory creates the Transport of a sender from its URL, e.g. kafka://broker:9092?topic=wf.
+type TransportFactory func(u *url.URL) (Transport, error)
+
+var transports = struct {
+	sync.RWMutex
+	factories map[string]TransportFactory
+}{factories: map[string]TransportFactory{}}
+
+// Register makes NewSender resolve URLs with the given scheme through factory, allowing other
+// modules to contribute transports, typically from an init function:
+//
+//	func init() {
+//		senders.Register("kafka", newKafkaTransport)
+//	}
+//
+// Schemes are case-insensitive. Register panics if factory is nil, if scheme is http or https,
+// or if it is called twice for the same scheme, including udp and tcp, registered by this package to
+// send lines to a proxy over UDP and TCP.
+func Register(scheme string, factory TransportFactory) {
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("senders: Register factory is nil")
+	}
+	if scheme == "http" || scheme == "https" {
+		panic("senders: Register cannot override the " + scheme + " scheme")
+	}
+
+	transports.Lock()
+	defer transports.Unlock()
+	if _, dup := transports.factories[scheme]; dup {
+		panic("senders: Register called twice for scheme " + scheme)
+	}
+	transports.factories[scheme] = factory
+}
+
+// Transports returns the sorted list of registered schemes.
+func Transports() []string {
+	transports.RLock()
+	defer transports.RUnlock()
+	schemes := make([]string, 0, len(transports.factories))
+	for scheme := range transports.factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+func lookupTransport(scheme string) (TransportFactory, bool) {
+	transports.RLock()
+	defer transports.RUnlock()
+	factory, ok := transports.factories[strings.ToLower(scheme)]
+	return factory, ok
+}
+
+func transportEndpoint(transportURL string, cfg *configuration) (endpoint, error) {
+	u, err := url.Parse(transportURL)
+	if err != nil {
+		return endpoint{}, err
+	}
+	factory, ok := lookupTransport(u.Scheme)
+	if !ok {
+		return endpoint{}, fmt.Errorf("no transport registered for scheme '%s'", u.Scheme)
+	}
+	if cfg.Protocol != "" && cfg.Protocol != ProtocolWavefront {
+		return endpoint{}, fmt.Errorf("the %s protocol is not supported by the '%s' transport", cfg.Protocol, u.Scheme)
+	}
+	transport, err := factory(u)
+	if err != nil {
+		return endpoint{}, fmt.Errorf("unable to create '%s' transport: %s", u.Scheme, err)
+	}
+
+	reporter := &transportReporter{transport: transport}
+	return endpoint{
+		tokenService: auth.NewNoopTokenService(),
+		transport:    transport,
+		newReporters: func(...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			return reporter, reporter
+		},
+	}, nil
+}
+
+// transportReporter adapts a Transport to the Reporter used by line handlers.
+type transportReporter struct {
+	transport Transport
+}
+
+func (r *transportReporter) Report(format string, pointLines []byte) (*http.Response, error) {
+	if err := r.transport.Send(format, pointLines); err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+}
+
+// closeTransport closes the transport of ep, if any and closable.
+func closeTransport(ep endpoint) {
+	if closer, ok := ep.transport.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
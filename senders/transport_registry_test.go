@@ -0,0 +1,91 @@
+package senders
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTransport struct {
+	mtx    sync.Mutex
+	url    *url.URL
+	lines  map[string][]string
+	fail   bool
+	closed bool
+}
+
+func (t *recordingTransport) Send(format string, lines []byte) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.fail {
+		return fmt.Errorf("broker unavailable")
+	}
+	t.lines[format] = append(t.lines[format], string(lines))
+	return nil
+}
+
+func (t *recordingTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+var testTransport = &recordingTransport{lines: map[string][]string{}}
+
+func init() {
+	Register("MemTest", func(u *url.URL) (Transport, error) {
+		if u.Query().Get("topic") == "" {
+			return nil, fmt.Errorf("missing topic")
+		}
+		testTransport.url = u
+		return testTransport, nil
+	})
+}
+
+func TestRegisteredTransport(t *testing.T) {
+	testTransport.lines = map[string][]string{}
+	testTransport.closed = false
+	sender, err := NewSender("memtest://broker:9092?topic=wf", SendInternalMetrics(false))
+	require.NoError(t, err)
+	assert.Equal(t, "broker:9092", testTransport.url.Host)
+
+	require.NoError(t, sender.SendMetric("my.metric", 1, 1700000000, "localhost", nil))
+	require.NoError(t, sender.SendSpan("op", 1700000000000, 10, "localhost",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459",
+		nil, nil, nil, nil))
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, []string{"\"my.metric\" 1 1700000000 source=\"localhost\"\n"}, testTransport.lines["wavefront"])
+	assert.Len(t, testTransport.lines["trace"], 1)
+
+	testTransport.fail = true
+	require.NoError(t, sender.SendMetric("my.metric", 2, 1700000000, "localhost", nil))
+	assert.Error(t, sender.Flush())
+	testTransport.fail = false
+	require.NoError(t, sender.Flush())
+	assert.Len(t, testTransport.lines["wavefront"], 2)
+
+	assert.Error(t, sender.(Reconfigurable).Reconfigure("http://localhost:2878"))
+	sender.Close()
+	assert.True(t, testTransport.closed)
+}
+
+func TestRegisteredTransportErrors(t *testing.T) {
+	_, err := NewSender("memtest://broker:9092")
+	assert.ErrorContains(t, err, "missing topic")
+	_, err = NewSender("memtest://broker:9092?topic=wf", Protocol(ProtocolOTLP))
+	assert.Error(t, err)
+	_, err = NewSender("kafka://broker:9092")
+	assert.ErrorContains(t, err, "invalid scheme")
+
+	assert.Contains(t, Transports(), "memtest")
+	assert.Panics(t, func() { Register("memtest", testTransportFactory) })
+	assert.Panics(t, func() { Register("HTTPS", testTransportFactory) })
+	assert.Panics(t, func() { Register("other", nil) })
+}
+
+func testTransportFactory(*url.URL) (Transport, error) {
+	return testTransport, nil
+}
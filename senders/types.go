@@ -1,6 +1,8 @@
 package senders
 
 import (
+	"context"
+
 	"github.com/wavefronthq/wavefront-sdk-go/event"
 	"github.com/wavefronthq/wavefront-sdk-go/histogram"
 )
@@ -13,6 +15,44 @@ type MetricSender interface {
 	// SendDeltaCounter sends a delta counter (counter aggregated at the Wavefront service) to Wavefront.
 	// the timestamp for a delta counter is assigned at the server side.
 	SendDeltaCounter(name string, value float64, source string, tags map[string]string) error
+
+	// SendDeltaCounterWithTimestamp sends a delta counter with a client-supplied timestamp, in epoch
+	// seconds or in the unit set with Timestamps, e.g. when backfilling. A zero ts is assigned at the server side, like SendDeltaCounter.
+	// Timestamps further from now than the allowed skew (see DeltaCounterTimestampSkew) return an error.
+	SendDeltaCounterWithTimestamp(name string, value float64, ts int64, source string, tags map[string]string) error
+}
+
+// TypedMetricSender Interface for sending metrics with non-float64 values to Wavefront
+type TypedMetricSender interface {
+	// SendIntMetric sends a single metric with an integer value.
+	SendIntMetric(name string, value int64, ts int64, source string, tags map[string]string) error
+
+	// SendBoolMetric sends a single metric with a value of 1 for true and 0 for false.
+	SendBoolMetric(name string, value bool, ts int64, source string, tags map[string]string) error
+
+	// SendAny sends a single metric whose value is any Go integer, float or bool type.
+	// time.Duration values are sent in milliseconds. Other types return an error.
+	SendAny(name string, value interface{}, ts int64, source string, tags map[string]string) error
+}
+
+// FlushReporter Interface for flushing data with a detailed outcome.
+type FlushReporter interface {
+	// FlushWithReport synchronously sends all the buffered data, whereas Flush sends at most
+	// one batch per data type, and returns the number of lines sent, buffered again and dropped,
+	// and the errors, of each data type, so that partial failures can be told apart.
+	FlushWithReport() FlushReport
+}
+
+// ContextSender Interface for sending data to Wavefront with a context.
+// Unlike their context-less counterparts, which fail right away when the sender's buffer
+// is full, these methods wait for room in the buffer until ctx is canceled or times out,
+// and then return ctx.Err(). They return ctx.Err() as well when ctx is already done.
+type ContextSender interface {
+	SendMetricCtx(ctx context.Context, name string, value float64, ts int64, source string, tags map[string]string) error
+	SendDeltaCounterCtx(ctx context.Context, name string, value float64, source string, tags map[string]string) error
+	SendDistributionCtx(ctx context.Context, name string, centroids []histogram.Centroid, hgs map[histogram.Granularity]bool, ts int64, source string, tags map[string]string) error
+	SendSpanCtx(ctx context.Context, name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error
+	SendEventCtx(ctx context.Context, name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error
 }
 
 // DistributionSender Interface for sending distributions to Wavefront
@@ -35,9 +75,11 @@ type SpanSender interface {
 	SendSpan(name string, startMillis, durationMillis int64, source, traceID, spanID string, parents, followsFrom []string, tags []SpanTag, spanLogs []SpanLog) error
 }
 
-// EventSender Interface for sending events to Wavefront. NOT yet supported.
+// EventSender Interface for sending events to Wavefront.
 type EventSender interface {
-	// SendEvent sends an event to Wavefront with optional tags
+	// SendEvent sends an event to Wavefront with optional tags. Direct ingestion senders post events
+	// in the JSON format of the event API; proxy and OTel report senders send them as @Event lines
+	// of the Wavefront data format. OTLP senders do not support events.
 	SendEvent(name string, startMillis, endMillis int64, source string, tags map[string]string, setters ...event.Option) error
 }
 
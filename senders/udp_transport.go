@@ -0,0 +1,111 @@
+package senders
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+const defaultDatagramSize = 1400
+
+func init() {
+	Register("udp", newUDPTransport)
+}
+
+// udpTransport sends lines to a proxy in UDP datagrams, for a sender created with a udp:// URL:
+//
+//	sender, err := senders.NewSender("udp://proxy:2878?datagramSize=8192&tracesPort=30001")
+//
+// Datagrams hold whole lines, up to datagramSize bytes, 1400 by default so that they fit in the
+// MTU of most networks. Spans and span logs are sent to tracesPort, the port of the URL by default.
+//
+// Delivery is best-effort: datagrams lost on the way and lines longer than datagramSize, which
+// are logged, are not sent again, so that senders never buffer data for an unreachable proxy.
+type udpTransport struct {
+	metrics      net.Conn
+	traces       net.Conn
+	datagramSize int
+}
+
+func newUDPTransport(u *url.URL) (Transport, error) {
+	if u.Port() == "" {
+		return nil, fmt.Errorf("missing port in '%s'", u)
+	}
+	query := u.Query()
+	t := &udpTransport{datagramSize: defaultDatagramSize}
+	if size := query.Get("datagramSize"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid datagramSize '%s'", size)
+		}
+		t.datagramSize = n
+	}
+
+	var err error
+	if t.metrics, err = net.Dial("udp", u.Host); err != nil {
+		return nil, err
+	}
+	t.traces = t.metrics
+	if port := query.Get("tracesPort"); port != "" && port != u.Port() {
+		if t.traces, err = net.Dial("udp", net.JoinHostPort(u.Hostname(), port)); err != nil {
+			_ = t.metrics.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *udpTransport) Send(format string, lines []byte) error {
+	conn := t.metrics
+	if format == "trace" || format == "spanLogs" {
+		conn = t.traces
+	}
+	datagrams, dropped := splitDatagrams(lines, t.datagramSize)
+	for _, datagram := range datagrams {
+		// best-effort: errors such as a refused connection are not worth buffering the lines for.
+		_, _ = conn.Write(datagram)
+	}
+	if dropped > 0 {
+		log.Printf("dropped %d %s lines longer than the %d bytes of a datagram\n", dropped, format, t.datagramSize)
+	}
+	return nil
+}
+
+func (t *udpTransport) Close() error {
+	if t.traces != t.metrics {
+		_ = t.traces.Close()
+	}
+	return t.metrics.Close()
+}
+
+// splitDatagrams splits newline terminated lines into datagrams of whole lines, of at most size
+// bytes, and returns the number of lines dropped for being longer than size on their own.
+// Datagrams share the memory of lines.
+func splitDatagrams(lines []byte, size int) (datagrams [][]byte, dropped int) {
+	start, end := 0, 0
+	for end < len(lines) {
+		next := bytes.IndexByte(lines[end:], '\n') + 1
+		if next == 0 {
+			next = len(lines) - end
+		}
+		switch {
+		case next > size:
+			if end > start {
+				datagrams = append(datagrams, lines[start:end])
+			}
+			dropped++
+			start = end + next
+		case end+next-start > size:
+			datagrams = append(datagrams, lines[start:end])
+			start = end
+		}
+		end += next
+	}
+	if end > start {
+		datagrams = append(datagrams, lines[start:end])
+	}
+	return datagrams, dropped
+}
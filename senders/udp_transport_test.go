@@ -0,0 +1,77 @@
+package senders
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDatagrams(t *testing.T) {
+	lines := []byte("a 1\nbb 2\n" + strings.Repeat("x", 20) + "\nc 3\nd 4\n")
+	datagrams, dropped := splitDatagrams(lines, 10)
+	assert.Equal(t, 1, dropped)
+	var got []string
+	for _, d := range datagrams {
+		assert.LessOrEqual(t, len(d), 10)
+		got = append(got, string(d))
+	}
+	assert.Equal(t, []string{"a 1\nbb 2\n", "c 3\nd 4\n"}, got)
+
+	datagrams, dropped = splitDatagrams([]byte("a 1\nb 2"), 100)
+	assert.Zero(t, dropped)
+	assert.Equal(t, [][]byte{[]byte("a 1\nb 2")}, datagrams)
+}
+
+func TestUDPTransport(t *testing.T) {
+	metrics, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer metrics.Close()
+	traces, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer traces.Close()
+	tracesPort := strconv.Itoa(traces.LocalAddr().(*net.UDPAddr).Port)
+
+	sender, err := NewSender("udp://"+metrics.LocalAddr().String()+"?datagramSize=200&tracesPort="+tracesPort,
+		SendInternalMetrics(false))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sender.SendMetric("cpu", float64(i), 1700000000, "web-01", nil))
+	}
+	require.NoError(t, sender.SendSpan("get", 1700000000000, 10, "web-01",
+		"7b3bf470-9456-11e8-9eb6-529269fb1459", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+	require.NoError(t, sender.Flush())
+
+	var received []string
+	buf := make([]byte, 1024)
+	for len(received) < 3 {
+		require.NoError(t, metrics.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := metrics.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, n, 200)
+		received = append(received, strings.Fields(strings.ReplaceAll(string(buf[:n]), " ", "_"))...)
+	}
+	assert.Equal(t, []string{
+		"\"cpu\"_0_1700000000_source=\"web-01\"",
+		"\"cpu\"_1_1700000000_source=\"web-01\"",
+		"\"cpu\"_2_1700000000_source=\"web-01\"",
+	}, received)
+
+	require.NoError(t, traces.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := traces.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), "\"get\" source=\"web-01\"")
+}
+
+func TestUDPTransport_InvalidURL(t *testing.T) {
+	_, err := NewSender("udp://localhost")
+	assert.Error(t, err)
+	_, err = NewSender("udp://localhost:2878?datagramSize=none")
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package senders
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+)
+
+// ValidatingSender is a Sender that formats and validates the data it is given, as a sender
+// created with StrictValidation would, but never sends it: Send* calls return the validation
+// error of each invalid point right away, and the lines of the valid ones are kept in memory,
+// so that instrumentation can be checked, e.g. in unit tests or QA, before it is shipped.
+type ValidatingSender interface {
+	Sender
+
+	// Lines returns the lines formatted so far, each ending with a newline, in the order they were
+	// sent, across all data types.
+	Lines() []string
+
+	// Reset discards the lines formatted so far.
+	Reset()
+}
+
+type validatingSender struct {
+	*realSender
+	lines *linesHandler
+}
+
+// NewValidatingSender creates a ValidatingSender. Options shaping the lines, such as Enrich,
+// RenameMetric or ValueBounds, apply as with NewSender; options about the connection are ignored.
+// Internal metrics are not reported.
+func NewValidatingSender(setters ...Option) (ValidatingSender, error) {
+	setters = append(setters, StrictValidation(), SendInternalMetrics(false))
+	cfg, err := createConfig("http://localhost", setters...)
+	if err != nil {
+		return nil, err
+	}
+	ep := endpoint{
+		newReporters: func(...internal.ReporterOption) (internal.Reporter, internal.Reporter) {
+			return nopReporter{}, nopReporter{}
+		},
+	}
+	sender := newRealSender(cfg, ep, directEndpoint)
+	lines := &linesHandler{}
+	sender.pointHandler = lines
+	sender.histoHandler = lines
+	sender.spanHandler = lines
+	sender.spanLogHandler = lines
+	sender.eventHandler = lines
+	sender.Start()
+	return &validatingSender{realSender: sender, lines: lines}, nil
+}
+
+func (sender *validatingSender) Lines() []string {
+	return sender.lines.get()
+}
+
+func (sender *validatingSender) Reset() {
+	sender.lines.reset()
+}
+
+// nopReporter reports nothing, for senders that never send.
+type nopReporter struct{}
+
+func (nopReporter) Report(string, []byte) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+// linesHandler keeps the lines it is given in memory.
+type linesHandler struct {
+	mtx   sync.Mutex
+	lines []string
+}
+
+func (h *linesHandler) HandleLine(line string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.lines = append(h.lines, line)
+	return nil
+}
+
+func (h *linesHandler) HandleLineCtx(ctx context.Context, line string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return h.HandleLine(line)
+}
+
+func (h *linesHandler) get() []string {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return append([]string(nil), h.lines...)
+}
+
+func (h *linesHandler) reset() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.lines = nil
+}
+
+func (h *linesHandler) Start()                     {}
+func (h *linesHandler) Stop()                      {}
+func (h *linesHandler) Flush() error               { return nil }
+func (h *linesHandler) FlushWithThrottling() error { return nil }
+func (h *linesHandler) GetFailureCount() int64     { return 0 }
+func (h *linesHandler) Format() string             { return "" }
+
+func (h *linesHandler) FlushAllWithResult() internal.FlushResult {
+	return internal.FlushResult{}
+}
@@ -0,0 +1,69 @@
+package senders
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestValidatingSender(t *testing.T) {
+	sender, err := NewValidatingSender(RenameMetric("old.name", "new.name"))
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.SendMetric("old.name", 1, 1533529977, "test", map[string]string{"env": "dev"}))
+	require.NoError(t, sender.SendDistribution("latency", []histogram.Centroid{{Value: 1, Count: 2}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 1533529977, "test", nil))
+	require.NoError(t, sender.SendEvent("deploy", 1533529977000, 0, "test", nil))
+
+	var validationErr *ValidationError
+	err = sender.SendMetric("bad name", 1, 0, "test", nil)
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "name", validationErr.Field)
+	err = sender.SendSpan("span", 0, 1, "test", "not a uuid", "0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil)
+	assert.Error(t, err)
+
+	require.NoError(t, sender.Flush())
+	lines := sender.Lines()
+	require.Len(t, lines, 3)
+	assert.Equal(t, "\"new.name\" 1 1533529977 source=\"test\" \"env\"=\"dev\"\n", lines[0])
+	assert.Equal(t, "!M 1533529977 #2 1 \"latency\" source=\"test\"\n", lines[1])
+	assert.Contains(t, lines[2], "@Event 1533529977000")
+
+	sender.Reset()
+	assert.Empty(t, sender.Lines())
+	assert.Equal(t, int64(0), sender.GetFailureCount())
+}
+
+func TestValidatingSender_AggregateDeltaCounters(t *testing.T) {
+	sender, err := NewValidatingSender(AggregateDeltaCounters())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, sender.SendDeltaCounter("requests", 1, "test", map[string]string{"env": "dev"}))
+	}
+	require.NoError(t, sender.Flush())
+	assert.Equal(t, []string{"\"∆requests\" 1000 source=\"test\" \"env\"=\"dev\"\n"}, sender.Lines())
+}
+
+func TestValidatingSender_AggregateDeltaCounters_Checks(t *testing.T) {
+	for _, aggregate := range []Option{AggregateDeltaCounters(), MinuteBucketedDeltaCounters()} {
+		sender, err := NewValidatingSender(aggregate, RejectNonFiniteValues(), ValueBounds("", 0, 100))
+		require.NoError(t, err)
+
+		assert.Error(t, sender.SendDeltaCounter("requests", math.Inf(1), "test", nil))
+		assert.Error(t, sender.SendDeltaCounter("requests", math.NaN(), "test", nil))
+		assert.Error(t, sender.SendDeltaCounter("requests", 1000, "test", nil))
+		require.NoError(t, sender.SendDeltaCounter("requests", 1, "test", nil))
+		sender.Close()
+		lines := sender.Lines()
+		require.Len(t, lines, 1)
+		assert.True(t, strings.HasPrefix(lines[0], "\"∆requests\" 1 "))
+	}
+}
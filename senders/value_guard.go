@@ -0,0 +1,175 @@
+package senders
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
+	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
+)
+
+type nonFinitePolicy int
+
+const (
+	nonFiniteAllow nonFinitePolicy = iota
+	nonFiniteReject
+	nonFiniteDrop
+	nonFiniteReplace
+)
+
+// RejectNonFiniteValues makes the sender refuse NaN and ±Inf metric and centroid values
+// with an error returned to the caller instead of sending them.
+// Rejections are counted by the points.non_finite internal metric.
+func RejectNonFiniteValues() Option {
+	return func(cfg *configuration) {
+		cfg.NonFinitePolicy = nonFiniteReject
+	}
+}
+
+// DropNonFiniteValues makes the sender silently discard NaN and ±Inf metric values.
+// Distributions only lose their non-finite centroids, and are discarded when none are left.
+// Dropped values are counted by the points.non_finite internal metric.
+func DropNonFiniteValues() Option {
+	return func(cfg *configuration) {
+		cfg.NonFinitePolicy = nonFiniteDrop
+	}
+}
+
+// ReplaceNonFiniteValues makes the sender send sentinel in place of NaN and ±Inf
+// metric and centroid values. Replaced values are counted by the points.non_finite internal metric.
+func ReplaceNonFiniteValues(sentinel float64) Option {
+	return func(cfg *configuration) {
+		cfg.NonFinitePolicy = nonFiniteReplace
+		cfg.NonFiniteSentinel = sentinel
+	}
+}
+
+// ValueBounds makes the sender refuse metric and centroid values outside [min, max]
+// for metrics whose name starts with prefix. When several prefixes match a metric,
+// the longest one wins. Rejections are counted by the points.out_of_bounds internal metric.
+func ValueBounds(prefix string, min, max float64) Option {
+	return func(cfg *configuration) {
+		cfg.ValueBounds = append(cfg.ValueBounds, valueBound{prefix: prefix, min: min, max: max})
+	}
+}
+
+type valueBound struct {
+	prefix string
+	min    float64
+	max    float64
+}
+
+type valueGuard struct {
+	nonFinitePolicy nonFinitePolicy
+	sentinel        float64
+	bounds          []valueBound
+
+	nonFinite   *sdkmetrics.DeltaCounter
+	outOfBounds *sdkmetrics.DeltaCounter
+}
+
+func newValueGuard(cfg *configuration, registry sdkmetrics.Registry) *valueGuard {
+	if cfg.NonFinitePolicy == nonFiniteAllow && len(cfg.ValueBounds) == 0 {
+		return nil
+	}
+	return &valueGuard{
+		nonFinitePolicy: cfg.NonFinitePolicy,
+		sentinel:        cfg.NonFiniteSentinel,
+		bounds:          cfg.ValueBounds,
+		nonFinite:       registry.NewDeltaCounter(sdkmetrics.PointsNonFinite),
+		outOfBounds:     registry.NewDeltaCounter(sdkmetrics.PointsOutOfBounds),
+	}
+}
+
+// apply returns the value to send for the named metric, and whether it should be sent at all.
+// An error is returned if the value must be refused. Dropped and refused values are counted in
+// dropped. A nil guard accepts every value as is.
+func (g *valueGuard) apply(name string, value float64, dropped *dropCounters) (float64, bool, error) {
+	if g == nil {
+		return value, true, nil
+	}
+	value, reason, err := g.check(name, value)
+	if reason != dropNone {
+		dropped.inc(reason)
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// check returns the value to send for the named metric, or the reason it must be dropped,
+// along with an error if it must be refused.
+func (g *valueGuard) check(name string, value float64) (float64, dropReason, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		switch g.nonFinitePolicy {
+		case nonFiniteReject:
+			g.nonFinite.Inc()
+			return value, dropNonFinite, fmt.Errorf("non-finite value %v rejected: metric=%s", value, name)
+		case nonFiniteDrop:
+			g.nonFinite.Inc()
+			return value, dropNonFinite, nil
+		case nonFiniteReplace:
+			g.nonFinite.Inc()
+			value = g.sentinel
+		}
+	}
+	if bound, ok := g.boundFor(name); ok && (value < bound.min || value > bound.max) {
+		g.outOfBounds.Inc()
+		return value, dropOutOfBounds, fmt.Errorf("value %v outside of [%v, %v] rejected: metric=%s", value, bound.min, bound.max, name)
+	}
+	return value, dropNone, nil
+}
+
+// applyCentroids is the distribution equivalent of apply. The centroids slice is copied
+// before any value is replaced or dropped. Only the distributions dropped as a whole are
+// counted in dropped.
+func (g *valueGuard) applyCentroids(name string, centroids []histogram.Centroid, dropped *dropCounters) ([]histogram.Centroid, bool, error) {
+	if g == nil {
+		return centroids, true, nil
+	}
+	result := centroids
+	copied := false
+	for i, centroid := range centroids {
+		value, reason, err := g.check(name, centroid.Value)
+		if err != nil {
+			dropped.inc(reason)
+			return nil, false, err
+		}
+		send := reason == dropNone
+		if send && value == centroid.Value {
+			if copied {
+				result = append(result, centroid)
+			}
+			continue
+		}
+		if !copied {
+			result = append(make([]histogram.Centroid, 0, len(centroids)), centroids[:i]...)
+			copied = true
+		}
+		if send {
+			result = append(result, histogram.Centroid{Value: value, Count: centroid.Count})
+		}
+	}
+	if len(result) == 0 {
+		if copied {
+			// only non-finite centroids are dropped without an error.
+			dropped.inc(dropNonFinite)
+		}
+		return result, false, nil
+	}
+	return result, true, nil
+}
+
+func (g *valueGuard) boundFor(name string) (valueBound, bool) {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, internal.DeltaPrefix), internal.AltDeltaPrefix)
+	var result valueBound
+	found := false
+	for _, bound := range g.bounds {
+		if strings.HasPrefix(name, bound.prefix) && (!found || len(bound.prefix) > len(result.prefix)) {
+			result = bound
+			found = true
+		}
+	}
+	return result, found
+}
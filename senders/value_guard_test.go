@@ -0,0 +1,111 @@
+package senders
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+)
+
+func TestValueGuard_Disabled(t *testing.T) {
+	cfg, err := createConfig("https://localhost")
+	assert.NoError(t, err)
+	guard := newValueGuard(cfg, &mockRegistry{})
+	assert.Nil(t, guard)
+	_, send, err := guard.apply("foo", math.NaN(), nil)
+	assert.NoError(t, err)
+	assert.True(t, send)
+}
+
+func TestValueGuard_RejectNonFinite(t *testing.T) {
+	registry := &mockRegistry{}
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.internalRegistry = registry
+	cfg, err := createConfig("https://localhost", RejectNonFiniteValues())
+	assert.NoError(t, err)
+	sender.valueGuard = newValueGuard(cfg, registry)
+	sender.pointsDropped = newDropCounters(registry, "points")
+	sender.histogramsDropped = newDropCounters(registry, "histograms")
+
+	assert.Error(t, sender.SendMetric("foo", math.NaN(), 0, "test", nil))
+	assert.Error(t, sender.SendMetric("foo", math.Inf(-1), 0, "test", nil))
+	assert.Error(t, sender.SendDeltaCounter("foo", math.Inf(1), "test", nil))
+	assert.NoError(t, sender.SendMetric("foo", 1, 0, "test", nil))
+	assert.Len(t, pointHandler.Lines, 1)
+	assert.Equal(t, int64(3), registry.deltaCounters["points.non_finite"].Count())
+	assert.Equal(t, 3, registry.PointsTracker().(*simpleTracker).invalid)
+
+	hgs := map[histogram.Granularity]bool{histogram.MINUTE: true}
+	assert.Error(t, sender.SendDistribution("foo", []histogram.Centroid{{Value: math.NaN(), Count: 1}}, hgs, 0, "test", nil))
+	assert.Equal(t, 1, registry.HistogramsTracker().(*simpleTracker).invalid)
+	assert.Equal(t, int64(3), registry.deltaCounters["points.dropped.non_finite"].Count())
+	assert.Equal(t, int64(1), registry.deltaCounters["histograms.dropped.non_finite"].Count())
+}
+
+func TestValueGuard_Bounds(t *testing.T) {
+	registry := &mockRegistry{}
+	cfg, err := createConfig("https://localhost",
+		ValueBounds("cpu.", 0, 100),
+		ValueBounds("cpu.load.", 0, 1000),
+	)
+	assert.NoError(t, err)
+	guard := newValueGuard(cfg, registry)
+
+	assert.NoError(t, guardErr(guard, "cpu.usage", 50))
+	assert.Error(t, guardErr(guard, "cpu.usage", 101))
+	assert.Error(t, guardErr(guard, "cpu.usage", -1))
+	assert.NoError(t, guardErr(guard, "cpu.load.avg", 500))
+	assert.Error(t, guardErr(guard, "∆cpu.usage", 101))
+	assert.NoError(t, guardErr(guard, "memory.used", 1e12))
+	assert.NoError(t, guardErr(guard, "cpu.usage", math.NaN()), "NaN is only rejected by RejectNonFiniteValues")
+	assert.Equal(t, int64(3), registry.deltaCounters["points.out_of_bounds"].Count())
+}
+
+func TestValueGuard_DropNonFinite(t *testing.T) {
+	registry := &mockRegistry{}
+	pointHandler := &mockHandler{}
+	histoHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.histoHandler = histoHandler
+	sender.internalRegistry = registry
+	cfg, err := createConfig("https://localhost", DropNonFiniteValues())
+	assert.NoError(t, err)
+	sender.valueGuard = newValueGuard(cfg, registry)
+	sender.pointsDropped = newDropCounters(registry, "points")
+	sender.histogramsDropped = newDropCounters(registry, "histograms")
+
+	assert.NoError(t, sender.SendMetric("foo", math.NaN(), 0, "test", nil))
+	assert.Empty(t, pointHandler.Lines)
+	assert.Equal(t, 0, registry.PointsTracker().(*simpleTracker).invalid)
+
+	hgs := map[histogram.Granularity]bool{histogram.MINUTE: true}
+	centroids := []histogram.Centroid{{Value: math.Inf(1), Count: 1}, {Value: 2, Count: 3}}
+	assert.NoError(t, sender.SendDistribution("foo", centroids, hgs, 0, "test", nil))
+	assert.Equal(t, "!M #3 2 \"foo\" source=\"test\"\n", histoHandler.Lines[0])
+	assert.True(t, math.IsInf(centroids[0].Value, 1), "caller centroids must not be modified")
+
+	assert.NoError(t, sender.SendDistribution("foo", centroids[:1], hgs, 0, "test", nil))
+	assert.Len(t, histoHandler.Lines, 1)
+	assert.Equal(t, int64(3), registry.deltaCounters["points.non_finite"].Count())
+	assert.Equal(t, int64(1), registry.deltaCounters["points.dropped.non_finite"].Count())
+	// only the distribution left without centroids counts as dropped.
+	assert.Equal(t, int64(1), registry.deltaCounters["histograms.dropped.non_finite"].Count())
+}
+
+func TestValueGuard_ReplaceNonFinite(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	cfg, err := createConfig("https://localhost", ReplaceNonFiniteValues(-1))
+	assert.NoError(t, err)
+	sender.valueGuard = newValueGuard(cfg, sender.internalRegistry)
+
+	assert.NoError(t, sender.SendMetric("foo", math.NaN(), 0, "test", nil))
+	assert.Equal(t, "\"foo\" -1 source=\"test\"\n", pointHandler.Lines[0])
+}
+
+func guardErr(guard *valueGuard, name string, value float64) error {
+	_, _, err := guard.apply(name, value, nil)
+	return err
+}
@@ -1,11 +1,15 @@
 package senders
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/internal"
 	"github.com/wavefronthq/wavefront-sdk-go/internal/sdkmetrics"
 )
 
@@ -331,6 +335,13 @@ func (m *mockHandler) HandleLine(line string) error {
 	return m.Error
 }
 
+func (m *mockHandler) HandleLineCtx(ctx context.Context, line string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.HandleLine(line)
+}
+
 func (m *mockHandler) Start() {
 }
 
@@ -345,6 +356,13 @@ func (m *mockHandler) FlushWithThrottling() error {
 	return m.Flush()
 }
 
+func (m *mockHandler) FlushAllWithResult() internal.FlushResult {
+	if m.Error != nil {
+		return internal.FlushResult{Buffered: len(m.Lines), Err: m.Error}
+	}
+	return internal.FlushResult{Sent: len(m.Lines)}
+}
+
 func (m *mockHandler) GetFailureCount() int64 {
 	return 0
 }
@@ -373,6 +391,7 @@ func (s *simpleTracker) IncDropped() {
 }
 
 type mockRegistry struct {
+	deltaCounters     map[string]*sdkmetrics.DeltaCounter
 	pointsTracker     *simpleTracker
 	histogramsTracker *simpleTracker
 	spansTracker      *simpleTracker
@@ -424,6 +443,104 @@ func (m *mockRegistry) EventsTracker() sdkmetrics.SuccessTracker {
 	return m.eventsTracker
 }
 
+func (m *mockRegistry) SendOnce(string, float64, map[string]string) {
+}
+
 func (m *mockRegistry) NewGauge(string, func() int64) *sdkmetrics.FunctionalGauge {
 	return &sdkmetrics.FunctionalGauge{}
 }
+
+func (m *mockRegistry) NewDeltaCounter(name string) *sdkmetrics.DeltaCounter {
+	if m.deltaCounters == nil {
+		m.deltaCounters = map[string]*sdkmetrics.DeltaCounter{}
+	}
+	if _, ok := m.deltaCounters[name]; !ok {
+		m.deltaCounters[name] = &sdkmetrics.DeltaCounter{}
+	}
+	return m.deltaCounters[name]
+}
+
+func TestWavefrontSender_SendDeltaCounter_MinuteBuckets(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.deltaAggregator = internal.NewDeltaAggregator(time.Minute, time.Hour, sender.emitDeltaPoint)
+
+	assert.NoError(t, sender.SendDeltaCounter("foo", 1, "test", nil))
+	assert.NoError(t, sender.SendDeltaCounter("foo", 2, "test", nil))
+	assert.Empty(t, pointHandler.Lines)
+
+	// the current minute is sent once over, or on close.
+	assert.NoError(t, sender.Flush())
+	assert.Empty(t, pointHandler.Lines)
+	sender.deltaAggregator.Stop()
+	assert.Len(t, pointHandler.Lines, 1)
+	assert.Regexp(t, "^\"∆foo\" 3 [0-9]+ source=\"test\"\n$", pointHandler.Lines[0])
+}
+
+func TestWavefrontSender_SendDeltaCounterWithTimestamp(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	ts := time.Now().Add(-10 * time.Minute).Unix()
+
+	assert.NoError(t, sender.SendDeltaCounterWithTimestamp("foo", 1, ts, "test", nil))
+	assert.Equal(t, fmt.Sprintf("\"∆foo\" 1 %d source=\"test\"\n", ts), pointHandler.Lines[0])
+	assert.NoError(t, sender.SendDeltaCounterWithTimestamp("foo", 1, 0, "test", nil))
+	assert.Equal(t, "\"∆foo\" 1 source=\"test\"\n", pointHandler.Lines[1])
+
+	assert.Error(t, sender.SendDeltaCounterWithTimestamp("foo", 1, time.Now().Add(-2*time.Hour).Unix(), "test", nil))
+	assert.Error(t, sender.SendDeltaCounterWithTimestamp("foo", 1, time.Now().Add(2*time.Hour).Unix(), "test", nil))
+	assert.Len(t, pointHandler.Lines, 2)
+
+	sender.deltaCounterSkew = 24 * time.Hour
+	assert.NoError(t, sender.SendDeltaCounterWithTimestamp("foo", 1, time.Now().Add(-2*time.Hour).Unix(), "test", nil))
+	assert.Len(t, pointHandler.Lines, 3)
+}
+
+func TestWavefrontSender_SendDeltaCounterWithTimestamp_MinuteBuckets(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	sender.deltaAggregator = internal.NewDeltaAggregator(time.Minute, time.Hour, sender.emitDeltaPoint)
+	past := time.Now().Add(-30 * time.Minute)
+
+	assert.NoError(t, sender.SendDeltaCounterWithTimestamp("foo", 1, past.Unix(), "test", nil))
+	assert.NoError(t, sender.SendDeltaCounterWithTimestamp("foo", 2, past.Unix(), "test", nil))
+	assert.NoError(t, sender.Flush())
+	assert.Equal(t, fmt.Sprintf("\"∆foo\" 3 %d source=\"test\"\n", past.Truncate(time.Minute).Unix()), pointHandler.Lines[0])
+}
+
+func TestWavefrontSender_ContextSends(t *testing.T) {
+	pointHandler := &mockHandler{}
+	sender := newMockSender(pointHandler)
+	ctx := context.Background()
+
+	assert.NoError(t, sender.SendMetricCtx(ctx, "foo", 1, 0, "", nil))
+	assert.NoError(t, sender.SendDeltaCounterCtx(ctx, "foo", 1, "", nil))
+	assert.NoError(t, sender.SendDistributionCtx(ctx, "foo", []histogram.Centroid{{Value: 1, Count: 1}},
+		map[histogram.Granularity]bool{histogram.MINUTE: true}, 0, "", nil))
+	assert.NoError(t, sender.SendSpanCtx(ctx, "foo", 0, 1, "", "7b3bf470-9456-11e8-9eb6-529269fb1459",
+		"0313bafe-9457-11e8-9eb6-529269fb1459", nil, nil, nil, nil))
+	assert.NoError(t, sender.SendEventCtx(ctx, "foo", 0, 1, "", nil))
+	assert.Len(t, pointHandler.Lines, 2)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, sender.SendMetricCtx(canceled, "foo", 1, 0, "", nil), context.Canceled)
+	assert.Len(t, pointHandler.Lines, 2)
+}
+
+func TestWavefrontSender_ContextSendsAfterClose(t *testing.T) {
+	server := startTestServer(false)
+	defer server.Close()
+	sender, err := NewSender(server.URL, SendInternalMetrics(false))
+	require.NoError(t, err)
+	sender.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- sender.SendMetricCtx(context.Background(), "foo", 1, 0, "", nil) }()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("SendMetricCtx blocked after Close")
+	}
+}
@@ -0,0 +1,211 @@
+// Package tracecontext converts between the W3C Trace Context headers, traceparent and tracestate
+// (https://www.w3.org/TR/trace-context/), and the UUID trace and span IDs of senders.SpanSender, so
+// that spans of services using standard propagation can be sent without custom conversion code.
+//
+//	parent, err := tracecontext.FromHeaders(req.Header)
+//	span := parent.Child()
+//	...
+//	sender.SendSpan("checkout", start, duration, "web-01", span.TraceID, span.SpanID,
+//		[]string{parent.SpanID}, nil, tags, nil)
+//	span.Inject(outgoing.Header)
+//
+// A W3C trace ID is the 16 bytes of the UUID trace ID, and a W3C span ID the last 8 bytes of the
+// UUID span ID, whose first 8 bytes are zero when converted from a W3C span ID: the IDs sent over
+// OTLP are the same.
+package tracecontext
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The names of the W3C Trace Context headers.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+const (
+	version        = "00"
+	sampledFlag    = 0x01
+	traceParentLen = 55
+)
+
+var (
+	zeroTraceID = strings.Repeat("0", 32)
+	zeroSpanID  = strings.Repeat("0", 16)
+)
+
+// SpanContext is the context of a span propagated in the traceparent and tracestate headers,
+// with UUID trace and span IDs.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+	// TraceState is the vendor-specific tracestate header, propagated as is.
+	TraceState string
+}
+
+// Parse returns the SpanContext of a traceparent header and of its tracestate header, which
+// may be empty. Traceparent headers of versions later than 00 are parsed as 00 ones, as the
+// specification requires.
+func Parse(traceParent, traceState string) (SpanContext, error) {
+	traceParent = strings.TrimSpace(traceParent)
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || !isLowerHex(parts[0]) || parts[0] == "ff" {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	if parts[0] == version && len(traceParent) != traceParentLen {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", traceParent)
+	}
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if len(traceID) != 32 || !isLowerHex(traceID) || traceID == zeroTraceID {
+		return SpanContext{}, fmt.Errorf("invalid trace ID in traceparent %q", traceParent)
+	}
+	if len(spanID) != 16 || !isLowerHex(spanID) || spanID == zeroSpanID {
+		return SpanContext{}, fmt.Errorf("invalid span ID in traceparent %q", traceParent)
+	}
+	if len(flags) != 2 || !isLowerHex(flags) {
+		return SpanContext{}, fmt.Errorf("invalid flags in traceparent %q", traceParent)
+	}
+	f, _ := hex.DecodeString(flags)
+	return SpanContext{
+		TraceID:    hexToUUID(traceID),
+		SpanID:     hexToUUID(zeroSpanID + spanID),
+		Sampled:    f[0]&sampledFlag != 0,
+		TraceState: strings.TrimSpace(traceState),
+	}, nil
+}
+
+// FromHeaders returns the SpanContext of the traceparent and tracestate headers of h. Several
+// tracestate headers are combined, as the specification requires.
+func FromHeaders(h http.Header) (SpanContext, error) {
+	traceParent := h.Values(TraceParentHeader)
+	if len(traceParent) != 1 {
+		return SpanContext{}, fmt.Errorf("expected one traceparent header, got %d", len(traceParent))
+	}
+	return Parse(traceParent[0], strings.Join(h.Values(TraceStateHeader), ","))
+}
+
+// TraceParent returns the traceparent header of sc, of version 00.
+func (sc SpanContext) TraceParent() (string, error) {
+	traceID, err := TraceID(sc.TraceID)
+	if err != nil {
+		return "", err
+	}
+	spanID, err := SpanID(sc.SpanID)
+	if err != nil {
+		return "", err
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return version + "-" + traceID + "-" + spanID + "-" + flags, nil
+}
+
+// Inject sets the traceparent header of sc in h, and its tracestate header unless empty.
+func (sc SpanContext) Inject(h http.Header) error {
+	traceParent, err := sc.TraceParent()
+	if err != nil {
+		return err
+	}
+	h.Set(TraceParentHeader, traceParent)
+	if sc.TraceState != "" {
+		h.Set(TraceStateHeader, sc.TraceState)
+	} else {
+		h.Del(TraceStateHeader)
+	}
+	return nil
+}
+
+// Child returns the context of a new span of the trace of sc, with a random span ID. Its parent
+// is the span of sc, given to SendSpan in parents.
+func (sc SpanContext) Child() SpanContext {
+	child := sc
+	child.SpanID = NewSpanID()
+	return child
+}
+
+// NewSpanID returns a random UUID span ID, that converts to a W3C span ID.
+func NewSpanID() string {
+	var id [8]byte
+	for {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("tracecontext: unable to generate a span ID: %s", err))
+		}
+		if id != [8]byte{} {
+			return hexToUUID(zeroSpanID + hex.EncodeToString(id[:]))
+		}
+	}
+}
+
+// TraceID returns the W3C trace ID, 32 lowercase hex digits, of the UUID trace ID uuid.
+func TraceID(uuid string) (string, error) {
+	id, err := uuidToHex(uuid)
+	if err != nil {
+		return "", err
+	}
+	if id == zeroTraceID {
+		return "", errors.New("invalid trace ID: all zeros")
+	}
+	return id, nil
+}
+
+// SpanID returns the W3C span ID, 16 lowercase hex digits, of the UUID span ID uuid: its last 8 bytes.
+func SpanID(uuid string) (string, error) {
+	id, err := uuidToHex(uuid)
+	if err != nil {
+		return "", err
+	}
+	if id[16:] == zeroSpanID {
+		return "", errors.New("invalid span ID: last 8 bytes all zeros")
+	}
+	return id[16:], nil
+}
+
+// TraceIDToUUID returns the UUID trace ID of the W3C trace ID traceID.
+func TraceIDToUUID(traceID string) (string, error) {
+	traceID = strings.ToLower(traceID)
+	if len(traceID) != 32 || !isLowerHex(traceID) || traceID == zeroTraceID {
+		return "", fmt.Errorf("invalid trace ID %q", traceID)
+	}
+	return hexToUUID(traceID), nil
+}
+
+// SpanIDToUUID returns the UUID span ID of the W3C span ID spanID.
+func SpanIDToUUID(spanID string) (string, error) {
+	spanID = strings.ToLower(spanID)
+	if len(spanID) != 16 || !isLowerHex(spanID) || spanID == zeroSpanID {
+		return "", fmt.Errorf("invalid span ID %q", spanID)
+	}
+	return hexToUUID(zeroSpanID + spanID), nil
+}
+
+// uuidToHex returns the 32 lowercase hex digits of uuid.
+func uuidToHex(uuid string) (string, error) {
+	id := strings.ToLower(strings.ReplaceAll(uuid, "-", ""))
+	if len(uuid) != 36 || len(id) != 32 || !isLowerHex(id) {
+		return "", fmt.Errorf("invalid UUID %q", uuid)
+	}
+	return id, nil
+}
+
+// hexToUUID formats 32 hex digits as a UUID.
+func hexToUUID(id string) string {
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package tracecontext
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParse(t *testing.T) {
+	sc, err := Parse(traceParent, " congo=t61rcWkgMzE ")
+	require.NoError(t, err)
+	assert.Equal(t, SpanContext{
+		TraceID:    "4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		SpanID:     "00000000-0000-0000-00f0-67aa0ba902b7",
+		Sampled:    true,
+		TraceState: "congo=t61rcWkgMzE",
+	}, sc)
+
+	sc, err = Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+	require.NoError(t, err)
+	assert.False(t, sc.Sampled)
+
+	// later versions may have more fields.
+	sc, err = Parse("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03-future", "")
+	require.NoError(t, err)
+	assert.True(t, sc.Sampled)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, tp := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-0100",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		_, err := Parse(tp, "")
+		assert.Error(t, err, tp)
+	}
+}
+
+func TestHeaders_RoundTrip(t *testing.T) {
+	in := http.Header{}
+	in.Set(TraceParentHeader, traceParent)
+	in.Add(TraceStateHeader, "rojo=00f067aa0ba902b7")
+	in.Add(TraceStateHeader, "congo=t61rcWkgMzE")
+
+	sc, err := FromHeaders(in)
+	require.NoError(t, err)
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE", sc.TraceState)
+
+	out := http.Header{}
+	require.NoError(t, sc.Inject(out))
+	assert.Equal(t, traceParent, out.Get(TraceParentHeader))
+	assert.Equal(t, "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE", out.Get(TraceStateHeader))
+
+	_, err = FromHeaders(http.Header{})
+	assert.Error(t, err)
+}
+
+func TestChild(t *testing.T) {
+	parent, err := Parse(traceParent, "")
+	require.NoError(t, err)
+	child := parent.Child()
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.NotEqual(t, parent.SpanID, child.SpanID)
+
+	tp, err := child.TraceParent()
+	require.NoError(t, err)
+	parsed, err := Parse(tp, "")
+	require.NoError(t, err)
+	assert.Equal(t, child, parsed)
+}
+
+func TestIDConversions(t *testing.T) {
+	traceID, err := TraceID("4BF92F35-77B3-4DA6-A3CE-929D0E0E4736")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+
+	// the span ID of a UUID is its last 8 bytes, as sent over OTLP.
+	spanID, err := SpanID("7b3bf470-9456-11e8-9eb6-529269fb1459")
+	require.NoError(t, err)
+	assert.Equal(t, "9eb6529269fb1459", spanID)
+
+	uuid, err := TraceIDToUUID(traceID)
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f35-77b3-4da6-a3ce-929d0e0e4736", uuid)
+	uuid, err = SpanIDToUUID(spanID)
+	require.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-9eb6-529269fb1459", uuid)
+
+	_, err = TraceID("not-a-uuid")
+	assert.Error(t, err)
+	_, err = SpanID("7b3bf470-9456-11e8-0000-000000000000")
+	assert.Error(t, err)
+	_, err = SpanIDToUUID("00f067aa0ba902")
+	assert.Error(t, err)
+}
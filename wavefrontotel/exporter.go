@@ -0,0 +1,205 @@
+// Package wavefrontotel exports the metrics and spans of the OpenTelemetry SDK through a sender,
+// so that applications already instrumented with OpenTelemetry report to Wavefront with the
+// batching, validation and internal metrics of this SDK.
+//
+//	sender, _ := senders.NewSender("http://localhost")
+//	exporter := wavefrontotel.New(sender, wavefrontotel.Application("checkout"))
+//	meterProvider := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)))
+//	tracerProvider := trace.NewTracerProvider(trace.WithBatcher(exporter))
+//
+// Gauges, up-down counters and cumulative sums are sent as metrics, and counters as delta
+// counters. Histograms are sent as Wavefront distributions, with a centroid per non-empty bucket.
+// Spans are sent with their attributes as tags, their events as span logs and their links as
+// follows-from references. The adapter is a module of its own, so that the SDK does not depend
+// on OpenTelemetry.
+package wavefrontotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// the resource attributes of OpenTelemetry semantic conventions the exporter reads.
+const (
+	serviceNameKey attribute.Key = "service.name"
+	hostNameKey    attribute.Key = "host.name"
+)
+
+var errShutdown = errors.New("exporter is shut down")
+
+// Sender is the part of senders.Sender the exporter sends metrics and spans through.
+type Sender interface {
+	senders.MetricSender
+	senders.DistributionSender
+	senders.SpanSender
+	Flush() error
+}
+
+// Exporter is both a metric.Exporter and a trace.SpanExporter, sending metrics and spans
+// through a Sender. Shutting it down flushes the sender, but does not close it.
+type Exporter struct {
+	sender      Sender
+	source      string
+	application string
+	tags        map[string]string
+
+	shutdown atomic.Bool
+}
+
+var (
+	_ metric.Exporter       = (*Exporter)(nil)
+	_ sdktrace.SpanExporter = (*Exporter)(nil)
+)
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// Source sets the source of the exported data. Defaults to the host.name attribute of the
+// resource, or else to the default source of the sender.
+func Source(source string) Option {
+	return func(e *Exporter) {
+		e.source = source
+	}
+}
+
+// Application sets the application tag of the exported data, which Wavefront groups traces by.
+func Application(application string) Option {
+	return func(e *Exporter) {
+		e.application = application
+	}
+}
+
+// Tags adds tags to all the exported data. Attributes take precedence.
+func Tags(tags map[string]string) Option {
+	return func(e *Exporter) {
+		e.tags = tags
+	}
+}
+
+// New creates an Exporter sending through sender.
+func New(sender Sender, setters ...Option) *Exporter {
+	e := &Exporter{sender: sender}
+	for _, set := range setters {
+		set(e)
+	}
+	return e
+}
+
+// Temporality returns delta temporality for counters and histograms, so that they are sent as
+// delta counters and distributions of the observations of each export, and cumulative
+// temporality for the other instruments.
+func (e *Exporter) Temporality(kind metric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case metric.InstrumentKindCounter, metric.InstrumentKindObservableCounter, metric.InstrumentKindHistogram:
+		return metricdata.DeltaTemporality
+	}
+	return metricdata.CumulativeTemporality
+}
+
+// Aggregation returns the default aggregation of kind.
+func (e *Exporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+// Export sends the metrics of rm. Metrics that fail to be sent do not stop the others.
+func (e *Exporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if e.shutdown.Load() {
+		return errShutdown
+	}
+	source, tags := e.resource(rm.Resource)
+	var errs []error
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := e.exportMetric(m, source, tags); err != nil {
+				errs = append(errs, fmt.Errorf("unable to export %s: %s", m.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Exporter) exportMetric(m metricdata.Metrics, source string, tags map[string]string) error {
+	switch data := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		return sendPoints(e.sender, m.Name, data.DataPoints, false, source, tags)
+	case metricdata.Gauge[float64]:
+		return sendPoints(e.sender, m.Name, data.DataPoints, false, source, tags)
+	case metricdata.Sum[int64]:
+		delta := data.IsMonotonic && data.Temporality == metricdata.DeltaTemporality
+		return sendPoints(e.sender, m.Name, data.DataPoints, delta, source, tags)
+	case metricdata.Sum[float64]:
+		delta := data.IsMonotonic && data.Temporality == metricdata.DeltaTemporality
+		return sendPoints(e.sender, m.Name, data.DataPoints, delta, source, tags)
+	case metricdata.Histogram[int64]:
+		return sendHistogram(e.sender, m.Name, data, source, tags)
+	case metricdata.Histogram[float64]:
+		return sendHistogram(e.sender, m.Name, data, source, tags)
+	case metricdata.ExponentialHistogram[int64]:
+		return sendExponentialHistogram(e.sender, m.Name, data, source, tags)
+	case metricdata.ExponentialHistogram[float64]:
+		return sendExponentialHistogram(e.sender, m.Name, data, source, tags)
+	}
+	return fmt.Errorf("unsupported aggregation %T", m.Data)
+}
+
+// ForceFlush flushes the sender.
+func (e *Exporter) ForceFlush(context.Context) error {
+	return e.sender.Flush()
+}
+
+// Shutdown flushes the sender. Exports afterwards return an error.
+func (e *Exporter) Shutdown(context.Context) error {
+	if e.shutdown.Swap(true) {
+		return nil
+	}
+	return e.sender.Flush()
+}
+
+// resource returns the source and the tags of the data of a resource.
+func (e *Exporter) resource(res *resource.Resource) (string, map[string]string) {
+	tags := make(map[string]string, len(e.tags)+2)
+	for k, v := range e.tags {
+		tags[k] = v
+	}
+	if e.application != "" {
+		tags["application"] = e.application
+	}
+	source := e.source
+	for _, kv := range res.Attributes() {
+		switch kv.Key {
+		case serviceNameKey:
+			tags["service"] = kv.Value.Emit()
+		case hostNameKey:
+			if source == "" {
+				source = kv.Value.Emit()
+			}
+		}
+	}
+	return source, tags
+}
+
+// tagsWith returns tags overridden by attrs.
+func tagsWith(tags map[string]string, attrs []attribute.KeyValue) map[string]string {
+	merged := make(map[string]string, len(tags)+len(attrs))
+	for k, v := range tags {
+		merged[k] = v
+	}
+	for _, kv := range attrs {
+		if value := kv.Value.Emit(); value != "" {
+			merged[string(kv.Key)] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,150 @@
+package wavefrontotel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var testResource = resource.NewSchemaless(
+	attribute.String("service.name", "checkout"),
+	attribute.String("host.name", "web-01"),
+)
+
+func TestExport_Metrics(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+	exporter := New(sender, Application("shop"))
+
+	now := time.Now()
+	attrs := attribute.NewSet(attribute.String("route", "/cart"))
+	rm := &metricdata.ResourceMetrics{
+		Resource: testResource,
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope: instrumentation.Scope{Name: "test"},
+			Metrics: []metricdata.Metrics{
+				{Name: "queue.depth", Data: metricdata.Gauge[int64]{DataPoints: []metricdata.DataPoint[int64]{
+					{Attributes: attrs, Time: now, Value: 42},
+				}}},
+				{Name: "requests", Data: metricdata.Sum[float64]{
+					Temporality: metricdata.DeltaTemporality,
+					IsMonotonic: true,
+					DataPoints:  []metricdata.DataPoint[float64]{{Attributes: attrs, Time: now, Value: 3}},
+				}},
+				{Name: "latency", Data: metricdata.Histogram[float64]{
+					Temporality: metricdata.DeltaTemporality,
+					DataPoints: []metricdata.HistogramDataPoint[float64]{{
+						Attributes:   attrs,
+						Time:         now,
+						Count:        4,
+						Bounds:       []float64{10, 100},
+						BucketCounts: []uint64{1, 3, 0},
+						Min:          metricdata.NewExtrema(2.0),
+						Max:          metricdata.NewExtrema(80.0),
+						Sum:          200,
+					}},
+				}},
+			},
+		}},
+	}
+	require.NoError(t, exporter.Export(context.Background(), rm))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.Contains(t, line, "source=\"web-01\"")
+		assert.Contains(t, line, "\"service\"=\"checkout\"")
+		assert.Contains(t, line, "\"application\"=\"shop\"")
+		assert.Contains(t, line, "\"route\"=\"/cart\"")
+	}
+	assert.True(t, strings.HasPrefix(lines[0], "\"queue.depth\" 42 "))
+	assert.True(t, strings.HasPrefix(lines[1], "\"∆requests\" 3 "))
+	assert.True(t, strings.HasPrefix(lines[2], "!M "))
+	assert.Contains(t, lines[2], "#1 6 ")
+	assert.Contains(t, lines[2], "#3 55 ")
+	assert.Contains(t, lines[2], " \"latency\"")
+}
+
+func TestExport_Shutdown(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+	exporter := New(sender)
+
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	assert.Error(t, exporter.Export(context.Background(), &metricdata.ResourceMetrics{}))
+	assert.Error(t, exporter.ExportSpans(context.Background(), nil))
+}
+
+func TestBucketCentroids(t *testing.T) {
+	assert.Equal(t, []histogram.Centroid{{Value: 5, Count: 1}, {Value: 55, Count: 3}, {Value: 150, Count: 2}},
+		bucketCentroids([]float64{10, 100}, []uint64{1, 3, 2}, 0, true, 200, true))
+	// the first and last buckets are centered on their bound without min and max.
+	assert.Equal(t, []histogram.Centroid{{Value: 10, Count: 1}, {Value: 100, Count: 2}},
+		bucketCentroids([]float64{10, 100}, []uint64{1, 0, 2}, 0, false, 0, false))
+}
+
+func TestExponentialCentroids(t *testing.T) {
+	// at scale 0, bucket i holds (2^i, 2^(i+1)].
+	assert.Equal(t, []histogram.Centroid{{Value: 3, Count: 2}, {Value: 12, Count: 1}},
+		exponentialCentroids(0, 1, []uint64{2, 0, 1}, 1))
+	assert.Equal(t, []histogram.Centroid{{Value: -3, Count: 2}},
+		exponentialCentroids(0, 1, []uint64{2}, -1))
+}
+
+func TestExportSpans(t *testing.T) {
+	sender, err := senders.NewValidatingSender()
+	require.NoError(t, err)
+	defer sender.Close()
+	exporter := New(sender, Application("shop"))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parentID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	start := time.UnixMilli(1533529977000)
+	stub := tracetest.SpanStub{
+		Name:        "checkout",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}),
+		Parent:      trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: parentID}),
+		SpanKind:    trace.SpanKindServer,
+		StartTime:   start,
+		EndTime:     start.Add(250 * time.Millisecond),
+		Attributes:  []attribute.KeyValue{attribute.Int("http.status_code", 500)},
+		Events: []sdktrace.Event{{
+			Name:       "exception",
+			Time:       start.Add(time.Millisecond),
+			Attributes: []attribute.KeyValue{attribute.String("exception.message", "boom")},
+		}},
+		Status:   sdktrace.Status{Code: codes.Error, Description: "boom"},
+		Resource: testResource,
+	}
+	require.NoError(t, exporter.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+
+	lines := sender.Lines()
+	require.Len(t, lines, 2)
+	span := lines[0]
+	assert.True(t, strings.HasPrefix(span, "\"checkout\" source=\"web-01\" traceId=4bf92f35-77b3-4da6-a3ce-929d0e0e4736 spanId=00000000-0000-0000-00f0-67aa0ba902b7 parent=00000000-0000-0000-b7ad-6b7169203331 "), span)
+	assert.Contains(t, span, "\"application\"=\"shop\"")
+	assert.Contains(t, span, "\"service\"=\"checkout\"")
+	assert.Contains(t, span, "\"span.kind\"=\"server\"")
+	assert.Contains(t, span, "\"error\"=\"true\"")
+	assert.Contains(t, span, "\"http.status_code\"=\"500\"")
+	assert.True(t, strings.HasSuffix(span, " 1533529977000 250\n"), span)
+	assert.Contains(t, lines[1], "\"event\":\"exception\"")
+	assert.Contains(t, lines[1], "\"exception.message\":\"boom\"")
+}
@@ -0,0 +1,26 @@
+module github.com/wavefronthq/wavefront-sdk-go/wavefrontotel
+
+go 1.20
+
+require (
+	github.com/stretchr/testify v1.8.4
+	github.com/wavefronthq/wavefront-sdk-go v0.0.0
+	go.opentelemetry.io/otel v1.21.0
+	go.opentelemetry.io/otel/sdk v1.21.0
+	go.opentelemetry.io/otel/sdk/metric v1.21.0
+	go.opentelemetry.io/otel/trace v1.21.0
+)
+
+require (
+	github.com/caio/go-tdigest/v4 v4.0.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.3.0 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel/metric v1.21.0 // indirect
+	golang.org/x/sys v0.14.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// the adapter is released with the SDK, and built against it.
+replace github.com/wavefronthq/wavefront-sdk-go => ../
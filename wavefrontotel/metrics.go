@@ -0,0 +1,124 @@
+package wavefrontotel
+
+import (
+	"errors"
+	"math"
+
+	"github.com/wavefronthq/wavefront-sdk-go/histogram"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var minute = map[histogram.Granularity]bool{histogram.MINUTE: true}
+
+// sendPoints sends the points of a gauge or a sum, as delta counters if delta.
+func sendPoints[N int64 | float64](sender Sender, name string, points []metricdata.DataPoint[N], delta bool, source string, tags map[string]string) error {
+	var errs []error
+	for _, p := range points {
+		var err error
+		if delta {
+			err = sender.SendDeltaCounterWithTimestamp(name, float64(p.Value), p.Time.Unix(), source, tagsWith(tags, p.Attributes.ToSlice()))
+		} else {
+			err = sender.SendMetric(name, float64(p.Value), p.Time.Unix(), source, tagsWith(tags, p.Attributes.ToSlice()))
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendHistogram sends the points of a delta histogram as distributions, and of a cumulative one
+// as the metrics name.count and name.sum, since their buckets hold every observation so far.
+func sendHistogram[N int64 | float64](sender Sender, name string, h metricdata.Histogram[N], source string, tags map[string]string) error {
+	var errs []error
+	for _, p := range h.DataPoints {
+		pointTags := tagsWith(tags, p.Attributes.ToSlice())
+		if h.Temporality == metricdata.CumulativeTemporality {
+			errs = append(errs,
+				sender.SendMetric(name+".count", float64(p.Count), p.Time.Unix(), source, pointTags),
+				sender.SendMetric(name+".sum", float64(p.Sum), p.Time.Unix(), source, pointTags))
+			continue
+		}
+		if p.Count == 0 {
+			continue
+		}
+		minValue, hasMin := p.Min.Value()
+		maxValue, hasMax := p.Max.Value()
+		centroids := bucketCentroids(p.Bounds, p.BucketCounts, float64(minValue), hasMin, float64(maxValue), hasMax)
+		errs = append(errs, sender.SendDistribution(name, centroids, minute, p.Time.Unix(), source, pointTags))
+	}
+	return errors.Join(errs...)
+}
+
+// bucketCentroids returns a centroid per non-empty bucket of an explicit bucket histogram, at the
+// middle of the bucket. The unbounded first and last buckets are centered on their only bound, or
+// on the min and max of the observations when known.
+func bucketCentroids(bounds []float64, counts []uint64, min float64, hasMin bool, max float64, hasMax bool) []histogram.Centroid {
+	var centroids []histogram.Centroid
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		var value float64
+		switch {
+		case len(bounds) == 0:
+			value = (min + max) / 2
+		case i == 0:
+			value = bounds[0]
+			if hasMin && min < value {
+				value = (min + value) / 2
+			}
+		case i >= len(bounds):
+			value = bounds[len(bounds)-1]
+			if hasMax && max > value {
+				value = (value + max) / 2
+			}
+		default:
+			value = (bounds[i-1] + bounds[i]) / 2
+		}
+		centroids = append(centroids, histogram.Centroid{Value: value, Count: int(count)})
+	}
+	return centroids
+}
+
+// sendExponentialHistogram sends the points of a delta exponential histogram as distributions,
+// and of a cumulative one as the metrics name.count and name.sum.
+func sendExponentialHistogram[N int64 | float64](sender Sender, name string, h metricdata.ExponentialHistogram[N], source string, tags map[string]string) error {
+	var errs []error
+	for _, p := range h.DataPoints {
+		pointTags := tagsWith(tags, p.Attributes.ToSlice())
+		if h.Temporality == metricdata.CumulativeTemporality {
+			errs = append(errs,
+				sender.SendMetric(name+".count", float64(p.Count), p.Time.Unix(), source, pointTags),
+				sender.SendMetric(name+".sum", float64(p.Sum), p.Time.Unix(), source, pointTags))
+			continue
+		}
+		if p.Count == 0 {
+			continue
+		}
+		var centroids []histogram.Centroid
+		if p.ZeroCount > 0 {
+			centroids = append(centroids, histogram.Centroid{Value: 0, Count: int(p.ZeroCount)})
+		}
+		centroids = append(centroids, exponentialCentroids(p.Scale, p.PositiveBucket.Offset, p.PositiveBucket.Counts, 1)...)
+		centroids = append(centroids, exponentialCentroids(p.Scale, p.NegativeBucket.Offset, p.NegativeBucket.Counts, -1)...)
+		errs = append(errs, sender.SendDistribution(name, centroids, minute, p.Time.Unix(), source, pointTags))
+	}
+	return errors.Join(errs...)
+}
+
+// exponentialCentroids returns a centroid per non-empty bucket of an exponential histogram, at the
+// middle of the bucket: bucket offset+i holds the absolute values in (base^(offset+i),
+// base^(offset+i+1)], where base is 2^(2^-scale).
+func exponentialCentroids(scale, offset int32, counts []uint64, sign float64) []histogram.Centroid {
+	base := math.Pow(2, math.Pow(2, -float64(scale)))
+	var centroids []histogram.Centroid
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		lower := math.Pow(base, float64(offset)+float64(i))
+		centroids = append(centroids, histogram.Centroid{Value: sign * (lower + lower*base) / 2, Count: int(count)})
+	}
+	return centroids
+}
@@ -0,0 +1,115 @@
+package wavefrontotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/wavefronthq/wavefront-sdk-go/senders"
+	"github.com/wavefronthq/wavefront-sdk-go/tracecontext"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExportSpans sends spans. Spans that fail to be sent do not stop the others.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.shutdown.Load() {
+		return errShutdown
+	}
+	var errs []error
+	for _, s := range spans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.exportSpan(s); err != nil {
+			errs = append(errs, fmt.Errorf("unable to export span %s: %s", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Exporter) exportSpan(s sdktrace.ReadOnlySpan) error {
+	traceID, err := tracecontext.TraceIDToUUID(s.SpanContext().TraceID().String())
+	if err != nil {
+		return err
+	}
+	spanID, err := tracecontext.SpanIDToUUID(s.SpanContext().SpanID().String())
+	if err != nil {
+		return err
+	}
+	var parents []string
+	if s.Parent().IsValid() {
+		parent, err := tracecontext.SpanIDToUUID(s.Parent().SpanID().String())
+		if err != nil {
+			return err
+		}
+		parents = append(parents, parent)
+	}
+	var followsFrom []string
+	for _, link := range s.Links() {
+		if !link.SpanContext.IsValid() {
+			continue
+		}
+		linked, err := tracecontext.SpanIDToUUID(link.SpanContext.SpanID().String())
+		if err != nil {
+			return err
+		}
+		followsFrom = append(followsFrom, linked)
+	}
+
+	source, tags := e.resource(s.Resource())
+	return e.sender.SendSpan(
+		s.Name(),
+		s.StartTime().UnixMilli(),
+		s.EndTime().Sub(s.StartTime()).Milliseconds(),
+		source,
+		traceID,
+		spanID,
+		parents,
+		followsFrom,
+		spanTags(s, tags),
+		spanLogs(s.Events()),
+	)
+}
+
+// spanTags returns the tags of a span: tags overridden by its attributes, its kind, and whether
+// it failed.
+func spanTags(s sdktrace.ReadOnlySpan, tags map[string]string) []senders.SpanTag {
+	merged := tagsWith(tags, s.Attributes())
+	if kind := s.SpanKind(); kind != trace.SpanKindUnspecified {
+		merged["span.kind"] = kind.String()
+	}
+	if status := s.Status(); status.Code == codes.Error {
+		merged["error"] = "true"
+		if status.Description != "" {
+			merged["otel.status_description"] = status.Description
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	spanTags := make([]senders.SpanTag, len(keys))
+	for i, k := range keys {
+		spanTags[i] = senders.SpanTag{Key: k, Value: merged[k]}
+	}
+	return spanTags
+}
+
+// spanLogs returns the events of a span as span logs, timestamped in microseconds, with the name
+// of each event in its event field.
+func spanLogs(events []sdktrace.Event) []senders.SpanLog {
+	if len(events) == 0 {
+		return nil
+	}
+	logs := make([]senders.SpanLog, len(events))
+	for i, event := range events {
+		fields := tagsWith(nil, event.Attributes)
+		fields["event"] = event.Name
+		logs[i] = senders.SpanLog{Timestamp: event.Time.UnixMicro(), Fields: fields}
+	}
+	return logs
+}